@@ -0,0 +1,236 @@
+package encoding
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// MediaRange is a media range of the `Accept` header, like "application/json;q=0.9".
+type MediaRange struct {
+	// Type is the lowercase media type, like "application/json", "application/*" or "*/*".
+	Type string
+	// Params is the parameters of the media range except q, it is nil if there are none.
+	Params map[string]string
+	// Q is the quality value, which defaults to 1, the media range with q=0 is not acceptable.
+	Q float64
+}
+
+// MajorType returns the major type of the media range, like "application" of "application/json".
+func (r MediaRange) MajorType() string {
+	major, _, _ := strings.Cut(r.Type, "/")
+	return major
+}
+
+// Subtype returns the subtype of the media range, like "json" of "application/json",
+// it is empty if the media range has no subtype, like "*".
+func (r MediaRange) Subtype() string {
+	_, subtype, _ := strings.Cut(r.Type, "/")
+	return subtype
+}
+
+// ParseAccept parses the `Accept` header values of RFC 7231 into the media ranges, which are sorted by
+// the quality value descending, the earlier one wins for the same quality value, across the header lines too.
+// The commas in the quoted parameters are not separators, the malformed media ranges are skipped.
+// It is the parser of the `Accept` negotiation of the Encoding.
+func ParseAccept(values ...string) []MediaRange {
+	var ranges []MediaRange
+	for _, value := range values {
+		for _, spec := range parseAcceptHeader(value) {
+			if spec == "" {
+				continue
+			}
+			mediaType, params, err := mime.ParseMediaType(spec)
+			if err != nil {
+				continue
+			}
+			r := MediaRange{Type: mediaType, Q: 1}
+			if q, ok := params["q"]; ok {
+				r.Q = parseQuality(q)
+				delete(params, "q")
+			}
+			if len(params) > 0 {
+				r.Params = params
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Q > ranges[j].Q
+	})
+	return ranges
+}
+
+// parseQuality parses the quality value, which is clamped to [0, 1],
+// the malformed one is ignored and defaults to 1.
+func parseQuality(value string) float64 {
+	q, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil || q > 1:
+		return 1
+	case q < 0:
+		return 0
+	default:
+		return q
+	}
+}
+
+// WithAcceptWildcardPriority sets the preferred MIME types of the `Accept` media range like "application/*",
+// which matches the registered MIME types of the major type in the priority order first,
+// then in the registration order, "*/*" always falls back to the "*" Marshaler.
+// The default registration order is Mime_JSON, Mime_PostForm, Mime_MultipartPostForm.
+func WithAcceptWildcardPriority(mimes ...string) Option {
+	return func(r *Encoding) {
+		r.wildcardPriority = make([]string, 0, len(mimes))
+		for _, mime := range mimes {
+			r.wildcardPriority = append(r.wildcardPriority, normalizeMIME(mime))
+		}
+	}
+}
+
+// register registers the bidirectional marshaler with the normalized MIME type, the re-registered
+// MIME type keeps its registration order.
+func (s *registry) register(mime string, marshaler codec.Marshaler) {
+	if _, ok := s.mimeMap[mime]; !ok {
+		major, _, _ := strings.Cut(mime, "/")
+		s.majorTypes[major] = append(s.majorTypes[major], mime)
+	}
+	s.mimeMap[mime] = marshaler
+	if s.outboundMap != nil {
+		s.outboundMap[mime] = marshaler
+	}
+}
+
+// unregister deletes the marshaler of the normalized MIME type.
+func (s *registry) unregister(mime string) {
+	if _, ok := s.mimeMap[mime]; !ok {
+		return
+	}
+	delete(s.mimeMap, mime)
+	delete(s.outboundMap, mime)
+	major, _, _ := strings.Cut(mime, "/")
+	mimes := s.majorTypes[major]
+	for i, v := range mimes {
+		if v == mime {
+			mimes = append(mimes[:i:i], mimes[i+1:]...)
+			break
+		}
+	}
+	if len(mimes) == 0 {
+		delete(s.majorTypes, major)
+	} else {
+		s.majorTypes[major] = mimes
+	}
+}
+
+// acceptMatch is how the `Accept` media type matches the registered MIME type.
+type acceptMatch int
+
+const (
+	acceptNoMatch    acceptMatch = iota
+	acceptRangeMatch             // matched by the media range like "application/*" or the structured syntax suffix.
+	acceptExactMatch
+)
+
+// lookupAccept returns the registered MIME type and marshaler which matches the `Accept` media type,
+// the media range like "application/*" matches the registered MIME type of the major type except the
+// excluded ones, which the client rejects with q=0, see WithAcceptWildcardPriority, "*/*" is not matched.
+func (s *registry) lookupAccept(mediaType string, excluded []string) (string, codec.Marshaler, acceptMatch) {
+	marshalers := s.outboundMarshalers()
+	major, ok := strings.CutSuffix(mediaType, "/*")
+	if !ok {
+		if mime, m, ok := lookup(marshalers, mediaType); ok {
+			return mime, m, acceptExactMatch
+		}
+		if mime, m, ok := lookupStructured(marshalers, mediaType); ok {
+			return mime, m, acceptRangeMatch
+		}
+		return "", nil, acceptNoMatch
+	}
+	if major == "*" {
+		return "", nil, acceptNoMatch
+	}
+	major = normalizeMIME(major)
+	for _, mime := range s.wildcardPriority {
+		if strings.HasPrefix(mime, major) && len(mime) > len(major) && mime[len(major)] == '/' {
+			if m, ok := marshalers[mime]; ok && !slices.Contains(excluded, mime) {
+				return mime, m, acceptRangeMatch
+			}
+		}
+	}
+	for _, mime := range s.majorTypes[major] {
+		if !slices.Contains(excluded, mime) {
+			return mime, marshalers[mime], acceptRangeMatch
+		}
+	}
+	return "", nil, acceptNoMatch
+}
+
+// excludedTypes returns the media types without wildcard which the client rejects with q=0,
+// the media ranges are sorted by the quality value descending, so they are the last ones.
+func excludedTypes(ranges []MediaRange) []string {
+	var excluded []string
+	for i := len(ranges) - 1; i >= 0 && ranges[i].Q <= 0; i-- {
+		if !strings.HasSuffix(ranges[i].Type, "/*") {
+			excluded = append(excluded, ranges[i].Type)
+		}
+	}
+	return excluded
+}
+
+// WithStrictAccept makes Render return ErrNotAcceptable without writing the body, and Handler answer
+// 406 Not Acceptable, when the `Accept` header is present but none of its media ranges, including
+// "*/*" and the partial wildcards like "application/*", matches a registered marshaler,
+// instead of falling back to the "*" Marshaler, see OutboundForRequestStrict.
+// WithOutboundFollowsInbound only applies to the absent `Accept` then.
+func WithStrictAccept() Option {
+	return func(r *Encoding) {
+		r.strictAccept = true
+	}
+}
+
+// OutboundForRequestStrict returns the marshaler for this request like OutboundForRequest,
+// but it returns ErrNotAcceptable if the `Accept` header is present and none of its media ranges,
+// including the wildcards, matches a registered marshaler, the absent `Accept` accepts anything.
+// The registered MIME type override of WithOutboundMIME is always acceptable.
+func (r *Encoding) OutboundForRequestStrict(req *http.Request) (codec.Marshaler, error) {
+	if !r.acceptable(req) {
+		return nil, ErrNotAcceptable
+	}
+	return r.OutboundForRequest(req), nil
+}
+
+// acceptable reports whether the request is acceptable, the registered URL extension of
+// WithExtensionNegotiation is always acceptable, see registry.acceptable.
+func (r *Encoding) acceptable(req *http.Request) bool {
+	s := r.load()
+	if _, _, ok := r.extensionMarshaler(s, req); ok {
+		return true
+	}
+	return s.acceptable(req)
+}
+
+// acceptable reports whether the `Accept` header of the request is absent,
+// or matches a registered marshaler or "*/*", or the MIME type override is registered.
+func (s *registry) acceptable(req *http.Request) bool {
+	if _, m, _ := s.overrideMarshaler(req, outboundMIMEKey{}); m != nil {
+		return true
+	}
+	accept := req.Header[acceptHeader]
+	if mime, _ := s.marshalerFromHeaderAccept(accept); mime != Mime_Wildcard {
+		return true
+	}
+	empty := true
+	for _, r := range ParseAccept(accept...) {
+		empty = false
+		if r.Type == "*/*" && r.Q > 0 {
+			return true
+		}
+	}
+	return empty
+}
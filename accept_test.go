@@ -0,0 +1,187 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/json"
+	"github.com/thinkgos/encoding/msgpack"
+	"github.com/thinkgos/encoding/xml"
+	"github.com/thinkgos/encoding/yaml"
+)
+
+func Test_ParseAccept(t *testing.T) {
+	got := ParseAccept(
+		`text/html, application/xml;q=0.5, application/json;q=0.9;v="a,b"`,
+		`*/*;q=0.1, image/png;q=0, application/yaml;q=2, text/plain;q=x, ;;`,
+	)
+	require.Equal(t, []MediaRange{
+		{Type: "text/html", Q: 1},
+		{Type: "application/yaml", Q: 1},
+		{Type: "text/plain", Q: 1},
+		{Type: "application/json", Params: map[string]string{"v": "a,b"}, Q: 0.9},
+		{Type: "application/xml", Q: 0.5},
+		{Type: "*/*", Q: 0.1},
+		{Type: "image/png", Q: 0},
+	}, got)
+	require.Empty(t, ParseAccept())
+
+	// the extension parameters and the header lines.
+	got = ParseAccept(
+		`application/vnd.myapp+json; version=2; charset="UTF-8"; q=0.8`,
+		`Text/*;q=0.8, *;q=0.5, application/json; charset=utf-8, bad/type;=x`,
+	)
+	require.Equal(t, []MediaRange{
+		{Type: "application/json", Params: map[string]string{"charset": "utf-8"}, Q: 1},
+		{Type: "application/vnd.myapp+json", Params: map[string]string{"version": "2", "charset": "UTF-8"}, Q: 0.8},
+		{Type: "text/*", Q: 0.8},
+		{Type: "*", Q: 0.5},
+	}, got)
+	for _, tt := range []struct {
+		r              MediaRange
+		major, subtype string
+	}{
+		{got[0], "application", "json"},
+		{got[1], "application", "vnd.myapp+json"},
+		{got[2], "text", "*"},
+		{got[3], "*", ""},
+	} {
+		require.Equal(t, tt.major, tt.r.MajorType(), tt.r.Type)
+		require.Equal(t, tt.subtype, tt.r.Subtype(), tt.r.Type)
+	}
+}
+
+func Test_Encoding_OutboundForRequest_Quality(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+
+	for _, tt := range []struct {
+		accept []string
+		want   string
+	}{
+		{[]string{"application/xml;q=0.5, application/json;q=0.9"}, Mime_JSON},
+		{[]string{"application/xml, application/json"}, Mime_XML},
+		{[]string{"application/xml;q=0.8, application/json;q=0.8"}, Mime_XML},
+		{[]string{"application/xml;q=0, text/html"}, Mime_Wildcard},
+		{[]string{"application/xml;q=0.1", "application/json;q=0.2"}, Mime_JSON},
+		{[]string{"application/xml;q=0.3", "application/json;q=0.3"}, Mime_XML},
+		{[]string{`application/xml;q=0.3;v="a,b", application/x-yaml;q=0.4`}, Mime_YAML},
+		{[]string{`application/x-yaml;q=0.4;v="a,b"`, "application/xml;q=0.7"}, Mime_XML},
+		{[]string{"*/*;q=1, application/json;q=0.1"}, Mime_JSON},
+		{[]string{"application/json;Q=0.2, application/xml;q=bad"}, Mime_XML},
+		{[]string{"text/html", "application/xml;q=0.9", "application/json"}, Mime_JSON},
+		{[]string{"text/html;q=0.9", " Application/XML "}, Mime_XML},
+		{[]string{"application/json; charset=utf-8; q=0.5", `application/xml;v="q=1, a";q=0.6`}, Mime_XML},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header[acceptHeader] = tt.accept
+		mime, _ := registry.outboundForRequest(req)
+		require.Equal(t, tt.want, mime, tt.accept)
+	}
+}
+
+func Test_Encoding_OutboundForRequest_WildcardSubtype(t *testing.T) {
+	outbound := func(registry *Encoding, accept string) string {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", accept)
+		mime, _ := registry.outboundForRequest(req)
+		return mime
+	}
+
+	registry := New()
+	require.NoError(t, registry.Register("text/xml", &xml.Codec{}))
+	require.NoError(t, registry.Register(Mime_MSGPACK, &msgpack.Codec{}))
+	require.Equal(t, Mime_JSON, outbound(registry, "application/*"))
+	require.Equal(t, "text/xml", outbound(registry, "TEXT/*"))
+	require.Equal(t, Mime_Wildcard, outbound(registry, "*/*"))
+	require.Equal(t, Mime_Wildcard, outbound(registry, "image/*"))
+	require.Equal(t, Mime_MSGPACK, outbound(registry, "application/*;q=0.5, application/x-msgpack"))
+	require.Equal(t, "text/xml", outbound(registry, "application/*;q=0.5, text/*;q=0.8"))
+	require.Equal(t, Mime_Wildcard, outbound(registry, "application/*;q=0"))
+	// the media types rejected with q=0 are not matched by the media range.
+	require.Equal(t, Mime_PostForm, outbound(registry, "application/*, application/json;q=0"))
+	require.Equal(t, Mime_MSGPACK, outbound(registry, "application/json;q=0, application/*, application/x-www-form-urlencoded;q=0"))
+
+	// the registration order.
+	require.NoError(t, registry.Delete(Mime_JSON))
+	require.Equal(t, Mime_PostForm, outbound(registry, "application/*"))
+	require.NoError(t, registry.Register(Mime_JSON, &json.Codec{}))
+	require.NoError(t, registry.Delete(Mime_PostForm))
+	require.Equal(t, Mime_MSGPACK, outbound(registry, "application/*"))
+
+	// the priority order.
+	registry = New(WithAcceptWildcardPriority("application/x-yaml", "APPLICATION/X-MSGPACK"))
+	require.Equal(t, Mime_JSON, outbound(registry, "application/*"))
+	require.NoError(t, registry.Register(Mime_MSGPACK, &msgpack.Codec{}))
+	require.Equal(t, Mime_MSGPACK, outbound(registry, "application/*"))
+	require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+	require.Equal(t, Mime_YAML, outbound(registry, "application/*"))
+	require.Equal(t, Mime_MSGPACK, outbound(registry, "application/*, application/x-yaml;q=0"))
+}
+
+func Test_Encoding_StrictAccept(t *testing.T) {
+	newRequest := func(accept ...string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header[acceptHeader] = accept
+		return req
+	}
+
+	// lenient by default.
+	w := httptest.NewRecorder()
+	require.NoError(t, New().Render(w, newRequest("text/html"), &TestMode{Id: "foo"}))
+	require.Contains(t, w.Header().Get("Content-Type"), Mime_JSON)
+
+	var renderErr error
+	registry := New(WithStrictAccept(), WithOnRenderError(func(_ *http.Request, err error) { renderErr = err }))
+	for _, tt := range []struct {
+		accept []string
+		want   error
+	}{
+		{nil, nil},
+		{[]string{""}, nil},
+		{[]string{"application/json"}, nil},
+		{[]string{"*/*"}, nil},
+		{[]string{"text/html", "*/*;q=0.1"}, nil},
+		{[]string{"application/*"}, nil},
+		{[]string{"application/vnd.a+json"}, nil},
+		{[]string{`text/html;v="a,b", */*`}, nil},
+		{[]string{"text/html"}, ErrNotAcceptable},
+		{[]string{"image/*, text/html"}, ErrNotAcceptable},
+		{[]string{"*/*;q=0"}, ErrNotAcceptable},
+		{[]string{"application/json;q=0, text/html"}, ErrNotAcceptable},
+		{[]string{`text/html;v="a,b"`}, ErrNotAcceptable},
+	} {
+		_, err := registry.OutboundForRequestStrict(newRequest(tt.accept...))
+		require.Equal(t, tt.want, err, tt.accept)
+
+		renderErr = nil
+		w := httptest.NewRecorder()
+		err = registry.Render(w, newRequest(tt.accept...), &TestMode{Id: "foo"})
+		require.Equal(t, tt.want, err, tt.accept)
+		require.Equal(t, tt.want, renderErr, tt.accept)
+		if tt.want != nil {
+			require.Empty(t, w.Body.Bytes())
+			require.Empty(t, w.Header().Get("Content-Type"))
+		}
+	}
+
+	// the override is always acceptable.
+	req := newRequest("text/html")
+	req = req.WithContext(WithOutboundMIME(req.Context(), Mime_JSON))
+	m, err := registry.OutboundForRequestStrict(req)
+	require.NoError(t, err)
+	require.Equal(t, registry.Get(Mime_JSON), m)
+
+	// the Handler answers 406.
+	w = httptest.NewRecorder()
+	registry.Handler(&TestMode{Id: "foo"}).ServeHTTP(w, newRequest("text/html"))
+	require.Equal(t, http.StatusNotAcceptable, w.Code)
+	require.Equal(t, "Accept", w.Header().Get("Vary"))
+	w = httptest.NewRecorder()
+	registry.Handler(&TestMode{Id: "foo"}).ServeHTTP(w, newRequest("text/html, */*;q=0.1"))
+	require.Equal(t, http.StatusOK, w.Code)
+}
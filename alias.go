@@ -0,0 +1,87 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RegisterAlias registers the case-insensitive MIME type alias of the target MIME type,
+// the alias always resolves to the marshaler which is currently registered for the target,
+// including the later overrides, and it is removed when the target is deleted.
+// The target may be an alias too, the alias chain is followed, but the cycle is an error.
+// Get and the header negotiation follow the alias transparently, like
+//
+//	_ = registry.RegisterAlias(Mime_XML2, Mime_XML)
+//	_ = registry.RegisterAlias("application/vnd.myapp+json", Mime_JSON)
+//
+// Register or Delete of the alias itself removes the alias.
+func (r *Encoding) RegisterAlias(alias, target string) error {
+	if len(alias) == 0 || len(target) == 0 {
+		return errors.New("encoding: empty MIME type")
+	}
+	if isSpecialMIME(alias) || isSpecialMIME(target) {
+		return fmt.Errorf("encoding: MIME alias(%s -> %s) can't be the special MIME type", alias, target)
+	}
+	alias, target = normalizeMIME(alias), normalizeMIME(target)
+	return r.update(func(s *registry) error {
+		for mime, n := target, 0; ; n++ {
+			if mime == alias || n > len(s.aliases) {
+				return fmt.Errorf("encoding: MIME alias(%s -> %s) cycle", alias, target)
+			}
+			next, ok := s.aliases[mime]
+			if !ok {
+				if _, ok := s.mimeMap[mime]; !ok {
+					return fmt.Errorf("encoding: MIME(%s) marshaller not registered", target)
+				}
+				break
+			}
+			mime = next
+		}
+		if s.aliases == nil {
+			s.aliases = make(map[string]string)
+		}
+		s.aliases[alias] = target
+		return nil
+	})
+}
+
+// isSpecialMIME reports whether the MIME type is the special one, like Mime_Query or Mime_Wildcard.
+func isSpecialMIME(mime string) bool {
+	switch mime {
+	case Mime_Query, Mime_Uri, Mime_Wildcard, Mime_DefaultInbound, Mime_DefaultOutbound:
+		return true
+	default:
+		return false
+	}
+}
+
+// resolveAliases registers the aliases with the marshaler of their targets,
+// and removes the aliases whose target is not registered any more.
+func (s *registry) resolveAliases() {
+	if len(s.aliases) == 0 {
+		return
+	}
+	// resolve all the chains before modifying the aliases.
+	targets := make(map[string]string, len(s.aliases))
+	for alias, mime := range s.aliases {
+		for n := 0; n < len(s.aliases); n++ {
+			next, ok := s.aliases[mime]
+			if !ok {
+				break
+			}
+			mime = next
+		}
+		targets[alias] = mime
+	}
+	for alias, mime := range targets {
+		if m, ok := s.mimeMap[mime]; ok {
+			s.register(alias, m)
+			if s.outboundMap != nil {
+				s.outboundMap[alias] = s.outboundMap[mime]
+			}
+		} else {
+			delete(s.aliases, alias)
+			s.unregister(alias)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/msgpack"
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_Encoding_RegisterAlias(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.NoError(t, registry.RegisterAlias("Text/XML", Mime_XML))
+	require.NoError(t, registry.RegisterAlias("application/vnd.myapp+xml", Mime_XML2))
+	require.Same(t, registry.Get(Mime_XML), registry.Get(Mime_XML2))
+	require.Same(t, registry.Get(Mime_XML), registry.Get("application/vnd.myapp+xml"))
+
+	// the header negotiation.
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("Accept", "application/vnd.myapp+xml")
+	mime, m := registry.InboundForRequest(req)
+	require.Equal(t, Mime_XML2, mime)
+	require.Same(t, registry.Get(Mime_XML), m)
+	mime, m = registry.OutboundForRequestWithMime(req)
+	require.Equal(t, "application/vnd.myapp+xml", mime)
+	require.Same(t, registry.Get(Mime_XML), m)
+
+	// the override of the target.
+	override := &xml.Codec{}
+	require.NoError(t, registry.Register(Mime_XML, override))
+	require.Same(t, override, registry.Get(Mime_XML2))
+	require.Same(t, override, registry.Get("application/vnd.myapp+xml"))
+
+	// the errors.
+	require.Error(t, registry.RegisterAlias("", Mime_XML))
+	require.Error(t, registry.RegisterAlias(Mime_Query, Mime_XML))
+	require.Error(t, registry.RegisterAlias("application/foo", Mime_Wildcard))
+	require.Error(t, registry.RegisterAlias("application/foo", "application/unknown"))
+	require.Error(t, registry.RegisterAlias(Mime_XML, Mime_XML))
+	require.Error(t, registry.RegisterAlias(Mime_XML2, "application/vnd.myapp+xml"))
+
+	// the deletion of the target removes the aliases.
+	clone := registry.Clone()
+	require.NoError(t, registry.Delete(Mime_XML))
+	require.Equal(t, []string{Mime_JSON, Mime_PostForm, Mime_MultipartPostForm}, registry.MIMETypes())
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.Same(t, registry.Get(Mime_Wildcard), registry.Get(Mime_XML2))
+	require.Same(t, override, clone.Get(Mime_XML2))
+
+	// the register or deletion of the alias itself removes the alias.
+	require.NoError(t, clone.Register(Mime_XML2, &msgpack.Codec{}))
+	require.NoError(t, clone.Register(Mime_XML, &xml.Codec{}))
+	require.IsType(t, &msgpack.Codec{}, clone.Get(Mime_XML2))
+	require.IsType(t, &msgpack.Codec{}, clone.Get("application/vnd.myapp+xml"))
+	require.NoError(t, clone.Delete("application/vnd.myapp+xml"))
+	require.NoError(t, clone.Register(Mime_XML2, &xml.Codec{}))
+	require.Same(t, clone.Get(Mime_Wildcard), clone.Get("application/vnd.myapp+xml"))
+}
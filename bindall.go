@@ -0,0 +1,130 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/thinkgos/encoding/form"
+)
+
+// BindAll binds the passed struct pointer with all the sources of the request in the order:
+// the uri values if raws is not empty, the query, and the body if the request has one,
+// the later source overwrites only the fields which are present in it, so the field which
+// is present in both the query and the body takes the body value, for example:
+//
+//	// POST /shops/{id}/items?dry_run=true
+//	err := enc.BindAll(req, url.Values{"id": {id}}, &v)
+//
+// The uri values and the query are decoded by the form codecs, which support the proto.Message
+// with the wrappers and the enums. The body of the proto.Message is decoded into a new message,
+// then its populated fields replace the ones of v, since the protobuf codecs reset the message,
+// the message, list and map fields are replaced as a whole, or merged by proto.Merge if Merge is set.
+// The required field of the form codecs is missing only if it is absent in all of the uri values,
+// the query and the form body, see RequiredFields.
+func (r *Encoding) BindAll(req *http.Request, raws url.Values, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindAll(req, raws, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindAll(req *http.Request, raws url.Values, v any, o *bindOptions) error {
+	var missing missingFields
+	if len(raws) > 0 {
+		err := bindError(Mime_Uri, v, o.configureForm(r.load().mimeUri).Decode(raws, v))
+		if err := missing.collect(err, true); err != nil {
+			return err
+		}
+	}
+	if err := missing.collect(r.bindQuery(req, v, o), true); err != nil {
+		return err
+	}
+	if !hasBody(req) {
+		return missing.err()
+	}
+	mediaType := r.NegotiateInbound(req).MediaType
+	formBody := mediaType == Mime_PostForm || mediaType == Mime_MultipartPostForm
+	done, err := r.prepareBody(req, r.maxBodyBytes)
+	if err != nil {
+		return err
+	}
+	m, ok := v.(proto.Message)
+	if !ok {
+		if err := missing.collect(done(r.bindRequestBody(req, v, o)), formBody); err != nil {
+			return err
+		}
+		return missing.err()
+	}
+	body := m.ProtoReflect().New()
+	if err := missing.collect(done(r.bindRequestBody(req, body.Interface(), o)), formBody); err != nil {
+		return err
+	}
+	if o != nil && o.merge {
+		proto.Merge(m, body.Interface())
+		return missing.err()
+	}
+	dst := m.ProtoReflect()
+	body.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		dst.Set(fd, value)
+		return true
+	})
+	return missing.err()
+}
+
+// missingFields collects the missing required fields of the sources of BindAll,
+// the field is missing only if it is missing in all the sources which check it, like the uri values,
+// the query and the form body, the JSON body does not check the required fields.
+type missingFields struct {
+	checked bool
+	fields  []string
+	last    *BindError // the last error of the missing fields.
+}
+
+// collect collects the missing fields of the error of the source, it returns the other errors,
+// checks reports whether the source checks the required fields.
+func (m *missingFields) collect(err error, checks bool) error {
+	var e *form.MissingFieldsError
+	if err != nil && !errors.As(err, &e) {
+		return err
+	}
+	if !checks {
+		return nil
+	}
+	var fields []string
+	if e != nil {
+		fields = e.Fields
+		errors.As(err, &m.last)
+	}
+	if !m.checked {
+		m.checked, m.fields = true, fields
+		return nil
+	}
+	intersected := m.fields[:0:0]
+	for _, field := range m.fields {
+		if slices.Contains(fields, field) {
+			intersected = append(intersected, field)
+		}
+	}
+	m.fields = intersected
+	return nil
+}
+
+// err returns the *BindError of the fields missing in all the sources, or nil.
+func (m *missingFields) err() error {
+	if len(m.fields) == 0 || m.last == nil {
+		return nil
+	}
+	return &BindError{
+		MIME:  m.last.MIME,
+		Type:  m.last.Type,
+		Field: m.fields[0],
+		Err:   &form.MissingFieldsError{Fields: m.fields},
+	}
+}
@@ -0,0 +1,76 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/thinkgos/encoding/jsonpb"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func Test_Encoding_BindAll(t *testing.T) {
+	type item struct {
+		ShopId int64  `json:"shop_id"`
+		DryRun bool   `json:"dry_run"`
+		Name   string `json:"name"`
+		Count  int    `json:"count"`
+	}
+	registry := New()
+	newRequest := func(method, query, body string) *http.Request {
+		var req *http.Request
+		if body == "" {
+			req = httptest.NewRequest(method, "http://example.com/shops/1/items?"+query, nil)
+		} else {
+			req = httptest.NewRequest(method, "http://example.com/shops/1/items?"+query, strings.NewReader(body))
+		}
+		req.Header.Set("Content-Type", Mime_JSON)
+		return req
+	}
+
+	t.Run("precedence", func(t *testing.T) {
+		got := &item{}
+		req := newRequest(http.MethodPost, "dry_run=true&name=query&count=1", `{"name":"body"}`)
+		require.NoError(t, registry.BindAll(req, url.Values{"shop_id": {"1"}, "name": {"uri"}}, got))
+		require.Equal(t, &item{ShopId: 1, DryRun: true, Name: "body", Count: 1}, got)
+
+		got = &item{}
+		req = newRequest(http.MethodPost, "name=query", `{"shop_id":2}`)
+		require.NoError(t, registry.BindAll(req, url.Values{"shop_id": {"1"}, "name": {"uri"}}, got))
+		require.Equal(t, &item{ShopId: 2, Name: "query"}, got)
+	})
+
+	t.Run("no body", func(t *testing.T) {
+		got := &item{}
+		require.NoError(t, registry.BindAll(newRequest(http.MethodPost, "name=query", ""), nil, got))
+		require.Equal(t, &item{Name: "query"}, got)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		require.Error(t, registry.BindAll(newRequest(http.MethodPost, "", "{"), nil, &item{}))
+		require.Error(t, registry.BindAll(newRequest(http.MethodPost, "count=x", ""), nil, &item{}))
+		require.Error(t, registry.BindAll(newRequest(http.MethodPost, "", ""), url.Values{"shop_id": {"x"}}, &item{}))
+	})
+
+	t.Run("proto", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Register(Mime_JSON, &jsonpb.Codec{}))
+		got := &examplepb.Complex{}
+		req := newRequest(http.MethodPost, "age=10&sex=woman&uint32=5&simples=a", `{"age":20,"numberOne":"body","simples":["b"]}`)
+		require.NoError(t, registry.BindAll(req, url.Values{"id": {"1"}}, got))
+		require.True(t, proto.Equal(&examplepb.Complex{
+			Id:      1,
+			Age:     20,
+			Sex:     examplepb.Sex_woman,
+			Uint32:  wrapperspb.UInt32(5),
+			NoOne:   "body",
+			Simples: []string{"b"},
+		}, got), got)
+	})
+}
@@ -0,0 +1,214 @@
+package encoding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindAny decodes the request into a generic value with the negotiated inbound codec.Marshaler,
+// without knowing the schema, like the audit, logging and proxy endpoints.
+// The dynamic type of the returned value is stable per MIME type:
+//
+//	GET, DELETE, HEAD, OPTIONS without body, see Bind --> url.Values of the query
+//	"application/x-www-form-urlencoded"               --> url.Values
+//	"multipart/form-data"                             --> url.Values of the values, the files are in req.MultipartForm
+//	"text/plain", "text/*" not registered             --> string
+//	"application/octet-stream"                        --> []byte
+//	others, like JSON, YAML, TOML and msgpack         --> nil, bool, string, json.Number, []any or map[string]any
+//
+// The documents are normalized so that the equivalent payloads of the different codecs are equal:
+// the numbers are json.Number, the map keys are strings, the byte strings are strings,
+// and the times are RFC 3339 strings. The empty body is nil.
+// The codecs without a generic representation, like protobuf and XML, return an error.
+func (r *Encoding) BindAny(req *http.Request) (any, error) {
+	v, err := r.bindAny(req)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return v, err
+}
+
+func (r *Encoding) bindAny(req *http.Request) (any, error) {
+	if r.bindsQuery(req) {
+		return req.URL.Query(), nil
+	}
+	done, err := r.prepareBody(req, r.maxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	v, err := r.bindAnyBody(req)
+	if err = done(err); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *Encoding) bindAnyBody(req *http.Request) (any, error) {
+	if err := r.checkOverride(req, inboundMIMEKey{}); err != nil {
+		return nil, err
+	}
+	n := r.NegotiateInbound(req)
+	switch n.MediaType {
+	case Mime_PostForm:
+		if req.PostForm == nil {
+			if err := req.ParseForm(); err != nil {
+				return nil, err
+			}
+		}
+		return req.PostForm, nil
+	case Mime_MultipartPostForm:
+		if req.MultipartForm == nil {
+			if err := readMultipartForm(req, n.Params["boundary"], r.multipartMemory(nil)); err != nil {
+				return nil, err
+			}
+		}
+		return url.Values(req.MultipartForm.Value), nil
+	case Mime_Wildcard, Mime_Plain, Mime_OctetStream:
+		if raw, ok := rawMediaType(req); ok {
+			if req.Body == nil {
+				return raw, nil
+			}
+			if _, ok := raw.(string); ok {
+				body, err := charsetReader(req.Body, n.Params["charset"])
+				if err != nil {
+					return nil, err
+				}
+				data, err := io.ReadAll(body)
+				if err != nil {
+					return nil, err
+				}
+				return string(data), nil
+			}
+			return io.ReadAll(req.Body)
+		}
+	}
+
+	if n.MediaType == Mime_XML || n.MediaType == Mime_XML2 || strings.HasSuffix(n.MediaType, "+xml") {
+		return nil, fmt.Errorf("encoding: marshaller(%v) has no generic representation", n.MediaType)
+	}
+	var v any
+	err := r.bindBody(req, n, &v, nil)
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAny(v)
+}
+
+// rawMediaType returns the zero value of the raw body of the request `Content-Type`,
+// an empty string for "text/*", an empty []byte for "application/octet-stream".
+func rawMediaType(req *http.Request) (any, bool) {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get(contentTypeHeader))
+	switch {
+	case err != nil:
+		return nil, false
+	case strings.HasPrefix(mediaType, "text/"):
+		return "", true
+	case mediaType == Mime_OctetStream:
+		return []byte{}, true
+	default:
+		return nil, false
+	}
+}
+
+// normalizeAny normalizes the generic document, see BindAny.
+func normalizeAny(v any) (any, error) {
+	switch vv := v.(type) {
+	case nil, bool, string, json.Number:
+		return vv, nil
+	case []byte:
+		return string(vv), nil
+	case time.Time:
+		return vv.Format(time.RFC3339Nano), nil
+	case map[string]any:
+		for k, e := range vv {
+			e, err := normalizeAny(e)
+			if err != nil {
+				return nil, err
+			}
+			vv[k] = e
+		}
+		return vv, nil
+	case map[any]any:
+		m := make(map[string]any, len(vv))
+		for k, e := range vv {
+			key, err := normalizeAny(k)
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(key)], err = normalizeAny(e); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []any:
+		for i, e := range vv {
+			e, err := normalizeAny(e)
+			if err != nil {
+				return nil, err
+			}
+			vv[i] = e
+		}
+		return vv, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(rv.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return json.Number(strconv.FormatUint(rv.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(rv.Float(), rv.Type().Bits())
+	case reflect.Map:
+		m := make(map[string]any, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			key, err := normalizeAny(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(key)], err = normalizeAny(iter.Value().Interface()); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		s := make([]any, rv.Len())
+		for i := range s {
+			e, err := normalizeAny(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			s[i] = e
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("encoding: unsupported generic value type(%T)", v)
+	}
+}
+
+// formatFloat formats the float like encoding/json does.
+func formatFloat(f float64, bits int) (json.Number, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("encoding: unsupported generic number(%v)", f)
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	return json.Number(strconv.FormatFloat(f, format, -1, bits)), nil
+}
@@ -0,0 +1,109 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/msgpack"
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/toml"
+	"github.com/thinkgos/encoding/xml"
+	"github.com/thinkgos/encoding/yaml"
+)
+
+func Test_Encoding_BindAny(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_MSGPACK, &msgpack.Codec{}))
+	require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+	require.NoError(t, registry.Register(Mime_TOML, &toml.Codec{}))
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.NoError(t, registry.Register(Mime_PROTOBUF, &pro.Codec{}))
+
+	newRequest := func(contentType string, body []byte) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	t.Run("documents", func(t *testing.T) {
+		doc := map[string]any{
+			"name":   "foo",
+			"count":  10,
+			"ratio":  1.5,
+			"tags":   []any{"a", "b"},
+			"nested": map[string]any{"ok": true, "id": -3},
+		}
+		want := map[string]any{
+			"name":   "foo",
+			"count":  json.Number("10"),
+			"ratio":  json.Number("1.5"),
+			"tags":   []any{"a", "b"},
+			"nested": map[string]any{"ok": true, "id": json.Number("-3")},
+		}
+		for _, mime := range []string{Mime_JSON, Mime_MSGPACK, Mime_YAML, Mime_TOML} {
+			t.Run(mime, func(t *testing.T) {
+				b, err := registry.Get(mime).Marshal(doc)
+				require.NoError(t, err)
+				got, err := registry.BindAny(newRequest(mime, b))
+				require.NoError(t, err)
+				require.Equal(t, want, got)
+			})
+		}
+	})
+	t.Run("scalars and arrays", func(t *testing.T) {
+		got, err := registry.BindAny(newRequest(Mime_JSON, []byte(`[1, 2.5, "x", null, 1e300]`)))
+		require.NoError(t, err)
+		require.Equal(t, []any{json.Number("1"), json.Number("2.5"), "x", nil, json.Number("1e300")}, got)
+
+		got, err = registry.BindAny(newRequest(Mime_YAML, []byte("- 1\n- 2.5\n- x\n- null\n- 1.0e+300\n")))
+		require.NoError(t, err)
+		require.Equal(t, []any{json.Number("1"), json.Number("2.5"), "x", nil, json.Number("1e+300")}, got)
+
+		got, err = registry.BindAny(newRequest(Mime_JSON, nil))
+		require.NoError(t, err)
+		require.Nil(t, got)
+	})
+	t.Run("form", func(t *testing.T) {
+		got, err := registry.BindAny(newRequest(Mime_PostForm, []byte("a=1&a=2&b=x")))
+		require.NoError(t, err)
+		require.Equal(t, url.Values{"a": {"1", "2"}, "b": {"x"}}, got)
+
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		require.NoError(t, mw.WriteField("a", "1"))
+		require.NoError(t, mw.Close())
+		got, err = registry.BindAny(newRequest(mw.FormDataContentType(), body.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, url.Values{"a": {"1"}}, got)
+
+		req := httptest.NewRequest(http.MethodGet, "http://example.com?a=1&b=x", nil)
+		got, err = registry.BindAny(req)
+		require.NoError(t, err)
+		require.Equal(t, url.Values{"a": {"1"}, "b": {"x"}}, got)
+	})
+	t.Run("raw", func(t *testing.T) {
+		got, err := registry.BindAny(newRequest("text/plain; charset=utf-8", []byte("hello")))
+		require.NoError(t, err)
+		require.Equal(t, "hello", got)
+
+		got, err = registry.BindAny(newRequest("application/octet-stream", []byte{0x00, 0x01}))
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x00, 0x01}, got)
+	})
+	t.Run("no generic representation", func(t *testing.T) {
+		_, err := registry.BindAny(newRequest(Mime_XML, []byte("<a>1</a>")))
+		require.Error(t, err)
+		_, err = registry.BindAny(newRequest(Mime_PROTOBUF, []byte{0x08, 0x01}))
+		require.Error(t, err)
+		_, err = registry.BindAny(newRequest(Mime_JSON, []byte(strings.Repeat("[", 3))))
+		require.Error(t, err)
+	})
+}
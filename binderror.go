@@ -0,0 +1,117 @@
+package encoding
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+
+	playgroundform "github.com/go-playground/form/v4"
+
+	"github.com/thinkgos/encoding/form"
+)
+
+// BindError is returned by Bind and the other binds when the marshaler fails to decode the request,
+// it carries the negotiated MIME type, the Go type of the target and the offending field if the decoder
+// exposes it, so the error middleware can respond with the structured 400 Bad Request uniformly, like:
+//
+//	var e *encoding.BindError
+//	if errors.As(err, &e) {
+//		// e.MIME, e.Type, e.Field
+//	}
+type BindError struct {
+	// MIME is the negotiated MIME type, like "application/json", Mime_Query or Mime_Uri.
+	MIME string
+	// Type is the Go type of the target, like "*pb.User".
+	Type string
+	// Sniffed reports whether the MIME type is sniffed from the body without the `Content-Type`,
+	// see WithContentSniffing.
+	Sniffed bool
+	// Field is the path of the offending field, like "user.age", it is empty if the decoder does not expose it,
+	// which is the field of *json.UnmarshalTypeError, *form.MapKeyError, the first one of *form.MissingFieldsError
+	// and the key of form.DecodeErrors.
+	Field string
+	// Err is the error of the decoder.
+	Err error
+}
+
+func (e *BindError) Error() string {
+	msg := "encoding: bind MIME(" + e.MIME + ") into " + e.Type
+	if e.Sniffed {
+		msg = "encoding: bind sniffed MIME(" + e.MIME + ") into " + e.Type
+	}
+	if e.Field != "" {
+		msg += ": field " + e.Field
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error { return e.Err }
+
+// RenderError is returned by Render and the handlers when the marshaler fails to encode the value,
+// the sibling of BindError.
+type RenderError struct {
+	// MIME is the negotiated MIME type, like "application/json".
+	MIME string
+	// Type is the Go type of the value, like "*pb.User".
+	Type string
+	// Err is the error of the marshaler.
+	Err error
+}
+
+func (e *RenderError) Error() string {
+	return "encoding: render " + e.Type + " as MIME(" + e.MIME + "): " + e.Err.Error()
+}
+
+func (e *RenderError) Unwrap() error { return e.Err }
+
+// bindError wraps the error of the decoder with *BindError, io.EOF of the empty body is kept,
+// see emptyBodyError.
+func bindError(mime string, v any, err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	var e *BindError
+	if errors.As(err, &e) {
+		return err
+	}
+	return &BindError{MIME: mime, Type: typeName(v), Field: errorField(err), Err: err}
+}
+
+// renderError wraps the error of the marshaler with *RenderError.
+func renderError(mime string, v any, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *RenderError
+	if errors.As(err, &e) {
+		return err
+	}
+	return &RenderError{MIME: mime, Type: typeName(v), Err: err}
+}
+
+// errorField returns the path of the offending field of the error if the decoder exposes it.
+func errorField(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Field
+	}
+	var missingErr *form.MissingFieldsError
+	if errors.As(err, &missingErr) && len(missingErr.Fields) > 0 {
+		return missingErr.Fields[0]
+	}
+	var mapKeyErr *form.MapKeyError
+	if errors.As(err, &mapKeyErr) {
+		return mapKeyErr.Field
+	}
+	var formErrs playgroundform.DecodeErrors
+	if errors.As(err, &formErrs) && len(formErrs) > 0 {
+		fields := make([]string, 0, len(formErrs))
+		for field := range formErrs {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		return fields[0]
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BindError(t *testing.T) {
+	type Nested struct {
+		Age int `json:"age" form:"age"`
+	}
+	type Target struct {
+		Name   string `json:"name" form:"name"`
+		Nested Nested `json:"nested" form:"nested"`
+	}
+	newRequest := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	registry := New()
+
+	tests := []struct {
+		name      string
+		bind      func() error
+		wantMIME  string
+		wantField string
+	}{
+		{
+			"json type",
+			func() error {
+				return registry.Bind(newRequest(Mime_JSON, `{"nested":{"age":"ten"}}`), &Target{})
+			},
+			Mime_JSON,
+			"nested.age",
+		},
+		{
+			"json syntax",
+			func() error { return registry.Bind(newRequest(Mime_JSON, `{"name":`), &Target{}) },
+			Mime_JSON,
+			"",
+		},
+		{
+			"form coercion",
+			func() error { return registry.Bind(newRequest(Mime_PostForm, "nested.age=ten"), &Target{}) },
+			Mime_PostForm,
+			"nested.age",
+		},
+		{
+			"query",
+			func() error {
+				req := httptest.NewRequest(http.MethodGet, "http://example.com?nested.age=ten", nil)
+				return registry.Bind(req, &Target{})
+			},
+			Mime_Query,
+			"nested.age",
+		},
+		{
+			"query map key",
+			func() error {
+				req := httptest.NewRequest(http.MethodGet, "http://example.com?labels%5Benv=prod", nil)
+				return registry.Bind(req, &Target{})
+			},
+			Mime_Query,
+			"labels",
+		},
+		{
+			"uri",
+			func() error { return registry.BindUri(url.Values{"nested.age": {"ten"}}, &Target{}) },
+			Mime_Uri,
+			"nested.age",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e *BindError
+			require.True(t, errors.As(tt.bind(), &e))
+			require.Equal(t, tt.wantMIME, e.MIME)
+			require.Equal(t, "*encoding.Target", e.Type)
+			require.Equal(t, tt.wantField, e.Field)
+			require.Error(t, e.Unwrap())
+		})
+	}
+
+	err := registry.Bind(newRequest(Mime_JSON, `{"nested":{"age":"ten"}}`), &Target{})
+	require.EqualError(t, err, "encoding: bind MIME(application/json) into *encoding.Target: field nested.age: "+errors.Unwrap(err).Error())
+
+	// the empty body and the oversize body are not the errors of the decoder.
+	err = New().Bind(newRequest(Mime_JSON, ""), &Target{})
+	require.ErrorIs(t, err, ErrEmptyBody)
+	require.False(t, errors.As(err, new(*BindError)))
+	err = New(WithMaxBodyBytes(4)).Bind(newRequest(Mime_JSON, `{"name":"foo"}`), &Target{})
+	require.ErrorIs(t, err, ErrBodyTooLarge)
+	require.False(t, errors.As(err, new(*BindError)))
+}
+
+func Test_RenderError(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_JSON, &failingMarshaler{}))
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_JSON)
+
+	err := registry.Render(httptest.NewRecorder(), req, &TestMode{})
+	var e *RenderError
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, Mime_JSON, e.MIME)
+	require.Equal(t, "*encoding.TestMode", e.Type)
+	require.EqualError(t, e.Unwrap(), "marshal failed")
+	require.EqualError(t, err, "encoding: render *encoding.TestMode as MIME(application/json): marshal failed")
+}
@@ -0,0 +1,59 @@
+package encoding
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// watchContext wraps the request body with the reader which returns the error of the request context
+// once it is done, so the context is checked between the reads of the body. It closes the body as well,
+// which unblocks the pending read of the bodies supporting the concurrent Close, like io.Pipe.
+// The body of the net/http server serializes Close with the pending Read, so that read returns only when
+// the data arrives, the connection is closed, like the disconnected client, or the read deadline expires,
+// see http.Server.ReadTimeout and http.ResponseController.SetReadDeadline.
+// The returned done restores the body, and replaces the error of the bind with the error of the context
+// if it is done, since the decoders may hide the error of the reader.
+func watchContext(req *http.Request) (done func(error) error) {
+	ctx := req.Context()
+	if ctx.Done() == nil || !hasBody(req) {
+		return noLimit
+	}
+	body := &contextReadCloser{ReadCloser: req.Body, ctx: ctx}
+	req.Body = body
+	stop := context.AfterFunc(ctx, func() {
+		_ = body.ReadCloser.Close()
+	})
+	return func(err error) error {
+		stop()
+		// the body may be replaced by the preserved one, see WithPreserveBody.
+		if req.Body == body {
+			req.Body = body.ReadCloser
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+		}
+		return err
+	}
+}
+
+// contextReadCloser is the body which fails with the error of the context once it is done.
+type contextReadCloser struct {
+	io.ReadCloser
+	ctx context.Context
+}
+
+func (c *contextReadCloser) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.ReadCloser.Read(p)
+	if err != nil {
+		if ctxErr := c.ctx.Err(); ctxErr != nil {
+			return n, ctxErr
+		}
+	}
+	return n, err
+}
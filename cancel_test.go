@@ -0,0 +1,99 @@
+package encoding
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Bind_ContextCanceled(t *testing.T) {
+	newRequest := func(ctx context.Context, contentType string, body io.Reader) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", body).WithContext(ctx)
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	registry := New()
+
+	t.Run("canceled before", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		body := &closeRecorder{Reader: strings.NewReader(`{"id":"foo"}`)}
+		err := registry.Bind(newRequest(ctx, Mime_JSON, body), &TestMode{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.False(t, errors.As(err, new(*BindError)))
+		n, _ := body.Reader.(*strings.Reader).Seek(0, io.SeekCurrent)
+		require.Zero(t, n)
+
+		req := httptest.NewRequest(http.MethodGet, "http://example.com?id=foo", nil).WithContext(ctx)
+		require.ErrorIs(t, registry.Bind(req, &TestMode{}), context.Canceled)
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		head        string
+	}{
+		{"json", Mime_JSON, `{"id":"foo",`},
+		{"form", Mime_PostForm, "id=foo&"},
+		{"multipart", Mime_MultipartPostForm + "; boundary=xxx", "--xxx\r\nContent-Disposition: form-data; name=\"id\"\r\n\r\nfoo"},
+	}
+	for _, tt := range tests {
+		t.Run("slow "+tt.name, func(t *testing.T) {
+			pr, pw := io.Pipe()
+			defer pw.Close()
+			go func() {
+				// the client stops sending after the head of the body.
+				_, _ = pw.Write([]byte(tt.head))
+			}()
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+
+			req := newRequest(ctx, tt.contentType, pr)
+			errCh := make(chan error, 1)
+			go func() { errCh <- registry.Bind(req, &TestMode{}) }()
+			select {
+			case err := <-errCh:
+				require.ErrorIs(t, err, context.Canceled)
+				require.Same(t, pr, req.Body)
+			case <-time.After(5 * time.Second):
+				t.Fatal("Bind is not aborted by the canceled context")
+			}
+		})
+	}
+
+	t.Run("canceled between reads", func(t *testing.T) {
+		// the body which ignores Close, like the body of the net/http server with a pending read.
+		ctx, cancel := context.WithCancel(context.Background())
+		body := &cancelingReader{data: []byte(`{"id":"foo",`), cancel: cancel}
+		err := registry.Bind(newRequest(ctx, Mime_JSON, io.NopCloser(body)), &TestMode{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 1, body.reads)
+	})
+
+	// the body is not wrapped without the done channel.
+	req := newRequest(context.Background(), Mime_JSON, strings.NewReader(`{"id":"foo"}`))
+	got := &TestMode{}
+	require.NoError(t, registry.Bind(req, got))
+	require.Equal(t, "foo", got.Id)
+}
+
+// cancelingReader returns the data of the first read, and cancels the context after it,
+// the later reads return the data again and never end.
+type cancelingReader struct {
+	data   []byte
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (c *cancelingReader) Read(p []byte) (int, error) {
+	c.reads++
+	c.cancel()
+	return copy(p, c.data), nil
+}
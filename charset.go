@@ -0,0 +1,178 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	xencoding "golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// ErrNotAcceptable is returned by Render in strict charset mode when none of the charsets
+// of the `Accept-Charset` header is supported, or in strict accept mode when none of the media ranges
+// of the `Accept` header is registered, the caller should respond with 406 Not Acceptable.
+var ErrNotAcceptable = errors.New("encoding: not acceptable")
+
+// ErrUnsupportedCharset is returned by Bind when the charset parameter of the request `Content-Type`
+// is unknown or not supported, the caller should respond with 415 Unsupported Media Type.
+var ErrUnsupportedCharset = errors.New("encoding: unsupported charset")
+
+var acceptCharsetHeader = http.CanonicalHeaderKey("Accept-Charset")
+
+// WithAcceptCharset enables the `Accept-Charset` negotiation in Render.
+// When the negotiated media type allows a charset, like text/* and XML, the marshaled bytes are transformed
+// to the charset with the highest q-value which is supported and represents all the characters,
+// and the charset parameter of the `Content-Type` is adjusted, the characters are never replaced.
+// JSON, YAML and TOML are always UTF-8. Unsupported charsets fall back to UTF-8,
+// or Render returns ErrNotAcceptable if strict is true.
+func WithAcceptCharset(strict bool) Option {
+	return func(r *Encoding) {
+		r.acceptCharset = true
+		r.strictCharset = strict
+	}
+}
+
+// charsetMediaType reports whether the media type allows a charset other than UTF-8,
+// like text/*, application/xml, or with +xml suffix, the JSON of RFC 8259 must be UTF-8.
+func charsetMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == Mime_XML ||
+		strings.HasSuffix(mediaType, "+xml")
+}
+
+// transformCharset transforms the UTF-8 data to the charset negotiated by the `Accept-Charset` header,
+// the charset which can not represent all the characters is skipped for the next acceptable one.
+// It returns the data and the content type unchanged if the media type does not allow a charset,
+// or the negotiated charset is UTF-8.
+func (r *Encoding) transformCharset(req *http.Request, contentType string, data []byte) ([]byte, string, error) {
+	values := req.Header[acceptCharsetHeader]
+	if len(values) == 0 {
+		return data, contentType, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	// the compressed content is binary.
+	if err != nil || !charsetMediaType(mediaType) || params["compression"] != "" {
+		return data, contentType, nil
+	}
+	for _, c := range negotiateCharset(values) {
+		if c.enc == nil {
+			return data, contentType, nil
+		}
+		// the encoder fails on the characters which can not be represented in the charset.
+		encoded, err := c.enc.NewEncoder().Bytes(data)
+		if err != nil {
+			continue
+		}
+		params["charset"] = c.name
+		return encoded, mime.FormatMediaType(mediaType, params), nil
+	}
+	if r.strictCharset {
+		return nil, "", ErrNotAcceptable
+	}
+	return data, contentType, nil
+}
+
+// charsetReader returns the reader which transcodes the body in the charset of the request `Content-Type`
+// to UTF-8, it returns the body itself if the charset is absent, UTF-8 or US-ASCII.
+// It returns ErrUnsupportedCharset if the charset is unknown or not supported.
+func charsetReader(body io.Reader, charset string) (io.Reader, error) {
+	if charset == "" ||
+		strings.EqualFold(charset, "utf-8") ||
+		strings.EqualFold(charset, "utf8") ||
+		strings.EqualFold(charset, "us-ascii") {
+		return body, nil
+	}
+	enc, err := ianaindex.MIME.Encoding(charset)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCharset, charset)
+	}
+	if enc == unicode.UTF8 {
+		return body, nil
+	}
+	return transform.NewReader(body, enc.NewDecoder()), nil
+}
+
+// acceptCharset is a charset of the `Accept-Charset` header with its q-value.
+type acceptCharset struct {
+	name string
+	q    float64
+}
+
+// supportedCharset is the supported charset with the lowercase IANA name, enc is nil for UTF-8.
+type supportedCharset struct {
+	name string
+	enc  xencoding.Encoding
+}
+
+// negotiateCharset returns the supported charsets of the `Accept-Charset` header in the order
+// of the q-value, which end with UTF-8 if it is acceptable, as it represents all the characters.
+// It returns UTF-8 if the header has no charsets, and nil if none of the charsets is supported.
+func negotiateCharset(values []string) []supportedCharset {
+	var charsets []acceptCharset
+	for _, value := range values {
+		for _, spec := range parseAcceptHeader(value) {
+			if c, ok := parseAcceptCharset(spec); ok {
+				charsets = append(charsets, c)
+			}
+		}
+	}
+	if len(charsets) == 0 {
+		return []supportedCharset{{}}
+	}
+	sort.SliceStable(charsets, func(i, j int) bool {
+		return charsets[i].q > charsets[j].q
+	})
+	var supported []supportedCharset
+	for _, c := range charsets {
+		if c.q <= 0 {
+			break
+		}
+		if c.name == "*" {
+			return append(supported, supportedCharset{})
+		}
+		enc, err := ianaindex.MIME.Encoding(c.name)
+		if err != nil || enc == nil {
+			continue
+		}
+		if enc == unicode.UTF8 {
+			return append(supported, supportedCharset{})
+		}
+		name, err := ianaindex.MIME.Name(enc)
+		if err != nil {
+			continue
+		}
+		supported = append(supported, supportedCharset{name: strings.ToLower(name), enc: enc})
+	}
+	return supported
+}
+
+// parseAcceptCharset parses the charset like "iso-8859-1;q=0.5", the q-value defaults to 1.
+func parseAcceptCharset(spec string) (acceptCharset, bool) {
+	name, params, _ := strings.Cut(spec, ";")
+	c := acceptCharset{name: strings.TrimSpace(name), q: 1}
+	if c.name == "" {
+		return c, false
+	}
+	for params != "" {
+		var param string
+		param, params, _ = strings.Cut(params, ";")
+		key, value, _ := strings.Cut(param, "=")
+		if strings.TrimSpace(key) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return c, false
+		}
+		c.q = q
+	}
+	return c, true
+}
@@ -0,0 +1,213 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/thinkgos/encoding/msgpack"
+	"github.com/thinkgos/encoding/xml"
+)
+
+type charsetModel struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func Test_Encoding_Render_AcceptCharset(t *testing.T) {
+	registry := New(WithAcceptCharset(false))
+	require.NoError(t, registry.Register(Mime_MSGPACK, &msgpack.Codec{}))
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	strict := New(WithAcceptCharset(true))
+	require.NoError(t, strict.Register(Mime_XML, &xml.Codec{}))
+	v := &charsetModel{Name: "café 中"}
+	gb18030, err := simplifiedchinese.GB18030.NewEncoder().Bytes([]byte("<charsetModel><name>café 中</name></charsetModel>"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		encoding        *Encoding
+		value           *charsetModel
+		accept          string
+		acceptCharset   string
+		wantContentType string
+		wantBody        []byte
+		wantErr         error
+	}{
+		{
+			name:            "no Accept-Charset",
+			encoding:        registry,
+			accept:          Mime_XML,
+			wantContentType: "application/xml; charset=utf-8",
+			wantBody:        []byte("<charsetModel><name>café 中</name></charsetModel>"),
+		},
+		{
+			name:            "latin-1 xml",
+			encoding:        registry,
+			value:           &charsetModel{Name: "café"},
+			accept:          Mime_XML,
+			acceptCharset:   "utf-8;q=0.5, Latin1;q=0.8, unknown",
+			wantContentType: "application/xml; charset=iso-8859-1",
+			wantBody:        []byte("<charsetModel><name>caf\xe9</name></charsetModel>"),
+		},
+		{
+			name:            "unrepresentable falls back to the next charset",
+			encoding:        registry,
+			accept:          Mime_XML,
+			acceptCharset:   "iso-8859-1, gb18030;q=0.5",
+			wantContentType: "application/xml; charset=gb18030",
+			wantBody:        gb18030,
+		},
+		{
+			name:            "unrepresentable falls back to utf-8",
+			encoding:        registry,
+			accept:          Mime_XML,
+			acceptCharset:   "iso-8859-1",
+			wantContentType: "application/xml; charset=utf-8",
+			wantBody:        []byte("<charsetModel><name>café 中</name></charsetModel>"),
+		},
+		{
+			name:          "unrepresentable in strict mode",
+			encoding:      strict,
+			accept:        Mime_XML,
+			acceptCharset: "iso-8859-1",
+			wantErr:       ErrNotAcceptable,
+		},
+		{
+			name:            "json is always utf-8",
+			encoding:        registry,
+			value:           &charsetModel{Name: "café"},
+			accept:          Mime_JSON,
+			acceptCharset:   "iso-8859-1",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        []byte(`{"name":"café"}`),
+		},
+		{
+			name:            "utf-8 preferred",
+			encoding:        registry,
+			accept:          Mime_JSON,
+			acceptCharset:   "iso-8859-1;q=0.5, utf-8",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        []byte(`{"name":"café 中"}`),
+		},
+		{
+			name:            "wildcard",
+			encoding:        registry,
+			accept:          Mime_JSON,
+			acceptCharset:   "*",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        []byte(`{"name":"café 中"}`),
+		},
+		{
+			name:            "unsupported falls back to utf-8",
+			encoding:        registry,
+			accept:          Mime_XML,
+			acceptCharset:   "unknown, iso-8859-1;q=0",
+			wantContentType: "application/xml; charset=utf-8",
+			wantBody:        []byte("<charsetModel><name>café 中</name></charsetModel>"),
+		},
+		{
+			name:          "unsupported in strict mode",
+			encoding:      strict,
+			accept:        Mime_XML,
+			acceptCharset: "unknown",
+			wantErr:       ErrNotAcceptable,
+		},
+		{
+			name:            "binary codec is not transformed",
+			encoding:        registry,
+			accept:          Mime_MSGPACK,
+			acceptCharset:   "iso-8859-1",
+			wantContentType: "application/x-msgpack; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.Header.Set("Accept", tt.accept)
+			if tt.acceptCharset != "" {
+				req.Header.Set("Accept-Charset", tt.acceptCharset)
+			}
+			value := tt.value
+			if value == nil {
+				value = v
+			}
+			w := httptest.NewRecorder()
+			err := tt.encoding.Render(w, req, value)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			if tt.wantBody != nil {
+				require.Equal(t, tt.wantBody, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func Test_NegotiateCharset(t *testing.T) {
+	tests := []struct {
+		values    []string
+		wantNames []string
+	}{
+		{[]string{""}, []string{""}},
+		{[]string{"ISO-8859-1"}, []string{"iso-8859-1"}},
+		{[]string{"unknown;q=1", "windows-1252;q=0.3"}, []string{"windows-1252"}},
+		{[]string{"iso-8859-1, gbk;q=0.8, utf-8;q=0.5, windows-1252;q=0.3"}, []string{"iso-8859-1", "gbk", ""}},
+		{[]string{"utf-8;q=0"}, nil},
+		{[]string{"iso-8859-1;q=x"}, []string{""}},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, c := range negotiateCharset(tt.values) {
+			names = append(names, c.name)
+		}
+		require.Equal(t, tt.wantNames, names, tt.values)
+	}
+}
+
+func Test_Encoding_Bind_Charset(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_XML2, &xml.Codec{}))
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("<charsetModel><name>中文</name></charsetModel>")
+	require.NoError(t, err)
+
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+		wantErr     error
+	}{
+		{"application/json; charset=ISO-8859-1", "{\"name\":\"caf\xe9\"}", "café", nil},
+		{"application/json; charset=utf-8", `{"name":"café"}`, "café", nil},
+		{"application/json; charset=US-ASCII", `{"name":"cafe"}`, "cafe", nil},
+		{"text/xml; charset=GBK", gbk, "中文", nil},
+		{"application/unknown; charset=windows-1252", "{\"name\":\"caf\xe9\"}", "café", nil},
+		{"application/json; charset=x-unknown", `{"name":"café"}`, "", ErrUnsupportedCharset},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		var got charsetModel
+		err := registry.Bind(req, &got)
+		if tt.wantErr != nil {
+			require.True(t, errors.Is(err, tt.wantErr), tt.contentType)
+			continue
+		}
+		require.NoError(t, err, tt.contentType)
+		require.Equal(t, tt.want, got.Name, tt.contentType)
+	}
+
+	// the raw text of BindAny.
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewBufferString("caf\xe9"))
+	req.Header.Set("Content-Type", "text/plain; charset=iso-8859-1")
+	v, err := registry.BindAny(req)
+	require.NoError(t, err)
+	require.Equal(t, "café", v)
+}
@@ -0,0 +1,51 @@
+// Package chiadapter provides the URL parameter binding of github.com/thinkgos/encoding for
+// github.com/go-chi/chi, it reads the URL parameters of the chi route context directly,
+// and reuses the uri codec registered on the Encoding.
+package chiadapter
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/thinkgos/encoding"
+)
+
+// WildcardKey is the key of the chi catch-all wildcard `*` URL parameter,
+// bind it with the tag like `json:"*"`.
+const WildcardKey = "*"
+
+// BindChi binds the passed struct pointer with the URL parameters of the chi route context,
+// like /users/{id}/posts/{postId} or the catch-all wildcard /files/*, using the uri codec.Marshaler,
+// the same as encoding.Encoding.BindUri does.
+// The missing or empty URL parameter is treated as absent, so it does not overwrite the field.
+// It is a no-op if the request is not routed by chi.
+func BindChi(reg *encoding.Encoding, r *http.Request, v any) error {
+	params := URLParams(r)
+	if params == nil {
+		return nil
+	}
+	return reg.BindUri(params, v)
+}
+
+// URLParams returns the URL parameters of the chi route context as url.Values,
+// it returns nil if the request is not routed by chi.
+// The nested routers may add the same key more than once, the last one wins like chi.URLParam,
+// the empty values are omitted.
+func URLParams(r *http.Request) url.Values {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return nil
+	}
+	params := &rctx.URLParams
+	values := make(url.Values, len(params.Keys))
+	for i, key := range params.Keys {
+		if i < len(params.Values) && params.Values[i] != "" {
+			values[key] = []string{params.Values[i]}
+		} else {
+			delete(values, key)
+		}
+	}
+	return values
+}
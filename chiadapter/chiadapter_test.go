@@ -0,0 +1,77 @@
+package chiadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+type post struct {
+	UserId int64  `json:"userId"`
+	PostId string `json:"postId"`
+	Path   string `json:"*"`
+}
+
+func TestBindChi(t *testing.T) {
+	registry := encoding.New()
+
+	var got post
+	var gotProto *examplepb.Complex
+	var gotErr error
+	handler := func(v any) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			gotErr = BindChi(registry, r, v)
+		}
+	}
+
+	sub := chi.NewRouter()
+	sub.Get("/posts/{postId}", handler(&got))
+	sub.Get("/files/*", handler(&got))
+	sub.Get("/complex/{id}/{age}", func(w http.ResponseWriter, r *http.Request) {
+		gotProto = &examplepb.Complex{NoOne: "keep"}
+		gotErr = BindChi(registry, r, gotProto)
+	})
+	mux := chi.NewRouter()
+	mux.Mount("/users/{userId}", sub)
+	// the nested router adds the same key again.
+	mux.Route("/teams/{postId}", func(r chi.Router) {
+		r.Get("/posts/{postId}", handler(&got))
+	})
+
+	tests := []struct {
+		path string
+		want post
+	}{
+		{"/users/1/posts/p%202", post{UserId: 1, PostId: "p 2"}},
+		{"/users/1/files/a/b/c.txt", post{UserId: 1, Path: "a/b/c.txt"}},
+		{"/users/1/files/", post{UserId: 1}},
+		{"/teams/t1/posts/p1", post{PostId: "p1"}},
+	}
+	for _, tt := range tests {
+		got = post{}
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		require.NoError(t, gotErr, tt.path)
+		require.Equal(t, tt.want, got, tt.path)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1/complex/11/20", nil))
+	require.NoError(t, gotErr)
+	require.True(t, proto.Equal(&examplepb.Complex{Id: 11, Age: 20, NoOne: "keep"}, gotProto))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/xx/posts/1", nil))
+	require.Error(t, gotErr)
+}
+
+func TestBindChi_NotRouted(t *testing.T) {
+	got := post{PostId: "keep"}
+	require.NoError(t, BindChi(encoding.New(), httptest.NewRequest(http.MethodGet, "/users/1", nil), &got))
+	require.Equal(t, post{PostId: "keep"}, got)
+	require.Nil(t, URLParams(httptest.NewRequest(http.MethodGet, "/users/1", nil)))
+}
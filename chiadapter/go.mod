@@ -0,0 +1,25 @@
+module github.com/thinkgos/encoding/chiadapter
+
+go 1.23
+
+require (
+	github.com/go-chi/chi/v5 v5.3.2
+	github.com/stretchr/testify v1.11.1
+	github.com/thinkgos/encoding v0.0.0-00010101000000-000000000000
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-playground/form/v4 v4.3.0 // indirect
+	github.com/klauspost/compress v1.18.1 // indirect
+	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/spf13/cast v1.10.0 // indirect
+	github.com/ugorji/go/codec v1.3.1 // indirect
+	golang.org/x/exp v0.0.0-20240525044651-4c93da0ed11d // indirect
+	golang.org/x/text v0.21.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/thinkgos/encoding => ../
@@ -0,0 +1,164 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// maxErrorBodySize is the max size of the non-2xx response body which is read.
+const maxErrorBodySize = 64 << 10
+
+// HTTPError is the error of the non-2xx response returned by DecodeResponse, Do and DoErr.
+type HTTPError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+	// Header is the header of the response.
+	Header http.Header
+	// Body is the raw body of the response, at most 64KB.
+	Body []byte
+	// Value is the decoded error value, the errOut of DoErr, it is nil if the error value is not supplied,
+	// or the body is empty or can not be decoded, like the HTML error page from the intermediaries.
+	Value any
+	// Err is the error of reading or decoding the body, if any.
+	Err error
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("encoding: unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Value == nil {
+		if body := strings.TrimSpace(string(e.Body)); body != "" {
+			if len(body) > 128 {
+				body = body[:128] + "..."
+			}
+			msg += ": " + body
+		}
+	}
+	return msg
+}
+
+// Unwrap returns the error of reading or decoding the body.
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+// UnsupportedResponseTypeError is returned by DecodeResponse when the `Content-Type` of the 2xx response
+// is not registered, it matches ErrUnsupportedMediaType with errors.Is, like UnsupportedMediaTypeError of Bind.
+type UnsupportedResponseTypeError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+	// ContentType is the offending `Content-Type` of the response.
+	ContentType string
+}
+
+func (e *UnsupportedResponseTypeError) Error() string {
+	return fmt.Sprintf("encoding: unsupported content type %q of status %d", e.ContentType, e.StatusCode)
+}
+
+// Is reports whether the target is ErrUnsupportedMediaType.
+func (e *UnsupportedResponseTypeError) Is(target error) bool {
+	return target == ErrUnsupportedMediaType
+}
+
+// DecodeResponse decodes the 2xx response body into out with the marshaler of InboundForResponse,
+// which picks the registered `Content-Type` or its "+json" or "+xml" suffix, the unregistered one returns
+// an *UnsupportedResponseTypeError, the absent one falls back to "*". The 204 response, the empty body and
+// the nil out are ignored. The body of the `Content-Encoding` is decoded, see RegisterContentEncoding.
+// For the non-2xx response, it returns an *HTTPError, and decodes the body into errOut if it is not nil
+// and the `Content-Type` is registered, or has a "+json" or "+xml" suffix like "application/problem+json",
+// the empty body or the body of other types, like the HTML error page, degrades to the raw body of HTTPError.
+// It drains and closes the body, so the connection can be reused.
+func (r *Encoding) DecodeResponse(resp *http.Response, out, errOut any) error {
+	success := resp.StatusCode >= 200 && resp.StatusCode <= 299
+	if resp.Body == nil || resp.Body == http.NoBody {
+		if success {
+			return nil
+		}
+		return r.decodeErrorResponse(resp, errOut)
+	}
+	body := resp.Body
+	defer func() {
+		resp.Body = body
+		// drain the body, so the connection can be reused.
+		_, _ = io.Copy(io.Discard, io.LimitReader(body, maxErrorBodySize))
+		_ = body.Close()
+	}()
+	if success && (out == nil || resp.StatusCode == http.StatusNoContent) {
+		return nil
+	}
+	if values := resp.Header[contentEncodingHeader]; len(values) > 0 {
+		decoded, closers, err := r.load().decodeContent(body, values)
+		switch {
+		case err == nil:
+			defer closeAll(closers)
+			resp.Body = decoded
+		case success && errors.Is(err, io.EOF):
+			// the empty body of the unknown length.
+			return nil
+		case success:
+			return err
+		default:
+			return &HTTPError{StatusCode: resp.StatusCode, Header: resp.Header, Err: err}
+		}
+	}
+	if !success {
+		return r.decodeErrorResponse(resp, errOut)
+	}
+	n := r.load().negotiateContentType(resp.Header[contentTypeHeader])
+	if contentType := resp.Header.Get(contentTypeHeader); n.MediaType == Mime_Wildcard && contentType != "" {
+		return &UnsupportedResponseTypeError{StatusCode: resp.StatusCode, ContentType: contentType}
+	}
+	err := n.Marshaler.NewDecoder(resp.Body).Decode(out)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// Do sends the request with the client, and decodes the response into out, like DoErr without the error value.
+func (r *Encoding) Do(client *http.Client, req *http.Request, out any) error {
+	return r.DoErr(client, req, out, nil)
+}
+
+// DoErr sends the request with the client, which is http.DefaultClient if it is nil,
+// and decodes the 2xx response into out, or the non-2xx response into errOut which
+// is returned as the Value of *HTTPError, see DecodeResponse. It closes the body.
+func (r *Encoding) DoErr(client *http.Client, req *http.Request, out, errOut any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	return r.DecodeResponse(resp, out, errOut)
+}
+
+func (r *Encoding) decodeErrorResponse(resp *http.Response, errOut any) error {
+	e := &HTTPError{StatusCode: resp.StatusCode, Header: resp.Header}
+	if resp.Body == nil {
+		return e
+	}
+	e.Body, e.Err = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if e.Err != nil || errOut == nil || len(bytes.TrimSpace(e.Body)) == 0 {
+		return e
+	}
+	m, ok := r.errorMarshaler(resp)
+	if !ok {
+		return e
+	}
+	if e.Err = m.Unmarshal(e.Body, errOut); e.Err == nil {
+		e.Value = errOut
+	}
+	return e
+}
+
+// errorMarshaler returns the marshaler of the registered `Content-Type` of the error response,
+// or the marshaler of the structured syntax suffix like "+json", it does not fall back to "*".
+func (r *Encoding) errorMarshaler(resp *http.Response) (codec.Marshaler, bool) {
+	n := r.load().negotiateContentType(resp.Header[contentTypeHeader])
+	return n.Marshaler, n.MediaType != Mime_Wildcard
+}
@@ -0,0 +1,208 @@
+package encoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+func newClientTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", Mime_JSON)
+		_, _ = io.WriteString(w, `{"id":"foo","name":"bar"}`)
+	})
+	mux.HandleFunc("/no-content", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/problem", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"id is required","instance":"/problem"}`)
+	})
+	mux.HandleFunc("/envelope", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", Mime_JSON)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"detail":"invalid name"}`)
+	})
+	mux.HandleFunc("/html", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = io.WriteString(w, "<html><body><h1>502 Bad Gateway</h1></body></html>")
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/malformed", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"title":`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func Test_Encoding_DoErr(t *testing.T) {
+	srv := newClientTestServer()
+	defer srv.Close()
+	registry := New()
+	newRequest := func(path string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	t.Run("2xx", func(t *testing.T) {
+		got := &TestMode{}
+		require.NoError(t, registry.Do(srv.Client(), newRequest("/ok"), got))
+		require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+
+		require.NoError(t, registry.DoErr(nil, newRequest("/no-content"), got, &problem{}))
+		require.NoError(t, registry.Do(nil, newRequest("/ok"), nil))
+	})
+	t.Run("400 problem+json", func(t *testing.T) {
+		errOut := &problem{}
+		err := registry.DoErr(srv.Client(), newRequest("/problem"), &TestMode{}, errOut)
+		var httpErr *HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+		require.Equal(t, "application/problem+json", httpErr.Header.Get("Content-Type"))
+		require.NoError(t, httpErr.Err)
+		require.Same(t, errOut, httpErr.Value)
+		require.Equal(t, &problem{Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "id is required"}, errOut)
+		require.Equal(t, "encoding: unexpected status 400 Bad Request", err.Error())
+	})
+	t.Run("422 registered content type", func(t *testing.T) {
+		errOut := &problem{}
+		var httpErr *HTTPError
+		require.ErrorAs(t, registry.DoErr(srv.Client(), newRequest("/envelope"), nil, errOut), &httpErr)
+		require.Equal(t, &problem{Detail: "invalid name"}, httpErr.Value)
+	})
+	t.Run("502 html", func(t *testing.T) {
+		errOut := &problem{}
+		err := registry.DoErr(srv.Client(), newRequest("/html"), &TestMode{}, errOut)
+		var httpErr *HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusBadGateway, httpErr.StatusCode)
+		require.Nil(t, httpErr.Value)
+		require.NoError(t, httpErr.Err)
+		require.Equal(t, &problem{}, errOut)
+		require.Contains(t, string(httpErr.Body), "502 Bad Gateway")
+		require.Contains(t, err.Error(), "502 Bad Gateway: <html>")
+	})
+	t.Run("empty body", func(t *testing.T) {
+		var httpErr *HTTPError
+		require.ErrorAs(t, registry.DoErr(srv.Client(), newRequest("/empty"), nil, &problem{}), &httpErr)
+		require.Equal(t, http.StatusInternalServerError, httpErr.StatusCode)
+		require.Nil(t, httpErr.Value)
+		require.Empty(t, httpErr.Body)
+		require.Equal(t, "encoding: unexpected status 500 Internal Server Error", httpErr.Error())
+	})
+	t.Run("malformed body", func(t *testing.T) {
+		err := registry.DoErr(srv.Client(), newRequest("/malformed"), nil, &problem{})
+		var httpErr *HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Nil(t, httpErr.Value)
+		require.Error(t, httpErr.Err)
+		require.Equal(t, httpErr.Err, errors.Unwrap(err))
+	})
+	t.Run("without error value", func(t *testing.T) {
+		var httpErr *HTTPError
+		require.ErrorAs(t, registry.Do(srv.Client(), newRequest("/problem"), nil), &httpErr)
+		require.Nil(t, httpErr.Value)
+		require.Contains(t, string(httpErr.Body), "id is required")
+	})
+}
+
+func Test_Encoding_DecodeResponse(t *testing.T) {
+	registry := New()
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {Mime_JSON}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	got := &TestMode{Id: "keep"}
+	require.NoError(t, registry.DecodeResponse(resp, got, nil))
+	require.Equal(t, &TestMode{Id: "keep"}, got)
+
+	resp = &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}}
+	var httpErr *HTTPError
+	require.ErrorAs(t, registry.DecodeResponse(resp, got, &problem{}), &httpErr)
+	require.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+
+	gzipped := func(data string) []byte {
+		buf := &bytes.Buffer{}
+		w := gzip.NewWriter(buf)
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	newResponse := func(statusCode int, contentType, contentEncoding string, body []byte) (*http.Response, *closeRecorder) {
+		rc := &closeRecorder{Reader: bytes.NewReader(body)}
+		resp := &http.Response{StatusCode: statusCode, Header: http.Header{}, Body: rc, ContentLength: int64(len(body))}
+		if contentType != "" {
+			resp.Header.Set("Content-Type", contentType)
+		}
+		if contentEncoding != "" {
+			resp.Header.Set("Content-Encoding", contentEncoding)
+		}
+		return resp, rc
+	}
+
+	t.Run("content encoding", func(t *testing.T) {
+		resp, rc := newResponse(http.StatusOK, Mime_JSON, "gzip", gzipped(`{"id":"foo","name":"bar"}`))
+		got := &TestMode{}
+		require.NoError(t, registry.DecodeResponse(resp, got, nil))
+		require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+		require.True(t, rc.closed)
+		require.Same(t, rc, resp.Body)
+
+		resp, _ = newResponse(http.StatusBadRequest, "application/problem+json", "gzip", gzipped(`{"status":400}`))
+		errOut := &problem{}
+		require.ErrorAs(t, registry.DecodeResponse(resp, nil, errOut), &httpErr)
+		require.Equal(t, &problem{Status: 400}, httpErr.Value)
+
+		resp, _ = newResponse(http.StatusOK, Mime_JSON, "compress", []byte("{}"))
+		require.ErrorIs(t, registry.DecodeResponse(resp, &TestMode{}, nil), ErrUnsupportedMediaType)
+	})
+	t.Run("unregistered content type", func(t *testing.T) {
+		resp, rc := newResponse(http.StatusCreated, "text/html", "", []byte("<html></html>"))
+		err := registry.DecodeResponse(resp, &TestMode{}, nil)
+		var typeErr *UnsupportedResponseTypeError
+		require.ErrorAs(t, err, &typeErr)
+		require.Equal(t, http.StatusCreated, typeErr.StatusCode)
+		require.Equal(t, "text/html", typeErr.ContentType)
+		require.ErrorIs(t, err, ErrUnsupportedMediaType)
+		require.True(t, rc.closed)
+
+		// the absent content type falls back to "*".
+		resp, _ = newResponse(http.StatusOK, "", "", []byte(`{"id":"foo"}`))
+		got := &TestMode{}
+		require.NoError(t, registry.DecodeResponse(resp, got, nil))
+		require.Equal(t, "foo", got.Id)
+	})
+	t.Run("no content", func(t *testing.T) {
+		resp, rc := newResponse(http.StatusNoContent, "text/html", "gzip", nil)
+		require.NoError(t, registry.DecodeResponse(resp, &TestMode{}, nil))
+		require.True(t, rc.closed)
+
+		resp, _ = newResponse(http.StatusOK, Mime_JSON, "gzip", nil)
+		resp.ContentLength = -1
+		require.NoError(t, registry.DecodeResponse(resp, &TestMode{}, nil))
+	})
+}
@@ -2,6 +2,7 @@ package codec
 
 import (
 	"io"
+	"mime/multipart"
 	"net/url"
 )
 
@@ -22,6 +23,12 @@ type Marshaler interface {
 	NewEncoder(w io.Writer) Encoder
 }
 
+// Sizer is an optional interface of the Marshaler which can cheaply predict the size of
+// the marshaled "v", it returns false if the size is unknown.
+type Sizer interface {
+	Size(v any) (int, bool)
+}
+
 // FormCodec encode or decode a url.values
 type FormCodec interface {
 	Encode(v any) (url.Values, error)
@@ -35,6 +42,19 @@ type UriEncoder interface {
 	EncodeUrl(pathTemplate string, v any, needQuery bool) string
 }
 
+// UriDecoder decode url path, it is an optional interface of the UriMarshaler.
+type UriDecoder interface {
+	// DecodeUrl decode the variables of url path into v.
+	// pathTemplate is a template of url path like http://helloworld.dev/{name}/sub/{sub.name},
+	DecodeUrl(pathTemplate, path string, v any) error
+}
+
+// MultipartDecoder decode the multipart form with the uploaded files,
+// it is an optional interface of the FormCodec registered for the multipart form.
+type MultipartDecoder interface {
+	DecodeMultipart(form *multipart.Form, v any) error
+}
+
 // FormMarshaler defines a conversion between byte sequence and gRPC payloads / fields.
 type FormMarshaler interface {
 	Marshaler
@@ -69,3 +89,32 @@ type EncoderFunc func(v any) error
 
 // Encode delegates invocations to the underlying function itself.
 func (f EncoderFunc) Encode(v any) error { return f(v) }
+
+// ResettableDecoderFactory is an optional interface which a Marshaler implements
+// to hand out pooled decoders, it avoids constructing a new decoder for each stream.
+type ResettableDecoderFactory interface {
+	// AcquireDecoder returns a pooled Decoder which reads byte sequence from "r".
+	AcquireDecoder(r io.Reader) Decoder
+	// ReleaseDecoder returns the Decoder acquired by AcquireDecoder to the pool,
+	// the Decoder must not be used after release.
+	ReleaseDecoder(d Decoder)
+}
+
+// DecoderOptions is the per-call options of the decoder, the nil field keeps the setting of the Marshaler.
+type DecoderOptions struct {
+	// DisallowUnknownFields overrides whether the decoder rejects the unknown fields.
+	DisallowUnknownFields *bool
+	// UseNumber overrides whether the decoder decodes a number into an any as a Number.
+	UseNumber *bool
+	// RequiredFields is the paths of the fields which must be present in the values of the form codecs,
+	// like the proto message whose tags can't be edited.
+	RequiredFields []string
+}
+
+// ConfigurableDecoder is an optional interface which a Marshaler implements to accept
+// the per-call DecoderOptions, the Marshaler which does not implement it ignores the options.
+type ConfigurableDecoder interface {
+	// WithDecoderOptions returns a copy of the Marshaler with the options applied,
+	// the receiver is not modified.
+	WithDecoderOptions(opts DecoderOptions) Marshaler
+}
@@ -0,0 +1,228 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression algorithms supported by CompressedCodec.
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// CompressedCodec is a Marshaler which transparently compresses the output of
+// the inner Marshaler, and decompresses the input before handing it to the inner Marshaler.
+type CompressedCodec struct {
+	Marshaler
+	// Algorithm is the compression algorithm, Gzip or Zstd.
+	Algorithm string
+	// Level is the compression level of the algorithm,
+	// zero means the default level of the algorithm.
+	Level int
+	// Sniff detects the compression by the magic bytes when unmarshal/decode,
+	// data without known magic bytes is passed to the inner Marshaler as is.
+	Sniff bool
+	// ContentTypeSuffix uses "+gzip" suffix of the inner content type,
+	// otherwise appends a "compression=gzip" parameter.
+	ContentTypeSuffix bool
+}
+
+// Compressed returns a Marshaler which compresses the inner Marshaler's output with algo.
+// level zero means the default level of the algorithm.
+func Compressed(inner Marshaler, algo string, level int) Marshaler {
+	return &CompressedCodec{
+		Marshaler: inner,
+		Algorithm: algo,
+		Level:     level,
+	}
+}
+
+// ContentType returns the inner content type with the compression suffix or parameter.
+//
+//	application/x-msgpack; charset=utf-8 --> application/x-msgpack; charset=utf-8; compression=gzip
+//	application/x-msgpack; charset=utf-8 --> application/x-msgpack+gzip; charset=utf-8 (ContentTypeSuffix)
+func (c *CompressedCodec) ContentType(v any) string {
+	contentType := c.Marshaler.ContentType(v)
+	if !c.ContentTypeSuffix {
+		return contentType + "; compression=" + c.Algorithm
+	}
+	mediaType, params, found := strings.Cut(contentType, ";")
+	if !found {
+		return mediaType + "+" + c.Algorithm
+	}
+	return mediaType + "+" + c.Algorithm + ";" + params
+}
+
+// Marshal marshals "v" with the inner Marshaler and compresses the output.
+func (c *CompressedCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.Marshaler.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	b := &bytes.Buffer{}
+	w, err := c.newWriter(b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Unmarshal decompresses "data" and unmarshals it into "v" with the inner Marshaler.
+func (c *CompressedCodec) Unmarshal(data []byte, v any) error {
+	algo := c.Algorithm
+	if c.Sniff {
+		algo = sniffCompression(data)
+		if algo == "" {
+			return c.Marshaler.Unmarshal(data, v)
+		}
+	}
+	rd, err := newDecompressReader(algo, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	data, err = io.ReadAll(rd)
+	if err != nil {
+		return fmt.Errorf("codec: %s decompress: %w", algo, err)
+	}
+	return c.Marshaler.Unmarshal(data, v)
+}
+
+// NewDecoder returns a Decoder which decompresses the stream from "r"
+// and decodes it with the inner Marshaler's Decoder, the decompressor is closed at the end of the stream.
+func (c *CompressedCodec) NewDecoder(r io.Reader) Decoder {
+	var decoder Decoder
+	var closer io.Closer
+	var eof bool
+	return DecoderFunc(func(v any) error {
+		if eof {
+			return io.EOF
+		}
+		if decoder == nil {
+			algo := c.Algorithm
+			if c.Sniff {
+				br := bufio.NewReader(r)
+				magic, _ := br.Peek(len(zstdMagic))
+				algo = sniffCompression(magic)
+				r = br
+			}
+			if algo == "" {
+				decoder = c.Marshaler.NewDecoder(r)
+			} else {
+				rd, err := newDecompressReader(algo, r)
+				if err != nil {
+					return err
+				}
+				decoder, closer = c.Marshaler.NewDecoder(rd), rd
+			}
+		}
+		err := decoder.Decode(v)
+		if errors.Is(err, io.EOF) {
+			eof = true
+			if closer != nil {
+				_ = closer.Close()
+			}
+		}
+		return err
+	})
+}
+
+// NewEncoder returns an Encoder which encodes with the inner Marshaler's Encoder
+// and compresses the stream into "w". every Encode writes a complete compressed
+// member/frame, so the output can be decoded as one concatenated stream.
+// The compressor is created once, and reset for the next member/frame after every Encode.
+func (c *CompressedCodec) NewEncoder(w io.Writer) Encoder {
+	var cw compressWriter
+	return EncoderFunc(func(v any) error {
+		if cw == nil {
+			var err error
+			if cw, err = c.newWriter(w); err != nil {
+				return err
+			}
+		} else {
+			cw.Reset(w)
+		}
+		if err := c.Marshaler.NewEncoder(cw).Encode(v); err != nil {
+			return err
+		}
+		return cw.Close()
+	})
+}
+
+// compressWriter is the compressor which can be reset to write the next member/frame,
+// like *gzip.Writer and *zstd.Encoder.
+type compressWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+func (c *CompressedCodec) newWriter(w io.Writer) (compressWriter, error) {
+	switch c.Algorithm {
+	case Gzip:
+		level := c.Level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case Zstd:
+		level := zstd.SpeedDefault
+		if c.Level != 0 {
+			level = zstd.EncoderLevelFromZstd(c.Level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+	default:
+		return nil, fmt.Errorf("codec: unsupported compression algorithm(%s)", c.Algorithm)
+	}
+}
+
+// newDecompressReader returns the decompressor of "r", which must be closed to release its resources.
+func newDecompressReader(algo string, r io.Reader) (io.ReadCloser, error) {
+	switch algo {
+	case Gzip:
+		rd, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("codec: gzip decompress: %w", err)
+		}
+		return rd, nil
+	case Zstd:
+		rd, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("codec: zstd decompress: %w", err)
+		}
+		return rd.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("codec: unsupported compression algorithm(%s)", algo)
+	}
+}
+
+// sniffCompression returns the compression algorithm detected by magic bytes,
+// empty if not known.
+func sniffCompression(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(data, zstdMagic):
+		return Zstd
+	default:
+		return ""
+	}
+}
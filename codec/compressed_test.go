@@ -0,0 +1,141 @@
+package codec_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/codec"
+	"github.com/thinkgos/encoding/json"
+)
+
+type compressedModel struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func Test_Compressed_ContentType(t *testing.T) {
+	c := codec.Compressed(&json.Codec{}, codec.Gzip, 0)
+	require.Equal(t, "application/json; charset=utf-8; compression=gzip", c.ContentType(nil))
+
+	c = &codec.CompressedCodec{
+		Marshaler:         &json.Codec{},
+		Algorithm:         codec.Zstd,
+		ContentTypeSuffix: true,
+	}
+	require.Equal(t, "application/json+zstd; charset=utf-8", c.ContentType(nil))
+}
+
+func Test_Compressed_Marshal_Unmarshal(t *testing.T) {
+	for _, algo := range []string{codec.Gzip, codec.Zstd} {
+		t.Run(algo, func(t *testing.T) {
+			c := codec.Compressed(&json.Codec{}, algo, 0)
+
+			want := &compressedModel{Id: "foo", Name: "bar"}
+			b, err := c.Marshal(want)
+			require.NoError(t, err)
+			require.NotEqual(t, `{"id":"foo","name":"bar"}`, string(b))
+
+			got := &compressedModel{}
+			err = c.Unmarshal(b, got)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+}
+
+func Test_Compressed_Encoder_Decoder(t *testing.T) {
+	for _, algo := range []string{codec.Gzip, codec.Zstd} {
+		t.Run(algo, func(t *testing.T) {
+			c := codec.Compressed(&json.Codec{}, algo, 1)
+
+			buf := &bytes.Buffer{}
+			encoder := c.NewEncoder(buf)
+			require.NoError(t, encoder.Encode(&compressedModel{Id: "1", Name: "foo"}))
+			require.NoError(t, encoder.Encode(&compressedModel{Id: "2", Name: "bar"}))
+
+			decoder := c.NewDecoder(buf)
+			got := &compressedModel{}
+			require.NoError(t, decoder.Decode(got))
+			require.Equal(t, &compressedModel{Id: "1", Name: "foo"}, got)
+			got = &compressedModel{}
+			require.NoError(t, decoder.Decode(got))
+			require.Equal(t, &compressedModel{Id: "2", Name: "bar"}, got)
+			// the decompressor is closed at the end of the stream.
+			require.ErrorIs(t, decoder.Decode(&compressedModel{}), io.EOF)
+			require.ErrorIs(t, decoder.Decode(&compressedModel{}), io.EOF)
+		})
+	}
+}
+
+func Test_Compressed_Sniff(t *testing.T) {
+	want := &compressedModel{Id: "foo", Name: "bar"}
+	b, err := codec.Compressed(&json.Codec{}, codec.Zstd, 0).Marshal(want)
+	require.NoError(t, err)
+
+	c := &codec.CompressedCodec{
+		Marshaler: &json.Codec{},
+		Algorithm: codec.Gzip,
+		Sniff:     true,
+	}
+	t.Run("compressed with other algorithm", func(t *testing.T) {
+		got := &compressedModel{}
+		require.NoError(t, c.Unmarshal(b, got))
+		require.Equal(t, want, got)
+
+		got = &compressedModel{}
+		require.NoError(t, c.NewDecoder(bytes.NewReader(b)).Decode(got))
+		require.Equal(t, want, got)
+	})
+	t.Run("uncompressed", func(t *testing.T) {
+		got := &compressedModel{}
+		require.NoError(t, c.Unmarshal([]byte(`{"id":"foo","name":"bar"}`), got))
+		require.Equal(t, want, got)
+
+		got = &compressedModel{}
+		require.NoError(t, c.NewDecoder(bytes.NewReader([]byte(`{"id":"foo","name":"bar"}`))).Decode(got))
+		require.Equal(t, want, got)
+	})
+}
+
+func Test_Compressed_Corrupted(t *testing.T) {
+	for _, algo := range []string{codec.Gzip, codec.Zstd} {
+		t.Run(algo, func(t *testing.T) {
+			c := codec.Compressed(&json.Codec{}, algo, 0)
+
+			b, err := c.Marshal(&compressedModel{Id: "foo", Name: "bar"})
+			require.NoError(t, err)
+			b = b[:len(b)/2]
+
+			err = c.Unmarshal(b, &compressedModel{})
+			require.Error(t, err)
+			err = c.NewDecoder(bytes.NewReader(b)).Decode(&compressedModel{})
+			require.Error(t, err)
+
+			err = c.Unmarshal([]byte("not compressed"), &compressedModel{})
+			require.Error(t, err)
+		})
+	}
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		c := codec.Compressed(&json.Codec{}, "lz4", 0)
+		_, err := c.Marshal(&compressedModel{})
+		require.Error(t, err)
+		err = c.Unmarshal([]byte("{}"), &compressedModel{})
+		require.Error(t, err)
+	})
+}
+
+func Benchmark_Compressed_Encoder(b *testing.B) {
+	for _, algo := range []string{codec.Gzip, codec.Zstd} {
+		b.Run(algo, func(b *testing.B) {
+			encoder := codec.Compressed(&json.Codec{}, algo, 1).NewEncoder(io.Discard)
+			v := &compressedModel{Id: "1", Name: "foo"}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = encoder.Encode(v)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TransformError is the error of the encode or decode function of TransformCodec,
+// which distinguishes it from the error of the inner Marshaler.
+type TransformError struct {
+	// Op is "encode" or "decode".
+	Op  string
+	Err error
+}
+
+func (e *TransformError) Error() string {
+	return fmt.Sprintf("codec: transform %s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the error of the transform function.
+func (e *TransformError) Unwrap() error { return e.Err }
+
+// TransformCodec is a Marshaler which post-processes the output of the inner Marshaler with Encode,
+// and pre-processes the input with Decode before handing it to the inner Marshaler,
+// like encryption or signing of the payload.
+type TransformCodec struct {
+	Marshaler
+	// Encode transforms the marshaled payload.
+	Encode func([]byte) ([]byte, error)
+	// Decode reverses Encode.
+	Decode func([]byte) ([]byte, error)
+	// ContentTypeSuffix is the suffix appended to the inner media type, like "+enc",
+	// empty keeps the inner content type.
+	ContentTypeSuffix string
+}
+
+// Transform returns a Marshaler which transforms the inner Marshaler's payload with encode and decode,
+// contentTypeSuffix is appended to the inner media type, like "+enc", empty keeps it.
+// It composes with the other wrappers, like Compressed:
+//
+//	codec.Transform(codec.Compressed(&json.Codec{}, codec.Gzip, 0), encrypt, decrypt, "+enc")
+func Transform(inner Marshaler, encode, decode func([]byte) ([]byte, error), contentTypeSuffix string) Marshaler {
+	return &TransformCodec{
+		Marshaler:         inner,
+		Encode:            encode,
+		Decode:            decode,
+		ContentTypeSuffix: contentTypeSuffix,
+	}
+}
+
+// ContentType returns the inner content type with the suffix.
+//
+//	application/json; charset=utf-8 --> application/json+enc; charset=utf-8
+func (c *TransformCodec) ContentType(v any) string {
+	contentType := c.Marshaler.ContentType(v)
+	if c.ContentTypeSuffix == "" {
+		return contentType
+	}
+	mediaType, params, found := strings.Cut(contentType, ";")
+	if !found {
+		return mediaType + c.ContentTypeSuffix
+	}
+	return mediaType + c.ContentTypeSuffix + ";" + params
+}
+
+// Marshal marshals "v" with the inner Marshaler and transforms the output with Encode.
+func (c *TransformCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.Marshaler.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.encode(data)
+}
+
+// Unmarshal transforms "data" with Decode and unmarshals it into "v" with the inner Marshaler.
+func (c *TransformCodec) Unmarshal(data []byte, v any) error {
+	data, err := c.decode(data)
+	if err != nil {
+		return err
+	}
+	return c.Marshaler.Unmarshal(data, v)
+}
+
+// NewDecoder returns a Decoder which reads the whole stream from "r" into a buffer on the first Decode,
+// transforms it with Decode and decodes it with the inner Marshaler's Decoder.
+// The transform works on the whole payload, so the stream is not decoded incrementally,
+// and the memory is proportional to the payload. It returns io.EOF if the stream is empty.
+func (c *TransformCodec) NewDecoder(r io.Reader) Decoder {
+	var decoder Decoder
+	return DecoderFunc(func(v any) error {
+		if decoder == nil {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if len(data) == 0 {
+				return io.EOF
+			}
+			if data, err = c.decode(data); err != nil {
+				return err
+			}
+			decoder = c.Marshaler.NewDecoder(bytes.NewReader(data))
+		}
+		return decoder.Decode(v)
+	})
+}
+
+// NewEncoder returns an Encoder which marshals "v" into a buffer with the inner Marshaler,
+// transforms it with Encode and writes it into "w".
+// Every Encode writes a complete transformed payload, so the stream of one Encode
+// is decodable by NewDecoder, the stream of multiple Encodes is not.
+func (c *TransformCodec) NewEncoder(w io.Writer) Encoder {
+	return EncoderFunc(func(v any) error {
+		data, err := c.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+func (c *TransformCodec) encode(data []byte) ([]byte, error) {
+	data, err := c.Encode(data)
+	if err != nil {
+		return nil, &TransformError{Op: "encode", Err: err}
+	}
+	return data, nil
+}
+
+func (c *TransformCodec) decode(data []byte) ([]byte, error) {
+	data, err := c.Decode(data)
+	if err != nil {
+		return nil, &TransformError{Op: "decode", Err: err}
+	}
+	return data, nil
+}
@@ -0,0 +1,99 @@
+package codec_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/codec"
+	"github.com/thinkgos/encoding/json"
+)
+
+// aesGCM returns the AES-GCM transforms of the key, the nonce is prepended to the ciphertext.
+func aesGCM(t *testing.T, key []byte) (encrypt, decrypt func([]byte) ([]byte, error)) {
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	encrypt = func(plaintext []byte) ([]byte, error) {
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		return aead.Seal(nonce, nonce, plaintext, nil), nil
+	}
+	decrypt = func(ciphertext []byte) ([]byte, error) {
+		if len(ciphertext) < aead.NonceSize() {
+			return nil, errors.New("ciphertext too short")
+		}
+		nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+		return aead.Open(nil, nonce, ciphertext, nil)
+	}
+	return encrypt, decrypt
+}
+
+func Test_Transform(t *testing.T) {
+	encrypt, decrypt := aesGCM(t, bytes.Repeat([]byte{1}, 32))
+	c := codec.Transform(&json.Codec{}, encrypt, decrypt, "+enc")
+	require.Equal(t, "application/json+enc; charset=utf-8", c.ContentType(nil))
+	require.Equal(t, "application/json; charset=utf-8", codec.Transform(&json.Codec{}, encrypt, decrypt, "").ContentType(nil))
+
+	want := &compressedModel{Id: "foo", Name: "bar"}
+	t.Run("marshal", func(t *testing.T) {
+		b, err := c.Marshal(want)
+		require.NoError(t, err)
+		require.NotContains(t, string(b), "foo")
+
+		got := &compressedModel{}
+		require.NoError(t, c.Unmarshal(b, got))
+		require.Equal(t, want, got)
+	})
+	t.Run("stream", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.NewEncoder(buf).Encode(want))
+
+		got := &compressedModel{}
+		require.NoError(t, c.NewDecoder(buf).Decode(got))
+		require.Equal(t, want, got)
+
+		require.ErrorIs(t, c.NewDecoder(&bytes.Buffer{}).Decode(got), io.EOF)
+	})
+	t.Run("compose with compressed", func(t *testing.T) {
+		c := codec.Transform(codec.Compressed(&json.Codec{}, codec.Gzip, 0), encrypt, decrypt, "+enc")
+		b, err := c.Marshal(want)
+		require.NoError(t, err)
+		got := &compressedModel{}
+		require.NoError(t, c.Unmarshal(b, got))
+		require.Equal(t, want, got)
+	})
+	t.Run("errors", func(t *testing.T) {
+		b, err := c.Marshal(want)
+		require.NoError(t, err)
+		b[len(b)-1] ^= 0xff
+
+		var transformErr *codec.TransformError
+		err = c.Unmarshal(b, &compressedModel{})
+		require.ErrorAs(t, err, &transformErr)
+		require.Equal(t, "decode", transformErr.Op)
+		require.ErrorAs(t, c.NewDecoder(bytes.NewReader(b)).Decode(&compressedModel{}), &transformErr)
+
+		// the error of the inner codec is not a TransformError.
+		_, err = c.Marshal(make(chan int))
+		require.Error(t, err)
+		require.False(t, errors.As(err, &transformErr))
+
+		failed := errors.New("key unavailable")
+		c := codec.Transform(&json.Codec{}, func([]byte) ([]byte, error) { return nil, failed }, decrypt, "")
+		_, err = c.Marshal(want)
+		require.ErrorAs(t, err, &transformErr)
+		require.Equal(t, "encode", transformErr.Op)
+		require.ErrorIs(t, err, failed)
+	})
+}
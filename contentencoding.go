@@ -0,0 +1,182 @@
+package encoding
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var contentEncodingHeader = http.CanonicalHeaderKey("Content-Encoding")
+
+// UnsupportedContentEncodingError is returned by Bind when the request `Content-Encoding` is not registered,
+// it matches ErrUnsupportedMediaType with errors.Is, so the caller can respond with 415 Unsupported Media Type.
+type UnsupportedContentEncodingError struct {
+	// Encoding is the offending content coding, like "compress".
+	Encoding string
+}
+
+func (e *UnsupportedContentEncodingError) Error() string {
+	return "encoding: unsupported content encoding: " + e.Encoding
+}
+
+// Is reports whether the target is ErrUnsupportedMediaType.
+func (e *UnsupportedContentEncodingError) Is(target error) bool {
+	return target == ErrUnsupportedMediaType
+}
+
+// ContentDecoder returns the reader which decodes the content coding of r, like gzip.NewReader.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// defaultContentDecoders returns the content decoders registered by New.
+func defaultContentDecoders() map[string]ContentDecoder {
+	gzipDecoder := func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+	return map[string]ContentDecoder{
+		"gzip":   gzipDecoder,
+		"x-gzip": gzipDecoder,
+		"deflate": func(r io.Reader) (io.ReadCloser, error) {
+			// "deflate" is the zlib format, but some clients send the raw deflate.
+			pr := &peekReader{r: r}
+			if zr, err := zlib.NewReader(pr); err == nil {
+				pr.buf, pr.done = nil, true
+				return zr, nil
+			}
+			return flate.NewReader(io.MultiReader(bytes.NewReader(pr.buf), r)), nil
+		},
+	}
+}
+
+// RegisterContentEncoding registers the decoder of the case-insensitive content coding of the request body,
+// like "zstd" or "br" with the third-party packages, "gzip", "x-gzip" and "deflate" are registered by New,
+// and "identity" is always a no-op. Bind decodes the body of the request `Content-Encoding` before the marshaler,
+// and WithMaxBodyBytes limits the decoded body, so the zip bomb can not exhaust the memory.
+func (r *Encoding) RegisterContentEncoding(name string, decoder ContentDecoder) error {
+	if name == "" || decoder == nil {
+		return errors.New("encoding: empty content encoding or nil decoder")
+	}
+	name = strings.ToLower(name)
+	if name == "identity" {
+		return errors.New("encoding: content encoding(identity) can't be registered")
+	}
+	return r.update(func(s *registry) error {
+		s.contentDecoders[name] = decoder
+		return nil
+	})
+}
+
+// prepareBody decodes the content coding of the request body and limits it to limit bytes,
+// the `Content-Digest` is verified over the raw body, see digestBody,
+// the reads fail once the request context is done, see watchContext.
+// The returned done restores the body, see limitBody.
+func (r *Encoding) prepareBody(req *http.Request, limit int64) (done func(error) error, err error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	watchDone := watchContext(req)
+	digestDone, err := r.digestBody(req, limit)
+	if err != nil {
+		return nil, watchDone(err)
+	}
+	decodeDone, err := r.decodeBody(req, limit)
+	if err != nil {
+		return nil, watchDone(digestDone(err))
+	}
+	return func(err error) error { return watchDone(digestDone(decodeDone(err))) }, nil
+}
+
+// decodeBody decodes the content coding of the request body and limits it to limit bytes,
+// the returned done restores the body. The form parsed by the middleware is not decoded,
+// the body has been consumed.
+func (r *Encoding) decodeBody(req *http.Request, limit int64) (done func(error) error, err error) {
+	values := req.Header[contentEncodingHeader]
+	if len(values) == 0 || !hasBody(req) ||
+		formParsed(req, r.load().negotiateContentType(req.Header[contentTypeHeader])) {
+		return limitBody(req, limit), nil
+	}
+	body := req.Body
+	decoded, closers, err := r.load().decodeContent(body, values)
+	if err != nil {
+		return nil, err
+	}
+	if len(closers) == 0 {
+		return limitBody(req, limit), nil
+	}
+	req.Body = decoded
+	// the encoded length is not the length of the decoded body.
+	contentLength := req.ContentLength
+	req.ContentLength = -1
+	limitDone := limitBody(req, limit)
+	return func(err error) error {
+		err = limitDone(err)
+		closeAll(closers)
+		req.Body, req.ContentLength = body, contentLength
+		return err
+	}, nil
+}
+
+// decodeContent wraps the body with the decoders of the content codings, which are listed
+// in the order they were applied, so they are decoded in the reverse order,
+// the closers are empty if the codings are identity.
+func (s *registry) decodeContent(body io.ReadCloser, values []string) (io.ReadCloser, []io.Closer, error) {
+	var codings []string
+	for _, value := range values {
+		for _, coding := range strings.Split(value, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != "" && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	if len(codings) == 0 {
+		return body, nil, nil
+	}
+	closers := make([]io.Closer, 0, len(codings))
+	var rd io.Reader = body
+	for i := len(codings) - 1; i >= 0; i-- {
+		decoder, ok := s.contentDecoders[codings[i]]
+		if !ok {
+			closeAll(closers)
+			return nil, nil, &UnsupportedContentEncodingError{Encoding: codings[i]}
+		}
+		rc, err := decoder(rd)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, fmt.Errorf("encoding: content encoding(%s): %w", codings[i], err)
+		}
+		closers = append(closers, rc)
+		rd = rc
+	}
+	return &contentDecodedBody{Reader: rd, Closer: body}, closers, nil
+}
+
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
+
+// contentDecodedBody is the decoded body, Close closes the original body.
+type contentDecodedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// peekReader records the bytes read from r until done, so they can be read again.
+type peekReader struct {
+	r    io.Reader
+	buf  []byte
+	done bool
+}
+
+func (p *peekReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if !p.done {
+		p.buf = append(p.buf, b[:n]...)
+	}
+	return n, err
+}
@@ -0,0 +1,105 @@
+package encoding
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Encoding_Bind_ContentEncoding(t *testing.T) {
+	const body = `{"id":"foo","name":"bar"}`
+	compress := func(newWriter func(io.Writer) io.WriteCloser, data string) []byte {
+		buf := &bytes.Buffer{}
+		w := newWriter(buf)
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	gzipWriter := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	zlibWriter := func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+	flateWriter := func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	}
+	newRequest := func(data []byte, contentEncoding string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(data))
+		req.Header.Set("Content-Type", Mime_JSON)
+		if contentEncoding != "" {
+			req.Header.Set("Content-Encoding", contentEncoding)
+		}
+		return req
+	}
+	registry := New()
+
+	tests := []struct {
+		name            string
+		data            []byte
+		contentEncoding string
+	}{
+		{"gzip", compress(gzipWriter, body), "gzip"},
+		{"x-gzip", compress(gzipWriter, body), "X-Gzip"},
+		{"deflate zlib", compress(zlibWriter, body), "deflate"},
+		{"deflate raw", compress(flateWriter, body), "deflate"},
+		{"identity", []byte(body), "identity"},
+		{"none", []byte(body), ""},
+		{"stacked", compress(gzipWriter, string(compress(zlibWriter, body))), "deflate, identity, gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.data, tt.contentEncoding)
+			rawBody, contentLength := req.Body, req.ContentLength
+			got := &TestMode{}
+			require.NoError(t, registry.Bind(req, got))
+			require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+			require.Equal(t, rawBody, req.Body)
+			require.Equal(t, contentLength, req.ContentLength)
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		err := registry.Bind(newRequest([]byte(body), "compress"), &TestMode{})
+		require.ErrorIs(t, err, ErrUnsupportedMediaType)
+		var e *UnsupportedContentEncodingError
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, "compress", e.Encoding)
+	})
+	t.Run("corrupt", func(t *testing.T) {
+		err := registry.Bind(newRequest([]byte(body), "gzip"), &TestMode{})
+		require.ErrorIs(t, err, gzip.ErrHeader)
+	})
+	t.Run("decoded body limited", func(t *testing.T) {
+		bomb := compress(gzipWriter, `{"id":"`+strings.Repeat("x", 1<<20)+`"}`)
+		require.Less(t, len(bomb), 4096)
+		r := New(WithMaxBodyBytes(4096))
+		require.ErrorIs(t, r.Bind(newRequest(bomb, "gzip"), &TestMode{}), ErrBodyTooLarge)
+		_, err := r.BindAny(newRequest(bomb, "gzip"))
+		require.ErrorIs(t, err, ErrBodyTooLarge)
+	})
+	t.Run("custom", func(t *testing.T) {
+		r := New()
+		require.Error(t, r.RegisterContentEncoding("identity", func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(r), nil }))
+		require.Error(t, r.RegisterContentEncoding("upper", nil))
+		require.NoError(t, r.RegisterContentEncoding("Lower", func(rd io.Reader) (io.ReadCloser, error) {
+			data, err := io.ReadAll(rd)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(strings.NewReader(strings.ToLower(string(data)))), nil
+		}))
+		got := &TestMode{}
+		require.NoError(t, r.Bind(newRequest([]byte(`{"id":"FOO","name":"BAR"}`), "lower"), got))
+		require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+		// the default registry is not changed.
+		require.ErrorIs(t, registry.Bind(newRequest([]byte(body), "lower"), &TestMode{}), ErrUnsupportedMediaType)
+	})
+}
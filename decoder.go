@@ -0,0 +1,62 @@
+package encoding
+
+import "github.com/thinkgos/encoding/codec"
+
+// AllowUnknownFields accepts the unknown fields of the body for this call,
+// like the internal importer which tolerates the newer clients,
+// the marshaler which does not implement codec.ConfigurableDecoder ignores it.
+func AllowUnknownFields() BindOption {
+	return func(o *bindOptions) {
+		disallow := false
+		o.decoder.DisallowUnknownFields = &disallow
+	}
+}
+
+// DisallowUnknownFields rejects the unknown fields of the body for this call,
+// like the public API which is strict about its input,
+// the marshaler which does not implement codec.ConfigurableDecoder ignores it.
+func DisallowUnknownFields() BindOption {
+	return func(o *bindOptions) {
+		disallow := true
+		o.decoder.DisallowUnknownFields = &disallow
+	}
+}
+
+// UseNumber overrides whether the number of the body is decoded into an any as a Number for this call,
+// the marshaler which does not implement codec.ConfigurableDecoder ignores it.
+func UseNumber(use bool) BindOption {
+	return func(o *bindOptions) {
+		o.decoder.UseNumber = &use
+	}
+}
+
+// RequiredFields requires the fields of the paths to be present in the values of the query, the form
+// and the uri for this call, like the proto message whose tags can't be edited, the path is the key
+// like "user_id" or "filter.page", the proto field also matches its JSON name, like "filter.pageSize".
+// The absent fields are reported by a *form.MissingFieldsError, the marshaler which does not implement
+// codec.ConfigurableDecoder ignores it, like the JSON body, see also the "required" tag option of the form codecs.
+func RequiredFields(paths ...string) BindOption {
+	return func(o *bindOptions) {
+		o.decoder.RequiredFields = append(o.decoder.RequiredFields, paths...)
+	}
+}
+
+// configureDecoder returns m with the decoder options of the call applied if it implements
+// codec.ConfigurableDecoder, otherwise m itself.
+func (o *bindOptions) configureDecoder(m codec.Marshaler) codec.Marshaler {
+	if o == nil || (o.decoder.DisallowUnknownFields == nil && o.decoder.UseNumber == nil && len(o.decoder.RequiredFields) == 0) {
+		return m
+	}
+	if d, ok := m.(codec.ConfigurableDecoder); ok {
+		return d.WithDecoderOptions(o.decoder)
+	}
+	return m
+}
+
+// configureForm returns m with the decoder options of the call applied, see configureDecoder.
+func (o *bindOptions) configureForm(m codec.FormMarshaler) codec.FormMarshaler {
+	if fm, ok := o.configureDecoder(m).(codec.FormMarshaler); ok {
+		return fm
+	}
+	return m
+}
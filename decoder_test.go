@@ -0,0 +1,132 @@
+package encoding
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/form"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+	"github.com/thinkgos/encoding/yaml"
+)
+
+func Test_Encoding_Bind_DecoderOptions(t *testing.T) {
+	newRequest := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	const unknown = `{"id":"foo","extra":1}`
+	registry := New()
+
+	// "application/json" accepts the unknown fields by default.
+	require.NoError(t, registry.Bind(newRequest(Mime_JSON, unknown), &TestMode{}))
+	err := registry.Bind(newRequest(Mime_JSON, unknown), &TestMode{}, DisallowUnknownFields())
+	require.ErrorContains(t, err, `unknown field "extra"`)
+	// the registered codec is not modified.
+	require.NoError(t, registry.Bind(newRequest(Mime_JSON, unknown), &TestMode{}))
+
+	// "*" rejects the unknown fields by default.
+	require.Error(t, registry.Bind(newRequest("", unknown), &TestMode{}))
+	got := &TestMode{}
+	require.NoError(t, registry.Bind(newRequest("", unknown), got, AllowUnknownFields()))
+	require.Equal(t, "foo", got.Id)
+
+	// the numbers.
+	m := map[string]any{}
+	require.NoError(t, registry.Bind(newRequest(Mime_JSON, `{"n":1}`), &m))
+	require.Equal(t, stdjson.Number("1"), m["n"])
+	m = map[string]any{}
+	require.NoError(t, registry.Bind(newRequest(Mime_JSON, `{"n":1}`), &m, UseNumber(false)))
+	require.Equal(t, float64(1), m["n"])
+
+	// the codec which does not implement codec.ConfigurableDecoder ignores the options.
+	require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+	require.NoError(t, registry.Bind(newRequest(Mime_YAML, "id: foo\nextra: 1\n"), &TestMode{}, DisallowUnknownFields()))
+}
+
+func Test_Encoding_Bind_RequiredFields(t *testing.T) {
+	type Target struct {
+		UserId int64  `json:"user_id,required"`
+		Name   string `json:"name"`
+	}
+	registry := New()
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com?name=foo", nil)
+	got := &Target{}
+	err := registry.BindQuery(req, got)
+	var e *BindError
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, Mime_Query, e.MIME)
+	require.Equal(t, "user_id", e.Field)
+	var missing *form.MissingFieldsError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"user_id"}, missing.Fields)
+	require.Equal(t, "foo", got.Name)
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com?user_id=0", nil)
+	require.NoError(t, registry.Bind(req, &Target{}))
+	err = registry.Bind(req, &Target{}, RequiredFields("name", "page"))
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"name", "page"}, missing.Fields)
+
+	// the proto message, whose tags can't be edited.
+	req = httptest.NewRequest(http.MethodGet, "http://example.com?stringValue=foo", nil)
+	msg := &examplepb.ABitOfEverything{}
+	require.NoError(t, registry.Bind(req, msg, RequiredFields("string_value")))
+	require.Equal(t, "foo", msg.StringValue)
+	err = registry.Bind(req, &examplepb.ABitOfEverything{}, RequiredFields("uuid", "string_value", "single_nested.name"))
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"uuid", "single_nested.name"}, missing.Fields)
+	require.NoError(t, registry.BindUri(url.Values{"uuid": {"foo"}}, &examplepb.ABitOfEverything{}, RequiredFields("uuid")))
+	require.Error(t, registry.BindUri(url.Values{}, &examplepb.ABitOfEverything{}, RequiredFields("uuid")))
+
+	// the form body.
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader("name=foo"))
+	req.Header.Set("Content-Type", Mime_PostForm)
+	err = registry.Bind(req, &Target{})
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, Mime_PostForm, e.MIME)
+	require.Equal(t, "user_id", e.Field)
+	// the JSON body ignores it.
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", Mime_JSON)
+	require.NoError(t, registry.Bind(req, &Target{}, RequiredFields("page")))
+}
+
+func Test_Encoding_BindAll_RequiredFields(t *testing.T) {
+	type Target struct {
+		UserId int64  `json:"user_id,required"`
+		Name   string `json:"name,required"`
+		Page   int    `json:"page"`
+	}
+	registry := New()
+
+	// the required field may be present in any of the sources.
+	req := httptest.NewRequest(http.MethodPost, "http://example.com?name=foo", strings.NewReader("page=2"))
+	req.Header.Set("Content-Type", Mime_PostForm)
+	got := &Target{}
+	require.NoError(t, registry.BindAll(req, url.Values{"user_id": {"1"}}, got))
+	require.Equal(t, &Target{UserId: 1, Name: "foo", Page: 2}, got)
+
+	req = httptest.NewRequest(http.MethodPost, "http://example.com?page=1", strings.NewReader("name=foo"))
+	req.Header.Set("Content-Type", Mime_PostForm)
+	err := registry.BindAll(req, url.Values{"page": {"1"}}, &Target{})
+	var missing *form.MissingFieldsError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"user_id"}, missing.Fields)
+	var e *BindError
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, "user_id", e.Field)
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com?page=1", nil)
+	err = registry.BindAll(req, nil, &Target{})
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"user_id", "name"}, missing.Fields)
+}
@@ -0,0 +1,56 @@
+package encoding
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+var (
+	defaultEncoding     atomic.Pointer[Encoding]
+	defaultEncodingOnce sync.Once
+)
+
+// Default returns the package-level default Encoding, which is created lazily by New
+// with WithAllBuiltin, it is used by the package-level functions like Bind and Render.
+// It is safe for concurrent use, see SetDefault to replace it.
+func Default() *Encoding {
+	if r := defaultEncoding.Load(); r != nil {
+		return r
+	}
+	defaultEncodingOnce.Do(func() {
+		defaultEncoding.CompareAndSwap(nil, New(WithAllBuiltin()))
+	})
+	return defaultEncoding.Load()
+}
+
+// SetDefault replaces the package-level default Encoding, like in the tests,
+// it panics if r is nil.
+func SetDefault(r *Encoding) {
+	if r == nil {
+		panic("encoding: nil default Encoding")
+	}
+	defaultEncoding.Store(r)
+}
+
+// Register registers the marshaler with the MIME type on the default Encoding, see Encoding.Register.
+func Register(mime string, marshaler codec.Marshaler) error {
+	return Default().Register(mime, marshaler)
+}
+
+// Bind binds the request with the default Encoding, see Encoding.Bind.
+func Bind(req *http.Request, v any, opts ...BindOption) error {
+	return Default().Bind(req, v, opts...)
+}
+
+// Render renders the response with the default Encoding, see Encoding.Render.
+func Render(w http.ResponseWriter, req *http.Request, v any) error {
+	return Default().Render(w, req, v)
+}
+
+// Encode encodes v with the marshaler of the contentType on the default Encoding, see Encoding.Encode.
+func Encode(contentType string, v any) ([]byte, error) {
+	return Default().Encode(contentType, v)
+}
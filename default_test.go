@@ -0,0 +1,51 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Default(t *testing.T) {
+	old := Default()
+	t.Cleanup(func() { SetDefault(old) })
+	require.Same(t, old, Default())
+	require.Contains(t, old.MIMETypes(), Mime_PROTOBUF)
+	require.Contains(t, old.MIMETypes(), Mime_XML2)
+
+	registry := New()
+	SetDefault(registry)
+	require.Same(t, registry, Default())
+	require.Panics(t, func() { SetDefault(nil) })
+
+	require.NoError(t, Register(Mime_XML, &marshalers[0]))
+	require.Same(t, &marshalers[0], registry.Get(Mime_XML))
+	require.NotSame(t, &marshalers[0], old.Get(Mime_XML))
+
+	data, err := Encode(Mime_JSON, &TestMode{Id: "foo"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"foo","name":""}`, string(data))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"id":"foo"}`))
+			req.Header.Set("Content-Type", Mime_JSON)
+			var got TestMode
+			if err := Bind(req, &got); err != nil || got.Id != "foo" {
+				t.Errorf("Bind() = %v, %v", got, err)
+			}
+			w := httptest.NewRecorder()
+			if err := Render(w, req, &got); err != nil {
+				t.Errorf("Render() = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
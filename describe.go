@@ -0,0 +1,83 @@
+package encoding
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EntryInfo is the description of a registered MIME type, see Describe.
+type EntryInfo struct {
+	// MIME is the registered MIME type, or the special one like Mime_Query and Mime_DefaultInbound.
+	MIME string
+	// Type is the concrete Go type name of the inbound marshaler, like "*json.Codec".
+	Type string
+	// OutboundType is the concrete Go type name of the outbound marshaler,
+	// it is empty if it is the same as Type, see RegisterOutbound.
+	OutboundType string
+	// AliasOf is the target MIME type if MIME is the alias, see RegisterAlias.
+	AliasOf string
+}
+
+// Describe returns the description of the registered MIME types and the special ones which are set,
+// sorted by the MIME type, only the Go type names of the marshalers are exposed.
+// It is safe to call concurrently with the lookups and the registrations.
+func (r *Encoding) Describe() []EntryInfo {
+	s := r.load()
+	outbound := s.outboundMarshalers()
+	entries := make([]EntryInfo, 0, len(s.mimeMap)+4)
+	for mime, m := range s.mimeMap {
+		entry := EntryInfo{
+			MIME:    mime,
+			Type:    typeName(m),
+			AliasOf: s.aliases[mime],
+		}
+		if out := typeName(outbound[mime]); out != entry.Type {
+			entry.OutboundType = out
+		}
+		entries = append(entries, entry)
+	}
+	if s.mimeQuery != nil {
+		entries = append(entries, EntryInfo{MIME: Mime_Query, Type: typeName(s.mimeQuery)})
+	}
+	if s.mimeUri != nil {
+		entries = append(entries, EntryInfo{MIME: Mime_Uri, Type: typeName(s.mimeUri)})
+	}
+	if s.mimeInboundDefault != nil {
+		entries = append(entries, EntryInfo{MIME: Mime_DefaultInbound, Type: typeName(s.mimeInboundDefault)})
+	}
+	if s.mimeOutboundDefault != nil {
+		entries = append(entries, EntryInfo{MIME: Mime_DefaultOutbound, Type: typeName(s.mimeOutboundDefault)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].MIME < entries[j].MIME })
+	return entries
+}
+
+// String implements fmt.Stringer, it returns the registry configuration one MIME type per line, see Describe.
+func (r *Encoding) String() string {
+	var b strings.Builder
+	for i, entry := range r.Describe() {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(entry.MIME)
+		b.WriteString(": ")
+		b.WriteString(entry.Type)
+		if entry.OutboundType != "" {
+			b.WriteString(" (outbound ")
+			b.WriteString(entry.OutboundType)
+			b.WriteByte(')')
+		}
+		if entry.AliasOf != "" {
+			b.WriteString(" (alias of ")
+			b.WriteString(entry.AliasOf)
+			b.WriteByte(')')
+		}
+	}
+	return b.String()
+}
+
+// typeName returns the concrete Go type name of the value, or "<nil>".
+func typeName(v any) string {
+	return fmt.Sprintf("%T", v)
+}
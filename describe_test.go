@@ -0,0 +1,38 @@
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/json"
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_Encoding_Describe(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.NoError(t, registry.RegisterAlias(Mime_XML2, Mime_XML))
+	require.NoError(t, registry.RegisterOutbound(Mime_PostForm, &json.Codec{}))
+
+	require.Equal(t, []EntryInfo{
+		{MIME: Mime_DefaultInbound, Type: "*json.Codec"},
+		{MIME: Mime_DefaultOutbound, Type: "*json.Codec"},
+		{MIME: Mime_Query, Type: "*form.QueryCodec"},
+		{MIME: Mime_Uri, Type: "*form.UriCodec"},
+		{MIME: Mime_JSON, Type: "*json.Codec"},
+		{MIME: Mime_PostForm, Type: "*form.Codec", OutboundType: "*json.Codec"},
+		{MIME: Mime_XML, Type: "*xml.Codec"},
+		{MIME: Mime_MultipartPostForm, Type: "*form.MultipartCodec"},
+		{MIME: Mime_XML2, Type: "*xml.Codec", AliasOf: Mime_XML},
+	}, registry.Describe())
+	require.Equal(t, `__MIME__/DEFAULT_INBOUND: *json.Codec
+__MIME__/DEFAULT_OUTBOUND: *json.Codec
+__MIME__/QUERY: *form.QueryCodec
+__MIME__/URI: *form.UriCodec
+application/json: *json.Codec
+application/x-www-form-urlencoded: *form.Codec (outbound *json.Codec)
+application/xml: *xml.Codec
+multipart/form-data: *form.MultipartCodec
+text/xml: *xml.Codec (alias of application/xml)`, registry.String())
+}
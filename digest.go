@@ -0,0 +1,178 @@
+package encoding
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// contentDigestHeader is the header of the content digest, see RFC 9530.
+var contentDigestHeader = http.CanonicalHeaderKey("Content-Digest")
+
+var (
+	// ErrDigestMismatch is returned by Bind when the `Content-Digest` of the request does not match the body.
+	ErrDigestMismatch = errors.New("encoding: content digest mismatch")
+	// ErrUnsupportedDigest is returned by Bind when the `Content-Digest` of the request is malformed
+	// or has none of the supported algorithms.
+	ErrUnsupportedDigest = errors.New("encoding: unsupported content digest")
+)
+
+// digestAlgorithms is the supported digest algorithms of RFC 9530, the insecure ones are not supported.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// WithContentDigest enables the `Content-Digest` of RFC 9530, Render sets the header with the digests of
+// the marshaled body, like `Content-Digest: sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`,
+// and Bind verifies the digests of the supported algorithms while decoding if the request carries the header,
+// the digests are of the raw body, before its `Content-Encoding` is decoded.
+// The algorithms are "sha-256" and "sha-512", it is "sha-256" if none is given, the unsupported ones are ignored.
+// Bind returns ErrDigestMismatch if any digest does not match, or ErrUnsupportedDigest if the header
+// is malformed or has none of the supported algorithms, the bound value must not be used then.
+// NOTE: the form parsed by the middleware can not be verified, as the body has been consumed.
+func WithContentDigest(algorithms ...string) Option {
+	return func(r *Encoding) {
+		if len(algorithms) == 0 {
+			algorithms = []string{"sha-256"}
+		}
+		r.contentDigest = r.contentDigest[:0]
+		for _, alg := range algorithms {
+			alg = strings.ToLower(alg)
+			if _, ok := digestAlgorithms[alg]; ok {
+				r.contentDigest = append(r.contentDigest, alg)
+			}
+		}
+	}
+}
+
+// contentDigest returns the `Content-Digest` header value of the data with the algorithms.
+func contentDigest(algorithms []string, data []byte) string {
+	var b strings.Builder
+	for i, alg := range algorithms {
+		h := digestAlgorithms[alg]()
+		h.Write(data)
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(alg)
+		b.WriteString("=:")
+		b.WriteString(base64.StdEncoding.EncodeToString(h.Sum(nil)))
+		b.WriteString(":")
+	}
+	return b.String()
+}
+
+// parseContentDigest parses the `Content-Digest` header, which is a structured field dictionary
+// of the byte sequences, like `sha-256=:base64:, sha-512=:base64:;param`, the parameters are ignored.
+// It returns the digests of the supported algorithms.
+func parseContentDigest(values []string) (map[string][]byte, error) {
+	digests := make(map[string][]byte)
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+			key, item, ok := strings.Cut(member, "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid member %q", ErrUnsupportedDigest, member)
+			}
+			item, _, _ = strings.Cut(item, ";")
+			item = strings.TrimSpace(item)
+			if len(item) < 2 || item[0] != ':' || item[len(item)-1] != ':' {
+				return nil, fmt.Errorf("%w: %s is not a byte sequence", ErrUnsupportedDigest, key)
+			}
+			if _, ok := digestAlgorithms[key]; !ok {
+				continue
+			}
+			digest, err := base64.StdEncoding.DecodeString(item[1 : len(item)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedDigest, key, err)
+			}
+			// the later member overrides the earlier one with the same key, like the structured field.
+			digests[key] = digest
+		}
+	}
+	if len(digests) == 0 {
+		return nil, fmt.Errorf("%w: no supported algorithm in %q", ErrUnsupportedDigest, strings.Join(values, ", "))
+	}
+	return digests, nil
+}
+
+// digestReadCloser hashes the bytes read.
+type digestReadCloser struct {
+	io.ReadCloser
+	hashes map[string]hash.Hash
+	n      int64 // the bytes read.
+}
+
+func (d *digestReadCloser) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	d.n += int64(n)
+	for _, h := range d.hashes {
+		h.Write(p[:n])
+	}
+	return n, err
+}
+
+// digestBody verifies the `Content-Digest` of the request if WithContentDigest is set, the digests are
+// computed over the raw body before its content coding is decoded, see RFC 9530. The returned done
+// hashes the rest of the body which the decoder does not read, and replaces the error of the bind
+// with ErrDigestMismatch if any digest does not match, the body which exceeds the limit is not drained,
+// the rest of the body is drained up to limit bytes, beyond which it fails with ErrBodyTooLarge.
+func (r *Encoding) digestBody(req *http.Request, limit int64) (done func(error) error, err error) {
+	values := req.Header[contentDigestHeader]
+	if len(r.contentDigest) == 0 || len(values) == 0 ||
+		formParsed(req, r.load().negotiateContentType(req.Header[contentTypeHeader])) {
+		return noDigest, nil
+	}
+	digests, err := parseContentDigest(values)
+	if err != nil {
+		return nil, err
+	}
+	body := &digestReadCloser{ReadCloser: req.Body, hashes: make(map[string]hash.Hash, len(digests))}
+	for alg := range digests {
+		body.hashes[alg] = digestAlgorithms[alg]()
+	}
+	if req.Body != nil {
+		req.Body = body
+	}
+	return func(err error) error {
+		if req.Body == body {
+			req.Body = body.ReadCloser
+		}
+		if errors.Is(err, ErrBodyTooLarge) || req.Context().Err() != nil {
+			return err
+		}
+		if body.ReadCloser != nil {
+			// hash the rest of the body, the decoder may not read to the end.
+			if limit <= 0 {
+				_, _ = io.Copy(io.Discard, body)
+			} else if _, _ = io.Copy(io.Discard, io.LimitReader(body, limit-body.n+1)); body.n > limit {
+				return fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, limit)
+			}
+		}
+		for alg, digest := range digests {
+			if subtle.ConstantTimeCompare(body.hashes[alg].Sum(nil), digest) != 1 {
+				return fmt.Errorf("%w: %s", ErrDigestMismatch, alg)
+			}
+		}
+		return err
+	}, nil
+}
+
+func noDigest(err error) error { return err }
+
+// formParsed reports whether the form of the request has been parsed by the middleware.
+func formParsed(req *http.Request, n Negotiation) bool {
+	return (n.MediaType == Mime_MultipartPostForm && req.MultipartForm != nil) ||
+		(n.MediaType == Mime_PostForm && req.PostForm != nil)
+}
@@ -0,0 +1,119 @@
+package encoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseContentDigest(t *testing.T) {
+	sum256 := sha256.Sum256([]byte("hello"))
+	sum512 := sha512.Sum512([]byte("hello"))
+	b64256 := base64.StdEncoding.EncodeToString(sum256[:])
+	b64512 := base64.StdEncoding.EncodeToString(sum512[:])
+
+	got, err := parseContentDigest([]string{"sha-256=:" + b64256 + ":;foo=bar , unixsum=:AAA=:", "sha-512=:" + b64512 + ":"})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"sha-256": sum256[:], "sha-512": sum512[:]}, got)
+
+	for _, value := range []string{
+		"md5=:XUFAKrxLKna5cZ2REBfFkg==:",
+		"sha-256",
+		"sha-256=" + b64256,
+		"sha-256=:not base64:",
+	} {
+		_, err = parseContentDigest([]string{value})
+		require.ErrorIs(t, err, ErrUnsupportedDigest, value)
+	}
+}
+
+func Test_Encoding_ContentDigest(t *testing.T) {
+	registry := New(WithContentDigest("SHA-256", "sha-512", "md5"))
+	require.Equal(t, []string{"sha-256", "sha-512"}, registry.contentDigest)
+
+	body := `{"id":"foo","name":"bar"}`
+	newRequest := func(body, digest string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", Mime_JSON)
+		if digest != "" {
+			req.Header.Set("Content-Digest", digest)
+		}
+		return req
+	}
+
+	t.Run("render", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", Mime_JSON)
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.Render(w, req, &TestMode{Id: "foo", Name: "bar"}))
+		require.Equal(t, contentDigest([]string{"sha-256", "sha-512"}, w.Body.Bytes()), w.Header().Get("Content-Digest"))
+
+		digests, err := parseContentDigest(w.Header().Values("Content-Digest"))
+		require.NoError(t, err)
+		sum := sha256.Sum256(w.Body.Bytes())
+		require.Equal(t, sum[:], digests["sha-256"])
+	})
+	t.Run("valid", func(t *testing.T) {
+		sum := sha256.Sum256([]byte(body))
+		got := &TestMode{}
+		digest := "unixsum=:AAA=:, sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+		require.NoError(t, registry.Bind(newRequest(body, digest), got))
+		require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+
+		// without the header, nothing is verified.
+		require.NoError(t, registry.Bind(newRequest(body, ""), &TestMode{}))
+	})
+	t.Run("tampered", func(t *testing.T) {
+		digest := contentDigest([]string{"sha-256", "sha-512"}, []byte(body))
+		err := registry.Bind(newRequest(`{"id":"foo","name":"baz"}`, digest), &TestMode{})
+		require.ErrorIs(t, err, ErrDigestMismatch)
+
+		// the trailing bytes which the decoder does not read are verified too.
+		err = registry.Bind(newRequest(body+"\n\n", digest), &TestMode{})
+		require.ErrorIs(t, err, ErrDigestMismatch)
+	})
+	t.Run("trailing data beyond the limit", func(t *testing.T) {
+		limited := New(WithContentDigest("sha-256"), WithMaxBodyBytes(1024))
+		trailing := body + strings.Repeat(" ", 4096)
+		req := newRequest(trailing, contentDigest([]string{"sha-256"}, []byte(trailing)))
+		req.ContentLength = -1
+		err := limited.Bind(req, &TestMode{})
+		require.ErrorIs(t, err, ErrBodyTooLarge)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		err := registry.Bind(newRequest(body, "md5=:XUFAKrxLKna5cZ2REBfFkg==:"), &TestMode{})
+		require.ErrorIs(t, err, ErrUnsupportedDigest)
+	})
+	t.Run("content coding", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		zw := gzip.NewWriter(buf)
+		_, err := zw.Write([]byte(body))
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		newGzipRequest := func(digest string) *http.Request {
+			req := newRequest(buf.String(), digest)
+			req.Header.Set("Content-Encoding", "gzip")
+			return req
+		}
+
+		// the digest is of the encoded body, not of the decoded one.
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(newGzipRequest(contentDigest([]string{"sha-256"}, buf.Bytes())), got))
+		require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+		err = registry.Bind(newGzipRequest(contentDigest([]string{"sha-256"}, []byte(body))), &TestMode{})
+		require.ErrorIs(t, err, ErrDigestMismatch)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		got := &TestMode{}
+		require.NoError(t, New().Bind(newRequest(body, "sha-256=:AAAA:"), got))
+		require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+	})
+}
@@ -0,0 +1,77 @@
+package encoding
+
+import (
+	"fmt"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// RegisterInbound registers the marshaler of the case-insensitive MIME type for the inbound only,
+// like Bind, InboundForRequest and InboundForResponse, the outbound keeps the bidirectional marshaler
+// of Register, like the strict decoder with the lenient encoder:
+//
+//	_ = registry.RegisterInbound(Mime_JSON, &json.Codec{UseNumber: true, DisallowUnknownFields: true})
+//	_ = registry.RegisterOutbound(Mime_JSON, &jsonpb.Codec{MarshalOptions: protojson.MarshalOptions{EmitUnpopulated: true}})
+//
+// If the MIME type is not registered yet, the marshaler is used for both directions until the other
+// direction is registered. "*" sets Mime_DefaultInbound. Register and Delete reset both directions.
+func (r *Encoding) RegisterInbound(mime string, marshaler codec.Marshaler) error {
+	if err := checkRegistration(mime, marshaler); err != nil {
+		return err
+	}
+	return r.update(func(s *registry) error {
+		return s.setDirection(mime, marshaler, false)
+	})
+}
+
+// RegisterOutbound registers the marshaler of the case-insensitive MIME type for the outbound only,
+// like Render, OutboundForRequest and Encode, see RegisterInbound. "*" sets Mime_DefaultOutbound.
+func (r *Encoding) RegisterOutbound(mime string, marshaler codec.Marshaler) error {
+	if err := checkRegistration(mime, marshaler); err != nil {
+		return err
+	}
+	return r.update(func(s *registry) error {
+		return s.setDirection(mime, marshaler, true)
+	})
+}
+
+// setDirection sets the marshaler of the MIME type for the inbound or outbound.
+func (s *registry) setDirection(mime string, marshaler codec.Marshaler, outbound bool) error {
+	switch mime {
+	case Mime_Wildcard:
+		if outbound {
+			s.mimeOutboundDefault = marshaler
+		} else {
+			s.mimeInboundDefault = marshaler
+		}
+		return nil
+	case Mime_Query, Mime_Uri, Mime_DefaultInbound, Mime_DefaultOutbound:
+		return fmt.Errorf("encoding: MIME(%s) can't be registered per direction", mime)
+	}
+	mime = normalizeMIME(mime)
+	delete(s.aliases, mime)
+	if _, ok := s.mimeMap[mime]; !ok {
+		s.register(mime, marshaler)
+		return nil
+	}
+	if s.outboundMap == nil {
+		s.outboundMap = make(map[string]codec.Marshaler, len(s.mimeMap))
+		for k, v := range s.mimeMap {
+			s.outboundMap[k] = v
+		}
+	}
+	if outbound {
+		s.outboundMap[mime] = marshaler
+	} else {
+		s.mimeMap[mime] = marshaler
+	}
+	return nil
+}
+
+// outboundMarshalers returns the registered marshalers of the outbound.
+func (s *registry) outboundMarshalers() map[string]codec.Marshaler {
+	if s.outboundMap != nil {
+		return s.outboundMap
+	}
+	return s.mimeMap
+}
@@ -0,0 +1,84 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/json"
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_Encoding_RegisterInboundOutbound(t *testing.T) {
+	registry := New()
+	bidirectional := registry.Get(Mime_JSON)
+	inbound, outbound := &json.Codec{DisallowUnknownFields: true}, &json.Codec{UseNumber: true}
+	require.NoError(t, registry.RegisterInbound("Application/JSON", inbound))
+
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Accept", "application/vnd.a+json, application/json")
+		return req
+	}
+	req := newRequest("")
+	_, in := registry.InboundForRequest(req)
+	require.Same(t, inbound, in)
+	require.Same(t, inbound, registry.Get(Mime_JSON))
+	require.Same(t, bidirectional, registry.OutboundForRequest(req))
+
+	require.NoError(t, registry.RegisterOutbound(Mime_JSON, outbound))
+	_, in = registry.InboundForRequest(req)
+	require.Same(t, inbound, in)
+	require.Same(t, outbound, registry.OutboundForRequest(req))
+	require.Same(t, inbound, registry.InboundForResponse(&http.Response{Header: http.Header{"Content-Type": {Mime_JSON}}}))
+	var got TestMode
+	require.Error(t, registry.Bind(newRequest(`{"id":"foo","unknown":1}`), &got))
+
+	// the override and the Accept range use the outbound.
+	req = req.WithContext(WithOutboundMIME(req.Context(), Mime_JSON))
+	require.Same(t, outbound, registry.OutboundForRequest(req))
+	req = newRequest("")
+	req.Header.Set("Accept", "application/*")
+	require.Same(t, outbound, registry.OutboundForRequest(req))
+
+	// the single registration serves both directions.
+	xmlCodec := &xml.Codec{}
+	require.NoError(t, registry.RegisterOutbound(Mime_XML, xmlCodec))
+	require.Same(t, xmlCodec, registry.Get(Mime_XML))
+	require.NoError(t, registry.RegisterAlias(Mime_XML2, Mime_XML))
+	req = newRequest("")
+	req.Header.Set("Accept", Mime_XML2)
+	require.Same(t, xmlCodec, registry.OutboundForRequest(req))
+
+	// the default.
+	require.NoError(t, registry.RegisterOutbound(Mime_Wildcard, &marshalers[0]))
+	require.Same(t, &marshalers[0], registry.Get(Mime_DefaultOutbound))
+	require.NotSame(t, &marshalers[0], registry.Get(Mime_Wildcard))
+	require.Error(t, registry.RegisterInbound(Mime_Query, &marshalers[0]))
+	require.Error(t, registry.RegisterOutbound(Mime_JSON, nil))
+
+	// Register resets both directions.
+	require.NoError(t, registry.Register(Mime_JSON, bidirectional))
+	_, in = registry.InboundForRequest(req)
+	require.Same(t, bidirectional, in)
+	req.Header.Set("Accept", Mime_JSON)
+	require.Same(t, bidirectional, registry.OutboundForRequest(req))
+}
+
+func Test_Encoding_Encode_Outbound(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.RegisterOutbound(Mime_JSON, &marshalers[0]))
+	require.NoError(t, registry.RegisterOutbound(Mime_Wildcard, &marshalers[1]))
+
+	_, err := registry.Encode(Mime_JSON, &TestMode{Id: "foo"})
+	require.EqualError(t, err, "not implemented")
+	_, err = registry.Encode("application/unknown", &TestMode{Id: "foo"})
+	require.EqualError(t, err, "not implemented")
+	data, err := registry.Encode(Mime_Wildcard, &TestMode{Id: "foo"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"foo","name":""}`, string(data))
+}
@@ -0,0 +1,64 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrEmptyBody is returned by Bind when the request body is empty and the marshaler fails to decode it,
+// unless WithAllowEmptyBody is set, it wraps io.EOF of the decoder too.
+var ErrEmptyBody = errors.New("encoding: empty request body")
+
+// WithAllowEmptyBody enables Bind to return nil for the empty request body, leaving v untouched,
+// so the endpoints with the optional body work naturally, the body is empty if its Content-Length is 0,
+// or the chunked body has no bytes. Otherwise Bind returns ErrEmptyBody if the marshaler can not
+// decode the empty body, like JSON.
+func WithAllowEmptyBody() Option {
+	return func(r *Encoding) {
+		r.allowEmptyBody = true
+	}
+}
+
+// emptyBody reports whether the request body is empty, it peeks the first byte of the unknown length body,
+// and the body is replaced by the one which reads the peeked byte first, the caller restores the body.
+func emptyBody(req *http.Request) (bool, error) {
+	if !hasBody(req) {
+		return true, nil
+	}
+	if req.ContentLength > 0 {
+		return false, nil
+	}
+	var b [1]byte
+	n, err := io.ReadFull(req.Body, b[:])
+	if n == 0 {
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+		return false, err
+	}
+	req.Body = &peekedReadCloser{
+		Reader:     io.MultiReader(bytes.NewReader(b[:n]), req.Body),
+		ReadCloser: req.Body,
+	}
+	return false, nil
+}
+
+// peekedReadCloser is the body whose peeked bytes are read first.
+type peekedReadCloser struct {
+	io.Reader
+	io.ReadCloser
+}
+
+func (p *peekedReadCloser) Read(b []byte) (int, error) { return p.Reader.Read(b) }
+
+// emptyBodyError returns ErrEmptyBody wrapping the error if the decoder fails with io.EOF,
+// which means the body is empty, io.ErrUnexpectedEOF means the body is truncated.
+func emptyBodyError(err error) error {
+	if err != nil && errors.Is(err, io.EOF) && !errors.Is(err, ErrEmptyBody) {
+		return fmt.Errorf("%w: %w", ErrEmptyBody, err)
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package encoding
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithAllowEmptyBody(t *testing.T) {
+	newRequest := func(body string, contentLength int64) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", Mime_JSON)
+		req.ContentLength = contentLength
+		return req
+	}
+	sink := newRecordingSink()
+	registry := New(WithAllowEmptyBody(), WithMetrics(sink))
+	for _, tt := range []struct {
+		name string
+		req  *http.Request
+	}{
+		{"no body", func() *http.Request {
+			req := newRequest("", 0)
+			req.Body = http.NoBody
+			return req
+		}()},
+		{"zero content length", newRequest("", 0)},
+		{"chunked zero bytes", newRequest("", -1)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &TestMode{Id: "keep"}
+			require.NoError(t, registry.Bind(tt.req, got))
+			require.Equal(t, &TestMode{Id: "keep"}, got)
+
+			err := New().Bind(tt.req, &TestMode{})
+			require.ErrorIs(t, err, ErrEmptyBody)
+			require.ErrorIs(t, err, io.EOF)
+		})
+	}
+
+	// the chunked body keeps the peeked byte.
+	body := `{"id":"foo"}`
+	got := &TestMode{}
+	require.NoError(t, registry.Bind(newRequest(body, -1), got))
+	require.Equal(t, "foo", got.Id)
+	require.Equal(t, len(body), sink.bind[Mime_JSON].bytes)
+
+	// the wrappers of the body are removed after the bind.
+	limited := New(WithAllowEmptyBody(), WithMaxBodyBytes(1024))
+	req := newRequest(body, -1)
+	original := req.Body
+	require.NoError(t, limited.Bind(req, &TestMode{}))
+	require.Equal(t, original, req.Body)
+
+	// the malformed body is not empty.
+	err := registry.Bind(newRequest(`{"id":`, -1), &TestMode{})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrEmptyBody)
+	require.NotErrorIs(t, New().Bind(newRequest(`{"id":`, -1), &TestMode{}), ErrEmptyBody)
+}
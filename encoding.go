@@ -1,12 +1,18 @@
 package encoding
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"mime"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/thinkgos/encoding/codec"
 	"github.com/thinkgos/encoding/form"
@@ -15,6 +21,15 @@ import (
 
 const defaultMemory = 32 << 20
 
+var (
+	// ErrNotRegistered is returned by DeleteStrict and DeleteAll when the MIME type is not registered.
+	ErrNotRegistered = errors.New("encoding: MIME not registered")
+	// ErrFrozen is returned by Register, Delete and the other mutations when the Encoding is frozen, see Freeze.
+	ErrFrozen = errors.New("encoding: registry is frozen")
+	// ErrUnknownExtension is returned by DecodeFile when the file extension is not registered, see RegisterExtension.
+	ErrUnknownExtension = errors.New("encoding: unknown file extension")
+)
+
 // Content-Type MIME of the most common data formats.
 const (
 	// MIMEURI is special form query.
@@ -22,8 +37,15 @@ const (
 	// Mime_Uri is special form uri.
 	Mime_Uri = "__MIME__/URI"
 	// Mime_Wildcard is the fallback special MIME type used for requests which do not match
-	// a registered MIME type.
+	// a registered MIME type, registering it sets both Mime_DefaultInbound and Mime_DefaultOutbound,
+	// getting it returns the Mime_DefaultInbound one.
 	Mime_Wildcard = "*"
+	// Mime_DefaultInbound is the special MIME type of the fallback marshaler of the request
+	// `Content-Type` which does not match a registered MIME type.
+	Mime_DefaultInbound = "__MIME__/DEFAULT_INBOUND"
+	// Mime_DefaultOutbound is the special MIME type of the fallback marshaler of the request
+	// `Accept` which is absent or does not match a registered MIME type.
+	Mime_DefaultOutbound = "__MIME__/DEFAULT_OUTBOUND"
 
 	Mime_JSON              = "application/json"
 	Mime_HTML              = "text/html"
@@ -37,6 +59,7 @@ const (
 	Mime_MSGPACK2          = "application/msgpack"
 	Mime_YAML              = "application/x-yaml"
 	Mime_TOML              = "application/toml"
+	Mime_OctetStream       = "application/octet-stream"
 )
 
 var (
@@ -45,11 +68,138 @@ var (
 )
 
 // Encoding is a mapping from MIME types to Marshalers.
+// It is safe for concurrent use, the lookups read an immutable snapshot without locking,
+// Register and Delete copy the snapshot, modify and swap it.
 type Encoding struct {
-	mimeMap      map[string]codec.Marshaler
-	mimeQuery    codec.FormMarshaler
-	mimeUri      codec.UriMarshaler
-	mimeWildcard codec.Marshaler
+	mu       sync.Mutex // serializes the writers.
+	snapshot atomic.Pointer[registry]
+	frozen   atomic.Bool
+
+	onBindError   func(*http.Request, error)
+	onRenderError func(*http.Request, error)
+	metrics       Sink
+	acceptCharset bool
+	strictCharset bool
+
+	getBodyBinding     bool
+	mirror             mirrorMode // the outbound follows the request body, see WithMirrorContentType.
+	strictMIMEOverride bool
+	strictAccept       bool
+	strictContentType  bool
+	requireContentType bool
+	sniffContentType   bool
+	methodOverride     bool
+
+	extensions map[string]string // the URL extension to the MIME type.
+
+	maxBodyBytes       int64 // the maximum size of the request body, 0 is unlimited.
+	multipartMaxMemory int64 // the maximum memory of the multipart form, 0 is defaultMemory.
+	allowEmptyBody     bool
+	preserveBody       bool
+
+	validator    func(v any) error
+	bodyDefaults bool
+
+	contentDigest []string // the algorithms of the `Content-Digest`.
+
+	wildcardPriority []string // the preferred MIME types of the `Accept` media range like "application/*".
+
+	marshalErrorFallback func(http.ResponseWriter, *http.Request, error)
+
+	onMarshal   func(mime string, v any, n int, err error)
+	onUnmarshal func(mime string, v any, n int, err error) error
+
+	registrations []registration // the marshalers of the options, which are registered by New.
+	initial       *registry      // the snapshot created by New, see Reset.
+}
+
+// registration is a marshaler to register with the MIME type.
+type registration struct {
+	mime      string
+	marshaler codec.Marshaler
+}
+
+// registry is an immutable snapshot of the Encoding,
+// it must not be modified after it is stored in the Encoding.
+type registry struct {
+	// mimeMap is the registered marshalers of the inbound, which are the bidirectional ones
+	// unless overridden by RegisterInbound.
+	mimeMap map[string]codec.Marshaler
+	// outboundMap is the registered marshalers of the outbound with the same MIME types as mimeMap,
+	// it is nil if it is the same as mimeMap, see RegisterOutbound.
+	outboundMap map[string]codec.Marshaler
+	mimeQuery   codec.FormMarshaler
+	mimeUri     codec.UriMarshaler
+	// mimeInboundDefault and mimeOutboundDefault is the "*" Marshaler of the inbound and outbound.
+	mimeInboundDefault  codec.Marshaler
+	mimeOutboundDefault codec.Marshaler
+	subprotocols        map[string]string
+	// majorTypes is the registered MIME types per major type in the insertion order,
+	// which matches the `Accept` media range like "application/*".
+	majorTypes map[string][]string
+	// wildcardPriority is the preferred MIME types of the media range like "application/*".
+	wildcardPriority []string
+	// aliases is the MIME type aliases to their targets, which are resolved in mimeMap, see RegisterAlias.
+	aliases map[string]string
+	// fileExtensions is the file extension with the leading dot to the MIME type, see RegisterExtension.
+	fileExtensions map[string]string
+	// contentDecoders is the decoders of the content coding of the request body, see RegisterContentEncoding.
+	contentDecoders map[string]ContentDecoder
+	// caches is the negotiation results of the headers with this snapshot.
+	caches *headerCaches
+}
+
+// clone returns a copy of the registry which can be modified.
+func (s *registry) clone() *registry {
+	mimeMap := make(map[string]codec.Marshaler, len(s.mimeMap))
+	for k, v := range s.mimeMap {
+		mimeMap[k] = v
+	}
+	var outboundMap map[string]codec.Marshaler
+	if s.outboundMap != nil {
+		outboundMap = make(map[string]codec.Marshaler, len(s.outboundMap))
+		for k, v := range s.outboundMap {
+			outboundMap[k] = v
+		}
+	}
+	subprotocols := make(map[string]string, len(s.subprotocols))
+	for k, v := range s.subprotocols {
+		subprotocols[k] = v
+	}
+	var aliases map[string]string
+	if len(s.aliases) > 0 {
+		aliases = make(map[string]string, len(s.aliases))
+		for k, v := range s.aliases {
+			aliases[k] = v
+		}
+	}
+	contentDecoders := make(map[string]ContentDecoder, len(s.contentDecoders))
+	for k, v := range s.contentDecoders {
+		contentDecoders[k] = v
+	}
+	fileExtensions := make(map[string]string, len(s.fileExtensions))
+	for k, v := range s.fileExtensions {
+		fileExtensions[k] = v
+	}
+	majorTypes := make(map[string][]string, len(s.majorTypes))
+	for k, v := range s.majorTypes {
+		majorTypes[k] = append([]string(nil), v...)
+	}
+	return &registry{
+		mimeMap:             mimeMap,
+		outboundMap:         outboundMap,
+		mimeQuery:           s.mimeQuery,
+		mimeUri:             s.mimeUri,
+		mimeInboundDefault:  s.mimeInboundDefault,
+		mimeOutboundDefault: s.mimeOutboundDefault,
+		subprotocols:        subprotocols,
+		majorTypes:          majorTypes,
+		aliases:             aliases,
+		fileExtensions:      fileExtensions,
+		contentDecoders:     contentDecoders,
+		wildcardPriority:    s.wildcardPriority,
+		caches:              newHeaderCaches(),
+	}
 }
 
 // New encoding with default Marshalers
@@ -62,7 +212,8 @@ type Encoding struct {
 //	mime_Uri:   form.UriCodec
 //	mime_Wildcard: json.Codec
 //
-// you can manually register your custom Marshaler.
+// you can manually register your custom Marshaler, or with the options like WithMarshaler
+// and WithAllBuiltin, which registers:
 //
 //	Mime_PROTOBUF: proto.Codec
 //	Mime_XML:      xml.Codec
@@ -71,100 +222,496 @@ type Encoding struct {
 //	Mime_MSGPACK2: msgpack.Codec
 //	Mime_YAML:     yaml.Codec
 //	Mime_TOML:    toml.Codec
-func New() *Encoding {
-	return &Encoding{
-		mimeMap: map[string]codec.Marshaler{
-			Mime_PostForm:          form.New("json"),
-			Mime_MultipartPostForm: &form.MultipartCodec{Codec: form.New("json")},
-			Mime_JSON:              &json.Codec{UseNumber: true, DisallowUnknownFields: false},
-		},
-		mimeQuery:    &form.QueryCodec{Codec: form.New("json")},
-		mimeUri:      &form.UriCodec{Codec: form.New("json")},
-		mimeWildcard: &json.Codec{UseNumber: true, DisallowUnknownFields: true},
+//	Mime_Plain:   text.Codec
+//	Mime_OctetStream: raw.Codec
+//
+// It panics if the registration of the options is invalid, see NewWithError.
+func New(opts ...Option) *Encoding {
+	r, err := NewWithError(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// NewWithError is like New, but it returns the error if the registration of the options is invalid,
+// like the empty MIME type or the nil marshaler.
+func NewWithError(opts ...Option) (*Encoding, error) {
+	r := &Encoding{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	wildcard := &json.Codec{UseNumber: true, DisallowUnknownFields: true}
+	s := &registry{
+		mimeMap:             make(map[string]codec.Marshaler),
+		mimeQuery:           &form.QueryCodec{Codec: form.New("json")},
+		mimeUri:             &form.UriCodec{Codec: form.New("json")},
+		mimeInboundDefault:  wildcard,
+		mimeOutboundDefault: wildcard,
+		subprotocols:        defaultSubprotocols(),
+		majorTypes:          make(map[string][]string),
+		wildcardPriority:    r.wildcardPriority,
+		fileExtensions:      make(map[string]string, len(DefaultExtensions)),
+		contentDecoders:     defaultContentDecoders(),
+		caches:              newHeaderCaches(),
+	}
+	for ext, mime := range DefaultExtensions {
+		s.fileExtensions[ext] = mime
+	}
+	s.register(Mime_JSON, &json.Codec{UseNumber: true, DisallowUnknownFields: false})
+	s.register(Mime_PostForm, form.New("json"))
+	s.register(Mime_MultipartPostForm, &form.MultipartCodec{Codec: form.New("json")})
+	for _, reg := range r.registrations {
+		if err := checkRegistration(reg.mime, reg.marshaler); err != nil {
+			return nil, err
+		}
+		if err := s.set(reg.mime, reg.marshaler); err != nil {
+			return nil, err
+		}
 	}
+	r.registrations = nil
+	r.initial = s
+	r.snapshot.Store(s)
+	return r, nil
 }
 
-// Register a marshaler for a case-sensitive MIME type string
-// ("*" to match any MIME type).
+// Clone returns a copy of the Encoding with the registered marshalers and the options,
+// the later Register and Delete of the copy do not affect the Encoding and vice versa.
+// The copy of a frozen Encoding is not frozen.
+// It is cheap, as the immutable snapshot of the registry is shared until either is modified,
+// so it can derive the per-route registries from a base one.
+func (r *Encoding) Clone() *Encoding {
+	c := &Encoding{
+		onBindError:          r.onBindError,
+		onRenderError:        r.onRenderError,
+		metrics:              r.metrics,
+		acceptCharset:        r.acceptCharset,
+		strictCharset:        r.strictCharset,
+		getBodyBinding:       r.getBodyBinding,
+		mirror:               r.mirror,
+		strictMIMEOverride:   r.strictMIMEOverride,
+		strictAccept:         r.strictAccept,
+		strictContentType:    r.strictContentType,
+		requireContentType:   r.requireContentType,
+		sniffContentType:     r.sniffContentType,
+		methodOverride:       r.methodOverride,
+		extensions:           r.extensions,
+		maxBodyBytes:         r.maxBodyBytes,
+		multipartMaxMemory:   r.multipartMaxMemory,
+		allowEmptyBody:       r.allowEmptyBody,
+		preserveBody:         r.preserveBody,
+		validator:            r.validator,
+		bodyDefaults:         r.bodyDefaults,
+		contentDigest:        r.contentDigest,
+		wildcardPriority:     r.wildcardPriority,
+		marshalErrorFallback: r.marshalErrorFallback,
+		onMarshal:            r.onMarshal,
+		onUnmarshal:          r.onUnmarshal,
+		initial:              r.initial,
+	}
+	c.snapshot.Store(r.load())
+	return c
+}
+
+// load returns the current snapshot.
+func (r *Encoding) load() *registry {
+	return r.snapshot.Load()
+}
+
+// Freeze makes the Encoding immutable, the later Register, Delete and the other mutations return ErrFrozen,
+// so the plugins can not re-register the marshalers accidentally after startup, and Reset does nothing.
+// The lookups are lock-free whether it is frozen or not. see Clone for an unfrozen copy.
+func (r *Encoding) Freeze() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frozen.Store(true)
+}
+
+// IsFrozen reports whether the Encoding is frozen, see Freeze.
+func (r *Encoding) IsFrozen() bool {
+	return r.frozen.Load()
+}
+
+// update applies fn to a copy of the current snapshot, and swaps it in if fn succeeds,
+// it returns ErrFrozen if the Encoding is frozen.
+func (r *Encoding) update(fn func(s *registry) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.frozen.Load() {
+		return ErrFrozen
+	}
+	s := r.load().clone()
+	if err := fn(s); err != nil {
+		return err
+	}
+	s.resolveAliases()
+	r.snapshot.Store(s)
+	return nil
+}
+
+// Register a marshaler for a case-insensitive MIME type string
+// ("*" to match any MIME type, both the inbound and outbound, see Mime_DefaultInbound
+// and Mime_DefaultOutbound to set them individually), the MIME type is normalized to lowercase.
 // you can override default marshaler with same MIME type
 func (r *Encoding) Register(mime string, marshaler codec.Marshaler) error {
+	if err := checkRegistration(mime, marshaler); err != nil {
+		return err
+	}
+	return r.update(func(s *registry) error {
+		return s.set(mime, marshaler)
+	})
+}
+
+// MustRegister is like Register, but it panics with the MIME type if the registration is invalid,
+// and returns the Encoding, so the registrations can be chained in the setup code, like
+//
+//	registry := New().MustRegister(Mime_PROTOBUF, &proto.Codec{}).MustRegister(Mime_YAML, &yaml.Codec{})
+func (r *Encoding) MustRegister(mime string, marshaler codec.Marshaler) *Encoding {
+	if err := r.Register(mime, marshaler); err != nil {
+		panic(fmt.Errorf("encoding: register MIME(%s): %w", mime, err))
+	}
+	return r
+}
+
+// RegisterAll registers the marshalers of the MIME types at once like Register,
+// none of them is registered if any is invalid, they are registered in the sorted order of the MIME types.
+func (r *Encoding) RegisterAll(marshalers map[string]codec.Marshaler) error {
+	mimes := make([]string, 0, len(marshalers))
+	for mime, marshaler := range marshalers {
+		if err := checkRegistration(mime, marshaler); err != nil {
+			return fmt.Errorf("encoding: register MIME(%s): %w", mime, err)
+		}
+		mimes = append(mimes, mime)
+	}
+	sort.Strings(mimes)
+	return r.update(func(s *registry) error {
+		for _, mime := range mimes {
+			if err := s.set(mime, marshalers[mime]); err != nil {
+				return fmt.Errorf("encoding: register MIME(%s): %w", mime, err)
+			}
+		}
+		return nil
+	})
+}
+
+// checkRegistration checks the MIME type and marshaler to register.
+func checkRegistration(mime string, marshaler codec.Marshaler) error {
 	if len(mime) == 0 {
 		return errors.New("encoding: empty MIME type")
 	}
 	if marshaler == nil {
 		return errors.New("encoding: marshaller should be not nil")
 	}
+	return nil
+}
+
+// set sets the marshaler of the MIME type, including the special ones.
+func (s *registry) set(mime string, marshaler codec.Marshaler) error {
 	switch mime {
 	case Mime_Query:
 		m, ok := marshaler.(codec.FormMarshaler)
 		if !ok {
 			return errors.New("encoding: marshaller should be implement codec.FormMarshaler")
 		}
-		r.mimeQuery = m
+		s.mimeQuery = m
 	case Mime_Uri:
 		m, ok := marshaler.(codec.UriMarshaler)
 		if !ok {
 			return errors.New("encoding: marshaller should be implement codec.UriMarshaler")
 		}
-		r.mimeUri = m
+		s.mimeUri = m
 	case Mime_Wildcard:
-		r.mimeWildcard = marshaler
+		s.mimeInboundDefault, s.mimeOutboundDefault = marshaler, marshaler
+	case Mime_DefaultInbound:
+		s.mimeInboundDefault = marshaler
+	case Mime_DefaultOutbound:
+		s.mimeOutboundDefault = marshaler
 	default:
-		r.mimeMap[mime] = marshaler
+		mime = normalizeMIME(mime)
+		delete(s.aliases, mime)
+		s.register(mime, marshaler)
 	}
 	return nil
 }
 
-// Get returns the marshalers with a case-sensitive MIME type string
+// Get returns the marshalers with a case-insensitive MIME type string
 // It checks the MIME type on the Encoding.
-// Otherwise, it follows the above logic for "*" Marshaler.
+// Otherwise, it follows the above logic for "*" Marshaler, which is the inbound one,
+// it returns the inbound marshaler of the MIME type too, see RegisterInbound.
+// If WithOnMarshal or WithOnUnmarshal is set, the returned marshaler invokes the hooks,
+// except the form marshalers which implement codec.FormCodec, like Mime_Query and Mime_PostForm.
 func (r *Encoding) Get(mime string) codec.Marshaler {
+	m, _ := r.Lookup(mime)
+	return m
+}
+
+// Lookup returns the marshaler of the case-insensitive MIME type like Get, but reports whether
+// the MIME type is registered, it is false if it falls back to the "*" Marshaler,
+// the special MIME types like Mime_Query and Mime_Wildcard are always registered.
+// It can be used to answer 415 Unsupported Media Type, or validate the configuration at startup.
+func (r *Encoding) Lookup(mime string) (codec.Marshaler, bool) {
+	s := r.load()
+	m := s.get(mime)
+	switch mime {
+	case Mime_Query, Mime_Uri:
+		return m, true
+	case Mime_Wildcard, Mime_DefaultInbound, Mime_DefaultOutbound:
+	default:
+		if _, ok := s.mimeMap[normalizeMIME(mime)]; !ok {
+			return r.hooked(s, Mime_Wildcard, m), false
+		}
+		mime = normalizeMIME(mime)
+		if _, ok := m.(codec.FormCodec); ok {
+			return m, true
+		}
+	}
+	return r.hooked(s, mime, m), true
+}
+
+// LookupBytes is the same as Lookup, but takes the MIME type as bytes, like the headers of fasthttp,
+// it does not allocate unless the MIME type is special or has upper-case letters,
+// or WithOnMarshal or WithOnUnmarshal is set, the hooked marshaler holds a copy of the MIME type.
+func (r *Encoding) LookupBytes(mime []byte) (codec.Marshaler, bool) {
+	switch string(mime) {
+	case Mime_Query, Mime_Uri, Mime_Wildcard, Mime_DefaultInbound, Mime_DefaultOutbound:
+		return r.Lookup(string(mime))
+	}
+	s := r.load()
+	m, ok := s.mimeMap[string(mime)]
+	if !ok {
+		if bytes.ContainsFunc(mime, func(c rune) bool { return 'A' <= c && c <= 'Z' }) {
+			return r.Lookup(string(mime))
+		}
+		return r.hooked(s, Mime_Wildcard, s.mimeInboundDefault), false
+	}
+	if _, ok := m.(codec.FormCodec); ok {
+		return m, true
+	}
+	if r.onMarshal == nil && r.onUnmarshal == nil {
+		return m, true
+	}
+	return r.hooked(s, string(mime), m), true
+}
+
+// Has reports whether the case-insensitive MIME type is registered, see Lookup.
+func (r *Encoding) Has(mime string) bool {
+	if isSpecialMIME(mime) {
+		return true
+	}
+	_, ok := r.load().mimeMap[normalizeMIME(mime)]
+	return ok
+}
+
+func (s *registry) get(mime string) codec.Marshaler {
 	switch mime {
 	case Mime_Query:
-		return r.mimeQuery
+		return s.mimeQuery
 	case Mime_Uri:
-		return r.mimeUri
-	case Mime_Wildcard:
-		return r.mimeWildcard
+		return s.mimeUri
+	case Mime_Wildcard, Mime_DefaultInbound:
+		return s.mimeInboundDefault
+	case Mime_DefaultOutbound:
+		return s.mimeOutboundDefault
 	default:
-		m := r.mimeMap[mime]
+		m := s.mimeMap[normalizeMIME(mime)]
 		if m == nil {
-			m = r.mimeWildcard
+			m = s.mimeInboundDefault
 		}
 		return m
 	}
 }
 
-// Delete remove the MIME type marshaler.
-// MIMEWildcard, MIMEQuery, MIMEURI should be always exist and valid.
+// Delete remove the MIME type marshaler, it does nothing if the MIME type is not registered, see DeleteStrict.
+// MIMEWildcard, MIMEQuery, MIMEURI, Mime_DefaultInbound, Mime_DefaultOutbound should be always exist and valid.
 func (r *Encoding) Delete(mime string) error {
+	if err := checkDeletion(mime); err != nil {
+		return err
+	}
+	return r.update(func(s *registry) error {
+		s.delete(normalizeMIME(mime))
+		return nil
+	})
+}
+
+// DeleteStrict is like Delete, but it returns ErrNotRegistered if the MIME type is not registered,
+// so the typo of the configuration can be detected.
+func (r *Encoding) DeleteStrict(mime string) error {
+	return r.DeleteAll(mime)
+}
+
+// DeleteAll removes the marshalers of the MIME types at once, none of them is removed
+// if any of them can't be deleted like Delete, or is not registered, see DeleteStrict.
+func (r *Encoding) DeleteAll(mimes ...string) error {
+	for _, mime := range mimes {
+		if err := checkDeletion(mime); err != nil {
+			return err
+		}
+	}
+	return r.update(func(s *registry) error {
+		for _, mime := range mimes {
+			mime = normalizeMIME(mime)
+			if _, ok := s.mimeMap[mime]; !ok {
+				return fmt.Errorf("%w: %s", ErrNotRegistered, mime)
+			}
+			s.delete(mime)
+		}
+		return nil
+	})
+}
+
+// Reset restores the Encoding to the marshalers, aliases and subprotocols created by New with its options,
+// the later Register, RegisterAlias, Delete and so on are discarded, it does nothing if the Encoding is frozen.
+func (r *Encoding) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.frozen.Load() {
+		r.snapshot.Store(r.initial)
+	}
+}
+
+// checkDeletion returns an error if the MIME type can't be deleted.
+func checkDeletion(mime string) error {
 	if mime == Mime_Wildcard ||
+		mime == Mime_DefaultInbound ||
+		mime == Mime_DefaultOutbound ||
 		mime == Mime_Query ||
 		mime == Mime_Uri {
 		return fmt.Errorf("encoding: MIME(%s) can't delete, but you can override it", mime)
 	}
-	delete(r.mimeMap, mime)
 	return nil
 }
 
+// delete removes the marshaler and the alias of the normalized MIME type.
+func (s *registry) delete(mime string) {
+	delete(s.aliases, mime)
+	s.unregister(mime)
+}
+
+// MIMETypes returns the sorted registered MIME types, which can be advertised as the `Accept`
+// and `Content-Type` of the API, the special MIME types like Mime_Query, Mime_Uri and Mime_Wildcard
+// are not included, see Get for their marshalers.
+func (r *Encoding) MIMETypes() []string {
+	s := r.load()
+	mimes := make([]string, 0, len(s.mimeMap))
+	for mime := range s.mimeMap {
+		mimes = append(mimes, mime)
+	}
+	sort.Strings(mimes)
+	return mimes
+}
+
+// Marshalers returns a copy of the registered MIME types and their inbound marshalers,
+// the special MIME types are not included like MIMETypes.
+func (r *Encoding) Marshalers() map[string]codec.Marshaler {
+	s := r.load()
+	marshalers := make(map[string]codec.Marshaler, len(s.mimeMap))
+	for mime, m := range s.mimeMap {
+		marshalers[mime] = m
+	}
+	return marshalers
+}
+
+// MustDelete is like Delete, but it panics with the MIME type if the MIME type can't be deleted,
+// and returns the Encoding, so it can be chained like MustRegister.
+func (r *Encoding) MustDelete(mime string) *Encoding {
+	if err := r.Delete(mime); err != nil {
+		panic(err)
+	}
+	return r
+}
+
 // InboundForRequest returns the inbound `Content-Type` and marshalers for this request.
 // It checks the registry on the Encoding for the MIME type set by the `Content-Type` header.
 // If it isn't set (or the request `Content-Type` is empty), checks for "*".
 // If there are multiple `Content-Type` headers set, choose the first one that it can
-// exactly match in the registry.
+// exactly match in the registry, or match by the structured syntax suffix like "+json".
 // Otherwise, it follows the above logic for "*" Marshaler.
+// see NegotiateInbound for the parameters of the `Content-Type`.
 func (r *Encoding) InboundForRequest(req *http.Request) (string, codec.Marshaler) {
-	return r.marshalerFromHeaderContentType(req.Header[contentTypeHeader])
+	n := r.NegotiateInbound(req)
+	return n.MediaType, n.Marshaler
 }
 
 // OutboundForRequest returns the marshalers for this request.
 // It checks the registry on the Encoding for the MIME type set by the `Accept` header.
 // If it isn't set (or the request `Accept` is empty), checks for "*".
-// If there are multiple `Accept` headers set, choose the first one that it can
-// exactly match in the registry.
+// It chooses the registered MIME type with the highest quality value, the earlier one wins
+// for the same quality value, the media type with q=0 is not acceptable, see ParseAccept.
+// The unregistered vendor type like "application/vnd.myapp.v2+json" matches the marshaler of
+// its structured syntax suffix, and Render echoes the vendor type in the `Content-Type`.
 // Otherwise, it follows the above logic for "*" Marshaler.
+// If WithMirrorContentType is set and the `Accept` is absent or only "*/*",
+// or WithOutboundFollowsInbound is set and the `Accept` is absent or unmatched,
+// it uses the marshaler of the registered request `Content-Type` before "*".
+// If WithExtensionNegotiation is set, the registered MIME type of the URL extension like "/users/42.json"
+// takes precedence over the `Accept` header.
+// The registered MIME type override of WithOutboundMIME takes precedence over the `Accept` header.
+// see OutboundForRequestWithMime for the negotiated MIME type, which Render uses for the `Content-Type`.
 func (r *Encoding) OutboundForRequest(req *http.Request) codec.Marshaler {
-	return r.marshalerFromHeaderAccept(req.Header[acceptHeader])
+	_, marshaler := r.outboundForRequest(req)
+	return marshaler
+}
+
+// OutboundForRequestWithMime returns the negotiated MIME type and marshaler for this request,
+// like OutboundForRequest, the MIME type is the registered one which the client asked for,
+// like "text/xml" even if the marshaler is registered under "application/xml" too,
+// the vendor type matched by the structured syntax suffix, or Mime_Wildcard.
+func (r *Encoding) OutboundForRequestWithMime(req *http.Request) (string, codec.Marshaler) {
+	return r.outboundForRequest(req)
+}
+
+// outboundForRequest returns the MIME type and marshaler for this request,
+// it is memoized if the request is returned by WithNegotiation.
+func (r *Encoding) outboundForRequest(req *http.Request) (string, codec.Marshaler) {
+	s := r.load()
+	if mime, m, _ := s.overrideMarshaler(req, outboundMIMEKey{}); m != nil {
+		return mime, m
+	}
+	c := negotiationFromRequest(req)
+	if c != nil && c.outboundSnapshot == s {
+		return c.outboundMime, c.outbound
+	}
+	mime, marshaler := r.negotiateOutbound(s, req)
+	if c != nil {
+		c.outboundSnapshot, c.outboundMime, c.outbound = s, mime, marshaler
+	}
+	return mime, marshaler
+}
+
+// negotiateOutbound negotiates the MIME type and marshaler for this request with the registry snapshot.
+func (r *Encoding) negotiateOutbound(s *registry, req *http.Request) (string, codec.Marshaler) {
+	if mime, m, ok := r.extensionMarshaler(s, req); ok {
+		return mime, m
+	}
+	accept := req.Header[acceptHeader]
+	if r.mirror == mirrorWildcardAccept && isWildcardAccept(accept) {
+		if n := s.negotiateContentType(req.Header[contentTypeHeader]); n.MediaType != Mime_Wildcard {
+			return n.MediaType, n.Marshaler
+		}
+	}
+	mime, marshaler := s.marshalerFromHeaderAccept(accept)
+	if r.mirror == mirrorUnmatchedAccept && mime == Mime_Wildcard {
+		if n := s.negotiateContentType(req.Header[contentTypeHeader]); n.MediaType != Mime_Wildcard && !excludedByAccept(accept, n.MediaType) {
+			return n.MediaType, n.Marshaler
+		}
+	}
+	if r.metrics != nil && mime == Mime_Wildcard {
+		r.metrics.IncFallback(false)
+	}
+	return mime, marshaler
+}
+
+// excludedByAccept reports whether the MIME type is explicitly excluded by q=0 in the `Accept` header.
+func excludedByAccept(accept []string, mime string) bool {
+	if len(accept) == 0 {
+		return false
+	}
+	for _, r := range ParseAccept(accept...) {
+		if r.Q == 0 && r.Type == mime {
+			return true
+		}
+	}
+	return false
 }
 
 // Bind checks the Method and Content-Type to select codec.Marshaler automatically,
@@ -175,33 +722,261 @@ func (r *Encoding) OutboundForRequest(req *http.Request) codec.Marshaler {
 //
 // It parses the request's body as JSON if Content-Type == "application/json" using JSON or XML as a JSON input.
 // It decodes the json payload into the struct specified as a pointer.
-func (r *Encoding) Bind(req *http.Request, v any) error {
-	if req.Method == http.MethodGet {
-		return r.BindQuery(req, v)
+// The GET request binds the query, unless WithGetBodyBinding is set and it has a body
+// with a registered Content-Type, the DELETE, HEAD and OPTIONS requests without a body bind the query too,
+// the method override of the POST request is honored if WithMethodOverride is set.
+// The form parsed before Bind, like by ParseForm or ParseMultipartForm, is reused from req.PostForm
+// and req.MultipartForm instead of the consumed body, see BindValues and BindMultipart.
+// It returns the error of the request context once it is done, like the client disconnects mid-upload,
+// which satisfies errors.Is(err, context.Canceled), so it can be told apart from the server errors.
+// The context is checked between the reads of the body, the pending read of the net/http server body
+// is not interrupted, see http.Server.ReadTimeout or http.ResponseController.SetReadDeadline to bound it.
+// The bound value is validated by the validator of WithValidator, see SkipValidation.
+func (r *Encoding) Bind(req *http.Request, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bind(req, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) bind(req *http.Request, v any, o *bindOptions) error {
+	return r.bindWithLimit(req, v, r.maxBodyBytes, o)
+}
+
+func (r *Encoding) bindWithLimit(req *http.Request, v any, limit int64, o *bindOptions) error {
+	if err := req.Context().Err(); err != nil {
+		return err
+	}
+	if r.bindsQuery(req) {
+		return r.bindQuery(req, v, o)
+	}
+	done, err := r.prepareBody(req, limit)
+	if err != nil {
+		return err
+	}
+	return done(r.bindRequestBody(req, v, o))
+}
+
+// bindRequestBody binds the body of the request with the negotiated inbound marshaler.
+func (r *Encoding) bindRequestBody(req *http.Request, v any, o *bindOptions) error {
+	if err := r.checkOverride(req, inboundMIMEKey{}); err != nil {
+		return err
+	}
+	n := r.NegotiateInbound(req)
+	if err := r.checkContentType(req, n); err != nil {
+		return err
+	}
+	n, restore, sniffed := r.sniffNegotiation(req, n)
+	defer restore()
+	err := r.bindNegotiated(req, n, v, o)
+	var e *BindError
+	if sniffed && errors.As(err, &e) {
+		e.Sniffed = true
+	}
+	return err
+}
+
+// bindNegotiated binds the body of the request with the negotiated inbound marshaler,
+// which is merged into the proto.Message if Merge is set, and applies the default values of WithBodyDefaults.
+func (r *Encoding) bindNegotiated(req *http.Request, n Negotiation, v any, o *bindOptions) error {
+	if err := r.decodeMerged(req, n, v, o); err != nil {
+		return err
+	}
+	if r.bodyDefaults && n.MediaType != Mime_PostForm && n.MediaType != Mime_MultipartPostForm {
+		if d, ok := r.load().mimeQuery.(interface{ ApplyDefaults(any) error }); ok {
+			return d.ApplyDefaults(v)
+		}
 	}
-	contentType, marshaller := r.InboundForRequest(req)
-	if contentType == Mime_MultipartPostForm {
+	return nil
+}
+
+func (r *Encoding) decodeNegotiated(req *http.Request, n Negotiation, v any, o *bindOptions) error {
+	if r.preserveBody && !formParsed(req, n) && hasBody(req) {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		defer func() { req.Body = io.NopCloser(bytes.NewReader(data)) }()
+	}
+	if r.allowEmptyBody && !formParsed(req, n) {
+		body := req.Body
+		if empty, err := emptyBody(req); empty || err != nil {
+			return err
+		}
+		// restore the body, the wrappers restore theirs only if it is on the request, see limitBody.
+		if peeked := req.Body; peeked != body {
+			defer func() {
+				if req.Body == peeked {
+					req.Body = body
+				}
+			}()
+		}
+	}
+	if r.metrics != nil || r.onUnmarshal != nil {
+		body := &countingReadCloser{ReadCloser: req.Body}
+		if req.Body != nil {
+			req.Body = body
+			defer func() { req.Body = body.ReadCloser }()
+		}
+		mime := registeredMIME(r.load().mimeMap, n.MediaType)
+		// bind first, body.n is the count of the bytes read by the bind.
+		err := emptyBodyError(r.bindBody(req, n, v, o))
+		err = r.unmarshaled(mime, v, body.n, err)
+		if r.metrics != nil {
+			r.metrics.IncBind(mime, body.n, err != nil)
+		}
+		return err
+	}
+	return emptyBodyError(r.bindBody(req, n, v, o))
+}
+
+// bindsQuery reports whether the query of the request should be bound instead of the body,
+// the GET request binds the query unless bindGetBody, the DELETE, HEAD and OPTIONS requests
+// bind the query if they have no body, the method is overridden if WithMethodOverride is set, see bindMethod.
+func (r *Encoding) bindsQuery(req *http.Request) bool {
+	switch r.bindMethod(req) {
+	case http.MethodGet:
+		return !r.bindGetBody(req)
+	case http.MethodDelete, http.MethodHead, http.MethodOptions:
+		return !hasBody(req)
+	default:
+		return false
+	}
+}
+
+// hasBody reports whether the request has a body, the unknown length body is a body.
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+}
+
+// bindGetBody reports whether the body of the GET request should be bound,
+// the body must be non-empty and the Content-Type must be registered.
+func (r *Encoding) bindGetBody(req *http.Request) bool {
+	if !r.getBodyBinding || !hasBody(req) {
+		return false
+	}
+	return r.load().negotiateContentType(req.Header[contentTypeHeader]).MediaType != Mime_Wildcard
+}
+
+func (r *Encoding) bindBody(req *http.Request, n Negotiation, v any, o *bindOptions) error {
+	marshaller, err := resolveMarshaler(n.Marshaler)
+	if err != nil {
+		return err
+	}
+	marshaller = o.configureDecoder(marshaller)
+	if n.MediaType == Mime_MultipartPostForm {
 		m, ok := marshaller.(codec.FormCodec)
 		if !ok {
-			return fmt.Errorf("encoding: not supported marshaller(%v)", contentType)
+			return fmt.Errorf("encoding: not supported marshaller(%v)", n.MediaType)
+		}
+		// reuse the multipart form parsed by the middleware, the body has been consumed.
+		if req.MultipartForm == nil {
+			if err := readMultipartForm(req, n.Params["boundary"], r.multipartMemory(o)); err != nil {
+				return err
+			}
+		}
+		return bindError(n.MediaType, v, decodeMultipart(m, req.MultipartForm, v))
+	}
+	if n.MediaType == Mime_PostForm && req.PostForm != nil {
+		// reuse the form parsed by the middleware, the body has been consumed.
+		if m, ok := marshaller.(codec.FormCodec); ok {
+			return bindError(n.MediaType, v, m.Decode(req.PostForm, v))
 		}
-		if err := req.ParseMultipartForm(defaultMemory); err != nil {
+	}
+	var body io.Reader = req.Body
+	if charset := n.Params["charset"]; charset != "" && req.Body != nil {
+		var err error
+		// transcode the non UTF-8 body, the decoders expect UTF-8.
+		if body, err = charsetReader(req.Body, charset); err != nil {
 			return err
 		}
-		return m.Decode(req.MultipartForm.Value, v)
 	}
-	return marshaller.NewDecoder(req.Body).
-		Decode(v)
+	if f, ok := marshaller.(codec.ResettableDecoderFactory); ok {
+		d := f.AcquireDecoder(body)
+		defer f.ReleaseDecoder(d)
+		return bindError(n.MediaType, v, d.Decode(v))
+	}
+	return bindError(n.MediaType, v, marshaller.NewDecoder(body).Decode(v))
 }
 
 // BindQuery binds the passed struct pointer using the query codec.Marshaler.
-func (r *Encoding) BindQuery(req *http.Request, v any) error {
-	return r.mimeQuery.Decode(req.URL.Query(), v)
+func (r *Encoding) BindQuery(req *http.Request, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindQuery(req, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindQuery(req *http.Request, v any, o *bindOptions) error {
+	err := bindError(Mime_Query, v, o.configureForm(r.load().mimeQuery).Decode(req.URL.Query(), v))
+	err = r.unmarshaled(Mime_Query, v, len(req.URL.RawQuery), err)
+	if r.metrics != nil {
+		r.metrics.IncBind(Mime_Query, len(req.URL.RawQuery), err != nil)
+	}
+	return err
 }
 
 // BindUri binds the passed struct pointer using the uri codec.Marshaler.
-func (r *Encoding) BindUri(raws url.Values, v any) error {
-	return r.mimeUri.Decode(raws, v)
+// NOTE: the *http.Request passed to the bind error callback is nil.
+func (r *Encoding) BindUri(raws url.Values, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, bindError(Mime_Uri, v, o.configureForm(r.load().mimeUri).Decode(raws, v)), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, nil, err)
+	}
+	return err
+}
+
+// BindPath binds the passed struct pointer with the variables of the request path,
+// which is matched against the path template like /v1/{name}/sub/{sub.name} using
+// the uri codec.Marshaler, the variable {name=**} matches multiple path segments.
+// It returns a *form.PathMismatchError if the request path does not match the template.
+func (r *Encoding) BindPath(req *http.Request, pathTemplate string, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindPath(req, pathTemplate, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindPath(req *http.Request, pathTemplate string, v any, o *bindOptions) error {
+	return r.bindUriFromPath(pathTemplate, req.URL.EscapedPath(), v, o)
+}
+
+// BindUriFromPath binds the passed struct pointer with the variables of the path like BindPath,
+// the path is the escaped request path, like "/v1/foo/sub/a%2Fb", so the framework integrations
+// need not extract the path variables into url.Values for BindUri.
+// NOTE: the *http.Request passed to the bind error callback is nil.
+func (r *Encoding) BindUriFromPath(pathTemplate, path string, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindUriFromPath(pathTemplate, path, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, nil, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindUriFromPath(pathTemplate, path string, v any, o *bindOptions) error {
+	m := o.configureForm(r.load().mimeUri)
+	if d, ok := m.(codec.UriDecoder); ok {
+		err := d.DecodeUrl(pathTemplate, path, v)
+		var mismatch *form.PathMismatchError
+		if errors.As(err, &mismatch) {
+			return err
+		}
+		return bindError(Mime_Uri, v, err)
+	}
+	vs, err := form.MatchPath(pathTemplate, path)
+	if err != nil {
+		return err
+	}
+	return bindError(Mime_Uri, v, m.Decode(vs, v))
 }
 
 // Render writes the response headers and calls the outbound marshalers for this request.
@@ -214,27 +989,133 @@ func (r *Encoding) BindUri(raws url.Values, v any) error {
 // If there are multiple Accept headers set, choose the first one that it can
 // exactly match in the registry.
 // Otherwise, it follows the above logic for "*" Marshaler.
+// Render never touches the ResponseWriter before Marshal succeeds, so the caller can write
+// its own error response if it returns an error, or use WithMarshalErrorFallback to do it.
+// Render sets the Content-Length header of the marshaled body, and writes the headers without the body
+// for the HEAD request.
+// Render does not write the status code, the first Write writes 200 OK if the header hasn't been written,
+// see RenderStatus to write the other status codes.
 func (r *Encoding) Render(w http.ResponseWriter, req *http.Request, v any) error {
-	if v == nil {
+	err := r.render(w, req, 0, v)
+	if err != nil && r.onRenderError != nil {
+		callErrorHook(r.onRenderError, req, err)
+	}
+	return err
+}
+
+// RenderStatus writes the response like Render with the status code, like http.StatusCreated,
+// the headers like the `Content-Type` and the `Content-Length` are set before the status code is written,
+// which the caller can't do by calling WriteHeader before Render, since the later headers are ignored.
+// The nil v still writes the status code without a body, so does the status code which does not allow
+// a body, like http.StatusNoContent and http.StatusNotModified.
+// Like Render, it never touches the ResponseWriter before Marshal succeeds.
+func (r *Encoding) RenderStatus(w http.ResponseWriter, req *http.Request, code int, v any) error {
+	err := checkStatusCode(code)
+	if err == nil {
+		err = r.render(w, req, code, v)
+	}
+	if err != nil && r.onRenderError != nil {
+		callErrorHook(r.onRenderError, req, err)
+	}
+	return err
+}
+
+// render writes the response with the status code, 0 leaves the status code to the first Write.
+func (r *Encoding) render(w http.ResponseWriter, req *http.Request, code int, v any) error {
+	if v == nil || !bodyAllowedForStatus(code) {
+		if code != 0 {
+			w.WriteHeader(code)
+		}
 		return nil
 	}
-	marshaller := r.OutboundForRequest(req)
-	data, err := marshaller.Marshal(v)
+	if err := r.checkOverride(req, outboundMIMEKey{}); err != nil {
+		return err
+	}
+	if r.strictAccept && !r.acceptable(req) {
+		if r.metrics != nil {
+			r.metrics.IncRender(Mime_Wildcard, 0, true)
+		}
+		return ErrNotAcceptable
+	}
+	mime, marshaller := r.outboundForRequest(req)
+	return r.renderNegotiated(w, req, code, v, mime, marshaller)
+}
+
+// renderNegotiated writes the response with the negotiated outbound MIME type and marshaler,
+// the status code is written after the headers, 0 leaves it to the first Write,
+// the body is not written for the HEAD request.
+func (r *Encoding) renderNegotiated(w http.ResponseWriter, req *http.Request, code int, v any, mime string, marshaller codec.Marshaler) error {
+	s := r.load()
+	key := registeredMIME(s.outboundMarshalers(), mime)
+	data, release, err := marshal(marshaller, v)
+	defer release()
+	err = renderError(mime, v, err)
+	r.marshaled(key, v, len(data), err)
 	if err != nil {
+		if r.metrics != nil {
+			r.metrics.IncRender(key, 0, true)
+		}
+		if r.marshalErrorFallback != nil {
+			r.marshalErrorFallback(w, req, err)
+		}
 		return err
 	}
-	w.Header().Set("Content-Type", marshaller.ContentType(v))
-	_, err = w.Write(data)
+	contentType := s.outboundContentType(mime, marshaller, marshaller.ContentType(v))
+	if r.acceptCharset {
+		data, contentType, err = r.transformCharset(req, contentType, data)
+		if err != nil {
+			if r.metrics != nil {
+				r.metrics.IncRender(key, 0, true)
+			}
+			return err
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	if len(r.contentDigest) > 0 {
+		w.Header().Set(contentDigestHeader, contentDigest(r.contentDigest, data))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if req != nil && req.Method == http.MethodHead {
+		// the zero code leaves the status to the writer, the wrappers write their pending one.
+		if code != 0 {
+			w.WriteHeader(code)
+		}
+		if r.metrics != nil {
+			r.metrics.IncRender(key, 0, false)
+		}
+		return nil
+	}
+	if code != 0 {
+		w.WriteHeader(code)
+	}
+	n, err := w.Write(data)
+	if r.metrics != nil {
+		r.metrics.IncRender(key, n, err != nil)
+	}
 	return err
 }
 
+// parseAcceptHeader splits the header by commas which are not in the quoted string,
+// and trims the spaces of each value.
 func parseAcceptHeader(header string) []string {
-	// TODO: cache header maps to avoid parse again?
-	values := strings.Split(header, ",")
-	for i := 0; i < len(values); i++ {
-		values[i] = strings.TrimSpace(values[i])
+	var values []string
+	var quoted, escaped bool
+
+	start := 0
+	for i := 0; i < len(header); i++ {
+		switch c := header[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			values = append(values, strings.TrimSpace(header[start:i]))
+			start = i + 1
+		}
 	}
-	return values
+	return append(values, strings.TrimSpace(header[start:]))
 }
 
 // InboundForResponse returns the inbound marshaler for this response.
@@ -244,74 +1125,188 @@ func parseAcceptHeader(header string) []string {
 // exactly match in the registry.
 // Otherwise, it follows the above logic for "*" Marshaler.
 func (r *Encoding) InboundForResponse(resp *http.Response) codec.Marshaler {
-	_, marshaler := r.marshalerFromHeaderContentType(resp.Header[contentTypeHeader])
-	return marshaler
+	return r.load().negotiateContentType(resp.Header[contentTypeHeader]).Marshaler
 }
 
-// Encode encode v use contentType
+// Encode encode v use contentType, with the outbound marshaler, see RegisterOutbound.
 func (r *Encoding) Encode(contentType string, v any) ([]byte, error) {
-	return r.Get(contentType).Marshal(v)
+	s := r.load()
+	mime, m := contentType, s.get(contentType)
+	if !isSpecialMIME(contentType) {
+		var ok bool
+		if m, ok = s.outboundMarshalers()[normalizeMIME(contentType)]; ok {
+			mime = normalizeMIME(contentType)
+		} else {
+			mime, m = Mime_Wildcard, s.mimeOutboundDefault
+		}
+	}
+	data, err := m.Marshal(v)
+	r.marshaled(mime, v, len(data), err)
+	return data, err
 }
 
 // EncodeQuery encode v to the query url.Values.
 func (r *Encoding) EncodeQuery(v any) (url.Values, error) {
-	return r.mimeQuery.Encode(v)
+	return r.load().mimeQuery.Encode(v)
 }
 
 // EncodeUrl encode msg to url path.
 // pathTemplate is a template of url path like http://helloworld.dev/{name}/sub/{sub.name},
 func (r *Encoding) EncodeUrl(athTemplate string, msg any, needQuery bool) string {
-	return r.mimeUri.EncodeUrl(athTemplate, msg, needQuery)
+	return r.load().mimeUri.EncodeUrl(athTemplate, msg, needQuery)
 }
 
-// marshalerFromHeaderContentType returns the `Content-Type` and marshaler from `Content-Type` header.
+// negotiateContentType returns the negotiation from `Content-Type` header.
 // It checks the registry on the Encoding for the MIME type set by the `Content-Type` header.
 // If it isn't set (or the `Content-Type` is empty), checks for "*".
 // If there are multiple `Content-Type` headers set, choose the first one that it can
-// exactly match in the registry.
-// Otherwise, it follows the above logic for "*" Marshaler.
-func (r *Encoding) marshalerFromHeaderContentType(values []string) (string, codec.Marshaler) {
-	var err error
-	var marshaler codec.Marshaler
-	var contentType string
+// exactly match in the registry, or match by the structured syntax suffix, like "application/problem+json"
+// matches the "application/json" marshaler, and the vendor type is the negotiated MIME type.
+// Otherwise, it follows the above logic for "*" Marshaler, with the parameters of the first valid header.
+// NOTE: it does not allocate, the result of the `Content-Type` with parameters or uppercase letters is cached.
+func (s *registry) negotiateContentType(values []string) Negotiation {
+	if len(values) != 1 || (strings.IndexByte(values[0], ';') < 0 && !hasUpper(values[0])) || s.caches == nil {
+		return s.parseContentType(values)
+	}
+	if n, ok := s.caches.contentTypes.Get(values[0]); ok {
+		return n
+	}
+	n := s.parseContentType(values)
+	// the boundary of the multipart is unique per request.
+	if n.MediaType != Mime_MultipartPostForm {
+		s.caches.contentTypes.Add(values[0], n)
+	}
+	return n
+}
 
-	for _, contentTypeVal := range values {
-		contentType, _, err = mime.ParseMediaType(contentTypeVal)
-		if err != nil {
-			continue
+// parseContentType returns the negotiation from `Content-Type` header without the cache.
+func (s *registry) parseContentType(values []string) Negotiation {
+	var fallbackParams map[string]string
+	for i, value := range values {
+		mediaType := value
+		var params map[string]string
+		if strings.IndexByte(value, ';') >= 0 {
+			var err error
+			mediaType, params, err = mime.ParseMediaType(value)
+			if err != nil {
+				continue
+			}
+			if i == 0 {
+				fallbackParams = params
+			}
 		}
-		if m, ok := r.mimeMap[contentType]; ok {
-			marshaler = m
+		if contentType, m, ok := lookupStructured(s.mimeMap, strings.TrimSpace(mediaType)); ok {
+			return Negotiation{MediaType: contentType, Params: params, Marshaler: m}
+		}
+	}
+	return Negotiation{MediaType: Mime_Wildcard, Params: fallbackParams, Marshaler: s.mimeInboundDefault}
+}
+
+// marshalerFromHeaderAccept returns the MIME type and marshaler from `Accept` header.
+// It checks the registry on the Encoding for the MIME types set by the `Accept` header,
+// which may be the media range like "application/*", see WithAcceptWildcardPriority,
+// and chooses the registered one with the highest quality value, the exact match wins for the same
+// quality value, then the earlier one across the header lines, the media type with q=0 is not acceptable.
+// If it isn't set (or none of the `Accept` is registered), checks for "*".
+// NOTE: it does not allocate, the result of the `Accept` with multiple media types, parameters
+// or uppercase letters is cached.
+func (s *registry) marshalerFromHeaderAccept(values []string) (string, codec.Marshaler) {
+	if len(values) != 1 || (strings.IndexAny(values[0], ",;") < 0 && !hasUpper(values[0])) || s.caches == nil {
+		return s.parseAccept(values)
+	}
+	if a, ok := s.caches.accepts.Get(values[0]); ok {
+		return a.mime, a.marshaler
+	}
+	mime, m := s.parseAccept(values)
+	s.caches.accepts.Add(values[0], acceptResult{mime: mime, marshaler: m})
+	return mime, m
+}
+
+// parseAccept returns the MIME type and marshaler from `Accept` header without the cache,
+// the media ranges are parsed by ParseAccept, except the single media type without parameters.
+func (s *registry) parseAccept(values []string) (string, codec.Marshaler) {
+	if len(values) == 1 && strings.IndexAny(values[0], ",;") < 0 {
+		if mime, m, match := s.lookupAccept(strings.TrimSpace(values[0]), nil); match != acceptNoMatch {
+			return mime, m
+		}
+		return Mime_Wildcard, s.mimeOutboundDefault
+	}
+	bestMime, bestQ, bestMatch := "", 0.0, acceptNoMatch
+	var best codec.Marshaler
+	// the media ranges are sorted by the quality value descending.
+	ranges := ParseAccept(values...)
+	excluded := excludedTypes(ranges)
+	for _, r := range ranges {
+		if r.Q <= 0 || r.Q < bestQ || bestMatch == acceptExactMatch {
 			break
 		}
+		if mime, m, match := s.lookupAccept(r.Type, excluded); match > bestMatch {
+			bestMime, bestQ, bestMatch, best = mime, r.Q, match, m
+		}
 	}
-	if marshaler == nil {
-		contentType = Mime_Wildcard
-		marshaler = r.mimeWildcard
+	if best == nil {
+		return Mime_Wildcard, s.mimeOutboundDefault
 	}
-	return contentType, marshaler
+	return bestMime, best
 }
 
-// marshalerFromHeaderAccept returns the marshalers from `Accept` header.
-// It checks the registry on the Encoding for the MIME type set by the `Accept` header.
-// If it isn't set (or the `Accept` is empty), checks for "*".
-// If there are multiple `Accept` headers set, choose the first one that it can
-// exactly match in the registry.
-// Otherwise, it follows the above logic for "*" Marshaler.
-func (r *Encoding) marshalerFromHeaderAccept(values []string) codec.Marshaler {
-	var marshaler codec.Marshaler
-
-	for _, acceptVal := range values {
-		headerValues := parseAcceptHeader(acceptVal)
-		for _, value := range headerValues {
-			if m, ok := r.mimeMap[value]; ok {
-				marshaler = m
-				break
+// isWildcardAccept reports whether the `Accept` header is absent or only "*/*".
+func isWildcardAccept(values []string) bool {
+	for _, accept := range values {
+		for len(accept) > 0 {
+			var value string
+			value, accept, _ = strings.Cut(accept, ",")
+			mediaType, _, _ := strings.Cut(value, ";")
+			if mediaType = strings.TrimSpace(mediaType); mediaType != "" && mediaType != "*/*" {
+				return false
 			}
 		}
 	}
-	if marshaler == nil {
-		marshaler = r.mimeWildcard
+	return true
+}
+
+// lookup returns the registered MIME type and marshaler of the marshalers, which is mimeMap or
+// the outboundMarshalers, which matches the media type case-insensitively, as the registered MIME types are lowercase.
+func lookup(marshalers map[string]codec.Marshaler, mediaType string) (string, codec.Marshaler, bool) {
+	mediaType = normalizeMIME(mediaType)
+	if m, ok := marshalers[mediaType]; ok {
+		return mediaType, m, true
 	}
-	return marshaler
+	return "", nil, false
+}
+
+// normalizeMIME returns the lowercase MIME type, it does not allocate if it is lowercase already.
+func normalizeMIME(mime string) string {
+	if hasUpper(mime) {
+		return strings.ToLower(mime)
+	}
+	return mime
+}
+
+func hasUpper(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if 'A' <= s[i] && s[i] <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStatusCode returns an error if the status code is not a valid 3-digit HTTP status code.
+func checkStatusCode(code int) error {
+	if code < 100 || code > 999 {
+		return fmt.Errorf("encoding: invalid status code %d", code)
+	}
+	return nil
+}
+
+// bodyAllowedForStatus reports whether the status code permits a body, see RFC 9110, 0 is 200 OK.
+func bodyAllowedForStatus(code int) bool {
+	switch {
+	case code >= 100 && code <= 199:
+		return false
+	case code == http.StatusNoContent, code == http.StatusNotModified:
+		return false
+	}
+	return true
 }
@@ -11,7 +11,11 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
@@ -69,9 +73,388 @@ func Test_Encoding_Register(t *testing.T) {
 		require.Error(t, err)
 		err = registry.Delete(Mime_Uri)
 		require.Error(t, err)
+		err = registry.Delete(Mime_DefaultInbound)
+		require.Error(t, err)
+		err = registry.Delete(Mime_DefaultOutbound)
+		require.Error(t, err)
+	})
+	t.Run("registered MIME types", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Register("Application/X-Protobuf", &pro.Codec{}))
+		require.NoError(t, registry.Register(Mime_Query, &form.QueryCodec{Codec: form.New("json")}))
+		require.NoError(t, registry.Register(Mime_Wildcard, &json.Codec{}))
+		require.Equal(t, []string{Mime_JSON, Mime_PROTOBUF, Mime_PostForm, Mime_MultipartPostForm}, registry.MIMETypes())
+
+		marshalers := registry.Marshalers()
+		require.Len(t, marshalers, 4)
+		require.Same(t, registry.Get(Mime_PROTOBUF), marshalers[Mime_PROTOBUF])
+		delete(marshalers, Mime_JSON)
+		require.NoError(t, registry.Delete(Mime_PostForm))
+		require.Equal(t, []string{Mime_JSON, Mime_PROTOBUF, Mime_MultipartPostForm}, registry.MIMETypes())
+		require.Len(t, marshalers, 3)
+	})
+	t.Run("delete strict", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Delete(Mime_XML))
+		require.ErrorIs(t, registry.DeleteStrict(Mime_XML), ErrNotRegistered)
+		require.NoError(t, registry.DeleteStrict("Application/JSON"))
+		require.False(t, registry.Has(Mime_JSON))
+		require.ErrorIs(t, registry.DeleteStrict(Mime_JSON), ErrNotRegistered)
+		require.EqualError(t, registry.DeleteStrict(Mime_Wildcard), "encoding: MIME(*) can't delete, but you can override it")
+	})
+	t.Run("delete all", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+		require.ErrorIs(t, registry.DeleteAll(Mime_XML, Mime_YAML), ErrNotRegistered)
+		require.Error(t, registry.DeleteAll(Mime_XML, Mime_Query))
+		require.True(t, registry.Has(Mime_XML))
+		require.NoError(t, registry.DeleteAll(Mime_XML, Mime_PostForm))
+		require.Equal(t, []string{Mime_JSON, Mime_MultipartPostForm}, registry.MIMETypes())
+		require.NoError(t, registry.DeleteAll())
+	})
+	t.Run("reset", func(t *testing.T) {
+		registry := New(WithMarshaler(Mime_XML, &xml.Codec{}))
+		want := registry.MIMETypes()
+		wildcard := registry.Get(Mime_Wildcard)
+		require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+		require.NoError(t, registry.Register(Mime_Wildcard, &marshalers[0]))
+		require.NoError(t, registry.Delete(Mime_XML))
+		require.NoError(t, registry.RegisterSubprotocol("custom", Mime_YAML))
+		clone := registry.Clone()
+
+		registry.Reset()
+		require.Equal(t, want, registry.MIMETypes())
+		require.Same(t, wildcard, registry.Get(Mime_Wildcard))
+		require.NotContains(t, registry.Subprotocols(), "custom")
+		require.Contains(t, clone.Subprotocols(), "custom")
+		clone.Reset()
+		require.Equal(t, want, clone.MIMETypes())
+	})
+	t.Run("must register", func(t *testing.T) {
+		registry := New().MustRegister(Mime_PROTOBUF, &pro.Codec{}).MustRegister(Mime_YAML, &yaml.Codec{}).MustDelete(Mime_PostForm)
+		require.Equal(t, []string{Mime_JSON, Mime_PROTOBUF, Mime_YAML, Mime_MultipartPostForm}, registry.MIMETypes())
+		require.PanicsWithError(t, "encoding: register MIME(application/x-yaml): encoding: marshaller should be not nil", func() {
+			registry.MustRegister(Mime_YAML, nil)
+		})
+		require.PanicsWithError(t, "encoding: MIME(__MIME__/URI) can't delete, but you can override it", func() {
+			registry.MustDelete(Mime_Uri)
+		})
+	})
+	t.Run("register all", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.RegisterAll(map[string]codec.Marshaler{
+			Mime_YAML:     &yaml.Codec{},
+			Mime_XML:      &xml.Codec{},
+			Mime_Wildcard: &marshalers[0],
+		}))
+		require.Equal(t, []string{Mime_JSON, Mime_PostForm, Mime_YAML, Mime_XML, Mime_MultipartPostForm}, registry.MIMETypes())
+		require.Same(t, &marshalers[0], registry.Get(Mime_Wildcard))
+
+		// none is registered if any is invalid.
+		err := registry.RegisterAll(map[string]codec.Marshaler{
+			Mime_PROTOBUF: &pro.Codec{},
+			Mime_Uri:      &marshalers[1],
+		})
+		require.ErrorContains(t, err, "MIME(__MIME__/URI)")
+		err = registry.RegisterAll(map[string]codec.Marshaler{
+			Mime_PROTOBUF: &pro.Codec{},
+			Mime_TOML:     nil,
+		})
+		require.ErrorContains(t, err, "MIME(application/toml)")
+		require.NotContains(t, registry.MIMETypes(), Mime_PROTOBUF)
+	})
+	t.Run("default inbound and outbound", func(t *testing.T) {
+		registry := New()
+		require.Same(t, registry.Get(Mime_DefaultInbound), registry.Get(Mime_DefaultOutbound))
+
+		inbound, outbound := &json.Codec{DisallowUnknownFields: true}, &json.Codec{UseNumber: true}
+		require.NoError(t, registry.Register(Mime_DefaultInbound, inbound))
+		require.NoError(t, registry.Register(Mime_DefaultOutbound, outbound))
+		require.Same(t, inbound, registry.Get(Mime_Wildcard))
+		require.Same(t, inbound, registry.Get(Mime_DefaultInbound))
+		require.Same(t, inbound, registry.Get("application/unknown"))
+		require.Same(t, outbound, registry.Get(Mime_DefaultOutbound))
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+		req.Header.Set("Content-Type", "application/unknown")
+		req.Header.Set("Accept", "application/unknown")
+		mime, in := registry.InboundForRequest(req)
+		require.Equal(t, Mime_Wildcard, mime)
+		require.Same(t, inbound, in)
+		mime, out := registry.OutboundForRequestWithMime(req)
+		require.Equal(t, Mime_Wildcard, mime)
+		require.Same(t, outbound, out)
+
+		// the wildcard sets both for compatibility.
+		require.NoError(t, registry.Register(Mime_Wildcard, &marshalers[0]))
+		require.Same(t, &marshalers[0], registry.Get(Mime_DefaultInbound))
+		require.Same(t, &marshalers[0], registry.Get(Mime_DefaultOutbound))
+	})
+}
+
+func Test_Encoding_Clone(t *testing.T) {
+	parent := New(WithStrictAccept())
+	require.NoError(t, parent.Register(Mime_PROTOBUF, &pro.Codec{}))
+	child := parent.Clone()
+	require.Equal(t, parent.MIMETypes(), child.MIMETypes())
+	require.Same(t, parent.Get(Mime_Query), child.Get(Mime_Query))
+	require.Same(t, parent.Get(Mime_Uri), child.Get(Mime_Uri))
+	require.Same(t, parent.Get(Mime_Wildcard), child.Get(Mime_Wildcard))
+
+	// the child does not affect the parent.
+	indented := &json.Codec{UseNumber: true}
+	require.NoError(t, child.Register(Mime_JSON, indented))
+	require.NoError(t, child.Register(Mime_XML, &xml.Codec{}))
+	require.NoError(t, child.Delete(Mime_PROTOBUF))
+	require.Same(t, indented, child.Get(Mime_JSON))
+	require.NotSame(t, indented, parent.Get(Mime_JSON))
+	require.Equal(t, []string{Mime_JSON, Mime_PROTOBUF, Mime_PostForm, Mime_MultipartPostForm}, parent.MIMETypes())
+	require.Equal(t, []string{Mime_JSON, Mime_PostForm, Mime_XML, Mime_MultipartPostForm}, child.MIMETypes())
+
+	// the parent does not affect the child.
+	require.NoError(t, parent.Register(Mime_YAML, &yaml.Codec{}))
+	require.NoError(t, parent.Register(Mime_Wildcard, &marshalers[0]))
+	require.NotContains(t, child.MIMETypes(), Mime_YAML)
+	require.NotSame(t, &marshalers[0], child.Get(Mime_Wildcard))
+
+	// the options are carried over.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", "text/html")
+	_, err := child.OutboundForRequestStrict(req)
+	require.Equal(t, ErrNotAcceptable, err)
+}
+
+func Test_Encoding_Concurrent(t *testing.T) {
+	registry := New()
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", Mime_XML)
+	req.Header.Set("Accept", Mime_YAML)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = registry.Register(Mime_XML, &xml.Codec{})
+				_ = registry.Register(Mime_YAML, &yaml.Codec{})
+				_ = registry.Delete(Mime_XML)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, m := registry.InboundForRequest(req)
+				require.NotNil(t, m)
+				require.NotNil(t, registry.OutboundForRequest(req))
+				require.NotNil(t, registry.Get(Mime_Wildcard))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, &yaml.Codec{}, registry.Get(Mime_YAML))
+}
+
+func Test_Encoding_Concurrent_Bind(t *testing.T) {
+	registry := New()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 200; j++ {
+			_ = registry.Register(Mime_JSON, &json.Codec{UseNumber: true})
+			_ = registry.Register(Mime_YAML, &yaml.Codec{})
+			_ = registry.Register(Mime_Wildcard, &json.Codec{UseNumber: true})
+			_ = registry.Delete(Mime_YAML)
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"id":"foo","name":"bar"}`))
+				req.Header.Set("Content-Type", "application/json; charset=utf-8")
+				req.Header.Set("Accept", "application/x-yaml, application/json;q=0.9")
+				var got TestMode
+				if err := registry.Bind(req, &got); err != nil || got.Id != "foo" {
+					t.Errorf("Bind() = %v, %v", got, err)
+					return
+				}
+				w := httptest.NewRecorder()
+				if err := registry.Render(w, req, &got); err != nil || w.Body.Len() == 0 {
+					t.Errorf("Render() = %q, %v", w.Body.String(), err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Benchmark_Encoding_InboundForRequest_Parallel(b *testing.B) {
+	registry := New()
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", Mime_JSON)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = registry.Register(Mime_XML, &xml.Codec{})
+			}
+		}
+	}()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = registry.InboundForRequest(req)
+		}
+	})
+}
+
+func Test_Encoding_Negotiation(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+
+	t.Run("content type", func(t *testing.T) {
+		tests := []struct {
+			contentType []string
+			wantMime    string
+		}{
+			{[]string{"application/xml"}, Mime_XML},
+			{[]string{" application/xml "}, Mime_XML},
+			{[]string{"Application/XML"}, Mime_XML},
+			{[]string{"application/xml; charset=utf-8"}, Mime_XML},
+			{[]string{"Application/XML; charset=utf-8"}, Mime_XML},
+			{[]string{"application/xml; charset"}, Mime_Wildcard},
+			{[]string{"application/unknown", "application/x-yaml"}, Mime_YAML},
+			// the first matching header line wins.
+			{[]string{"application/xml", "application/x-yaml"}, Mime_XML},
+			{[]string{"application/x-yaml; charset=utf-8", "application/xml"}, Mime_YAML},
+			{[]string{"application/xml; charset", "application/x-yaml"}, Mime_YAML},
+			{[]string{"application/unknown"}, Mime_Wildcard},
+			{nil, Mime_Wildcard},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprint(tt.contentType), func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+				req.Header[contentTypeHeader] = tt.contentType
+				gotMime, got := registry.InboundForRequest(req)
+				require.Equal(t, tt.wantMime, gotMime)
+				require.Equal(t, registry.Get(tt.wantMime), got)
+			})
+		}
+	})
+	t.Run("accept", func(t *testing.T) {
+		tests := []struct {
+			accept   []string
+			wantMime string
+		}{
+			{[]string{"application/xml"}, Mime_XML},
+			{[]string{"Application/XML"}, Mime_XML},
+			{[]string{"text/html, application/x-yaml, application/xml"}, Mime_YAML},
+			{[]string{"text/html,application/xml;q=0.9,*/*;q=0.8"}, Mime_XML},
+			{[]string{"application/xml;q=0, application/x-yaml"}, Mime_YAML},
+			{[]string{"application/xml; Q = 0.0 ; level=1, application/x-yaml"}, Mime_YAML},
+			{[]string{`application/xml; foo="a,b;q=0", application/x-yaml`}, Mime_XML},
+			{[]string{`application/xml; foo="a,b"; q=0, application/x-yaml`}, Mime_YAML},
+			{[]string{"application/unknown", "application/x-yaml", "application/xml"}, Mime_YAML},
+			// the first matching header line wins.
+			{[]string{"application/xml", "application/x-yaml"}, Mime_XML},
+			{[]string{"text/html, application/x-yaml", "application/xml"}, Mime_YAML},
+			{[]string{`application/xml; foo="a,b"`, "application/x-yaml"}, Mime_XML},
+			{[]string{"application/xml;q=0", "application/x-yaml", "application/xml"}, Mime_YAML},
+			{[]string{"application/unknown"}, Mime_Wildcard},
+			{[]string{""}, Mime_Wildcard},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprint(tt.accept), func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+				req.Header[acceptHeader] = tt.accept
+				require.Equal(t, registry.Get(tt.wantMime), registry.OutboundForRequest(req))
+			})
+		}
 	})
 }
 
+func Test_Encoding_CaseInsensitiveMIME(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register("Application/X-Custom", marshalers[0]))
+	require.Equal(t, marshalers[0], registry.Get("application/x-custom"))
+	require.Equal(t, marshalers[0], registry.Get("APPLICATION/X-CUSTOM"))
+
+	// the same type in another casing overrides it.
+	require.NoError(t, registry.Register("application/x-CUSTOM", marshalers[1]))
+	require.Equal(t, marshalers[1], registry.Get("Application/X-Custom"))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", "Application/JSON; charset=UTF-8")
+	req.Header.Set("Accept", "APPLICATION/X-Custom")
+	contentType, m := registry.InboundForRequest(req)
+	require.Equal(t, Mime_JSON, contentType)
+	require.IsType(t, &json.Codec{}, m)
+	require.Equal(t, marshalers[1], registry.OutboundForRequest(req))
+
+	require.NoError(t, registry.Delete("APPLICATION/x-custom"))
+	require.Equal(t, registry.Get(Mime_Wildcard), registry.Get("application/x-custom"))
+	require.Equal(t, registry.Get(Mime_Wildcard), registry.OutboundForRequest(req))
+
+	require.NoError(t, registry.RegisterSubprotocol("custom", "Application/JSON"))
+	m, err := registry.ForSubprotocol("custom")
+	require.NoError(t, err)
+	require.IsType(t, &json.Codec{}, m)
+}
+
+func Benchmark_Encoding_InboundForRequest(b *testing.B) {
+	registry := New()
+	for _, contentType := range []string{
+		"application/json",
+		"Application/JSON",
+		"application/json; charset=utf-8",
+	} {
+		b.Run(contentType, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+			req.Header.Set("Content-Type", contentType)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = registry.InboundForRequest(req)
+			}
+		})
+	}
+}
+
+func Benchmark_Encoding_OutboundForRequest(b *testing.B) {
+	registry := New()
+	require.NoError(b, registry.Register(Mime_XML, &xml.Codec{}))
+	for _, accept := range []string{
+		"application/json",
+		"application/json, text/plain, */*",
+		"text/html, application/xhtml+xml, application/xml, */*",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	} {
+		b.Run(accept, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.Header.Set("Accept", accept)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = registry.OutboundForRequest(req)
+			}
+		})
+	}
+}
+
 func Test_Encoding_Inbound_Or_OutBound_ForRequest_Wildcard(t *testing.T) {
 	var registry = New()
 
@@ -217,7 +600,9 @@ var protoMessage = &examplepb.ABitOfEverything{
 }
 
 func Test_Encoding_Bind(t *testing.T) {
-	registry := New()
+	var bindErrors int
+	sink := newRecordingSink()
+	registry := New(WithOnBindError(func(*http.Request, error) { bindErrors++ }), WithMetrics(sink))
 	_ = registry.Register(Mime_PROTOBUF, &pro.Codec{})
 	_ = registry.Register(Mime_XML, &xml.Codec{})
 	_ = registry.Register(Mime_XML2, &xml.Codec{})
@@ -423,8 +808,33 @@ func Test_Encoding_Bind(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"json - invalid",
+			func() (*http.Request, error) {
+				r, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://example.com", bytes.NewBufferString(`{"id":`))
+				if err != nil {
+					return nil, err
+				}
+				r.Header.Set("Content-Type", "application/json")
+				return r, nil
+			},
+			&TestMode{},
+			true,
+		},
+		{
+			"form - method get so it query, invalid",
+			func() (*http.Request, error) {
+				return http.NewRequestWithContext(context.Background(), http.MethodGet, "http://example.com?id=xxx", nil)
+			},
+			&examplepb.Complex{},
+			true,
+		},
 	}
+	var wantBindErrors int
 	for _, tt := range tests {
+		if tt.wantErr {
+			wantBindErrors++
+		}
 		t.Run(tt.name, func(t *testing.T) {
 			req, err := tt.genReq()
 			if err != nil {
@@ -434,6 +844,9 @@ func Test_Encoding_Bind(t *testing.T) {
 			if err = registry.Bind(req, got.Interface()); (err != nil) != tt.wantErr {
 				t.Errorf("Bind() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantErr {
+				return
+			}
 			if _, ok := tt.want.(proto.Message); ok {
 				if diff := proto.Equal(got.Interface().(proto.Message), tt.want.(proto.Message)); !diff {
 					t.Errorf("got = %v, want %v", got, tt.want)
@@ -443,6 +856,203 @@ func Test_Encoding_Bind(t *testing.T) {
 			}
 		})
 	}
+	require.Equal(t, wantBindErrors, bindErrors)
+	require.Equal(t, len(tests), total(sink.bind).requests)
+	require.Equal(t, wantBindErrors, total(sink.bind).errors)
+	require.Equal(t, 2, sink.bind[Mime_Query].requests)
+	require.Equal(t, 1, sink.bind[Mime_Wildcard].requests)
+	require.Equal(t, 2, sink.bind[Mime_JSON].requests)
+	require.Equal(t, [2]int{0, 1}, sink.fallbacks)
+}
+
+func Test_Encoding_Bind_QueryMethods(t *testing.T) {
+	registry := New()
+	newRequest := func(method, body string, contentLength int64) *http.Request {
+		var rd io.Reader
+		if body != "" {
+			rd = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(method, "http://example.com?id=query&name=bar", rd)
+		req.Header.Set("Content-Type", Mime_JSON)
+		if contentLength != 0 {
+			req.ContentLength = contentLength
+		}
+		return req
+	}
+	for _, tt := range []struct {
+		name string
+		req  *http.Request
+		want *TestMode
+	}{
+		{"delete without body", newRequest(http.MethodDelete, "", 0), &TestMode{Id: "query", Name: "bar"}},
+		{"head without body", newRequest(http.MethodHead, "", 0), &TestMode{Id: "query", Name: "bar"}},
+		{"options without body", newRequest(http.MethodOptions, "", 0), &TestMode{Id: "query", Name: "bar"}},
+		{"delete with body", newRequest(http.MethodDelete, `{"id":"body"}`, 0), &TestMode{Id: "body"}},
+		{"delete with unknown length body", newRequest(http.MethodDelete, `{"id":"body"}`, -1), &TestMode{Id: "body"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &TestMode{}
+			require.NoError(t, registry.Bind(tt.req, got))
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	// the POST request without body still binds the body.
+	require.Error(t, registry.Bind(newRequest(http.MethodPost, "", 0), &TestMode{}))
+
+	v, err := registry.BindAny(newRequest(http.MethodDelete, "", 0))
+	require.NoError(t, err)
+	require.Equal(t, url.Values{"id": {"query"}, "name": {"bar"}}, v)
+}
+
+func Test_Encoding_Bind_MultipartFile(t *testing.T) {
+	newRequest := func(t *testing.T, filename string, data []byte) *http.Request {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		require.NoError(t, mw.WriteField("id", "foo"))
+		fw, err := mw.CreateFormFile("avatar", filename)
+		require.NoError(t, err)
+		_, err = fw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		r := httptest.NewRequest(http.MethodPost, "http://example.com", body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		return r
+	}
+	type upload struct {
+		Id     string     `json:"id"`
+		Avatar *form.File `json:"avatar,file,accept=image/png"`
+	}
+
+	registry := New()
+	got := &upload{}
+	require.NoError(t, registry.Bind(newRequest(t, "avatar.txt", []byte("\x89PNG\r\n\x1a\n")), got))
+	require.Equal(t, "foo", got.Id)
+	require.Equal(t, "avatar.txt", got.Avatar.Filename)
+	require.Equal(t, "image/png", got.Avatar.DetectedType)
+
+	var typeErr *form.FileTypeError
+	require.ErrorAs(t, registry.Bind(newRequest(t, "avatar.png", []byte("MZ\x90\x00")), &upload{}), &typeErr)
+	require.Equal(t, "avatar", typeErr.Field)
+	require.Equal(t, "application/octet-stream", typeErr.DetectedType)
+}
+
+func Test_Encoding_Bind_Map(t *testing.T) {
+	registry := New()
+
+	// GET with the query.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com?id=foo&tag=a&tag=b", nil)
+	gotAny := map[string]any{}
+	require.NoError(t, registry.Bind(req, &gotAny))
+	require.Equal(t, map[string]any{"id": "foo", "tag": []string{"a", "b"}}, gotAny)
+	var gotString map[string]string
+	require.NoError(t, registry.BindQuery(req, &gotString))
+	require.Equal(t, map[string]string{"id": "foo", "tag": "a"}, gotString)
+	var gotValues url.Values
+	require.NoError(t, registry.BindQuery(req, &gotValues))
+	require.Equal(t, url.Values{"id": {"foo"}, "tag": {"a", "b"}}, gotValues)
+
+	// the multipart values, the files are skipped.
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	require.NoError(t, mw.WriteField("id", "foo"))
+	require.NoError(t, mw.WriteField("tag", "a"))
+	require.NoError(t, mw.WriteField("tag", "b"))
+	fw, err := mw.CreateFormFile("avatar", "avatar.png")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("\x89PNG\r\n\x1a\n"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	gotAny = nil
+	require.NoError(t, registry.Bind(req, &gotAny))
+	require.Equal(t, map[string]any{"id": "foo", "tag": []string{"a", "b"}}, gotAny)
+
+	// the body codecs.
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"id":"foo","tag":["a","b"]}`))
+	req.Header.Set("Content-Type", Mime_JSON)
+	gotAny = nil
+	require.NoError(t, registry.Bind(req, &gotAny))
+	require.Equal(t, map[string]any{"id": "foo", "tag": []any{"a", "b"}}, gotAny)
+}
+
+func Test_Encoding_Bind_ParsedForm(t *testing.T) {
+	registry := New()
+	want := &TestMode{Id: "foo", Name: "bar"}
+
+	newMultipartRequest := func(t *testing.T) *http.Request {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		require.NoError(t, mw.WriteField("id", "foo"))
+		require.NoError(t, mw.WriteField("name", "bar"))
+		require.NoError(t, mw.Close())
+		r := httptest.NewRequest(http.MethodPost, "http://example.com", body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		return r
+	}
+	newPostFormRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader("id=foo&name=bar"))
+		r.Header.Set("Content-Type", Mime_PostForm)
+		return r
+	}
+
+	t.Run("multipart - unparsed", func(t *testing.T) {
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(newMultipartRequest(t), got))
+		require.Equal(t, want, got)
+	})
+	t.Run("multipart - parsed", func(t *testing.T) {
+		r := newMultipartRequest(t)
+		require.NoError(t, r.ParseMultipartForm(defaultMemory))
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(r, got))
+		require.Equal(t, want, got)
+	})
+	t.Run("multipart - consumed but unparsed", func(t *testing.T) {
+		r := newMultipartRequest(t)
+		_, err := io.Copy(io.Discard, r.Body)
+		require.NoError(t, err)
+		err = registry.Bind(r, &TestMode{})
+		require.ErrorIs(t, err, io.EOF)
+		require.ErrorContains(t, err, "already consumed")
+	})
+	t.Run("form - unparsed", func(t *testing.T) {
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(newPostFormRequest(), got))
+		require.Equal(t, want, got)
+	})
+	t.Run("form - parsed", func(t *testing.T) {
+		r := newPostFormRequest()
+		require.NoError(t, r.ParseForm())
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(r, got))
+		require.Equal(t, want, got)
+	})
+}
+
+func Benchmark_Encoding_Bind(b *testing.B) {
+	registry := New()
+	require.NoError(b, registry.Register(Mime_MSGPACK, &msgpack.Codec{}))
+	want := &TestMode{Id: "foo", Name: "bar"}
+	for _, mime := range []string{Mime_JSON, Mime_MSGPACK} {
+		body, err := registry.Encode(mime, want)
+		require.NoError(b, err)
+		b.Run(mime, func(b *testing.B) {
+			rd := bytes.NewReader(body)
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+			req.Header.Set("Content-Type", mime)
+			req.Body = io.NopCloser(rd)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rd.Reset(body)
+				if err := registry.Bind(req, &TestMode{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
 
 func Test_Encoding_BindQuery(t *testing.T) {
@@ -508,6 +1118,82 @@ func Test_Encoding_BindQuery(t *testing.T) {
 	}
 }
 
+func Test_Encoding_EncodeQuery_Map(t *testing.T) {
+	registry := New()
+
+	t.Run("go map", func(t *testing.T) {
+		type selector struct {
+			Labels map[string]string         `json:"labels"`
+			Limits map[string]int            `json:"limits"`
+			Flags  map[int]bool              `json:"flags"`
+			Nested map[string]map[string]int `json:"nested"`
+		}
+		want := &selector{
+			Labels: map[string]string{"tier": "web", "env": "prod"},
+			Limits: map[string]int{"cpu": 2},
+			Flags:  map[int]bool{1: true},
+			Nested: map[string]map[string]int{"a": {"b": 1}},
+		}
+		vs, err := registry.EncodeQuery(want)
+		require.NoError(t, err)
+		require.Equal(t, "flags%5B1%5D=true&labels%5Benv%5D=prod&labels%5Btier%5D=web&limits%5Bcpu%5D=2&nested%5Ba%5D%5Bb%5D=1", vs.Encode())
+
+		got := &selector{}
+		req := httptest.NewRequest(http.MethodGet, "http://example.com?"+vs.Encode(), nil)
+		require.NoError(t, registry.BindQuery(req, got))
+		require.Equal(t, want, got)
+	})
+	t.Run("proto map", func(t *testing.T) {
+		want := &examplepb.ABitOfEverything{
+			MapValue:          map[string]examplepb.NumericEnum{"a": examplepb.NumericEnum_ONE, "b": examplepb.NumericEnum_ZERO},
+			MappedStringValue: map[string]string{"env": "prod"},
+		}
+		vs, err := registry.EncodeQuery(want)
+		require.NoError(t, err)
+		require.Equal(t, url.Values{"map_value[a]": {"1"}, "map_value[b]": {"0"}, "mapped_string_value[env]": {"prod"}}, vs)
+
+		got := &examplepb.ABitOfEverything{}
+		req := httptest.NewRequest(http.MethodGet, "http://example.com?"+vs.Encode(), nil)
+		require.NoError(t, registry.BindQuery(req, got))
+		require.True(t, proto.Equal(want, got))
+	})
+}
+
+type benchQueryModel struct {
+	F1  string  `json:"f1"`
+	F2  string  `json:"f2"`
+	F3  string  `json:"f3"`
+	F4  string  `json:"f4"`
+	F5  string  `json:"f5"`
+	F6  int     `json:"f6"`
+	F7  int     `json:"f7"`
+	F8  int     `json:"f8"`
+	F9  int     `json:"f9"`
+	F10 int     `json:"f10"`
+	F11 int64   `json:"f11"`
+	F12 int64   `json:"f12"`
+	F13 uint    `json:"f13"`
+	F14 uint    `json:"f14"`
+	F15 bool    `json:"f15"`
+	F16 bool    `json:"f16"`
+	F17 float64 `json:"f17"`
+	F18 float64 `json:"f18"`
+	F19 *string `json:"f19"`
+	F20 *int    `json:"f20"`
+}
+
+func Benchmark_Encoding_BindQuery(b *testing.B) {
+	registry := New()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com?f1=a&f2=b&f3=c&f4=d&f5=e&f6=6&f7=7&f8=8&f9=9&f10=10&f11=11&f12=12&f13=13&f14=14&f15=true&f16=false&f17=1.5&f18=2.5&f19=s&f20=20", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := registry.BindQuery(req, &benchQueryModel{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func Test_Encoding_BindUri(t *testing.T) {
 	registry := New()
 	require.NoError(t, registry.Register(Mime_Uri, form.New("json")))
@@ -570,6 +1256,46 @@ func Test_Encoding_BindUri(t *testing.T) {
 	}
 }
 
+// uriMarshaler hides the codec.UriDecoder of the uri marshaler.
+type uriMarshaler struct {
+	codec.UriMarshaler
+}
+
+func Test_Encoding_BindPath(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		marshaler codec.UriMarshaler
+	}{
+		{"uri decoder", form.New("json")},
+		{"uri marshaler", uriMarshaler{form.New("json")}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotErr error
+			registry := New(WithOnBindError(func(_ *http.Request, err error) { gotErr = err }))
+			require.NoError(t, registry.Register(Mime_Uri, tt.marshaler))
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/v1/foo%20bar/names/a/b?id=query", nil)
+			got := &TestMode{}
+			require.NoError(t, registry.BindPath(req, "/v1/{id}/names/{name=**}", got))
+			require.Equal(t, &TestMode{Id: "foo bar", Name: "a/b"}, got)
+
+			err := registry.BindPath(req, "/v2/{id}", &TestMode{})
+			var mismatch *form.PathMismatchError
+			require.ErrorAs(t, err, &mismatch)
+			require.Equal(t, "/v2/{id}", mismatch.Template)
+			require.Equal(t, err, gotErr)
+
+			got = &TestMode{}
+			require.NoError(t, registry.BindUriFromPath("/v1/{id}/names/{name=**}", "/v1/a%2Fb/names/c", got))
+			require.Equal(t, &TestMode{Id: "a/b", Name: "c"}, got)
+			err = registry.BindUriFromPath("/v2/{id}", "/v1/foo", &TestMode{})
+			require.ErrorAs(t, err, &mismatch)
+			require.Contains(t, err.Error(), "/v2/{id}")
+			require.Equal(t, err, gotErr)
+		})
+	}
+}
+
 // helper
 func alloc(t reflect.Type) reflect.Value {
 	if t == nil {
@@ -579,6 +1305,13 @@ func alloc(t reflect.Type) reflect.Value {
 }
 
 func Test_Encoding_Render(t *testing.T) {
+	var renderErrors int
+	sink := newRecordingSink()
+	onRenderError := WithOnRenderError(func(*http.Request, error) { renderErrors++ })
+	withMetrics := WithMetrics(sink)
+	protoEncoding := New(onRenderError, withMetrics)
+	require.NoError(t, protoEncoding.Register(Mime_PROTOBUF, &pro.Codec{}))
+
 	type args struct {
 		w      http.ResponseWriter
 		genReq func() (*http.Request, error)
@@ -593,7 +1326,7 @@ func Test_Encoding_Render(t *testing.T) {
 	}{
 		{
 			"<nil> payload",
-			New(),
+			New(onRenderError, withMetrics),
 			args{
 				w: httptest.NewRecorder(),
 				genReq: func() (*http.Request, error) {
@@ -606,7 +1339,7 @@ func Test_Encoding_Render(t *testing.T) {
 		},
 		{
 			"<nil> payload",
-			New(),
+			New(onRenderError, withMetrics),
 			args{
 				w: httptest.NewRecorder(),
 				genReq: func() (*http.Request, error) {
@@ -625,8 +1358,33 @@ func Test_Encoding_Render(t *testing.T) {
 			`{"id":"foo","name":"bar"}`,
 			false,
 		},
+		{
+			"proto marshaler with not proto payload",
+			protoEncoding,
+			args{
+				w: httptest.NewRecorder(),
+				genReq: func() (*http.Request, error) {
+					req, err := http.NewRequest(http.MethodPost, "http://example.com", nil) // nolint: noctx
+					if err != nil {
+						return nil, err
+					}
+					req.Header.Set("Accept", Mime_PROTOBUF)
+					return req, nil
+				},
+				v: TestMode{
+					Id:   "foo",
+					Name: "bar",
+				},
+			},
+			"",
+			true,
+		},
 	}
+	var wantRenderErrors int
 	for _, tt := range tests {
+		if tt.wantErr {
+			wantRenderErrors++
+		}
 		t.Run(tt.name, func(t *testing.T) {
 			req, err := tt.args.genReq()
 			if err != nil {
@@ -641,6 +1399,11 @@ func Test_Encoding_Render(t *testing.T) {
 			}
 		})
 	}
+	require.Equal(t, wantRenderErrors, renderErrors)
+	// <nil> payload is not rendered.
+	require.Equal(t, counter{requests: 1, bytes: len(`{"id":"foo","name":"bar"}`)}, sink.render[Mime_JSON])
+	require.Equal(t, counter{requests: 1, errors: 1}, sink.render[Mime_PROTOBUF])
+	require.Equal(t, [2]int{}, sink.fallbacks)
 }
 
 func Test_ParseAcceptHeader(t *testing.T) {
@@ -659,6 +1422,11 @@ func Test_ParseAcceptHeader(t *testing.T) {
 			"application/json,text/plain,   */*",
 			[]string{"application/json", "text/plain", "*/*"},
 		},
+		{
+			"",
+			`application/json; foo="a,\"b", text/plain`,
+			[]string{`application/json; foo="a,\"b"`, "text/plain"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -728,3 +1496,165 @@ func Test_Encoding_Inbound_ForRequest_NotWildcard(t *testing.T) {
 		})
 	}
 }
+
+func Test_Encoding_Lookup(t *testing.T) {
+	registry := New()
+	m, ok := registry.Lookup("Application/JSON")
+	require.True(t, ok)
+	require.Same(t, registry.Get(Mime_JSON), m)
+	m, ok = registry.Lookup("application/unknown")
+	require.False(t, ok)
+	require.Same(t, registry.Get(Mime_Wildcard), m)
+	for _, mime := range []string{Mime_Query, Mime_Uri, Mime_Wildcard, Mime_DefaultInbound, Mime_DefaultOutbound} {
+		m, ok = registry.Lookup(mime)
+		require.True(t, ok, mime)
+		require.Same(t, registry.Get(mime), m, mime)
+		require.True(t, registry.Has(mime), mime)
+	}
+	require.True(t, registry.Has(Mime_PostForm))
+	require.False(t, registry.Has(Mime_XML))
+	require.NoError(t, registry.Register(Mime_XML, registry.Get(Mime_Wildcard)))
+	require.True(t, registry.Has(Mime_XML))
+}
+
+func Test_Encoding_LookupBytes(t *testing.T) {
+	registry := New()
+	for _, mime := range []string{Mime_JSON, "Application/JSON", "application/unknown", Mime_PostForm,
+		Mime_Query, Mime_Uri, Mime_Wildcard, Mime_DefaultInbound, Mime_DefaultOutbound} {
+		want, wantOk := registry.Lookup(mime)
+		m, ok := registry.LookupBytes([]byte(mime))
+		require.Equal(t, wantOk, ok, mime)
+		require.Same(t, want, m, mime)
+	}
+
+	mime := []byte(Mime_JSON)
+	allocs := testing.AllocsPerRun(100, func() { _, _ = registry.LookupBytes(mime) })
+	require.Zero(t, allocs)
+	mime = []byte("application/unknown")
+	allocs = testing.AllocsPerRun(100, func() { _, _ = registry.LookupBytes(mime) })
+	require.Zero(t, allocs)
+
+	// the hooked marshaler does not hold the passed bytes.
+	hooked := New(WithOnMarshal(func(string, any, int, error) {}))
+	mime = []byte(Mime_JSON)
+	m, ok := hooked.LookupBytes(mime)
+	require.True(t, ok)
+	copy(mime, "xxxxxxxxxxxxxxxx")
+	require.Equal(t, Mime_JSON, m.(*hookedMarshaler).mime)
+}
+
+func Test_Encoding_Freeze(t *testing.T) {
+	registry := New()
+	require.False(t, registry.IsFrozen())
+	registry.Freeze()
+	require.True(t, registry.IsFrozen())
+
+	require.Equal(t, ErrFrozen, registry.Register(Mime_JSON, &json.Codec{}))
+	require.Equal(t, ErrFrozen, registry.RegisterInbound(Mime_JSON, &json.Codec{}))
+	require.Equal(t, ErrFrozen, registry.RegisterAlias(Mime_XML2, Mime_JSON))
+	require.Equal(t, ErrFrozen, registry.Delete(Mime_JSON))
+	require.Equal(t, ErrFrozen, registry.RegisterSubprotocol("custom", Mime_JSON))
+	require.Panics(t, func() { registry.MustRegister(Mime_XML, &xml.Codec{}) })
+	registry.DeleteSubprotocol("json")
+	registry.Reset()
+	require.True(t, registry.Has(Mime_JSON))
+	require.Contains(t, registry.Subprotocols(), "json")
+
+	// the clone is not frozen.
+	clone := registry.Clone()
+	require.False(t, clone.IsFrozen())
+	require.NoError(t, clone.Register(Mime_XML, &xml.Codec{}))
+	require.False(t, registry.Has(Mime_XML))
+}
+
+func Test_Encoding_RenderStatus(t *testing.T) {
+	registry := New()
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", Mime_JSON)
+		return req
+	}
+
+	t.Run("status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.RenderStatus(w, newRequest(), http.StatusCreated, &TestMode{Id: "foo"}))
+		resp := w.Result()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		// the headers are set before the status code is written.
+		require.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.JSONEq(t, `{"id":"foo","name":""}`, w.Body.String())
+	})
+	t.Run("nil value", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.RenderStatus(w, newRequest(), http.StatusAccepted, nil))
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Zero(t, w.Body.Len())
+		require.Empty(t, w.Header().Get("Content-Type"))
+	})
+	t.Run("no body status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.RenderStatus(w, newRequest(), http.StatusNoContent, &TestMode{Id: "foo"}))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Zero(t, w.Body.Len())
+	})
+	t.Run("invalid status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.EqualError(t, registry.RenderStatus(w, newRequest(), 42, &TestMode{}), "encoding: invalid status code 42")
+		require.False(t, w.Flushed)
+		require.Empty(t, w.Header())
+	})
+	t.Run("marshal failed", func(t *testing.T) {
+		r := New()
+		require.NoError(t, r.Register(Mime_JSON, &failingMarshaler{}))
+		w := httptest.NewRecorder()
+		err := r.RenderStatus(w, newRequest(), http.StatusCreated, &TestMode{})
+		require.ErrorContains(t, err, "marshal failed")
+		// the ResponseWriter is not touched.
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header())
+	})
+	t.Run("render keeps the written status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		w.WriteHeader(http.StatusNotFound)
+		require.NoError(t, registry.Render(w, newRequest(), &TestMode{Id: "foo"}))
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		require.NoError(t, registry.Render(w, newRequest(), &TestMode{Id: "foo"}))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func Test_Encoding_Render_ContentLength(t *testing.T) {
+	registry := New()
+	newRequest := func(method string) *http.Request {
+		req := httptest.NewRequest(method, "http://example.com", nil)
+		req.Header.Set("Accept", Mime_JSON)
+		return req
+	}
+	v := &TestMode{Id: "foo", Name: "bar"}
+
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, newRequest(http.MethodGet), v))
+	require.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+	want := w.Body.String()
+
+	// the HEAD request gets the headers without the body.
+	w = httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, newRequest(http.MethodHead), v))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, strconv.Itoa(len(want)), w.Header().Get("Content-Length"))
+	require.Zero(t, w.Body.Len())
+
+	w = httptest.NewRecorder()
+	require.NoError(t, registry.RenderStatus(w, newRequest(http.MethodHead), http.StatusCreated, v))
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, strconv.Itoa(len(want)), w.Header().Get("Content-Length"))
+	require.Zero(t, w.Body.Len())
+
+	w = httptest.NewRecorder()
+	require.NoError(t, registry.RenderWith(w, newRequest(http.MethodHead), v, Mime_JSON))
+	require.Equal(t, strconv.Itoa(len(want)), w.Header().Get("Content-Length"))
+	require.Zero(t, w.Body.Len())
+}
@@ -0,0 +1,80 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorResponse is the body rendered by RenderError.
+type ErrorResponse struct {
+	Code    int    `json:"code" xml:"code" yaml:"code" toml:"code" msgpack:"code"`
+	Message string `json:"message" xml:"message" yaml:"message" toml:"message" msgpack:"message"`
+}
+
+// StatusCode returns the HTTP status code of the error returned by the binds and the renders.
+//
+//   - The error which implements `StatusCode() int` in the chain, like *json.SchemaError
+//     or the errors of go-kit, uses its status code.
+//   - ErrBodyTooLarge is 413 Request Entity Too Large.
+//   - ErrUnsupportedMediaType and ErrUnsupportedCharset are 415 Unsupported Media Type.
+//   - ErrNotAcceptable is 406 Not Acceptable.
+//   - *BindError, ErrEmptyBody, ErrDigestMismatch and ErrUnsupportedDigest are 400 Bad Request.
+//   - Otherwise, like *RenderError, it is 500 Internal Server Error.
+func StatusCode(err error) int {
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		if code := sc.StatusCode(); checkStatusCode(code) == nil {
+			return code
+		}
+	}
+	var bindErr *BindError
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType), errors.Is(err, ErrUnsupportedCharset):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrNotAcceptable):
+		return http.StatusNotAcceptable
+	case errors.Is(err, ErrEmptyBody), errors.Is(err, ErrDigestMismatch), errors.Is(err, ErrUnsupportedDigest),
+		errors.As(err, &bindErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RenderError writes the error as ErrorResponse with the status code of StatusCode in the format
+// negotiated by the `Accept` header, like RenderStatus. The message is err.Error(), so wrap the error
+// which must not be exposed to the client. If the negotiated marshaler can't marshal ErrorResponse,
+// like the protobuf one, it falls back to a "text/plain" body, it returns the error of the render.
+func (r *Encoding) RenderError(w http.ResponseWriter, req *http.Request, err error) error {
+	code := StatusCode(err)
+	tw := &trackingWriter{ResponseWriter: w}
+	renderErr := r.RenderStatus(tw, req, code, &ErrorResponse{Code: code, Message: err.Error()})
+	if renderErr != nil && !tw.written {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Type", "text/plain; charset=utf-8")
+		h.Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(err.Error()))
+	}
+	return renderErr
+}
+
+// trackingWriter records whether the status code or the body is written,
+// so the fallback does not write twice.
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) WriteHeader(code int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
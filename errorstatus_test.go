@@ -0,0 +1,75 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/json"
+)
+
+type statusCodeError struct{ code int }
+
+func (e *statusCodeError) Error() string   { return "status code error" }
+func (e *statusCodeError) StatusCode() int { return e.code }
+
+func Test_StatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&statusCodeError{http.StatusTeapot}, http.StatusTeapot},
+		{fmt.Errorf("wrapped: %w", &statusCodeError{http.StatusConflict}), http.StatusConflict},
+		{&statusCodeError{0}, http.StatusInternalServerError},
+		{&json.SchemaError{}, http.StatusBadRequest},
+		{fmt.Errorf("%w: limit 1 bytes", ErrBodyTooLarge), http.StatusRequestEntityTooLarge},
+		{&BindError{Err: fmt.Errorf("%w: limit 1 bytes", ErrBodyTooLarge)}, http.StatusRequestEntityTooLarge},
+		{ErrUnsupportedMediaType, http.StatusUnsupportedMediaType},
+		{ErrUnsupportedCharset, http.StatusUnsupportedMediaType},
+		{ErrNotAcceptable, http.StatusNotAcceptable},
+		{ErrEmptyBody, http.StatusBadRequest},
+		{ErrDigestMismatch, http.StatusBadRequest},
+		{ErrUnsupportedDigest, http.StatusBadRequest},
+		{&BindError{Err: errors.New("invalid")}, http.StatusBadRequest},
+		{&RenderError{Err: errors.New("invalid")}, http.StatusInternalServerError},
+		{errors.New("unknown"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, StatusCode(tt.err), tt.err.Error())
+	}
+}
+
+func Test_Encoding_RenderError(t *testing.T) {
+	registry := New()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_JSON)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.RenderError(w, req, fmt.Errorf("%w: limit 1 bytes", ErrBodyTooLarge)))
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"code":413,"message":"encoding: request body too large: limit 1 bytes"}`, w.Body.String())
+
+	// the marshaler which can't marshal ErrorResponse falls back to the plain text.
+	failing := New()
+	require.NoError(t, failing.Register(Mime_JSON, &failingMarshaler{}))
+	w = httptest.NewRecorder()
+	err := failing.RenderError(w, req, &statusCodeError{http.StatusTeapot})
+	var renderErr *RenderError
+	require.ErrorAs(t, err, &renderErr)
+	require.Equal(t, http.StatusTeapot, w.Code)
+	require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, "status code error", w.Body.String())
+
+	// the marshal error fallback writes the response, it is not written twice.
+	failing = New(WithMarshalErrorFallback(nil))
+	require.NoError(t, failing.Register(Mime_JSON, &failingMarshaler{}))
+	w = httptest.NewRecorder()
+	require.Error(t, failing.RenderError(w, req, &statusCodeError{http.StatusTeapot}))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
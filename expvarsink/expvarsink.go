@@ -0,0 +1,122 @@
+// Package expvarsink implements the encoding.Sink backed by expvar.
+//
+// The metrics are published as an expvar.Map with the layout:
+//
+//	{
+//	  "bind":     {"application/json": {"requests": 1, "bytes": 25, "errors": 0}},
+//	  "render":   {"application/json": {"requests": 1, "bytes": 25, "errors": 0}},
+//	  "fallback": {"inbound": 0, "outbound": 1}
+//	}
+package expvarsink
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/thinkgos/encoding"
+)
+
+var _ encoding.Sink = (*Sink)(nil)
+
+// Sink is an encoding.Sink which records the metrics in expvar.
+type Sink struct {
+	root     *expvar.Map
+	bind     counters
+	render   counters
+	inbound  *expvar.Int
+	outbound *expvar.Int
+}
+
+// New returns a Sink published as name in expvar.
+// Like expvar.Publish, it panics if name is already registered.
+func New(name string) *Sink {
+	s := newSink()
+	expvar.Publish(name, s.root)
+	return s
+}
+
+// newSink returns a Sink which is not published.
+func newSink() *Sink {
+	s := &Sink{
+		root:     new(expvar.Map).Init(),
+		bind:     counters{m: new(expvar.Map).Init()},
+		render:   counters{m: new(expvar.Map).Init()},
+		inbound:  new(expvar.Int),
+		outbound: new(expvar.Int),
+	}
+	fallback := new(expvar.Map).Init()
+	fallback.Set("inbound", s.inbound)
+	fallback.Set("outbound", s.outbound)
+	s.root.Set("bind", s.bind.m)
+	s.root.Set("render", s.render.m)
+	s.root.Set("fallback", fallback)
+	return s
+}
+
+// Map returns the expvar.Map of the metrics.
+func (s *Sink) Map() *expvar.Map { return s.root }
+
+// IncBind implements encoding.Sink.
+func (s *Sink) IncBind(mime string, bytes int, err bool) {
+	s.bind.get(mime).inc(bytes, err)
+}
+
+// IncRender implements encoding.Sink.
+func (s *Sink) IncRender(mime string, bytes int, err bool) {
+	s.render.get(mime).inc(bytes, err)
+}
+
+// IncFallback implements encoding.Sink.
+func (s *Sink) IncFallback(inbound bool) {
+	if inbound {
+		s.inbound.Add(1)
+	} else {
+		s.outbound.Add(1)
+	}
+}
+
+// counters is the per-MIME counters.
+type counters struct {
+	mu    sync.Mutex
+	m     *expvar.Map
+	cache sync.Map // map[string]*counter
+}
+
+// get returns the counter of the mime, it creates the counter if not exist.
+func (c *counters) get(mime string) *counter {
+	if v, ok := c.cache.Load(mime); ok {
+		return v.(*counter)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.cache.Load(mime); ok {
+		return v.(*counter)
+	}
+	cnt := &counter{
+		requests: new(expvar.Int),
+		bytes:    new(expvar.Int),
+		errors:   new(expvar.Int),
+	}
+	m := new(expvar.Map).Init()
+	m.Set("requests", cnt.requests)
+	m.Set("bytes", cnt.bytes)
+	m.Set("errors", cnt.errors)
+	c.m.Set(mime, m)
+	c.cache.Store(mime, cnt)
+	return cnt
+}
+
+// counter is the counter of a MIME type.
+type counter struct {
+	requests *expvar.Int
+	bytes    *expvar.Int
+	errors   *expvar.Int
+}
+
+func (c *counter) inc(bytes int, err bool) {
+	c.requests.Add(1)
+	c.bytes.Add(int64(bytes))
+	if err {
+		c.errors.Add(1)
+	}
+}
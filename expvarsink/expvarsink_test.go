@@ -0,0 +1,77 @@
+package expvarsink
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding"
+)
+
+type metrics struct {
+	Bind     map[string]map[string]int64 `json:"bind"`
+	Render   map[string]map[string]int64 `json:"render"`
+	Fallback map[string]int64            `json:"fallback"`
+}
+
+func snapshot(t *testing.T, s *Sink) metrics {
+	var m metrics
+	require.NoError(t, json.Unmarshal([]byte(s.Map().String()), &m))
+	return m
+}
+
+func Test_Sink(t *testing.T) {
+	sink := New("encoding_test")
+	require.Equal(t, sink.Map(), expvar.Get("encoding_test"))
+
+	registry := encoding.New(encoding.WithMetrics(sink))
+
+	body := `{"id":"foo"}`
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+	req.Header.Set("Content-Type", encoding.Mime_JSON)
+	require.NoError(t, registry.Bind(req, &struct {
+		Id string `json:"id"`
+	}{}))
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{`))
+	req.Header.Set("Content-Type", "application/unknown")
+	require.Error(t, registry.Bind(req, &struct{}{}))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, req, map[string]string{"id": "foo"}))
+
+	require.Equal(t, metrics{
+		Bind: map[string]map[string]int64{
+			encoding.Mime_JSON:     {"requests": 1, "bytes": int64(len(body)), "errors": 0},
+			encoding.Mime_Wildcard: {"requests": 1, "bytes": 1, "errors": 1},
+		},
+		Render: map[string]map[string]int64{
+			encoding.Mime_Wildcard: {"requests": 1, "bytes": int64(w.Body.Len()), "errors": 0},
+		},
+		Fallback: map[string]int64{"inbound": 1, "outbound": 1},
+	}, snapshot(t, sink))
+}
+
+func Test_Sink_Concurrent(t *testing.T) {
+	sink := newSink()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sink.IncBind(encoding.Mime_JSON, 1, j%2 == 0)
+				sink.IncRender(encoding.Mime_XML, 2, false)
+			}
+		}()
+	}
+	wg.Wait()
+	m := snapshot(t, sink)
+	require.Equal(t, map[string]int64{"requests": 800, "bytes": 800, "errors": 400}, m.Bind[encoding.Mime_JSON])
+	require.Equal(t, map[string]int64{"requests": 800, "bytes": 1600, "errors": 0}, m.Render[encoding.Mime_XML])
+}
@@ -0,0 +1,138 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// DefaultExtensions is the default mapping from the URL extension to the MIME type of WithExtensionNegotiation,
+// and the default mapping from the file extension to the MIME type installed by New, see RegisterExtension.
+var DefaultExtensions = map[string]string{
+	".json":    Mime_JSON,
+	".xml":     Mime_XML,
+	".yaml":    Mime_YAML,
+	".yml":     Mime_YAML,
+	".toml":    Mime_TOML,
+	".msgpack": Mime_MSGPACK,
+	".pb":      Mime_PROTOBUF,
+}
+
+// WithExtensionNegotiation enables the URL extension of the request path, like "/users/42.json",
+// to select the outbound marshaler before the `Accept` header, the extensions map the case-insensitive
+// extension with the leading dot to the MIME type, DefaultExtensions is used if it is nil.
+// The extension is honored only if its MIME type is registered, otherwise it falls through to
+// the `Accept` negotiation, the query and the last path segment without extension are not considered.
+// The registered MIME type override of WithOutboundMIME still takes precedence.
+// see TrimExtension to strip the extension before routing or binding the path.
+func WithExtensionNegotiation(extensions map[string]string) Option {
+	return func(r *Encoding) {
+		if extensions == nil {
+			extensions = DefaultExtensions
+		}
+		r.extensions = make(map[string]string, len(extensions))
+		for ext, mime := range extensions {
+			r.extensions[normalizeExtension(ext)] = normalizeMIME(mime)
+		}
+	}
+}
+
+// TrimExtension returns the path without the extension of the last path segment, like
+// "/users/42.json" --> "/users/42", and the lowercase extension with the leading dot.
+// The dot file like "/.json" has no extension.
+func TrimExtension(path string) (string, string) {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 || i == len(path)-1 {
+		return path, ""
+	}
+	slash := strings.LastIndexByte(path, '/')
+	if i <= slash+1 {
+		return path, ""
+	}
+	return path[:i], strings.ToLower(path[i:])
+}
+
+// extensionMarshaler returns the registered MIME type and marshaler of the URL extension of the request.
+func (r *Encoding) extensionMarshaler(s *registry, req *http.Request) (string, codec.Marshaler, bool) {
+	if len(r.extensions) == 0 || req.URL == nil {
+		return "", nil, false
+	}
+	_, ext := TrimExtension(req.URL.Path)
+	if ext == "" {
+		return "", nil, false
+	}
+	mime, ok := r.extensions[ext]
+	if !ok {
+		return "", nil, false
+	}
+	return lookup(s.outboundMarshalers(), mime)
+}
+
+// RegisterExtension registers the case-insensitive file extension, with or without the leading dot,
+// to the case-insensitive MIME type, the MIME type need not be registered yet,
+// it is resolved by GetByExtension, DecodeFile and RenderFile on every use.
+// New installs DefaultExtensions, they can be overridden.
+func (r *Encoding) RegisterExtension(ext, mime string) error {
+	if len(ext) == 0 || ext == "." || len(mime) == 0 {
+		return errors.New("encoding: empty file extension or MIME type")
+	}
+	if isSpecialMIME(mime) {
+		return fmt.Errorf("encoding: file extension(%s) can't be the special MIME type", ext)
+	}
+	ext, mime = normalizeExtension(ext), normalizeMIME(mime)
+	return r.update(func(s *registry) error {
+		s.fileExtensions[ext] = mime
+		return nil
+	})
+}
+
+// GetByExtension returns the marshaler of the case-insensitive file extension, with or without the leading dot,
+// like ".json" or "yaml", it returns nil if the extension or its MIME type is not registered,
+// it never falls back to the "*" Marshaler, see RegisterExtension.
+func (r *Encoding) GetByExtension(ext string) codec.Marshaler {
+	m, _ := r.extensionFileMarshaler(ext)
+	return m
+}
+
+// DecodeFile decodes the file into v with the marshaler picked by the extension of the path,
+// the file is streamed through the decoder of the marshaler.
+// It returns ErrUnknownExtension if the extension is not registered, or ErrNotRegistered
+// if the MIME type of the extension is not registered, see RegisterExtension.
+func (r *Encoding) DecodeFile(path string, v any) error {
+	m, err := r.extensionFileMarshaler(filepath.Ext(path))
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.NewDecoder(f).Decode(v)
+}
+
+// extensionFileMarshaler returns the marshaler of the file extension.
+func (r *Encoding) extensionFileMarshaler(ext string) (codec.Marshaler, error) {
+	mime, ok := r.load().fileExtensions[normalizeExtension(ext)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExtension, ext)
+	}
+	m, ok := r.Lookup(mime)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, mime)
+	}
+	return m, nil
+}
+
+// normalizeExtension returns the lowercase extension with the leading dot.
+func normalizeExtension(ext string) string {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return strings.ToLower(ext)
+}
@@ -0,0 +1,129 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/xml"
+	"github.com/thinkgos/encoding/yaml"
+)
+
+func Test_TrimExtension(t *testing.T) {
+	for _, tt := range []struct {
+		path, want, ext string
+	}{
+		{"/users/42.json", "/users/42", ".json"},
+		{"/users/42.XML", "/users/42", ".xml"},
+		{"/users/42", "/users/42", ""},
+		{"/v1.2/users", "/v1.2/users", ""},
+		{"/users/.json", "/users/.json", ""},
+		{"/users/42.", "/users/42.", ""},
+		{"42.tar.gz", "42.tar", ".gz"},
+		{"", "", ""},
+	} {
+		path, ext := TrimExtension(tt.path)
+		require.Equal(t, tt.want, path, tt.path)
+		require.Equal(t, tt.ext, ext, tt.path)
+	}
+}
+
+func Test_WithExtensionNegotiation(t *testing.T) {
+	registry := New(WithExtensionNegotiation(nil), WithStrictAccept())
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+
+	for _, tt := range []struct {
+		url    string
+		accept string
+		want   string
+	}{
+		{"http://example.com/users/42.xml", Mime_JSON, Mime_XML},
+		{"http://example.com/users/42.JSON", Mime_XML, Mime_JSON},
+		{"http://example.com/users/42.json?format=.xml", Mime_XML, Mime_JSON},
+		{"http://example.com/users/42?format=.xml", Mime_JSON, Mime_JSON},
+		{"http://example.com/users/42.yaml", Mime_XML, Mime_XML},
+		{"http://example.com/v1.xml/users/42", Mime_JSON, Mime_JSON},
+		{"http://example.com/users/42.html", Mime_XML, Mime_XML},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		req.Header.Set("Accept", tt.accept)
+		mime, _ := registry.OutboundForRequestWithMime(req)
+		require.Equal(t, tt.want, mime, tt.url)
+	}
+
+	// the extension is acceptable in the strict accept mode.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/users/42.xml", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, req, &TestMode{Id: "foo"}))
+	require.Contains(t, w.Header().Get("Content-Type"), Mime_XML)
+
+	// the override takes precedence.
+	req = req.WithContext(WithOutboundMIME(req.Context(), Mime_JSON))
+	mime, _ := registry.OutboundForRequestWithMime(req)
+	require.Equal(t, Mime_JSON, mime)
+
+	// the custom extensions.
+	registry = New(WithExtensionNegotiation(map[string]string{"XML": "Application/XML"}))
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/users/42.xml", nil)
+	mime, _ = registry.OutboundForRequestWithMime(req)
+	require.Equal(t, Mime_XML, mime)
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/users/42.json", nil)
+	mime, _ = registry.OutboundForRequestWithMime(req)
+	require.Equal(t, Mime_Wildcard, mime)
+
+	// it is opt-in.
+	mime, _ = New().OutboundForRequestWithMime(httptest.NewRequest(http.MethodGet, "http://example.com/users/42.json", nil))
+	require.Equal(t, Mime_Wildcard, mime)
+}
+
+func Test_Encoding_DecodeFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		return path
+	}
+	registry := New()
+	require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+
+	// the default extensions.
+	require.Same(t, registry.Get(Mime_JSON), registry.GetByExtension(".JSON"))
+	require.Same(t, registry.Get(Mime_YAML), registry.GetByExtension("yml"))
+	require.Nil(t, registry.GetByExtension(".xml"))
+	require.Nil(t, registry.GetByExtension(".ini"))
+
+	got := &TestMode{}
+	require.NoError(t, registry.DecodeFile(writeFile("config.json", `{"id":"foo"}`), got))
+	require.Equal(t, "foo", got.Id)
+	got = &TestMode{}
+	require.NoError(t, registry.DecodeFile(writeFile("config.YAML", "id: bar\n"), got))
+	require.Equal(t, "bar", got.Id)
+
+	// the unknown extension does not fall back to the "*" Marshaler.
+	require.ErrorIs(t, registry.DecodeFile(writeFile("config.ini", `{"id":"foo"}`), got), ErrUnknownExtension)
+	require.ErrorIs(t, registry.DecodeFile(writeFile("config", `{"id":"foo"}`), got), ErrUnknownExtension)
+	require.ErrorIs(t, registry.DecodeFile(writeFile("config.xml", `<TestMode/>`), got), ErrNotRegistered)
+	require.ErrorIs(t, registry.DecodeFile(filepath.Join(dir, "missing.json"), got), os.ErrNotExist)
+
+	// the custom extension.
+	require.Error(t, registry.RegisterExtension("", Mime_JSON))
+	require.Error(t, registry.RegisterExtension(".conf", ""))
+	require.Error(t, registry.RegisterExtension(".conf", Mime_Query))
+	require.NoError(t, registry.RegisterExtension("CONF", "Application/X-YAML"))
+	got = &TestMode{}
+	require.NoError(t, registry.DecodeFile(writeFile("app.conf", "id: baz\n"), got))
+	require.Equal(t, "baz", got.Id)
+	require.NoError(t, registry.RegisterExtension(".yaml", Mime_JSON))
+	require.Same(t, registry.Get(Mime_JSON), registry.GetByExtension(".yaml"))
+
+	// Reset restores the default extensions.
+	registry.Reset()
+	require.Nil(t, registry.GetByExtension(".conf"))
+	require.Nil(t, registry.GetByExtension(".yaml"))
+}
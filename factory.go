@@ -0,0 +1,100 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// RegisterFactory registers the factory of the marshaler for a case-insensitive MIME type like Register,
+// the factory is invoked once on the first use of the marshaler, like Bind, Render or Encode with the MIME type,
+// and the result is cached, so the expensive marshaler does not slow down New if it is never used.
+// If the factory fails, the error wrapped with the MIME type is returned by every use of the marshaler.
+// The concurrent first uses invoke the factory exactly once.
+func (r *Encoding) RegisterFactory(mime string, factory func() (codec.Marshaler, error)) error {
+	if factory == nil {
+		return errors.New("encoding: marshaller factory should be not nil")
+	}
+	return r.Register(mime, &lazyMarshaler{mime: normalizeMIME(mime), factory: factory})
+}
+
+// lazyMarshaler is the marshaler which is created by the factory on the first use.
+type lazyMarshaler struct {
+	mime    string
+	factory func() (codec.Marshaler, error)
+	once    sync.Once
+	m       codec.Marshaler
+	err     error
+}
+
+// resolve returns the marshaler created by the factory.
+func (l *lazyMarshaler) resolve() (codec.Marshaler, error) {
+	l.once.Do(func() {
+		l.m, l.err = l.factory()
+		if l.err == nil && l.m == nil {
+			l.err = errors.New("nil marshaller")
+		}
+		if l.err != nil {
+			l.err = fmt.Errorf("encoding: MIME(%s) marshaller factory: %w", l.mime, l.err)
+		}
+	})
+	return l.m, l.err
+}
+
+func (l *lazyMarshaler) ContentType(v any) string {
+	m, err := l.resolve()
+	if err != nil {
+		return l.mime
+	}
+	return m.ContentType(v)
+}
+
+func (l *lazyMarshaler) Marshal(v any) ([]byte, error) {
+	m, err := l.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return m.Marshal(v)
+}
+
+func (l *lazyMarshaler) Unmarshal(data []byte, v any) error {
+	m, err := l.resolve()
+	if err != nil {
+		return err
+	}
+	return m.Unmarshal(data, v)
+}
+
+func (l *lazyMarshaler) NewDecoder(r io.Reader) codec.Decoder {
+	m, err := l.resolve()
+	if err != nil {
+		return errorCoder{err}
+	}
+	return m.NewDecoder(r)
+}
+
+func (l *lazyMarshaler) NewEncoder(w io.Writer) codec.Encoder {
+	m, err := l.resolve()
+	if err != nil {
+		return errorCoder{err}
+	}
+	return m.NewEncoder(w)
+}
+
+// errorCoder is the Decoder and Encoder which always returns the error.
+type errorCoder struct{ err error }
+
+func (e errorCoder) Decode(any) error { return e.err }
+func (e errorCoder) Encode(any) error { return e.err }
+
+// resolveMarshaler returns the marshaler created by the factory if it is registered by RegisterFactory,
+// so the optional interfaces of it, like codec.FormCodec and codec.Sizer, are available.
+func resolveMarshaler(m codec.Marshaler) (codec.Marshaler, error) {
+	if l, ok := m.(*lazyMarshaler); ok {
+		return l.resolve()
+	}
+	return m, nil
+}
@@ -0,0 +1,95 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/codec"
+	"github.com/thinkgos/encoding/json"
+)
+
+func Test_Encoding_RegisterFactory(t *testing.T) {
+	const mime = "application/x-lazy"
+
+	t.Run("invalid", func(t *testing.T) {
+		registry := New()
+		require.Error(t, registry.RegisterFactory(mime, nil))
+		require.Error(t, registry.RegisterFactory("", func() (codec.Marshaler, error) { return &json.Codec{}, nil }))
+		require.Error(t, registry.RegisterFactory(Mime_Query, func() (codec.Marshaler, error) { return &json.Codec{}, nil }))
+	})
+
+	t.Run("once", func(t *testing.T) {
+		var calls atomic.Int32
+		registry := New()
+		require.NoError(t, registry.RegisterFactory(mime, func() (codec.Marshaler, error) {
+			calls.Add(1)
+			return &json.Codec{}, nil
+		}))
+		require.True(t, registry.Has(mime))
+		require.Zero(t, calls.Load())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"id":"foo"}`))
+				req.Header.Set("Content-Type", mime)
+				got := &TestMode{}
+				if err := registry.Bind(req, got); err != nil || got.Id != "foo" {
+					t.Errorf("Bind: %v, %+v", err, got)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+				req.Header.Set("Accept", mime)
+				w := httptest.NewRecorder()
+				if err := registry.Render(w, req, &TestMode{Id: "foo"}); err != nil {
+					t.Errorf("Render: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, int32(1), calls.Load())
+
+		data, err := registry.Encode(mime, &TestMode{Id: "foo"})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":"foo","name":""}`, string(data))
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var calls atomic.Int32
+		errFactory := errors.New("factory failed")
+		registry := New()
+		require.NoError(t, registry.RegisterFactory(mime, func() (codec.Marshaler, error) {
+			calls.Add(1)
+			return nil, errFactory
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", mime)
+		err := registry.Bind(req, &TestMode{})
+		require.ErrorIs(t, err, errFactory)
+		require.Contains(t, err.Error(), mime)
+
+		req = httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", mime)
+		err = registry.Render(httptest.NewRecorder(), req, &TestMode{})
+		require.ErrorIs(t, err, errFactory)
+		require.Contains(t, err.Error(), mime)
+
+		_, err = registry.Encode(mime, &TestMode{})
+		require.ErrorIs(t, err, errFactory)
+		require.Contains(t, err.Error(), mime)
+		require.Equal(t, int32(1), calls.Load())
+	})
+}
@@ -0,0 +1,176 @@
+// Package fastadapter provides Bind/Render of github.com/thinkgos/encoding for
+// github.com/valyala/fasthttp, the header negotiation works on fasthttp's byte-slice
+// header API directly, and reuses the codecs registered on the Encoding.
+package fastadapter
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/thinkgos/encoding"
+	"github.com/thinkgos/encoding/codec"
+)
+
+var (
+	strAccept            = []byte("Accept")
+	strWildcard          = []byte(encoding.Mime_Wildcard)
+	strMultipartFormData = []byte(encoding.Mime_MultipartPostForm)
+)
+
+// Bind checks the Method and Content-Type to select codec.Marshaler automatically,
+// like encoding.Encoding.Bind does, but it does not limit, decode or verify the body,
+// the body of the GET request is never bound, see encoding.WithGetBodyBinding.
+//
+//	GET                         --> BindQuery
+//	DELETE/HEAD/OPTIONS no body --> BindQuery
+//	"multipart/form-data"       --> the values and files of ctx.MultipartForm()
+//	"application/json"          --> JSON codec.Marshaler
+//	"application/xml"           --> XML codec.Marshaler
+func Bind(reg *encoding.Encoding, ctx *fasthttp.RequestCtx, v any) error {
+	if bindsQuery(ctx) {
+		return BindQuery(reg, ctx, v)
+	}
+	contentType, marshaller := InboundForRequest(reg, ctx)
+	if bytes.Equal(contentType, strMultipartFormData) {
+		m, ok := marshaller.(codec.FormCodec)
+		if !ok {
+			return fmt.Errorf("encoding: not supported marshaller(%s)", contentType)
+		}
+		form, err := ctx.MultipartForm()
+		if err != nil {
+			return err
+		}
+		if md, ok := marshaller.(codec.MultipartDecoder); ok {
+			return md.DecodeMultipart(form, v)
+		}
+		return m.Decode(form.Value, v)
+	}
+	return marshaller.Unmarshal(ctx.PostBody(), v)
+}
+
+// bindsQuery reports whether the query of the request should be bound instead of the body,
+// the same as encoding.Encoding.Bind does without encoding.WithGetBodyBinding and encoding.WithMethodOverride.
+func bindsQuery(ctx *fasthttp.RequestCtx) bool {
+	switch {
+	case ctx.IsGet():
+		return true
+	case ctx.IsDelete(), ctx.IsHead(), ctx.IsOptions():
+		return len(ctx.PostBody()) == 0
+	default:
+		return false
+	}
+}
+
+// BindQuery binds the passed struct pointer with ctx.QueryArgs() using the query codec.Marshaler.
+func BindQuery(reg *encoding.Encoding, ctx *fasthttp.RequestCtx, v any) error {
+	return formCodec(reg, encoding.Mime_Query).Decode(argsValues(ctx.QueryArgs()), v)
+}
+
+// BindUri binds the passed struct pointer with the user values, which are set by
+// the router like github.com/fasthttp/router, using the uri codec.Marshaler.
+// only the string and []byte user values are used.
+func BindUri(reg *encoding.Encoding, ctx *fasthttp.RequestCtx, v any) error {
+	vs := make(url.Values)
+	ctx.VisitUserValues(func(key []byte, value any) {
+		switch val := value.(type) {
+		case string:
+			vs.Add(string(key), val)
+		case []byte:
+			vs.Add(string(key), string(val))
+		}
+	})
+	return formCodec(reg, encoding.Mime_Uri).Decode(vs, v)
+}
+
+// Render writes the response headers and body with the outbound marshaler for this request.
+// It checks the registry on the Encoding for the MIME type set by the Accept header, see OutboundForRequest,
+// the `Content-Type` and the `Content-Length` are set like encoding.Encoding.Render does.
+func Render(reg *encoding.Encoding, ctx *fasthttp.RequestCtx, v any) error {
+	if v == nil {
+		return nil
+	}
+	marshaller := OutboundForRequest(reg, ctx)
+	data, err := marshaller.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ctx.SetContentType(marshaller.ContentType(v))
+	ctx.Response.Header.SetContentLength(len(data))
+	ctx.Response.SetBodyRaw(data)
+	return nil
+}
+
+// InboundForRequest returns the inbound media type and marshaler for this request.
+// It checks the registry on the Encoding for the media type set by the `Content-Type` header.
+// If it isn't set or not registered, returns "*" with the wildcard marshaler.
+// NOTE: the returned media type refers to the request header, it is valid only
+// until the ctx is released.
+func InboundForRequest(reg *encoding.Encoding, ctx *fasthttp.RequestCtx) ([]byte, codec.Marshaler) {
+	mediaType := parseMediaType(ctx.Request.Header.ContentType())
+	if m, ok := lookup(reg, mediaType); ok {
+		return mediaType, m
+	}
+	return strWildcard, reg.Get(encoding.Mime_Wildcard)
+}
+
+// OutboundForRequest returns the marshaler for this request.
+// It checks the registry on the Encoding for the media type set by the `Accept` header,
+// choose the first one that it can exactly match in the registry.
+// Otherwise, it returns the default outbound marshaler, see encoding.Mime_DefaultOutbound.
+// Unlike encoding.Encoding.OutboundForRequest, which negotiates the registry on the parsed header,
+// it does not allocate, so the quality values, the wildcards like "application/*" and the structured
+// syntax suffixes like "application/problem+json" are not matched, the first exact match wins.
+func OutboundForRequest(reg *encoding.Encoding, ctx *fasthttp.RequestCtx) codec.Marshaler {
+	for _, accept := range ctx.Request.Header.PeekAll(string(strAccept)) {
+		for len(accept) > 0 {
+			var value []byte
+			if idx := bytes.IndexByte(accept, ','); idx >= 0 {
+				value, accept = accept[:idx], accept[idx+1:]
+			} else {
+				value, accept = accept, nil
+			}
+			if m, ok := lookup(reg, parseMediaType(value)); ok {
+				return m
+			}
+		}
+	}
+	return reg.Get(encoding.Mime_DefaultOutbound)
+}
+
+// lookup returns the marshaler registered for the media type.
+func lookup(reg *encoding.Encoding, mediaType []byte) (codec.Marshaler, bool) {
+	if len(mediaType) == 0 || string(mediaType) == encoding.Mime_Wildcard {
+		return nil, false
+	}
+	return reg.LookupBytes(mediaType)
+}
+
+// parseMediaType returns the media type without parameters and surrounding spaces,
+// it is lower-cased, which only allocates if it contains upper-case letters.
+func parseMediaType(value []byte) []byte {
+	if idx := bytes.IndexByte(value, ';'); idx >= 0 {
+		value = value[:idx]
+	}
+	value = bytes.TrimSpace(value)
+	for _, c := range value {
+		if 'A' <= c && c <= 'Z' {
+			return bytes.ToLower(value)
+		}
+	}
+	return value
+}
+
+func argsValues(args *fasthttp.Args) url.Values {
+	vs := make(url.Values, args.Len())
+	args.VisitAll(func(key, value []byte) {
+		vs.Add(string(key), string(value))
+	})
+	return vs
+}
+
+func formCodec(reg *encoding.Encoding, mime string) codec.FormCodec {
+	return reg.Get(mime).(codec.FormCodec)
+}
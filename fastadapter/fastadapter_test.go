@@ -0,0 +1,334 @@
+package fastadapter
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/thinkgos/encoding"
+	"github.com/thinkgos/encoding/msgpack"
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+	"github.com/thinkgos/encoding/toml"
+	"github.com/thinkgos/encoding/xml"
+	"github.com/thinkgos/encoding/yaml"
+)
+
+type TestMode struct {
+	Id   string `json:"id" yaml:"id" xml:"id" toml:"id" msgpack:"id"`
+	Name string `json:"name" yaml:"name" xml:"name" toml:"name" msgpack:"name"`
+}
+
+func newEncoding() *encoding.Encoding {
+	registry := encoding.New()
+	_ = registry.Register(encoding.Mime_PROTOBUF, &pro.Codec{})
+	_ = registry.Register(encoding.Mime_XML, &xml.Codec{})
+	_ = registry.Register(encoding.Mime_XML2, &xml.Codec{})
+	_ = registry.Register(encoding.Mime_MSGPACK, &msgpack.Codec{})
+	_ = registry.Register(encoding.Mime_MSGPACK2, &msgpack.Codec{})
+	_ = registry.Register(encoding.Mime_YAML, &yaml.Codec{})
+	_ = registry.Register(encoding.Mime_TOML, &toml.Codec{})
+	return registry
+}
+
+func newRequestCtx(method, uri, contentType string, body []byte) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	if contentType != "" {
+		ctx.Request.Header.SetContentType(contentType)
+	}
+	ctx.Request.SetBody(body)
+	return ctx
+}
+
+func marshal(t *testing.T, registry *encoding.Encoding, mime string, v any) []byte {
+	b, err := registry.Encode(mime, v)
+	require.NoError(t, err)
+	return b
+}
+
+func Test_Bind(t *testing.T) {
+	registry := newEncoding()
+
+	multipartBody := &bytes.Buffer{}
+	mw := multipart.NewWriter(multipartBody)
+	require.NoError(t, mw.WriteField("id", "foo"))
+	require.NoError(t, mw.WriteField("name", "bar"))
+	require.NoError(t, mw.Close())
+
+	testMode := &TestMode{Id: "foo", Name: "bar"}
+	complexMessage := &examplepb.Complex{
+		Id:     11,
+		Uint32: wrapperspb.UInt32(1234),
+		Bool:   wrapperspb.Bool(true),
+	}
+	tests := []struct {
+		name    string
+		ctx     *fasthttp.RequestCtx
+		want    any
+		wantErr bool
+	}{
+		{
+			"default: marshaler",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/unknown", marshal(t, registry, encoding.Mime_Wildcard, complexMessage)),
+			complexMessage,
+			false,
+		},
+		{
+			"form - application/x-www-form-urlencoded",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/x-www-form-urlencoded", []byte(`id=foo&name=bar`)),
+			testMode,
+			false,
+		},
+		{
+			"form - method get so it query",
+			newRequestCtx(http.MethodGet, "http://example.com?id=foo&name=bar", "application/x-www-form-urlencoded", nil),
+			testMode,
+			false,
+		},
+		{
+			"form - method delete without body so it query",
+			newRequestCtx(http.MethodDelete, "http://example.com?id=foo&name=bar", "", nil),
+			testMode,
+			false,
+		},
+		{
+			"form - method delete with body",
+			newRequestCtx(http.MethodDelete, "http://example.com?id=baz", "application/json", []byte(`{"id":"foo","name":"bar"}`)),
+			testMode,
+			false,
+		},
+		{
+			"form - proto query",
+			newRequestCtx(http.MethodGet, "http://example.com?id=11&uint32=1234&bool=true", "", nil),
+			complexMessage,
+			false,
+		},
+		{
+			"form - MultipartForm",
+			newRequestCtx(http.MethodPost, "http://example.com", mw.FormDataContentType(), multipartBody.Bytes()),
+			testMode,
+			false,
+		},
+		{
+			"json",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/json", []byte(`{"id":"foo"}`)),
+			&examplepb.SimpleMessage{Id: "foo"},
+			false,
+		},
+		{
+			"json - upper case with parameters",
+			newRequestCtx(http.MethodPost, "http://example.com", "Application/JSON; charset=utf-8", []byte(`{"id":"foo"}`)),
+			&examplepb.SimpleMessage{Id: "foo"},
+			false,
+		},
+		{
+			"proto",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/x-protobuf", marshal(t, registry, encoding.Mime_PROTOBUF, complexMessage)),
+			complexMessage,
+			false,
+		},
+		{
+			"yaml",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/x-yaml", []byte("id: foo\nname: bar")),
+			testMode,
+			false,
+		},
+		{
+			"xml",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/xml", []byte("<TestMode><id>foo</id><name>bar</name></TestMode>")),
+			testMode,
+			false,
+		},
+		{
+			"toml",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/toml", []byte("id=\"foo\"\nname=\"bar\"")),
+			testMode,
+			false,
+		},
+		{
+			"msgpack",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/x-msgpack", marshal(t, registry, encoding.Mime_MSGPACK, testMode)),
+			testMode,
+			false,
+		},
+		{
+			"invalid json",
+			newRequestCtx(http.MethodPost, "http://example.com", "application/json", []byte(`{"id":`)),
+			testMode,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reflect.New(reflect.TypeOf(tt.want).Elem())
+			err := Bind(registry, tt.ctx, got.Interface())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if _, ok := tt.want.(proto.Message); ok {
+				require.True(t, proto.Equal(got.Interface().(proto.Message), tt.want.(proto.Message)), "got = %v, want %v", got, tt.want)
+			} else {
+				require.Equal(t, tt.want, got.Interface())
+			}
+		})
+	}
+}
+
+func Test_BindUri(t *testing.T) {
+	registry := newEncoding()
+
+	ctx := newRequestCtx(http.MethodGet, "http://example.com", "", nil)
+	ctx.SetUserValue("id", "foo")
+	ctx.SetUserValue("name", []byte("bar"))
+	ctx.SetUserValue("ignore", 1)
+	got := &TestMode{}
+	require.NoError(t, BindUri(registry, ctx, got))
+	require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+}
+
+func Test_Render(t *testing.T) {
+	registry := newEncoding()
+
+	tests := []struct {
+		name            string
+		accept          []string
+		v               any
+		wantContentType string
+		want            string
+	}{
+		{
+			"<nil> payload",
+			nil,
+			nil,
+			"text/plain; charset=utf-8",
+			"",
+		},
+		{
+			"no accept",
+			nil,
+			TestMode{Id: "foo", Name: "bar"},
+			"application/json; charset=utf-8",
+			`{"id":"foo","name":"bar"}`,
+		},
+		{
+			"json with parameters",
+			[]string{"application/json; charset=utf-8"},
+			TestMode{Id: "foo", Name: "bar"},
+			"application/json; charset=utf-8",
+			`{"id":"foo","name":"bar"}`,
+		},
+		{
+			"first matched",
+			[]string{"application/unknown, text/xml;q=0.9, application/json"},
+			TestMode{Id: "foo", Name: "bar"},
+			"application/xml; charset=utf-8",
+			`<TestMode><id>foo</id><name>bar</name></TestMode>`,
+		},
+		{
+			"quality values are not weighted",
+			[]string{"application/x-yaml;q=0.1, application/json"},
+			TestMode{Id: "foo", Name: "bar"},
+			"application/x-yaml; charset=utf-8",
+			"id: foo\nname: bar\n",
+		},
+		{
+			"range and suffix are not matched",
+			[]string{"application/*, application/problem+json"},
+			TestMode{Id: "foo", Name: "bar"},
+			"application/json; charset=utf-8",
+			`{"id":"foo","name":"bar"}`,
+		},
+		{
+			"multiple accept headers",
+			[]string{"application/unknown", "application/x-yaml"},
+			TestMode{Id: "foo", Name: "bar"},
+			"application/x-yaml; charset=utf-8",
+			"id: foo\nname: bar\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newRequestCtx(http.MethodGet, "http://example.com", "", nil)
+			for _, accept := range tt.accept {
+				ctx.Request.Header.Add("Accept", accept)
+			}
+			require.NoError(t, Render(registry, ctx, tt.v))
+			require.Equal(t, tt.wantContentType, string(ctx.Response.Header.ContentType()))
+			require.Equal(t, tt.want, string(ctx.Response.Body()))
+			if tt.v != nil {
+				require.Equal(t, len(tt.want), ctx.Response.Header.ContentLength())
+			}
+		})
+	}
+}
+
+func Test_Render_Error(t *testing.T) {
+	registry := newEncoding()
+
+	ctx := newRequestCtx(http.MethodGet, "http://example.com", "", nil)
+	ctx.Request.Header.Set("Accept", encoding.Mime_PROTOBUF)
+	require.Error(t, Render(registry, ctx, TestMode{}))
+	require.Empty(t, ctx.Response.Body())
+}
+
+func Test_OutboundForRequest_NoAlloc(t *testing.T) {
+	registry := newEncoding()
+	ctx := newRequestCtx(http.MethodGet, "http://example.com", "", nil)
+	ctx.Request.Header.Set("Accept", "text/html, application/json")
+	allocs := testing.AllocsPerRun(100, func() { _ = OutboundForRequest(registry, ctx) })
+	require.Zero(t, allocs)
+}
+
+func Benchmark_Bind_NetHTTP(b *testing.B) {
+	registry := newEncoding()
+	body := []byte(`{"id":"foo","name":"bar"}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		_ = registry.Bind(req, &TestMode{})
+	}
+}
+
+func Benchmark_Bind_Fasthttp(b *testing.B) {
+	registry := newEncoding()
+	ctx := newRequestCtx(http.MethodPost, "http://example.com", "application/json", []byte(`{"id":"foo","name":"bar"}`))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Bind(registry, ctx, &TestMode{})
+	}
+}
+
+func Benchmark_Render_NetHTTP(b *testing.B) {
+	registry := newEncoding()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", "text/html, application/json")
+	v := &TestMode{Id: "foo", Name: "bar"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = registry.Render(httptest.NewRecorder(), req, v)
+	}
+}
+
+func Benchmark_Render_Fasthttp(b *testing.B) {
+	registry := newEncoding()
+	ctx := newRequestCtx(http.MethodGet, "http://example.com", "", nil)
+	ctx.Request.Header.Set("Accept", "text/html, application/json")
+	v := &TestMode{Id: "foo", Name: "bar"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Render(registry, ctx, v)
+	}
+}
@@ -0,0 +1,94 @@
+package encoding
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+)
+
+// sniffLen is the number of bytes used by http.DetectContentType.
+const sniffLen = 512
+
+// RenderFile streams the reader r as the response body of the file name.
+//
+//   - the `Content-Type` is kept if it is set already, otherwise it is resolved by the extension of name
+//     registered in the Encoding, see RegisterExtension, which does not depend on the mime types of the host,
+//     otherwise it is detected by http.DetectContentType with the first 512 bytes.
+//   - the `Content-Length` is set if size is non-negative, a negative size uses chunked transfer.
+//   - the `Content-Disposition` is set to attachment with the filename if name is not empty.
+//   - the body is not written for the HEAD request.
+//
+// The reader is closed if it implements io.Closer.
+func (r *Encoding) RenderFile(w http.ResponseWriter, req *http.Request, name string, rd io.Reader, size int64) error {
+	err := r.renderFile(w, req, name, rd, size)
+	if err != nil && r.onRenderError != nil {
+		callErrorHook(r.onRenderError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) renderFile(w http.ResponseWriter, req *http.Request, name string, rd io.Reader, size int64) error {
+	if c, ok := rd.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	h := w.Header()
+	contentType := h.Get(contentTypeHeader)
+	if contentType == "" {
+		if ext := filepath.Ext(name); ext != "" {
+			contentType = r.load().fileExtensions[normalizeExtension(ext)]
+		}
+		if contentType == "" {
+			br := bufio.NewReaderSize(rd, sniffLen)
+			buf, err := br.Peek(sniffLen)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			contentType = http.DetectContentType(buf)
+			rd = br
+		}
+		h.Set(contentTypeHeader, contentType)
+	}
+	if size >= 0 {
+		h.Set("Content-Length", strconv.FormatInt(size, 10))
+	} else {
+		h.Del("Content-Length")
+	}
+	if name != "" {
+		h.Set("Content-Disposition", attachmentDisposition(name))
+	}
+	if req != nil && req.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	var n int64
+	var err error
+	if size >= 0 {
+		n, err = io.CopyN(w, rd, size)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+	} else {
+		n, err = io.Copy(w, rd)
+	}
+	if r.metrics != nil {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		r.metrics.IncRender(mediaType, int(n), err != nil)
+	}
+	return err
+}
+
+// attachmentDisposition returns the `Content-Disposition` of the attachment with the base name of the file,
+// the non-ASCII filename is encoded as RFC 2231.
+func attachmentDisposition(name string) string {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(name)})
+	if disposition == "" {
+		return "attachment"
+	}
+	return disposition
+}
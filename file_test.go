@@ -0,0 +1,93 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func Test_Encoding_RenderFile(t *testing.T) {
+	registry := New()
+
+	t.Run("known size buffer", func(t *testing.T) {
+		body := []byte(`{"id":"foo"}`)
+		rd := &closeRecorder{Reader: bytes.NewReader(body)}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		require.NoError(t, registry.RenderFile(w, req, "export/data.json", rd, int64(len(body))))
+		require.True(t, rd.closed)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Equal(t, "12", w.Header().Get("Content-Length"))
+		require.Equal(t, `attachment; filename=data.json`, w.Header().Get("Content-Disposition"))
+		require.Equal(t, body, w.Body.Bytes())
+	})
+	t.Run("unknown size pipe", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = pw.Write([]byte("<html><body>hello</body></html>"))
+			_ = pw.Close()
+		}()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		require.NoError(t, registry.RenderFile(w, req, "", pr, -1))
+		require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Empty(t, w.Header().Get("Content-Length"))
+		require.Empty(t, w.Header().Get("Content-Disposition"))
+		require.Equal(t, "<html><body>hello</body></html>", w.Body.String())
+	})
+	t.Run("HEAD request", func(t *testing.T) {
+		rd := &closeRecorder{Reader: bytes.NewReader([]byte("hello"))}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodHead, "http://example.com", nil)
+		require.NoError(t, registry.RenderFile(w, req, "报告.txt", rd, 5))
+		require.True(t, rd.closed)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "5", w.Header().Get("Content-Length"))
+		require.Equal(t, "attachment; filename*=utf-8''%E6%8A%A5%E5%91%8A.txt", w.Header().Get("Content-Disposition"))
+		require.Empty(t, w.Body.String())
+	})
+	t.Run("registered extension", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.RegisterExtension("foo", "application/x-foo"))
+		for name, want := range map[string]string{
+			"config.YML": Mime_YAML,
+			"data.foo":   "application/x-foo",
+			// the unregistered extension is detected by the content.
+			"data.unknown": "text/plain; charset=utf-8",
+		} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			require.NoError(t, registry.RenderFile(w, req, name, bytes.NewReader([]byte("id: foo")), 7))
+			require.Equal(t, want, w.Header().Get("Content-Type"), name)
+			require.Equal(t, "id: foo", w.Body.String())
+		}
+	})
+	t.Run("Content-Type set already", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		require.NoError(t, registry.RenderFile(w, req, "a.json", bytes.NewReader([]byte("{}")), 2))
+		require.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+	})
+	t.Run("short reader", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		err := registry.RenderFile(w, req, "a.json", bytes.NewReader([]byte("{}")), 10)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
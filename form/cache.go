@@ -0,0 +1,80 @@
+package form
+
+import (
+	"reflect"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// structFieldsKey is the key of the struct fields cache,
+// the same type with different tag name has different fields.
+type structFieldsKey struct {
+	typ     reflect.Type
+	tagName string
+}
+
+// structFieldsCache caches the struct fields, map[structFieldsKey]map[string]int.
+var structFieldsCache sync.Map
+
+// cachedStructFields returns the mapping from the field name to the field index of
+// the struct type t, it is computed once per type and tag name.
+// the field name is the name of the tag, or the field name if the tag has no name,
+// the exported fields only, and fields with tag "-" are omitted,
+// if there are duplicate names, the first one wins.
+func cachedStructFields(t reflect.Type, tagName string) map[string]int {
+	key := structFieldsKey{t, tagName}
+	if fields, ok := structFieldsCache.Load(key); ok {
+		return fields.(map[string]int)
+	}
+
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// we can't access the value of unexported fields
+		if field.PkgPath != "" {
+			continue
+		}
+		// don't check if it's omitted
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		name := field.Name
+		if tagNamed, _ := parseTag(tag); tagNamed != "" {
+			name = tagNamed
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = i
+		}
+	}
+	actual, _ := structFieldsCache.LoadOrStore(key, fields)
+	return actual.(map[string]int)
+}
+
+// protoFieldsCache caches the proto message fields,
+// map[protoreflect.MessageDescriptor]map[string]protoreflect.FieldDescriptor.
+var protoFieldsCache sync.Map
+
+// cachedProtoFields returns the mapping from both the proto field name and
+// the JSON field name to the field descriptor of the message descriptor md,
+// it is computed once per message descriptor.
+// the proto field name takes precedence over the JSON field name.
+func cachedProtoFields(md protoreflect.MessageDescriptor) map[string]protoreflect.FieldDescriptor {
+	if fields, ok := protoFieldsCache.Load(md); ok {
+		return fields.(map[string]protoreflect.FieldDescriptor)
+	}
+
+	fds := md.Fields()
+	fields := make(map[string]protoreflect.FieldDescriptor, fds.Len()*2) //nolint:gomnd
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		fields[fd.JSONName()] = fd
+	}
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		fields[string(fd.Name())] = fd
+	}
+	actual, _ := protoFieldsCache.LoadOrStore(md, fields)
+	return actual.(map[string]protoreflect.FieldDescriptor)
+}
@@ -0,0 +1,151 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+type benchModel struct {
+	F1  string  `json:"f1"`
+	F2  string  `json:"f2"`
+	F3  string  `json:"f3"`
+	F4  string  `json:"f4"`
+	F5  string  `json:"f5"`
+	F6  int     `json:"f6"`
+	F7  int     `json:"f7"`
+	F8  int     `json:"f8"`
+	F9  int     `json:"f9"`
+	F10 int     `json:"f10"`
+	F11 int64   `json:"f11"`
+	F12 int64   `json:"f12"`
+	F13 uint    `json:"f13"`
+	F14 uint    `json:"f14"`
+	F15 bool    `json:"f15"`
+	F16 bool    `json:"f16"`
+	F17 float64 `json:"f17"`
+	F18 float64 `json:"f18"`
+	F19 *string `json:"f19"`
+	F20 *int    `json:"f20"`
+}
+
+func benchValues() url.Values {
+	vs := make(url.Values)
+	for i := 1; i <= 20; i++ {
+		var value string
+		switch {
+		case i <= 5 || i == 19:
+			value = "foo"
+		case i == 15 || i == 16:
+			value = "true"
+		case i == 17 || i == 18:
+			value = "1.5"
+		default:
+			value = strconv.Itoa(i)
+		}
+		vs.Set("f"+strconv.Itoa(i), value)
+	}
+	return vs
+}
+
+func Benchmark_Decode(b *testing.B) {
+	c := New("json")
+	vs := benchValues()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.Decode(vs, &benchModel{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_Decode_Proto(b *testing.B) {
+	c := New("json")
+	vs := url.Values{
+		"id":          {"2233"},
+		"numberOne":   {"2233"},
+		"simples":     {"3344", "5566"},
+		"b":           {"true"},
+		"sex":         {"woman"},
+		"age":         {"18"},
+		"a":           {"19"},
+		"count":       {"3"},
+		"price":       {"11.23"},
+		"d":           {"22.22"},
+		"byte":        {"aGVsbG8="},
+		"timestamp":   {"2021-01-01T10:02:03Z"},
+		"duration":    {"1m"},
+		"field":       {"1,2,3"},
+		"double":      {"12.33"},
+		"float":       {"12.34"},
+		"int64":       {"64"},
+		"int32":       {"32"},
+		"uint64":      {"64"},
+		"uint32":      {"32"},
+		"bool":        {"false"},
+		"string":      {"go-kratos"},
+		"map[kratos]": {"https://go-kratos.dev/"},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.Decode(vs, &examplepb.Complex{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_EncodeUrl(b *testing.B) {
+	c := New("json")
+	v := &NoProtoHello{Name: "foo", Sub: &NoProtoSub{Name: "bar"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.EncodeUrl("http://helloworld.dev/{name}/sub/{sub.name}", v, false)
+	}
+}
+
+func Benchmark_EncodeUrl_Proto(b *testing.B) {
+	c := New("json")
+	v := &examplepb.HelloRequest{Name: "foo", Sub: &examplepb.Sub{Name: "bar"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.EncodeUrl("http://helloworld.dev/{name}/sub/{sub.naming}", v, false)
+	}
+}
+
+func Test_cachedStructFields_TagName(t *testing.T) {
+	type model struct {
+		Aaa    string `json:"aaa" form:"xxx"`
+		Bbb    string `json:"bbb" form:"-"`
+		Ccc    string `json:"-"`
+		hidden string //nolint:unused
+	}
+	require.Equal(t, map[string]int{"aaa": 0, "bbb": 1}, cachedStructFields(reflect.TypeOf(model{}), "json"))
+	require.Equal(t, map[string]int{"xxx": 0, "Ccc": 2}, cachedStructFields(reflect.TypeOf(model{}), "form"))
+
+	v := &model{Aaa: "foo", Bbb: "bar", Ccc: "baz"}
+	require.Equal(t, "/foo/bar/{Ccc}", New("json").EncodeUrl("/{aaa}/{bbb}/{Ccc}", v, false))
+	require.Equal(t, "/foo/{bbb}/baz", New("form").EncodeUrl("/{xxx}/{bbb}/{Ccc}", v, false))
+}
+
+func Test_cachedProtoFields(t *testing.T) {
+	fields := cachedProtoFields((&examplepb.HelloRequest{}).ProtoReflect().Descriptor())
+	require.Equal(t, "sub", string(fields["sub"].Name()))
+	require.Equal(t, "update_mask", string(fields["update_mask"].Name()))
+	require.Equal(t, "update_mask", string(fields["updateMask"].Name()))
+	require.Nil(t, fields["unknown"])
+}
+
+func Test_Decode_PointerToProto(t *testing.T) {
+	var msg *examplepb.SimpleMessage
+	require.NoError(t, New("json").Decode(url.Values{"id": {"foo"}}, &msg))
+	require.Equal(t, "foo", msg.Id)
+}
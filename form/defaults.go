@@ -0,0 +1,152 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTagName is the struct tag of the default value of the field.
+const defaultTagName = "default"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fieldDefault is the default value of the field keyed by its namespace, like "page" or "filter.sort".
+type fieldDefault struct {
+	key    string
+	index  []int
+	values []string
+}
+
+// structDefaultsCache caches the default values of the struct fields, map[structFieldsKey][]fieldDefault.
+var structDefaultsCache sync.Map
+
+// cachedStructDefaults returns the default values of the fields of the struct type t,
+// which have the `default:"..."` tag, including the nested struct fields,
+// the default value of the slice and array fields is split by comma.
+func cachedStructDefaults(t reflect.Type, tagName string) []fieldDefault {
+	key := structFieldsKey{t, tagName}
+	if defaults, ok := structDefaultsCache.Load(key); ok {
+		return defaults.([]fieldDefault)
+	}
+	defaults := appendStructDefaults(nil, t, tagName, "", nil)
+	actual, _ := structDefaultsCache.LoadOrStore(key, defaults)
+	return actual.([]fieldDefault)
+}
+
+func appendStructDefaults(defaults []fieldDefault, t reflect.Type, tagName, prefix string, index []int) []fieldDefault {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		name, _ := parseTag(tag)
+		fieldIndex := append(append([]int(nil), index...), i)
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			defaults = appendStructDefaults(defaults, field.Type, tagName, prefix, fieldIndex)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if value, ok := field.Tag.Lookup(defaultTagName); ok {
+			values := []string{value}
+			if k := field.Type.Kind(); k == reflect.Slice || k == reflect.Array {
+				values = strings.Split(value, ",")
+			}
+			defaults = append(defaults, fieldDefault{key: prefix + name, index: fieldIndex, values: values})
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			defaults = appendStructDefaults(defaults, field.Type, tagName, prefix+name+".", fieldIndex)
+		}
+	}
+	return defaults
+}
+
+// present reports whether the key is present in vs, like `key` or `key[0]`.
+func present(vs url.Values, key string) bool {
+	if _, ok := vs[key]; ok {
+		return true
+	}
+	for k := range vs {
+		if len(k) > len(key) && k[len(key)] == '[' && strings.HasPrefix(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeDefaults decodes the default values of the struct fields whose keys are absent in vs into v,
+// with the same coercion rules as the values, so the explicit zero value, like `page=0`, is kept.
+func (c *Codec) decodeDefaults(vs url.Values, v any, rv reflect.Value) error {
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var defaults url.Values
+	for _, d := range cachedStructDefaults(rv.Type(), c.TagName) {
+		if !present(vs, d.key) {
+			if defaults == nil {
+				defaults = make(url.Values)
+			}
+			defaults[d.key] = d.values
+		}
+	}
+	if len(defaults) == 0 {
+		return nil
+	}
+	return c.Decoder.Decode(v, defaults)
+}
+
+// ApplyDefaults sets the default values of the `default:"..."` tag to the fields of the struct pointer v
+// which are the zero value, with the same coercion rules as Decode.
+// Unlike Decode, it can not distinguish the explicit zero value from the absent one,
+// it is for the values decoded by the other codecs, like JSON.
+func (c *Codec) ApplyDefaults(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var defaults url.Values
+	for _, d := range cachedStructDefaults(rv.Type(), c.TagName) {
+		if rv.FieldByIndex(d.index).IsZero() {
+			if defaults == nil {
+				defaults = make(url.Values)
+			}
+			defaults[d.key] = d.values
+		}
+	}
+	if len(defaults) == 0 {
+		return nil
+	}
+	return c.Decoder.Decode(v, defaults)
+}
+
+// decodeDuration decodes a time.Duration like "1s" or "1m30s", or the nanoseconds like "1000",
+// the empty value is zero.
+func decodeDuration(values []string) (any, error) {
+	s := strings.TrimSpace(values[0])
+	if s == "" {
+		return time.Duration(0), nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(n), nil
+	}
+	return time.ParseDuration(s)
+}
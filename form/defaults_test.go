@@ -0,0 +1,87 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type defaultsFilter struct {
+	Sort string `json:"sort" default:"created_at"`
+}
+
+type defaultsEmbedded struct {
+	Lang string `json:"lang" default:"en"`
+}
+
+type defaultsQuery struct {
+	defaultsEmbedded
+	Page     int            `json:"page" default:"1"`
+	PageSize int            `json:"page_size" default:"20"`
+	Verbose  bool           `json:"verbose" default:"true"`
+	Timeout  time.Duration  `json:"timeout" default:"1m30s"`
+	Tags     []string       `json:"tags" default:"a,b"`
+	Filter   defaultsFilter `json:"filter"`
+	Name     string         `json:"name"`
+	Skip     string         `json:"-" default:"skip"`
+}
+
+func TestCodec_Decode_Defaults(t *testing.T) {
+	c := New("json")
+
+	got := &defaultsQuery{}
+	require.NoError(t, c.Decode(url.Values{}, got))
+	require.Equal(t, &defaultsQuery{
+		defaultsEmbedded: defaultsEmbedded{Lang: "en"},
+		Page:             1,
+		PageSize:         20,
+		Verbose:          true,
+		Timeout:          90 * time.Second,
+		Tags:             []string{"a", "b"},
+		Filter:           defaultsFilter{Sort: "created_at"},
+	}, got)
+
+	// the explicit zero values are kept.
+	got = &defaultsQuery{}
+	require.NoError(t, c.Decode(url.Values{
+		"lang":        {"zh"},
+		"page":        {"0"},
+		"verbose":     {"false"},
+		"timeout":     {"1000"},
+		"tags[0]":     {"c"},
+		"filter.sort": {""},
+	}, got))
+	require.Equal(t, &defaultsQuery{
+		defaultsEmbedded: defaultsEmbedded{Lang: "zh"},
+		Page:             0,
+		PageSize:         20,
+		Verbose:          false,
+		Timeout:          time.Microsecond,
+		Tags:             []string{"c"},
+	}, got)
+
+	// the invalid default value.
+	require.Error(t, c.Decode(url.Values{}, &struct {
+		Page int `json:"page" default:"x"`
+	}{}))
+}
+
+func TestCodec_ApplyDefaults(t *testing.T) {
+	c := New("json")
+	got := &defaultsQuery{Page: 3, Filter: defaultsFilter{Sort: "name"}}
+	require.NoError(t, c.ApplyDefaults(got))
+	require.Equal(t, &defaultsQuery{
+		defaultsEmbedded: defaultsEmbedded{Lang: "en"},
+		Page:             3,
+		PageSize:         20,
+		Verbose:          true,
+		Timeout:          90 * time.Second,
+		Tags:             []string{"a", "b"},
+		Filter:           defaultsFilter{Sort: "name"},
+	}, got)
+
+	require.NoError(t, c.ApplyDefaults((*defaultsQuery)(nil)))
+	require.NoError(t, c.ApplyDefaults(&[]string{}))
+}
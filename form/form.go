@@ -4,6 +4,7 @@ import (
 	"io"
 	"net/url"
 	"reflect"
+	"time"
 
 	"github.com/go-playground/form/v4"
 	"google.golang.org/protobuf/proto"
@@ -11,6 +12,8 @@ import (
 	"github.com/thinkgos/encoding/codec"
 )
 
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
 type Codec struct {
 	Encoder *form.Encoder
 	Decoder *form.Decoder
@@ -20,6 +23,9 @@ type Codec struct {
 	UseProtoNames bool
 	// UseEnumNumbers emits enum values as numbers.
 	UseEnumNumbers bool
+
+	// requiredFields is the paths of the required fields of the call, see WithDecoderOptions.
+	requiredFields []string
 }
 
 // New returns a new Codec,
@@ -31,12 +37,19 @@ func New(tagName string) *Codec {
 	encoder.SetTagName(tagName)
 	decoder := form.NewDecoder()
 	decoder.SetTagName(tagName)
+	decoder.RegisterTagNameFunc(tagNameFunc(tagName))
+	decoder.RegisterCustomTypeFunc(decodeFlagBool, false)
+	decoder.RegisterCustomTypeFunc(decodeDuration, time.Duration(0))
+	for _, typ := range numberPtrTypes {
+		decoder.RegisterCustomTypeFunc(decodeNumberPtr(reflect.TypeOf(typ)), typ)
+	}
 	return &Codec{
 		encoder,
 		decoder,
 		tagName,
 		true,
 		true,
+		nil,
 	}
 }
 
@@ -137,6 +150,9 @@ func (c *Codec) NewEncoder(w io.Writer) codec.Encoder {
 	})
 }
 
+// Encode encodes v into url.Values, v is a struct pointer or proto.Message.
+// The nil pointer is omitted, the non-nil pointer to an empty string is encoded as `name=`,
+// even if the field has the omitempty tag option.
 func (c *Codec) Encode(v any) (url.Values, error) {
 	var vs url.Values
 	var err error
@@ -157,25 +173,87 @@ func (c *Codec) Encode(v any) (url.Values, error) {
 	return vs, nil
 }
 
+// Decode decodes the url.Values into v, v is a struct pointer, proto.Message or the pointer to the map
+// with the string key, like map[string]any, map[string]string or map[string][]string, see decodeMap.
+// The present key with an empty value, like `?name=` or `?name`, is distinguished from the absent key:
+//
+//	field type                       | absent    | present, empty | present, non-empty
+//	string                           | untouched | ""             | value
+//	bool                             | untouched | true           | value
+//	number                           | untouched | untouched      | value
+//	*string, *bool, *number          | untouched | new(zero)      | value, *bool is true
+//	[]string, []number               | untouched | [""], [0]      | values
+//	proto scalar                     | untouched | untouched      | value
+//	proto bool, BoolValue            | untouched | true           | value
+//	proto optional scalar            | untouched | zero           | value
+//	proto wrappers except BoolValue  | untouched | zero wrapper   | value
+//
+// The repeated keys, like `tags=a&tags=b` or `tags[]=a&tags[]=b`, are decoded into the slice, the array
+// and the proto repeated field, the values of `tags` come before the ones of `tags[]`. The slice and array
+// fields with the "comma" tag option, like `json:"tags,comma"`, also split the values on commas,
+// `tags=a,b&tags=c` --> [a b c], see normalizeValues.
+// The field with the "required" tag option, like `json:"user_id,required"`, must be present in the values,
+// or it returns a *MissingFieldsError listing all the missing keys after decoding the others,
+// the required fields of the proto message are set per call, see WithDecoderOptions.
+// The map field accepts the bracketed key like `labels[env]=prod`, or the dotted key like `labels.env=prod`,
+// see normalizeMapKeys, the malformed key like `labels[env` returns a *MapKeyError.
+// The time.Duration accepts the duration like "1m30s" or the nanoseconds, the empty value is zero.
+// The absent field of the struct with the `default:"..."` tag is decoded from the default value,
+// the default value of the slice is comma-separated, like `default:"a,b"`.
+//
+// NOTE: the pointer of the named number type, like *time.Duration, is not allocated with empty value.
 func (c *Codec) Decode(vs url.Values, v any) error {
 	if m, ok := v.(proto.Message); ok {
-		return DecodeValues(m, vs)
+		return c.decodeProto(m, vs)
 	}
 	rv := reflect.ValueOf(v)
 	for rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
 		}
+		if rv.Type().Implements(protoMessageType) {
+			return c.decodeProto(rv.Interface().(proto.Message), vs)
+		}
 		rv = rv.Elem()
 	}
-	if m, ok := rv.Interface().(proto.Message); ok {
-		return DecodeValues(m, vs)
+	if decodeMap(vs, rv) {
+		return nil
+	}
+	var missing []string
+	if rv.Kind() == reflect.Struct {
+		for k := range vs {
+			if err := checkMapKey(k); err != nil {
+				return err
+			}
+		}
+		vs = normalizeMapKeys(vs, cachedMapFields(rv.Type(), c.TagName))
+		vs = normalizeValues(vs, cachedCommaFields(rv.Type(), c.TagName))
+		missing = appendMissingFields(missing, vs, cachedRequiredFields(rv.Type(), c.TagName))
+		missing = appendMissingFields(missing, vs, c.requiredFields)
+	}
+	if err := c.Decoder.Decode(v, vs); err != nil {
+		return err
+	}
+	if err := c.decodeDefaults(vs, v, rv); err != nil {
+		return err
+	}
+	return missingFieldsError(missing)
+}
+
+// decodeProto decodes the values into the proto message, and checks the required fields of the call.
+func (c *Codec) decodeProto(m proto.Message, vs url.Values) error {
+	if err := DecodeValues(m, vs); err != nil {
+		return err
 	}
-	return c.Decoder.Decode(v, vs)
+	return missingFieldsError(missingProtoFields(m.ProtoReflect().Descriptor(), vs, c.requiredFields))
 }
 
 type MultipartCodec struct {
 	*Codec
+	// AcceptFileTypes is the allow-list of the detected content type of the uploaded files,
+	// like "image/png" or "image/*", it is overridden by the `accept=` tag option of the field.
+	// Empty means any type is accepted, see DecodeMultipart.
+	AcceptFileTypes []string
 }
 
 func (*MultipartCodec) ContentType(_ any) string {
@@ -291,3 +291,57 @@ func growCap(oldCap, cap int) int {
 	}
 	return newCap
 }
+
+// decodeFlagBool decodes a bool, the present key with an empty value, like the bare flag `?verbose`, is true.
+// it accepts the same values as the form.Decoder does, like "1", "t", "on", "yes", "0", "f", "off", "no".
+func decodeFlagBool(values []string) (any, error) {
+	switch values[0] {
+	case "", "1", "t", "T", "true", "TRUE", "True", "on", "yes", "ok":
+		return true, nil
+	case "0", "f", "F", "false", "FALSE", "False", "off", "no":
+		return false, nil
+	}
+	return nil, &strconv.NumError{Func: "ParseBool", Num: values[0], Err: strconv.ErrSyntax}
+}
+
+// numberPtrTypes is the pointer of the number types which are allocated with the zero value
+// when the key is present with an empty value.
+var numberPtrTypes = []any{
+	(*int)(nil), (*int8)(nil), (*int16)(nil), (*int32)(nil), (*int64)(nil),
+	(*uint)(nil), (*uint8)(nil), (*uint16)(nil), (*uint32)(nil), (*uint64)(nil),
+	(*float32)(nil), (*float64)(nil),
+}
+
+// decodeNumberPtr returns the decoder of the pointer of the number type t,
+// the present key with an empty value allocates the zero value, like `?age=` --> new(int).
+func decodeNumberPtr(t reflect.Type) func(values []string) (any, error) {
+	te := t.Elem()
+	return func(values []string) (any, error) {
+		v := reflect.New(te)
+		s := strings.TrimSpace(values[0])
+		if s == "" {
+			return v.Interface(), nil
+		}
+		switch te.Kind() { // nolint: exhaustive
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, err := strconv.ParseInt(s, 10, te.Bits())
+			if err != nil {
+				return nil, err
+			}
+			v.Elem().SetInt(i)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			i, err := strconv.ParseUint(s, 10, te.Bits())
+			if err != nil {
+				return nil, err
+			}
+			v.Elem().SetUint(i)
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(s, te.Bits())
+			if err != nil {
+				return nil, err
+			}
+			v.Elem().SetFloat(f)
+		}
+		return v.Interface(), nil
+	}
+}
@@ -247,3 +247,140 @@ func Test_NewDecoder_Decode(t *testing.T) {
 		})
 	}
 }
+
+func TestDecode_FlagBool(t *testing.T) {
+	type Flags struct {
+		Verbose bool   `json:"verbose"`
+		DryRun  *bool  `json:"dry_run"`
+		Force   bool   `json:"force"`
+		Quiet   *bool  `json:"quiet"`
+		Name    string `json:"name"`
+		Age     int    `json:"age"`
+	}
+	codec := New("json")
+
+	vs, err := url.ParseQuery("verbose&dry_run&name&age")
+	require.NoError(t, err)
+	got := &Flags{Force: true}
+	require.NoError(t, codec.Decode(vs, got))
+	require.True(t, got.Verbose)
+	require.NotNil(t, got.DryRun)
+	require.True(t, *got.DryRun)
+	require.True(t, got.Force) // absent keeps untouched
+	require.Nil(t, got.Quiet)  // absent keeps untouched
+	require.Equal(t, "", got.Name)
+	require.Equal(t, 0, got.Age)
+
+	vs, err = url.ParseQuery("verbose=false&dry_run=0&force=off&quiet=yes")
+	require.NoError(t, err)
+	got = &Flags{Verbose: true, Force: true}
+	require.NoError(t, codec.Decode(vs, got))
+	require.False(t, got.Verbose)
+	require.NotNil(t, got.DryRun)
+	require.False(t, *got.DryRun)
+	require.False(t, got.Force)
+	require.NotNil(t, got.Quiet)
+	require.True(t, *got.Quiet)
+
+	require.Error(t, codec.Decode(url.Values{"verbose": {"x"}}, &Flags{}))
+}
+
+func TestDecode_PresentEmpty(t *testing.T) {
+	type Filter struct {
+		Name   *string  `json:"name"`
+		Age    *int     `json:"age"`
+		Score  *float64 `json:"score"`
+		Flag   *bool    `json:"flag"`
+		Title  string   `json:"title"`
+		Count  int      `json:"count"`
+		Tags   []string `json:"tags"`
+		Levels []int    `json:"levels"`
+		Size   *uint8   `json:"size"`
+	}
+	str := func(s string) *string { return &s }
+	num := func(i int) *int { return &i }
+	flt := func(f float64) *float64 { return &f }
+	bl := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name    string
+		query   string
+		init    Filter
+		want    Filter
+		wantErr bool
+	}{
+		{
+			name:  "absent",
+			query: "",
+			init:  Filter{Title: "keep", Count: 1},
+			want:  Filter{Title: "keep", Count: 1},
+		},
+		{
+			name:  "present, empty",
+			query: "name=&age=&score=&flag=&title=&count=&tags=&levels=",
+			init:  Filter{Title: "keep", Count: 1},
+			want: Filter{
+				Name:   str(""),
+				Age:    num(0),
+				Score:  flt(0),
+				Flag:   bl(true),
+				Title:  "",
+				Count:  1,
+				Tags:   []string{""},
+				Levels: []int{0},
+			},
+		},
+		{
+			name:  "present, non-empty",
+			query: "name=foo&age=18&score=1.5&flag=false&title=bar&count=2&tags=a&tags=b&levels=1",
+			want: Filter{
+				Name:   str("foo"),
+				Age:    num(18),
+				Score:  flt(1.5),
+				Flag:   bl(false),
+				Title:  "bar",
+				Count:  2,
+				Tags:   []string{"a", "b"},
+				Levels: []int{1},
+			},
+		},
+		{
+			name:    "present, invalid number",
+			query:   "age=x",
+			wantErr: true,
+		},
+		{
+			name:    "present, out of range",
+			query:   "size=256",
+			wantErr: true,
+		},
+	}
+	codec := New("json")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			got := tt.init
+			err = codec.Decode(vs, &got)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncode_PresentEmpty(t *testing.T) {
+	type Filter struct {
+		Name      *string `json:"name"`
+		Nickname  *string `json:"nickname,omitempty"`
+		Title     *string `json:"title"`
+		Signature *string `json:"signature"`
+	}
+	empty, foo := "", "foo"
+	vs, err := New("json").Encode(&Filter{Name: &empty, Nickname: &empty, Title: &foo})
+	require.NoError(t, err)
+	require.Equal(t, "name=&nickname=&title=foo", vs.Encode())
+}
@@ -0,0 +1,56 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+)
+
+// decodeMap decodes the url.Values into the map with the string key, it reports whether rv is such a map,
+// which is the target of the schemaless endpoints, like the webhook receivers:
+//
+//	map type             | single value | repeated values
+//	map[string]any       | string       | []string
+//	map[string]string    | value        | the first value
+//	map[string][]string  | []string     | []string
+//
+// The values are merged into the existing map, the nil map is allocated.
+func decodeMap(vs url.Values, rv reflect.Value) bool {
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return false
+	}
+	t := rv.Type()
+	elem := t.Elem()
+	var value func([]string) reflect.Value
+	switch {
+	case elem.Kind() == reflect.Interface && elem.NumMethod() == 0:
+		value = func(values []string) reflect.Value {
+			if len(values) == 1 {
+				return reflect.ValueOf(values[0])
+			}
+			return reflect.ValueOf(values)
+		}
+	case elem.Kind() == reflect.String:
+		value = func(values []string) reflect.Value {
+			return reflect.ValueOf(values[0]).Convert(elem)
+		}
+	case elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.String:
+		value = func(values []string) reflect.Value {
+			return reflect.ValueOf(append([]string(nil), values...)).Convert(elem)
+		}
+	default:
+		return false
+	}
+	if rv.IsNil() {
+		if !rv.CanSet() {
+			return false
+		}
+		rv.Set(reflect.MakeMapWithSize(t, len(vs)))
+	}
+	for k, values := range vs {
+		if len(values) == 0 {
+			continue
+		}
+		rv.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), value(values))
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode_Map(t *testing.T) {
+	type Labels map[string]string
+
+	c := New("json")
+	vs := url.Values{"id": {"foo"}, "tag": {"a", "b"}, "empty": {}}
+
+	gotAny := map[string]any{"keep": 1}
+	require.NoError(t, c.Decode(vs, &gotAny))
+	require.Equal(t, map[string]any{"keep": 1, "id": "foo", "tag": []string{"a", "b"}}, gotAny)
+
+	var gotLabels Labels
+	require.NoError(t, c.Decode(vs, &gotLabels))
+	require.Equal(t, Labels{"id": "foo", "tag": "a"}, gotLabels)
+
+	var gotSlice map[string][]string
+	require.NoError(t, c.Decode(vs, &gotSlice))
+	require.Equal(t, map[string][]string{"id": {"foo"}, "tag": {"a", "b"}}, gotSlice)
+	gotSlice["tag"][0] = "c"
+	require.Equal(t, "a", vs["tag"][0])
+
+	// the non-pointer map is decoded in place.
+	gotString := map[string]string{}
+	require.NoError(t, c.Decode(vs, gotString))
+	require.Equal(t, map[string]string{"id": "foo", "tag": "a"}, gotString)
+}
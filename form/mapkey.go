@@ -0,0 +1,130 @@
+package form
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MapKeyError is returned by Decode and DecodeValues when the key of the map field is malformed,
+// like `labels[env` whose bracket is not closed.
+type MapKeyError struct {
+	// Field is the key before the bracket, like "labels".
+	Field string
+	// Key is the malformed map key, like "env".
+	Key string
+}
+
+func (e *MapKeyError) Error() string {
+	return fmt.Sprintf("form: malformed map key %q of field %q", e.Key, e.Field)
+}
+
+// checkMapKey returns a *MapKeyError if the brackets of the key are not balanced,
+// the bracket is followed by "[", "." or the end of the key, like `labels[env]` or `items[0].name`.
+func checkMapKey(key string) error {
+	start := -1
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '[':
+			if start >= 0 {
+				return &MapKeyError{Field: key[:start], Key: key[start+1:]}
+			}
+			start = i
+		case ']':
+			if start < 0 {
+				return &MapKeyError{Field: key[:i], Key: key[i:]}
+			}
+			if i+1 < len(key) && key[i+1] != '[' && key[i+1] != '.' {
+				return &MapKeyError{Field: key[:start], Key: key[start+1:]}
+			}
+			start = -1
+		}
+	}
+	if start >= 0 {
+		return &MapKeyError{Field: key[:start], Key: key[start+1:]}
+	}
+	return nil
+}
+
+// mapFieldsCache caches the keys of the map fields, map[structFieldsKey][]string.
+var mapFieldsCache sync.Map
+
+// cachedMapFields returns the keys of the map fields of the struct type t whose value is not a struct,
+// including the nested struct fields, like "labels" or "filter.labels".
+func cachedMapFields(t reflect.Type, tagName string) []string {
+	key := structFieldsKey{t, tagName}
+	if keys, ok := mapFieldsCache.Load(key); ok {
+		return keys.([]string)
+	}
+	keys := appendMapFields(nil, t, tagName, "")
+	actual, _ := mapFieldsCache.LoadOrStore(key, keys)
+	return actual.([]string)
+}
+
+func appendMapFields(keys []string, t reflect.Type, tagName, prefix string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		name, _ := parseTag(tag)
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			keys = appendMapFields(keys, field.Type, tagName, prefix)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		switch ft := field.Type; {
+		case ft.Kind() == reflect.Map:
+			elem := ft.Elem()
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct || elem == timeType {
+				keys = append(keys, prefix+name)
+			}
+		case ft.Kind() == reflect.Struct && ft != timeType:
+			keys = appendMapFields(keys, ft, tagName, prefix+name+".")
+		}
+	}
+	return keys
+}
+
+// normalizeMapKeys returns the values with the dotted keys of the map fields converted to the bracketed ones,
+// like `labels.env` --> `labels[env]`, the rest of the key is the map key, like `labels.a.b` --> `labels[a.b]`.
+// The values of `labels[env]` come before the ones of `labels.env`.
+// vs is not modified, it is returned as is if there is nothing to normalize.
+func normalizeMapKeys(vs url.Values, mapKeys []string) url.Values {
+	var normalized url.Values
+	for _, field := range mapKeys {
+		prefix := field + "."
+		for k, values := range vs {
+			if len(k) <= len(prefix) || !strings.HasPrefix(k, prefix) {
+				continue
+			}
+			if normalized == nil {
+				normalized = make(url.Values, len(vs))
+				for k, values := range vs {
+					normalized[k] = values
+				}
+			}
+			key := field + "[" + k[len(prefix):] + "]"
+			delete(normalized, k)
+			normalized[key] = append(append([]string(nil), vs[key]...), values...)
+		}
+	}
+	if normalized == nil {
+		return vs
+	}
+	return normalized
+}
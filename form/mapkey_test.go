@@ -0,0 +1,170 @@
+package form
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func TestDecode_MapKey(t *testing.T) {
+	type Filter struct {
+		Labels map[string]string `json:"labels"`
+	}
+	type Search struct {
+		Labels map[string]string `json:"labels"`
+		Ids    map[int64]string  `json:"ids"`
+		Filter Filter            `json:"filter"`
+	}
+	c := New("json")
+
+	tests := []struct {
+		name string
+		vs   url.Values
+		want Search
+	}{
+		{
+			"bracketed",
+			url.Values{"labels[env]": {"prod"}, "ids[1]": {"a"}, "filter.labels[tier]": {"web"}},
+			Search{Labels: map[string]string{"env": "prod"}, Ids: map[int64]string{1: "a"}, Filter: Filter{Labels: map[string]string{"tier": "web"}}},
+		},
+		{
+			"dotted",
+			url.Values{"labels.env": {"prod"}, "labels.a.b": {"c"}, "ids.2": {"b"}, "filter.labels.tier": {"web"}},
+			Search{Labels: map[string]string{"env": "prod", "a.b": "c"}, Ids: map[int64]string{2: "b"}, Filter: Filter{Labels: map[string]string{"tier": "web"}}},
+		},
+		{
+			"bracketed before dotted",
+			url.Values{"labels[env]": {"prod"}, "labels.env": {"dev"}},
+			Search{Labels: map[string]string{"env": "prod"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Search
+			require.NoError(t, c.Decode(tt.vs, &got))
+			require.Equal(t, tt.want, got)
+
+			// round-trip
+			vs, err := c.Encode(&got)
+			require.NoError(t, err)
+			var again Search
+			require.NoError(t, c.Decode(vs, &again))
+			require.Equal(t, got, again)
+		})
+	}
+
+	err := c.Decode(url.Values{"ids[x]": {"a"}}, &Search{})
+	require.Error(t, err)
+
+	for key, want := range map[string]*MapKeyError{
+		"labels[env":       {Field: "labels", Key: "env"},
+		"labels[env]x":     {Field: "labels", Key: "env]x"},
+		"labels[a[b]]":     {Field: "labels", Key: "a[b]]"},
+		"labelsenv]":       {Field: "labelsenv", Key: "]"},
+		"filter.labels[ab": {Field: "filter.labels", Key: "ab"},
+	} {
+		err := c.Decode(url.Values{key: {"prod"}}, &Search{})
+		var e *MapKeyError
+		require.True(t, errors.As(err, &e), key)
+		require.Equal(t, want, e, key)
+	}
+	require.EqualError(t, &MapKeyError{Field: "labels", Key: "env"}, `form: malformed map key "env" of field "labels"`)
+}
+
+func TestDecodeValues_MapKey(t *testing.T) {
+	tests := []struct {
+		name string
+		vs   url.Values
+		want map[string]string
+	}{
+		{"bracketed", url.Values{"mapped_string_value[env]": {"prod"}}, map[string]string{"env": "prod"}},
+		{"dotted", url.Values{"mapped_string_value.env": {"prod"}}, map[string]string{"env": "prod"}},
+		{"json name", url.Values{"mappedStringValue.env": {"prod"}}, map[string]string{"env": "prod"}},
+		{"bracketed dot", url.Values{"mapped_string_value[a.b]": {"c"}}, map[string]string{"a.b": "c"}},
+		{"dotted dot", url.Values{"mapped_string_value.a.b": {"c"}}, map[string]string{"a.b": "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &examplepb.ABitOfEverything{}
+			require.NoError(t, DecodeValues(msg, tt.vs))
+			require.Equal(t, tt.want, msg.MappedStringValue)
+
+			// round-trip
+			vs, err := EncodeValues(msg, true, false)
+			require.NoError(t, err)
+			again := &examplepb.ABitOfEverything{}
+			require.NoError(t, DecodeValues(again, vs))
+			require.True(t, proto.Equal(msg, again))
+		})
+	}
+
+	err := DecodeValues(&examplepb.ABitOfEverything{}, url.Values{"mapped_string_value[env": {"prod"}})
+	var e *MapKeyError
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, &MapKeyError{Field: "mapped_string_value", Key: "env"}, e)
+}
+
+func TestDecodeValues_Int64MapKey(t *testing.T) {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("mapkey_test.proto"),
+		Package: proto.String("form.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Names"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("names"),
+				JsonName: proto.String("names"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".form.test.Names.NamesEntry"),
+			}},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("NamesEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("key"),
+						JsonName: proto.String("key"),
+						Number:   proto.Int32(1),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+					},
+					{
+						Name:     proto.String("value"),
+						JsonName: proto.String("value"),
+						Number:   proto.Int32(2),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	md := fd.Messages().ByName("Names")
+
+	msg := dynamicpb.NewMessage(md)
+	require.NoError(t, DecodeValues(msg, url.Values{"names[1]": {"a"}, "names.2": {"b"}}))
+	names := msg.Get(md.Fields().ByName("names")).Map()
+	require.Equal(t, 2, names.Len())
+	require.Equal(t, "a", names.Get(protoreflect.ValueOfInt64(1).MapKey()).String())
+	require.Equal(t, "b", names.Get(protoreflect.ValueOfInt64(2).MapKey()).String())
+
+	vs, err := EncodeValues(msg, true, false)
+	require.NoError(t, err)
+	require.Equal(t, url.Values{"names[1]": {"a"}, "names[2]": {"b"}}, vs)
+
+	err = DecodeValues(dynamicpb.NewMessage(md), url.Values{"names[x]": {"a"}})
+	require.ErrorContains(t, err, `parsing map key "names"`)
+}
@@ -0,0 +1,211 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// sniffLen is the number of bytes which http.DetectContentType considers.
+const sniffLen = 512
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	fileType            = reflect.TypeOf((*File)(nil))
+	fileSliceType       = reflect.TypeOf([]*File(nil))
+)
+
+// File is the uploaded file of the multipart form with the sniffed content type.
+type File struct {
+	*multipart.FileHeader
+	// DetectedType is the content type detected by http.DetectContentType
+	// from the first 512 bytes of the file, like "image/png".
+	DetectedType string
+}
+
+// FileTypeError is returned when the detected content type of the uploaded file is not accepted.
+type FileTypeError struct {
+	Field        string   // the name of the field.
+	Filename     string   // the client-declared file name.
+	DetectedType string   // the detected content type.
+	Accept       []string // the accepted content types.
+}
+
+func (e *FileTypeError) Error() string {
+	return fmt.Sprintf("form: file %q of field %q: detected type %q is not accepted, accept [%s]",
+		e.Filename, e.Field, e.DetectedType, strings.Join(e.Accept, " "))
+}
+
+// fileField is a struct field which binds the uploaded files.
+type fileField struct {
+	index  int
+	name   string
+	accept []string // the `accept=` tag option, nil if not set.
+}
+
+// fileFieldsCache caches the file fields, map[structFieldsKey][]fileField.
+var fileFieldsCache sync.Map
+
+// DecodeMultipart decodes the values of the multipart form like Decode, and binds the uploaded files
+// into the top-level struct fields of type *multipart.FileHeader, []*multipart.FileHeader, *File or []*File,
+// the field name is the same as the value, like:
+//
+//	type Upload struct {
+//		Name    string                  `json:"name"`
+//		Avatar  *form.File              `json:"avatar,file,accept=image/png|image/jpeg"`
+//		Attachs []*multipart.FileHeader `json:"attachs,file"`
+//	}
+//
+// The "file" tag option is optional. The content type of the file is sniffed by http.DetectContentType,
+// if the field is a *File or []*File, or the accepted types is set by the `accept=` tag option, which
+// are separated by spaces or "|" and support the wildcard like "image/*", or AcceptFileTypes of the codec.
+// NOTE: go vet reports the spaces in the json and xml tags, use "|" instead,
+// like `json:"avatar,accept=image/png|image/jpeg"`.
+// It returns a *FileTypeError if the detected type is not accepted,
+// the sniffing opens the file again, it does not consume the file for later readers.
+func (c *MultipartCodec) DecodeMultipart(form *multipart.Form, v any) error {
+	if form == nil {
+		return errors.New("form: nil multipart form")
+	}
+	// the missing fields may be the uploaded files.
+	missingErr := filterFiles(c.Decode(form.Value, v), form.File)
+	var e *MissingFieldsError
+	if missingErr != nil && !errors.As(missingErr, &e) {
+		return missingErr
+	}
+	if len(form.File) == 0 {
+		return missingErr
+	}
+	if _, ok := v.(proto.Message); ok {
+		return missingErr
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return missingErr
+	}
+	for _, f := range cachedFileFields(rv.Type(), c.TagName) {
+		headers := form.File[f.name]
+		if len(headers) == 0 {
+			continue
+		}
+		accept := f.accept
+		if accept == nil {
+			accept = c.AcceptFileTypes
+		}
+		field := rv.Field(f.index)
+		sniff := len(accept) > 0 || field.Type() == fileType || field.Type() == fileSliceType
+		files := make([]*File, 0, len(headers))
+		for _, fh := range headers {
+			file := &File{FileHeader: fh}
+			if sniff {
+				detected, err := sniffFileType(fh)
+				if err != nil {
+					return fmt.Errorf("form: file %q of field %q: %w", fh.Filename, f.name, err)
+				}
+				if !acceptFileType(accept, detected) {
+					return &FileTypeError{Field: f.name, Filename: fh.Filename, DetectedType: detected, Accept: accept}
+				}
+				file.DetectedType = detected
+			}
+			files = append(files, file)
+		}
+		switch field.Type() {
+		case fileHeaderType:
+			field.Set(reflect.ValueOf(headers[0]))
+		case fileHeaderSliceType:
+			field.Set(reflect.ValueOf(headers))
+		case fileType:
+			field.Set(reflect.ValueOf(files[0]))
+		case fileSliceType:
+			field.Set(reflect.ValueOf(files))
+		}
+	}
+	return missingErr
+}
+
+// cachedFileFields returns the file fields of the struct type t, it is computed once per type and tag name.
+func cachedFileFields(t reflect.Type, tagName string) []fileField {
+	key := structFieldsKey{t, tagName}
+	if fields, ok := fileFieldsCache.Load(key); ok {
+		return fields.([]fileField)
+	}
+	var fields []fileField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		switch field.Type {
+		case fileHeaderType, fileHeaderSliceType, fileType, fileSliceType:
+		default:
+			continue
+		}
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		if name == "" {
+			name = field.Name
+		}
+		f := fileField{index: i, name: name}
+		for _, opt := range opts {
+			if types, ok := strings.CutPrefix(opt, "accept="); ok {
+				f.accept = strings.FieldsFunc(types, isAcceptSeparator)
+			}
+		}
+		fields = append(fields, f)
+	}
+	actual, _ := fileFieldsCache.LoadOrStore(key, fields)
+	return actual.([]fileField)
+}
+
+// isAcceptSeparator reports whether r separates the types of the `accept=` tag option.
+func isAcceptSeparator(r rune) bool { return r == ' ' || r == '|' }
+
+// sniffFileType detects the content type from the first 512 bytes of the file.
+func sniffFileType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var buf [sniffLen]byte
+	n, err := io.ReadFull(f, buf[:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// acceptFileType reports whether the detected type is accepted, the parameters like charset are ignored,
+// the empty accept accepts any type.
+func acceptFileType(accept []string, detected string) bool {
+	if len(accept) == 0 {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(detected, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	for _, typ := range accept {
+		if strings.EqualFold(typ, mediaType) || typ == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(typ, "/*"); ok &&
+			len(mediaType) > len(prefix) && mediaType[len(prefix)] == '/' && strings.EqualFold(mediaType[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
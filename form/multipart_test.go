@@ -0,0 +1,178 @@
+package form
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+var (
+	pngPayload = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 600)...)
+	exePayload = append([]byte("MZ\x90\x00\x03\x00\x00\x00"), bytes.Repeat([]byte{0xff}, 64)...)
+)
+
+type multipartPart struct {
+	field       string
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// newMultipartForm returns the parsed multipart form with the values and files.
+func newMultipartForm(t *testing.T, values map[string]string, parts ...multipartPart) *multipart.Form {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for k, v := range values {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	for _, p := range parts {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="`+p.field+`"; filename="`+p.filename+`"`)
+		h.Set("Content-Type", p.contentType)
+		pw, err := w.CreatePart(h)
+		require.NoError(t, err)
+		_, err = pw.Write(p.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	form, err := multipart.NewReader(body, w.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	return form
+}
+
+type upload struct {
+	Name    string                  `json:"name"`
+	Avatar  *File                   `json:"avatar,file,accept=image/png|image/jpeg"`
+	Images  []*File                 `json:"images,accept=image/*"`
+	Attachs []*multipart.FileHeader `json:"attachs,file"`
+	Raw     *multipart.FileHeader   `json:"raw"`
+	Ignored *multipart.FileHeader   `json:"-"`
+}
+
+func TestMultipartCodec_DecodeMultipart(t *testing.T) {
+	codec := &MultipartCodec{Codec: New("json")}
+
+	t.Run("png renamed to txt", func(t *testing.T) {
+		form := newMultipartForm(t, map[string]string{"name": "foo"},
+			multipartPart{"avatar", "avatar.txt", "text/plain", pngPayload},
+			multipartPart{"images", "a.txt", "text/plain", pngPayload},
+			multipartPart{"images", "b.png", "image/png", pngPayload},
+			multipartPart{"attachs", "run.exe", "application/x-msdownload", exePayload},
+			multipartPart{"raw", "raw.bin", "application/octet-stream", exePayload},
+			multipartPart{"Ignored", "ignored.bin", "application/octet-stream", exePayload},
+		)
+		got := &upload{}
+		require.NoError(t, codec.DecodeMultipart(form, got))
+		require.Equal(t, "foo", got.Name)
+		require.Equal(t, "avatar.txt", got.Avatar.Filename)
+		require.Equal(t, "image/png", got.Avatar.DetectedType)
+		require.Len(t, got.Images, 2)
+		require.Equal(t, "image/png", got.Images[0].DetectedType)
+		require.Equal(t, "b.png", got.Images[1].Filename)
+		require.Len(t, got.Attachs, 1)
+		require.Equal(t, "run.exe", got.Attachs[0].Filename)
+		require.Equal(t, "raw.bin", got.Raw.Filename)
+		require.Nil(t, got.Ignored)
+
+		// the sniffing does not consume the file.
+		f, err := got.Avatar.Open()
+		require.NoError(t, err)
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		require.Equal(t, pngPayload, data)
+	})
+	t.Run("disallowed executable", func(t *testing.T) {
+		form := newMultipartForm(t, nil,
+			multipartPart{"avatar", "avatar.png", "image/png", exePayload},
+		)
+		err := codec.DecodeMultipart(form, &upload{})
+		var typeErr *FileTypeError
+		require.ErrorAs(t, err, &typeErr)
+		require.Equal(t, "avatar", typeErr.Field)
+		require.Equal(t, "avatar.png", typeErr.Filename)
+		require.Equal(t, "application/octet-stream", typeErr.DetectedType)
+		require.Equal(t, []string{"image/png", "image/jpeg"}, typeErr.Accept)
+		require.Contains(t, err.Error(), `"avatar"`)
+		require.Contains(t, err.Error(), `"application/octet-stream"`)
+
+		form = newMultipartForm(t, nil,
+			multipartPart{"images", "a.png", "image/png", pngPayload},
+			multipartPart{"images", "b.png", "image/png", exePayload},
+		)
+		require.ErrorAs(t, codec.DecodeMultipart(form, &upload{}), &typeErr)
+		require.Equal(t, "b.png", typeErr.Filename)
+	})
+	t.Run("codec allow-list", func(t *testing.T) {
+		codec := &MultipartCodec{Codec: New("json"), AcceptFileTypes: []string{"text/plain"}}
+		form := newMultipartForm(t, nil,
+			multipartPart{"attachs", "note.txt", "text/plain", []byte("hello world")},
+			multipartPart{"avatar", "avatar.png", "image/png", pngPayload},
+		)
+		got := &upload{}
+		// the tag option overrides the allow-list of the codec.
+		require.NoError(t, codec.DecodeMultipart(form, got))
+		require.Equal(t, "note.txt", got.Attachs[0].Filename)
+		require.Equal(t, "image/png", got.Avatar.DetectedType)
+
+		form = newMultipartForm(t, nil,
+			multipartPart{"raw", "run.txt", "text/plain", exePayload},
+		)
+		var typeErr *FileTypeError
+		require.ErrorAs(t, codec.DecodeMultipart(form, &upload{}), &typeErr)
+		require.Equal(t, "raw", typeErr.Field)
+	})
+	t.Run("values only", func(t *testing.T) {
+		got := &examplepb.HelloRequest{}
+		form := newMultipartForm(t, map[string]string{"name": "foo"},
+			multipartPart{"avatar", "avatar.png", "image/png", exePayload},
+		)
+		require.NoError(t, codec.DecodeMultipart(form, got))
+		require.Equal(t, "foo", got.Name)
+
+		require.Error(t, codec.DecodeMultipart(nil, &upload{}))
+	})
+}
+
+func TestMultipartCodec_DecodeMultipart_FormTag(t *testing.T) {
+	codec := &MultipartCodec{Codec: New("form")}
+	var got struct {
+		Avatar *File `form:"avatar,file,accept=image/png image/jpeg"`
+	}
+	require.NoError(t, codec.DecodeMultipart(newMultipartForm(t, nil,
+		multipartPart{"avatar", "avatar.txt", "text/plain", pngPayload},
+	), &got))
+	require.Equal(t, "image/png", got.Avatar.DetectedType)
+
+	var typeErr *FileTypeError
+	require.ErrorAs(t, codec.DecodeMultipart(newMultipartForm(t, nil,
+		multipartPart{"avatar", "avatar.png", "image/png", exePayload},
+	), &got), &typeErr)
+	require.Equal(t, []string{"image/png", "image/jpeg"}, typeErr.Accept)
+}
+
+func Test_acceptFileType(t *testing.T) {
+	tests := []struct {
+		accept   []string
+		detected string
+		want     bool
+	}{
+		{nil, "application/octet-stream", true},
+		{[]string{"image/png"}, "image/png", true},
+		{[]string{"IMAGE/PNG"}, "image/png", true},
+		{[]string{"image/png"}, "image/jpeg", false},
+		{[]string{"image/*"}, "image/jpeg", true},
+		{[]string{"image/*"}, "imagex/jpeg", false},
+		{[]string{"text/plain"}, "text/plain; charset=utf-8", true},
+		{[]string{"*/*"}, "application/octet-stream", true},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, acceptFileType(tt.accept, tt.detected), "%v %s", tt.accept, tt.detected)
+	}
+}
@@ -23,15 +23,41 @@ import (
 var errInvalidFormatMapKey = errors.New("invalid formatting for map key")
 
 // DecodeValues decode url value into proto message.
+// The map field accepts the bracketed key like `labels[env]=prod`, or the dotted key like `labels.env=prod`,
+// the key of the map<int64, ...> is coerced, it returns a *MapKeyError if the bracket is not closed.
 func DecodeValues(msg proto.Message, values url.Values) error {
+	var buf [8]string
 	for k, v := range values {
-		if err := populateFieldValues(msg.ProtoReflect(), strings.Split(k, "."), v); err != nil {
+		if err := checkMapKey(k); err != nil {
+			return err
+		}
+		if err := populateFieldValues(msg.ProtoReflect(), splitFieldPath(buf[:0], k), v); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// splitFieldPath slices key into the field path separated by ".", and appends them to dst,
+// the "." in the brackets is a part of the map key, like `labels[a.b]`.
+func splitFieldPath(dst []string, key string) []string {
+	var depth, start int
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				dst = append(dst, key[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(dst, key[start:])
+}
+
 func populateFieldValues(v protoreflect.Message, fieldPath []string, values []string) error {
 	if len(fieldPath) < 1 {
 		return errors.New("no field path")
@@ -52,9 +78,9 @@ func populateFieldValues(v protoreflect.Message, fieldPath []string, values []st
 		}
 
 		if fd.Message() == nil || fd.Cardinality() == protoreflect.Repeated {
-			if fd.IsMap() && len(fieldPath) > 1 {
-				// post subfield
-				return populateMapField(fd, v.Mutable(fd).Map(), []string{fieldPath[1]}, values)
+			if fd.IsMap() && strings.IndexByte(fieldName, '[') < 0 {
+				// the dotted map key, like `labels.env`, the rest of the path is the key.
+				return populateMapValue(fd, v.Mutable(fd).Map(), strings.Join(fieldPath[i+1:], "."), values)
 			}
 			return fmt.Errorf("invalid path: %q is not a message", fieldName)
 		}
@@ -79,14 +105,14 @@ func populateFieldValues(v protoreflect.Message, fieldPath []string, values []st
 }
 
 func getFieldDescriptor(v protoreflect.Message, fieldName string) protoreflect.FieldDescriptor {
-	var fields = v.Descriptor().Fields()
-	var fd = getDescriptorByFieldAndName(fields, fieldName)
+	var md = v.Descriptor()
+	var fd = getDescriptorByFieldAndName(md, fieldName)
 	if fd == nil {
 		switch {
-		case v.Descriptor().FullName() == structMessageFullname:
-			fd = fields.ByNumber(structFieldsFieldNumber)
+		case md.FullName() == structMessageFullname:
+			fd = md.Fields().ByNumber(structFieldsFieldNumber)
 		case len(fieldName) > 2 && strings.HasSuffix(fieldName, "[]"):
-			fd = getDescriptorByFieldAndName(fields, strings.TrimSuffix(fieldName, "[]"))
+			fd = getDescriptorByFieldAndName(md, strings.TrimSuffix(fieldName, "[]"))
 		default:
 			// If the type is map, you get the string "map[kratos]", where "map" is a field of proto and "kratos" is a key of map
 			// Use symbol . for separating fields/structs. (eg. structfield.field)
@@ -95,23 +121,33 @@ func getFieldDescriptor(v protoreflect.Message, fieldName string) protoreflect.F
 			if err != nil {
 				break
 			}
-			fd = getDescriptorByFieldAndName(fields, field)
+			fd = getDescriptorByFieldAndName(md, field)
 		}
 	}
 	return fd
 }
 
-func getDescriptorByFieldAndName(fields protoreflect.FieldDescriptors, fieldName string) protoreflect.FieldDescriptor {
-	var fd protoreflect.FieldDescriptor
-	if fd = fields.ByName(protoreflect.Name(fieldName)); fd == nil {
-		fd = fields.ByJSONName(fieldName)
-	}
-	return fd
+func getDescriptorByFieldAndName(md protoreflect.MessageDescriptor, fieldName string) protoreflect.FieldDescriptor {
+	return cachedProtoFields(md)[fieldName]
 }
 
 func populateField(fd protoreflect.FieldDescriptor, v protoreflect.Message, value string) error {
 	if value == "" {
-		return nil
+		switch {
+		case isBoolField(fd):
+			// the bare flag like `?verbose` is true.
+			value = "true"
+		case fd.Message() != nil && isWrapperMessage(fd.Message().FullName()):
+			// the present key allocates the wrapper with the zero value.
+			v.Set(fd, protoreflect.ValueOfMessage(v.NewField(fd).Message()))
+			return nil
+		case fd.Message() == nil && fd.HasPresence():
+			// the present key sets the optional scalar to the zero value.
+			v.Set(fd, fd.Default())
+			return nil
+		default:
+			return nil
+		}
 	}
 	val, err := parseField(fd, value)
 	if err != nil {
@@ -121,6 +157,36 @@ func populateField(fd protoreflect.FieldDescriptor, v protoreflect.Message, valu
 	return nil
 }
 
+// wrapperMessages is the full names of the wrapper types, except google.protobuf.BoolValue.
+var wrapperMessages = map[protoreflect.FullName]struct{}{
+	"google.protobuf.DoubleValue": {},
+	"google.protobuf.FloatValue":  {},
+	"google.protobuf.Int64Value":  {},
+	"google.protobuf.Int32Value":  {},
+	"google.protobuf.UInt64Value": {},
+	"google.protobuf.UInt32Value": {},
+	"google.protobuf.StringValue": {},
+	bytesMessageFullname:          {},
+}
+
+// isWrapperMessage reports whether the message is a wrapper type except google.protobuf.BoolValue.
+func isWrapperMessage(name protoreflect.FullName) bool {
+	_, ok := wrapperMessages[name]
+	return ok
+}
+
+// isBoolField reports whether the field is a bool or google.protobuf.BoolValue.
+func isBoolField(fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() { // nolint: exhaustive
+	case protoreflect.BoolKind:
+		return true
+	case protoreflect.MessageKind:
+		return fd.Message().FullName() == "google.protobuf.BoolValue"
+	default:
+		return false
+	}
+}
+
 func populateRepeatedField(fd protoreflect.FieldDescriptor, list protoreflect.List, values []string) error {
 	for _, value := range values {
 		v, err := parseField(fd, value)
@@ -141,6 +207,12 @@ func populateMapField(fd protoreflect.FieldDescriptor, mp protoreflect.Map, fiel
 	if err != nil {
 		return err
 	}
+	return populateMapValue(fd, mp, keyName, values[vKey:])
+}
+
+// populateMapValue sets the last value to the map with the key, which is coerced to the key type of the map.
+func populateMapValue(fd protoreflect.FieldDescriptor, mp protoreflect.Map, keyName string, values []string) error {
+	vKey := len(values) - 1
 	key, err := parseField(fd.MapKey(), keyName)
 	if err != nil {
 		return fmt.Errorf("parsing map key %q: %w", fd.FullName().Name(), err)
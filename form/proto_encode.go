@@ -63,11 +63,11 @@ func encodeByField(u url.Values, path string, m protoreflect.Message, useProtoNa
 			if v.Map().Len() > 0 {
 				mm, err := encodeMapField(fd, v.Map(), useEnumNumbers)
 				if err != nil {
-					finalErr = err
+					finalErr = fmt.Errorf("form: map field %q: %w", newPath, err)
 					return false
 				}
 				for k, value := range mm {
-					u.Set(fmt.Sprintf("%s[%s]", newPath, k), value)
+					u.Set(newPath+"["+k+"]", value)
 				}
 			}
 		case (fd.Kind() == protoreflect.MessageKind) || (fd.Kind() == protoreflect.GroupKind):
@@ -92,7 +92,7 @@ func encodeByField(u url.Values, path string, m protoreflect.Message, useProtoNa
 		return true
 	})
 
-	return nil
+	return finalErr
 }
 
 func encodeRepeatedField(fieldDescriptor protoreflect.FieldDescriptor, list protoreflect.List, useEnumNumbers bool) ([]string, error) {
@@ -108,21 +108,28 @@ func encodeRepeatedField(fieldDescriptor protoreflect.FieldDescriptor, list prot
 	return values, nil
 }
 
+// encodeMapField encodes the map field to the key value pairs, the key and value are converted
+// with the same rules as the scalar fields, the message value other than the well-known types is not supported.
 func encodeMapField(fieldDescriptor protoreflect.FieldDescriptor, mp protoreflect.Map, useEnumNumbers bool) (map[string]string, error) {
-	m := make(map[string]string)
+	var finalErr error
+	m := make(map[string]string, mp.Len())
 	mp.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
-		key, err := EncodeField(fieldDescriptor.MapValue(), k.Value(), useEnumNumbers)
+		key, err := EncodeField(fieldDescriptor.MapKey(), k.Value(), useEnumNumbers)
 		if err != nil {
+			finalErr = err
 			return false
 		}
 		value, err := EncodeField(fieldDescriptor.MapValue(), v, useEnumNumbers)
 		if err != nil {
+			finalErr = fmt.Errorf("key %q: %w", key, err)
 			return false
 		}
 		m[key] = value
 		return true
 	})
-
+	if finalErr != nil {
+		return nil, finalErr
+	}
 	return m, nil
 }
 
@@ -1,6 +1,7 @@
 package form
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -74,3 +75,78 @@ func TestProto_Codec(t *testing.T) {
 		require.Empty(t, cmp.Diff(got, got, protocmp.Transform()))
 	})
 }
+
+func TestDecodeValues_FlagBool(t *testing.T) {
+	vs, err := url.ParseQuery("b&bool&id")
+	require.NoError(t, err)
+	got := &examplepb.Complex{}
+	require.NoError(t, DecodeValues(got, vs))
+	require.True(t, got.B)
+	require.Equal(t, true, got.GetBool().GetValue())
+	require.Zero(t, got.Id)
+
+	vs, err = url.ParseQuery("b=false&bool=false")
+	require.NoError(t, err)
+	got = &examplepb.Complex{B: true}
+	require.NoError(t, DecodeValues(got, vs))
+	require.False(t, got.B)
+	require.NotNil(t, got.Bool)
+	require.False(t, got.Bool.Value)
+
+	// absent keys leave the fields untouched.
+	got = &examplepb.Complex{B: true}
+	require.NoError(t, DecodeValues(got, url.Values{"id": {"1"}}))
+	require.True(t, got.B)
+	require.Nil(t, got.Bool)
+}
+
+func TestDecodeValues_PresentEmpty(t *testing.T) {
+	vs, err := url.ParseQuery("string=&int64=&double=&bytes=&id=&no_one=")
+	require.NoError(t, err)
+	got := &examplepb.Complex{NoOne: "keep"}
+	require.NoError(t, DecodeValues(got, vs))
+	require.NotNil(t, got.String_)
+	require.Empty(t, got.String_.Value)
+	require.NotNil(t, got.Int64)
+	require.Zero(t, got.Int64.Value)
+	require.NotNil(t, got.Double)
+	require.NotNil(t, got.Bytes)
+	require.Zero(t, got.Id)
+	require.Equal(t, "keep", got.NoOne)
+	// absent keys leave the wrappers nil.
+	require.Nil(t, got.Int32)
+	require.Nil(t, got.Uint64)
+}
+
+func TestEncodeValues_Map(t *testing.T) {
+	msg := &examplepb.ABitOfEverything{
+		MapValue: map[string]examplepb.NumericEnum{
+			"b": examplepb.NumericEnum_ONE,
+			"a": examplepb.NumericEnum_ZERO,
+		},
+		MappedStringValue: map[string]string{"env": "prod", "tier": "web"},
+	}
+
+	vs, err := EncodeValues(msg, true, false)
+	require.NoError(t, err)
+	require.Equal(t, "map_value%5Ba%5D=ZERO&map_value%5Bb%5D=ONE&mapped_string_value%5Benv%5D=prod&mapped_string_value%5Btier%5D=web", vs.Encode())
+
+	vs, err = EncodeValues(msg, false, true)
+	require.NoError(t, err)
+	require.Equal(t, url.Values{
+		"mapValue[a]":             {"0"},
+		"mapValue[b]":             {"1"},
+		"mappedStringValue[env]":  {"prod"},
+		"mappedStringValue[tier]": {"web"},
+	}, vs)
+
+	got := &examplepb.ABitOfEverything{}
+	require.NoError(t, DecodeValues(got, vs))
+	require.Empty(t, cmp.Diff(msg, got, protocmp.Transform()))
+
+	// the nested message value is rejected.
+	_, err = EncodeValues(&examplepb.ABitOfEverything{
+		MappedNestedValue: map[string]*examplepb.ABitOfEverything_Nested{"n": {Name: "foo"}},
+	}, true, true)
+	require.ErrorContains(t, err, `form: map field "mapped_nested_value": key "n": unsupported message type`)
+}
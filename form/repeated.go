@@ -0,0 +1,144 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// commaTagOption is the tag option of the slice and array fields which splits the values on commas,
+// like `json:"tags,comma"` accepts `tags=a,b` as well as `tags=a&tags=b`.
+const commaTagOption = "comma"
+
+// tagNameFunc returns the name of the field without the tag options for form.Decoder,
+// which only strips the last option, like `json:"tags,omitempty,comma"`.
+func tagNameFunc(tagName string) func(field reflect.StructField) string {
+	return func(field reflect.StructField) string {
+		name, _ := parseTag(field.Tag.Get(tagName))
+		return name
+	}
+}
+
+// commaFieldsCache caches the keys of the comma fields, map[structFieldsKey][]string.
+var commaFieldsCache sync.Map
+
+// cachedCommaFields returns the keys of the slice and array fields of the struct type t
+// with the comma tag option, including the nested struct fields, like "tags" or "filter.ids".
+func cachedCommaFields(t reflect.Type, tagName string) []string {
+	key := structFieldsKey{t, tagName}
+	if keys, ok := commaFieldsCache.Load(key); ok {
+		return keys.([]string)
+	}
+	keys := appendCommaFields(nil, t, tagName, "")
+	actual, _ := commaFieldsCache.LoadOrStore(key, keys)
+	return actual.([]string)
+}
+
+func appendCommaFields(keys []string, t reflect.Type, tagName, prefix string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			keys = appendCommaFields(keys, field.Type, tagName, prefix)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		switch k := field.Type.Kind(); {
+		case (k == reflect.Slice || k == reflect.Array) && opts.Contains(commaTagOption):
+			keys = append(keys, prefix+name)
+		case k == reflect.Struct && field.Type != timeType:
+			keys = appendCommaFields(keys, field.Type, tagName, prefix+name+".")
+		}
+	}
+	return keys
+}
+
+// normalizeValues returns the values with the repeated keys like `tags[]` merged into `tags`,
+// and the values of the comma fields split on commas, see appendSplitComma. The conflicts are resolved in order,
+// the values of `tags` come before the ones of `tags[]`, then each value is split,
+// so `tags=a,b&tags=c&tags[]=d` is [a b c d] with the comma tag option, and [a,b c d] without it.
+// vs is not modified, it is returned as is if there is nothing to normalize.
+func normalizeValues(vs url.Values, commaKeys []string) url.Values {
+	if !needsNormalize(vs, commaKeys) {
+		return vs
+	}
+	normalized := make(url.Values, len(vs))
+	for k, values := range vs {
+		if strings.HasSuffix(k, "[]") && len(k) > 2 {
+			continue
+		}
+		normalized[k] = values
+	}
+	for k, values := range vs {
+		if strings.HasSuffix(k, "[]") && len(k) > 2 {
+			key := k[:len(k)-2]
+			normalized[key] = append(append([]string(nil), normalized[key]...), values...)
+		}
+	}
+	for _, key := range commaKeys {
+		values, ok := normalized[key]
+		if !ok {
+			continue
+		}
+		split := make([]string, 0, len(values))
+		for _, value := range values {
+			split = appendSplitComma(split, value)
+		}
+		normalized[key] = split
+	}
+	return normalized
+}
+
+// needsNormalize reports whether vs has the repeated keys like `tags[]`, or the comma fields.
+func needsNormalize(vs url.Values, commaKeys []string) bool {
+	for _, key := range commaKeys {
+		if present(vs, key) {
+			return true
+		}
+	}
+	for k := range vs {
+		if strings.HasSuffix(k, "[]") && len(k) > 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// appendSplitComma appends the elements of the value split by the commas which are not in the quoted string,
+// the elements are trimmed and the empty ones are omitted, like `a, "b,c",` --> [a "b,c"].
+func appendSplitComma(dst []string, value string) []string {
+	var quoted, escaped bool
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			if s := strings.TrimSpace(value[start:i]); s != "" {
+				dst = append(dst, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(value[start:]); s != "" {
+		dst = append(dst, s)
+	}
+	return dst
+}
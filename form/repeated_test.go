@@ -0,0 +1,132 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func TestDecode_Repeated(t *testing.T) {
+	type Filter struct {
+		Ids []int64 `json:"ids,omitempty,comma"`
+	}
+	type Search struct {
+		Tags   []string `json:"tags,comma"`
+		Raw    []string `json:"raw"`
+		Scores [3]int   `json:"scores,comma"`
+		Filter Filter   `json:"filter"`
+	}
+	c := New("json")
+
+	tests := []struct {
+		name string
+		vs   url.Values
+		want Search
+	}{
+		{
+			"repeated keys",
+			url.Values{"tags": {"a", "b"}, "raw": {"x", "y"}, "scores": {"1", "2"}, "filter.ids": {"1", "2"}},
+			Search{Tags: []string{"a", "b"}, Raw: []string{"x", "y"}, Scores: [3]int{1, 2}, Filter: Filter{Ids: []int64{1, 2}}},
+		},
+		{
+			"brackets",
+			url.Values{"tags[]": {"a", "b"}, "raw[]": {"x"}},
+			Search{Tags: []string{"a", "b"}, Raw: []string{"x"}},
+		},
+		{
+			"comma",
+			url.Values{"tags": {"a,b"}, "raw": {"x,y"}, "scores": {"1,2,3"}, "filter.ids": {"1, 2"}},
+			Search{Tags: []string{"a", "b"}, Raw: []string{"x,y"}, Scores: [3]int{1, 2, 3}, Filter: Filter{Ids: []int64{1, 2}}},
+		},
+		{
+			"conflicts",
+			url.Values{"tags": {"a,b", "c"}, "tags[]": {"d,e"}, "raw": {"x,y"}, "raw[]": {"z"}},
+			Search{Tags: []string{"a", "b", "c", "d", "e"}, Raw: []string{"x,y", "z"}},
+		},
+		{
+			"quoted and empty",
+			url.Values{"tags": {`a,"b,c",,`}},
+			Search{Tags: []string{"a", `"b,c"`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Search{}
+			require.NoError(t, c.Decode(tt.vs, &got))
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	// the values are not modified.
+	vs := url.Values{"tags": {"a,b"}, "tags[]": {"c"}}
+	require.NoError(t, c.Decode(vs, &Search{}))
+	require.Equal(t, url.Values{"tags": {"a,b"}, "tags[]": {"c"}}, vs)
+}
+
+func TestDecode_ProtoRepeated(t *testing.T) {
+	c := New("json")
+
+	got := &examplepb.ABitOfEverything{}
+	require.NoError(t, c.Decode(url.Values{
+		"repeated_string_value":    {"a", "b"},
+		"repeated_enum_value[]":    {"ZERO", "1"},
+		"repeated_enum_annotation": {"ONE", "0"},
+	}, got))
+	require.Equal(t, []string{"a", "b"}, got.RepeatedStringValue)
+	require.Equal(t, []examplepb.NumericEnum{examplepb.NumericEnum_ZERO, examplepb.NumericEnum_ONE}, got.RepeatedEnumValue)
+	require.Equal(t, []examplepb.NumericEnum{examplepb.NumericEnum_ONE, examplepb.NumericEnum_ZERO}, got.RepeatedEnumAnnotation)
+
+	// the repeated wrappers.
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("repeated_test.proto"),
+		Package:    proto.String("form.test"),
+		Dependency: []string{"google/protobuf/wrappers.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Wrappers"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:     proto.String("ids"),
+					JsonName: proto.String("ids"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+					TypeName: proto.String(".google.protobuf.Int64Value"),
+				},
+				{
+					Name:     proto.String("names"),
+					JsonName: proto.String("names"),
+					Number:   proto.Int32(2),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+					TypeName: proto.String(".google.protobuf.StringValue"),
+				},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	md := fd.Messages().ByName("Wrappers")
+	msg := dynamicpb.NewMessage(md)
+	require.NoError(t, c.Decode(url.Values{"ids": {"1", "2"}, "names[]": {"a", "b"}}, msg))
+	wrapperValues := func(name protoreflect.Name) []any {
+		list := msg.Get(md.Fields().ByName(name)).List()
+		values := make([]any, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			m := list.Get(i).Message()
+			values = append(values, m.Get(m.Descriptor().Fields().ByName("value")).Interface())
+		}
+		return values
+	}
+	require.Equal(t, []any{int64(1), int64(2)}, wrapperValues("ids"))
+	require.Equal(t, []any{"a", "b"}, wrapperValues("names"))
+}
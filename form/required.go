@@ -0,0 +1,215 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// requiredTagOption is the tag option of the field which must be present in the values,
+// like `json:"user_id,required"`, the explicit empty value like `user_id=` is present.
+const requiredTagOption = "required"
+
+// MissingFieldsError is returned by Decode when the required fields are absent in the values,
+// the fields are still decoded, it lists all the missing keys in order, like ["user_id" "filter.page"].
+type MissingFieldsError struct {
+	// Fields is the keys of the missing fields.
+	Fields []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("form: missing required fields %q", e.Fields)
+}
+
+// missingFieldsError returns a *MissingFieldsError of the missing fields, or nil if there is none.
+func missingFieldsError(missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return &MissingFieldsError{Fields: missing}
+}
+
+// WithDecoderOptions returns a copy of the Codec with the required fields of the options,
+// the other options are ignored, see codec.ConfigurableDecoder.
+func (c *Codec) WithDecoderOptions(opts codec.DecoderOptions) codec.Marshaler {
+	return c.withRequiredFields(opts.RequiredFields)
+}
+
+func (c *MultipartCodec) WithDecoderOptions(opts codec.DecoderOptions) codec.Marshaler {
+	if len(opts.RequiredFields) == 0 {
+		return c
+	}
+	cc := *c
+	cc.Codec = c.Codec.withRequiredFields(opts.RequiredFields)
+	return &cc
+}
+
+func (c *QueryCodec) WithDecoderOptions(opts codec.DecoderOptions) codec.Marshaler {
+	if len(opts.RequiredFields) == 0 {
+		return c
+	}
+	return &QueryCodec{Codec: c.Codec.withRequiredFields(opts.RequiredFields)}
+}
+
+func (c *UriCodec) WithDecoderOptions(opts codec.DecoderOptions) codec.Marshaler {
+	if len(opts.RequiredFields) == 0 {
+		return c
+	}
+	return &UriCodec{Codec: c.Codec.withRequiredFields(opts.RequiredFields)}
+}
+
+// withRequiredFields returns a copy of the Codec with the paths appended to the required fields.
+func (c *Codec) withRequiredFields(paths []string) *Codec {
+	if len(paths) == 0 {
+		return c
+	}
+	cc := *c
+	cc.requiredFields = append(append([]string(nil), c.requiredFields...), paths...)
+	return &cc
+}
+
+// requiredFieldsCache caches the keys of the required fields, map[structFieldsKey][]string.
+var requiredFieldsCache sync.Map
+
+// cachedRequiredFields returns the keys of the fields of the struct type t with the required tag option,
+// including the nested struct fields, like "user_id" or "filter.page".
+func cachedRequiredFields(t reflect.Type, tagName string) []string {
+	key := structFieldsKey{t, tagName}
+	if keys, ok := requiredFieldsCache.Load(key); ok {
+		return keys.([]string)
+	}
+	keys := appendRequiredFields(nil, t, tagName, "")
+	actual, _ := requiredFieldsCache.LoadOrStore(key, keys)
+	return actual.([]string)
+}
+
+func appendRequiredFields(keys []string, t reflect.Type, tagName, prefix string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			keys = appendRequiredFields(keys, field.Type, tagName, prefix)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if opts.Contains(requiredTagOption) {
+			keys = append(keys, prefix+name)
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			keys = appendRequiredFields(keys, field.Type, tagName, prefix+name+".")
+		}
+	}
+	return keys
+}
+
+// appendMissingFields appends the keys which are absent in vs to dst, the duplicated keys are skipped.
+func appendMissingFields(dst []string, vs url.Values, keys []string) []string {
+	for _, key := range keys {
+		if !presentField(vs, key) && !slices.Contains(dst, key) {
+			dst = append(dst, key)
+		}
+	}
+	return dst
+}
+
+// presentField reports whether the key or its nested keys are present in vs,
+// like `filter`, `filter[0]` or `filter.page`.
+func presentField(vs url.Values, key string) bool {
+	if present(vs, key) {
+		return true
+	}
+	for k := range vs {
+		if len(k) > len(key) && k[len(key)] == '.' && strings.HasPrefix(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingProtoFields returns the paths which are absent in vs, the path and the keys of vs
+// are matched by the proto names of the fields, so `userId` is present for the path "user_id".
+func missingProtoFields(md protoreflect.MessageDescriptor, vs url.Values, paths []string) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+	keys := make(url.Values, len(vs))
+	for k := range vs {
+		keys[protoPath(md, k)] = nil
+	}
+	var missing []string
+	for _, path := range paths {
+		if !presentField(keys, protoPath(md, path)) && !slices.Contains(missing, path) {
+			missing = append(missing, path)
+		}
+	}
+	return missing
+}
+
+// protoPath returns the key with the proto names of the fields, like "filter.pageSize[0]" --> "filter.page_size",
+// the unknown field and the rest of the key after the non-message field are kept, like the map key.
+func protoPath(md protoreflect.MessageDescriptor, key string) string {
+	var buf [8]string
+	var b strings.Builder
+	for i, name := range splitFieldPath(buf[:0], key) {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		var fd protoreflect.FieldDescriptor
+		if md != nil {
+			if idx := strings.IndexByte(name, '['); idx >= 0 {
+				name = name[:idx]
+			}
+			fd = md.Fields().ByName(protoreflect.Name(name))
+			if fd == nil {
+				fd = md.Fields().ByJSONName(name)
+			}
+		}
+		if fd == nil {
+			b.WriteString(name)
+			md = nil
+			continue
+		}
+		b.WriteString(string(fd.Name()))
+		md = nil
+		if fd.Message() != nil && !fd.IsMap() && !fd.IsList() {
+			md = fd.Message()
+		}
+	}
+	return b.String()
+}
+
+// filterFiles returns the error without the missing fields which are the uploaded files of form.File.
+func filterFiles(err error, files map[string][]*multipart.FileHeader) error {
+	var e *MissingFieldsError
+	if len(files) == 0 || !errors.As(err, &e) {
+		return err
+	}
+	missing := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		if len(files[field]) == 0 {
+			missing = append(missing, field)
+		}
+	}
+	return missingFieldsError(missing)
+}
@@ -0,0 +1,130 @@
+package form
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/codec"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func TestDecode_Required(t *testing.T) {
+	type Filter struct {
+		Sort string `json:"sort,required"`
+	}
+	type Search struct {
+		UserId int64  `json:"user_id,required"`
+		Page   int    `json:"page,omitempty,required"`
+		Name   string `json:"name"`
+		Filter Filter `json:"filter"`
+	}
+	c := New("json")
+
+	tests := []struct {
+		name    string
+		vs      url.Values
+		want    Search
+		missing []string
+	}{
+		{
+			"present",
+			url.Values{"user_id": {"1"}, "page": {"2"}, "filter.sort": {"name"}},
+			Search{UserId: 1, Page: 2, Filter: Filter{Sort: "name"}},
+			nil,
+		},
+		{
+			"explicit zero",
+			url.Values{"user_id": {""}, "page": {"0"}, "filter.sort": {""}},
+			Search{},
+			nil,
+		},
+		{
+			"missing",
+			url.Values{"page": {"2"}, "name": {"foo"}},
+			Search{Page: 2, Name: "foo"},
+			[]string{"user_id", "filter.sort"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Search
+			err := c.Decode(tt.vs, &got)
+			// the present fields are decoded anyway.
+			require.Equal(t, tt.want, got)
+			if tt.missing == nil {
+				require.NoError(t, err)
+				return
+			}
+			var e *MissingFieldsError
+			require.True(t, errors.As(err, &e))
+			require.Equal(t, tt.missing, e.Fields)
+		})
+	}
+
+	// the required fields of the call.
+	required := c.WithDecoderOptions(codec.DecoderOptions{RequiredFields: []string{"name", "user_id"}}).(*Codec)
+	err := required.Decode(url.Values{"page": {"2"}, "filter.sort": {"name"}}, &Search{})
+	require.EqualError(t, err, `form: missing required fields ["user_id" "name"]`)
+	require.NoError(t, c.Decode(url.Values{"user_id": {"1"}, "page": {"2"}, "filter.sort": {"name"}}, &Search{}))
+	require.Same(t, c, c.WithDecoderOptions(codec.DecoderOptions{}))
+}
+
+func TestDecode_RequiredProto(t *testing.T) {
+	c := New("json").WithDecoderOptions(codec.DecoderOptions{
+		RequiredFields: []string{"string_value", "single_nested.name", "mapped_string_value"},
+	}).(*Codec)
+
+	msg := &examplepb.ABitOfEverything{}
+	vs := url.Values{"stringValue": {"foo"}, "single_nested.name": {"bar"}, "mappedStringValue[env]": {"prod"}}
+	require.NoError(t, c.Decode(vs, msg))
+	require.Equal(t, "foo", msg.StringValue)
+
+	msg = &examplepb.ABitOfEverything{}
+	err := c.Decode(url.Values{"singleNested.amount": {"1"}}, msg)
+	var e *MissingFieldsError
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, []string{"string_value", "single_nested.name", "mapped_string_value"}, e.Fields)
+	require.Equal(t, uint32(1), msg.SingleNested.Amount)
+
+	// the proto message has no required fields without the options.
+	require.NoError(t, New("json").Decode(url.Values{}, &examplepb.ABitOfEverything{}))
+}
+
+func TestDecodeMultipart_Required(t *testing.T) {
+	type Upload struct {
+		Id   string                `json:"id,required"`
+		File *multipart.FileHeader `json:"file,required"`
+	}
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	fw, err := mw.CreateFormFile("file", "file.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	form, err := multipart.NewReader(buf, mw.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	defer form.RemoveAll() //nolint: errcheck
+
+	c := &MultipartCodec{Codec: New("json")}
+	got := &Upload{}
+	err = c.DecodeMultipart(form, got)
+	require.EqualError(t, err, `form: missing required fields ["id"]`)
+	require.NotNil(t, got.File)
+
+	form.Value = map[string][]string{"id": {"foo"}}
+	require.NoError(t, c.DecodeMultipart(form, &Upload{}))
+
+	configured, ok := c.WithDecoderOptions(codec.DecoderOptions{RequiredFields: []string{"name"}}).(*MultipartCodec)
+	require.True(t, ok)
+	require.EqualError(t, configured.DecodeMultipart(form, &Upload{}), `form: missing required fields ["name"]`)
+	_, ok = (&QueryCodec{Codec: New("json")}).WithDecoderOptions(codec.DecoderOptions{RequiredFields: []string{"name"}}).(*QueryCodec)
+	require.True(t, ok)
+	_, ok = (&UriCodec{Codec: New("json")}).WithDecoderOptions(codec.DecoderOptions{RequiredFields: []string{"name"}}).(*UriCodec)
+	require.True(t, ok)
+}
@@ -0,0 +1,347 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding/internal/lru"
+)
+
+// templateCacheSize is the size of the parsed path template cache used by EncodeUrl.
+const templateCacheSize = 512
+
+// templateCache caches the parsed path templates of EncodeUrl, keyed by the template.
+var templateCache = lru.New[string, *pathTemplate](templateCacheSize)
+
+// PathTemplate is a compiled path template like http://helloworld.dev/{name}/sub/{sub.name}:verb,
+// it is safe for concurrent use.
+// The variable {name} or {name=*} matches a single path segment, and {name=**} matches
+// multiple path segments.
+type PathTemplate struct {
+	codec *Codec
+	tpl   *pathTemplate
+}
+
+// pathTemplate is the parsed path template, which is independent of the Codec.
+type pathTemplate struct {
+	template string
+	segments []segment
+	verb     string
+}
+
+// segment is a literal, or a variable if fieldPath is not empty.
+type segment struct {
+	literal   string   // the literal, or the raw variable like {sub.name}.
+	key       string   // the variable key like sub.name.
+	fieldPath []string // the variable field path like [sub name].
+	greedy    bool     // the variable matches multiple path segments, like {name=**}.
+}
+
+// PathMismatchError is returned when the path does not match the path template.
+type PathMismatchError struct {
+	Template string
+	Path     string
+}
+
+func (e *PathMismatchError) Error() string {
+	return fmt.Sprintf("form: path %q does not match template %q", e.Path, e.Template)
+}
+
+// CompileTemplate parses the path template like http://helloworld.dev/{name}/sub/{sub.name}:verb,
+// the variable is a field path separated by "." in braces.
+// It returns an error if the braces are unbalanced or the variable is empty or invalid.
+func (c *Codec) CompileTemplate(tpl string) (*PathTemplate, error) {
+	t, err := parsePathTemplate(tpl, true)
+	if err != nil {
+		return nil, err
+	}
+	return &PathTemplate{codec: c, tpl: t}, nil
+}
+
+// Template returns the path template.
+func (t *PathTemplate) Template() string { return t.tpl.template }
+
+// Verb returns the verb suffix of the path template, like "verb" of /v1/{name}:verb.
+func (t *PathTemplate) Verb() string { return t.tpl.verb }
+
+// Variables returns the variable keys of the path template, like [name sub.name].
+func (t *PathTemplate) Variables() []string {
+	var keys []string
+	for _, seg := range t.tpl.segments {
+		if len(seg.fieldPath) > 0 {
+			keys = append(keys, seg.key)
+		}
+	}
+	return keys
+}
+
+// Execute encodes msg to url path with the template, the same as EncodeUrl does.
+// It returns an error if any variable can not be resolved, or the query can not be encoded.
+func (t *PathTemplate) Execute(msg any, needQuery bool) (string, error) {
+	path, err := t.tpl.execute(t.codec, msg, needQuery)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Match matches the path against the template, and returns the percent-decoded variables
+// keyed by the field path like sub.name, the empty variables are omitted.
+// It returns a *PathMismatchError if the path does not match the template.
+func (t *PathTemplate) Match(path string) (url.Values, error) {
+	return t.tpl.match(path)
+}
+
+// Decode decodes the variables of the path into v, the same as DecodeUrl does.
+func (t *PathTemplate) Decode(path string, v any) error {
+	vs, err := t.tpl.match(path)
+	if err != nil {
+		return err
+	}
+	return t.codec.Decode(vs, v)
+}
+
+// lookupPathTemplate returns the parsed path template from cache, or parses and caches it.
+func lookupPathTemplate(tpl string) *pathTemplate {
+	if t, ok := templateCache.Get(tpl); ok {
+		return t
+	}
+	t, _ := parsePathTemplate(tpl, false)
+	templateCache.Add(tpl, t)
+	return t
+}
+
+// parsePathTemplate parses the path template.
+// if strict is false, it never fails, the invalid variables are treated as literals.
+func parsePathTemplate(tpl string, strict bool) (*pathTemplate, error) {
+	t := &pathTemplate{template: tpl}
+
+	var literal strings.Builder
+	appendLiteral := func() {
+		if literal.Len() > 0 {
+			t.segments = append(t.segments, segment{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(tpl); i++ {
+		switch tpl[i] {
+		case '{':
+			end := strings.IndexByte(tpl[i+1:], '}')
+			if end < 0 {
+				if strict {
+					return nil, fmt.Errorf("form: path template %q: unbalanced braces at %d", tpl, i)
+				}
+				literal.WriteByte(tpl[i])
+				continue
+			}
+			key, pattern, hasPattern := strings.Cut(tpl[i+1:i+1+end], "=")
+			err := validateVariable(key)
+			if err == nil && hasPattern && pattern != "*" && pattern != "**" {
+				err = fmt.Errorf("invalid variable pattern %q", pattern)
+			}
+			if err != nil {
+				if strict {
+					return nil, fmt.Errorf("form: path template %q: %w at %d", tpl, err, i)
+				}
+				literal.WriteByte(tpl[i])
+				continue
+			}
+			appendLiteral()
+			t.segments = append(t.segments, segment{
+				literal:   tpl[i : i+end+2],
+				key:       key,
+				fieldPath: strings.Split(key, "."),
+				greedy:    pattern == "**",
+			})
+			i += end + 1
+		case '}':
+			if strict {
+				return nil, fmt.Errorf("form: path template %q: unbalanced braces at %d", tpl, i)
+			}
+			literal.WriteByte(tpl[i])
+		default:
+			literal.WriteByte(tpl[i])
+		}
+	}
+	appendLiteral()
+	t.verb = parseVerb(tpl)
+	return t, nil
+}
+
+// validateVariable checks the variable is a field path like sub.name.
+func validateVariable(key string) error {
+	if key == "" {
+		return errors.New("empty variable")
+	}
+	for _, name := range strings.Split(key, ".") {
+		if name == "" {
+			return fmt.Errorf("invalid variable %q", key)
+		}
+		for _, c := range name {
+			if !(c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')) {
+				return fmt.Errorf("invalid variable %q", key)
+			}
+		}
+	}
+	return nil
+}
+
+// parseVerb returns the verb suffix in the last segment of the path,
+// like "verb" of /v1/{name}:verb.
+func parseVerb(tpl string) string {
+	path := tpl
+	if idx := strings.Index(path, "://"); idx >= 0 {
+		// skip the scheme and host, like http://localhost:8080
+		path = path[idx+3:]
+		slash := strings.IndexByte(path, '/')
+		if slash < 0 {
+			return ""
+		}
+		path = path[slash:]
+	}
+	last := path[strings.LastIndexByte(path, '/')+1:]
+	if idx := strings.LastIndexByte(last, '}'); idx >= 0 {
+		last = last[idx+1:]
+	}
+	if idx := strings.LastIndexByte(last, ':'); idx >= 0 {
+		return last[idx+1:]
+	}
+	return ""
+}
+
+// match matches the path against the template, the query and fragment of the path are ignored.
+// If the template has the scheme and host but the path has not, only the path of the template is matched.
+func (t *pathTemplate) match(path string) (url.Values, error) {
+	if idx := strings.IndexAny(path, "?#"); idx >= 0 {
+		path = path[:idx]
+	}
+	tpl := t
+	if hostPath := stripSchemeHost(t.template); len(hostPath) != len(t.template) && !strings.Contains(path, "://") {
+		tpl = lookupPathTemplate(hostPath)
+	}
+	values := make([]string, len(tpl.segments))
+	if !matchSegments(tpl.segments, path, values) {
+		return nil, &PathMismatchError{Template: t.template, Path: path}
+	}
+	vs := make(url.Values)
+	for i, seg := range tpl.segments {
+		if len(seg.fieldPath) == 0 || values[i] == "" {
+			continue
+		}
+		value, err := url.PathUnescape(values[i])
+		if err != nil {
+			return nil, fmt.Errorf("form: path template %q: variable %q: %w", t.template, seg.key, err)
+		}
+		vs.Add(seg.key, value)
+	}
+	return vs, nil
+}
+
+// matchSegments reports whether the path matches the segments, the raw value of the variable
+// is stored in values at the same index. The variable matches as long as possible, and backtracks
+// if the rest does not match, like the verb suffix of /v1/{name}:verb.
+func matchSegments(segments []segment, path string, values []string) bool {
+	if len(segments) == 0 {
+		return path == ""
+	}
+	seg := segments[0]
+	if len(seg.fieldPath) == 0 {
+		return strings.HasPrefix(path, seg.literal) &&
+			matchSegments(segments[1:], path[len(seg.literal):], values[1:])
+	}
+	end := len(path)
+	if !seg.greedy {
+		if idx := strings.IndexByte(path, '/'); idx >= 0 {
+			end = idx
+		}
+	}
+	for n := end; n >= 0; n-- {
+		if matchSegments(segments[1:], path[n:], values[1:]) {
+			values[0] = path[:n]
+			return true
+		}
+	}
+	return false
+}
+
+// stripSchemeHost returns the path of the template, like /v1/{name} of http://localhost:8080/v1/{name}.
+func stripSchemeHost(tpl string) string {
+	idx := strings.Index(tpl, "://")
+	if idx < 0 {
+		return tpl
+	}
+	rest := tpl[idx+3:]
+	slash := strings.IndexByte(rest, '/')
+	if slash < 0 {
+		return "/"
+	}
+	return rest[slash:]
+}
+
+// execute encodes v to url path, the variables which can not be resolved are kept as is,
+// it returns the path with the first error.
+func (t *pathTemplate) execute(c *Codec, v any, needQuery bool) (string, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return t.template, nil
+	}
+
+	var firstErr error
+	var b strings.Builder
+	var pathParams map[string]struct{}
+
+	mg, isProto := v.(proto.Message)
+	b.Grow(len(t.template))
+	for _, seg := range t.segments {
+		if len(seg.fieldPath) == 0 {
+			b.WriteString(seg.literal)
+			continue
+		}
+		var value string
+		var err error
+		if isProto {
+			value, err = getValueFromProtoWithField(mg.ProtoReflect(), seg.fieldPath)
+		} else {
+			value, err = getValueWithField(v, seg.fieldPath, c.TagName)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			b.WriteString(seg.literal)
+			continue
+		}
+		if needQuery {
+			if pathParams == nil {
+				pathParams = make(map[string]struct{})
+			}
+			pathParams[seg.key] = struct{}{}
+		}
+		b.WriteString(value)
+	}
+	path := b.String()
+	if needQuery {
+		queryParams, err := c.Encode(v)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else if len(queryParams) > 0 {
+			for key := range pathParams {
+				delete(queryParams, key)
+			}
+			if query := queryParams.Encode(); query != "" {
+				path += "?" + query
+			}
+		}
+	} else if isProto {
+		if query := c.EncodeFieldMask(mg.ProtoReflect()); query != "" {
+			path += "?" + query
+		}
+	}
+	return path, firstErr
+}
@@ -0,0 +1,113 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func Test_CompileTemplate(t *testing.T) {
+	codec := &UriCodec{Codec: New("json").DisableUseProtoNames()}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, tpl := range []string{
+			"/v1/{name",
+			"/v1/name}",
+			"/v1/{}",
+			"/v1/{sub.}",
+			"/v1/{.name}",
+			"/v1/{na-me}",
+			"/v1/{name=}",
+			"/v1/{name=***}",
+		} {
+			_, err := codec.CompileTemplate(tpl)
+			require.Error(t, err, tpl)
+		}
+	})
+	t.Run("verb", func(t *testing.T) {
+		tests := []struct {
+			tpl  string
+			verb string
+		}{
+			{"/v1/{name}:publish", "publish"},
+			{"/v1/{name}", ""},
+			{"http://localhost:8080/v1/{name}:cancel", "cancel"},
+			{"http://localhost:8080", ""},
+			{"http://localhost:8080/v1/hello", ""},
+		}
+		for _, tt := range tests {
+			tpl, err := codec.CompileTemplate(tt.tpl)
+			require.NoError(t, err)
+			require.Equal(t, tt.tpl, tpl.Template())
+			require.Equal(t, tt.verb, tpl.Verb(), tt.tpl)
+		}
+	})
+	t.Run("match", func(t *testing.T) {
+		tpl, err := codec.CompileTemplate("/v1/{name=**}/sub/{sub.naming}:get")
+		require.NoError(t, err)
+		require.Equal(t, []string{"name", "sub.naming"}, tpl.Variables())
+		require.Equal(t, "get", tpl.Verb())
+
+		vs, err := tpl.Match("/v1/a/b/sub/go:get")
+		require.NoError(t, err)
+		require.Equal(t, url.Values{"name": {"a/b"}, "sub.naming": {"go"}}, vs)
+
+		got := &examplepb.HelloRequest{}
+		require.NoError(t, tpl.Decode("/v1/a/b/sub/go:get", got))
+		require.Equal(t, "a/b", got.Name)
+		require.Equal(t, "go", got.GetSub().GetName())
+
+		_, err = tpl.Match("/v2/a/b/sub/go:get")
+		require.ErrorAs(t, err, new(*PathMismatchError))
+		require.ErrorAs(t, tpl.Decode("/v2", got), new(*PathMismatchError))
+	})
+	t.Run("execute", func(t *testing.T) {
+		tpl, err := codec.CompileTemplate("http://hello.dev/v1/{name}/sub/{sub.naming}:get")
+		require.NoError(t, err)
+		require.Equal(t, []string{"name", "sub.naming"}, tpl.Variables())
+
+		got, err := tpl.Execute(&examplepb.HelloRequest{Name: "test", Sub: &examplepb.Sub{Name: "go"}}, false)
+		require.NoError(t, err)
+		require.Equal(t, "http://hello.dev/v1/test/sub/go:get", got)
+
+		got, err = tpl.Execute(&NoProtoHello{Name: "test", Id: []int64{1, 2}}, true)
+		require.Error(t, err)
+		require.Empty(t, got)
+
+		tpl, err = codec.CompileTemplate("/v1/{name}")
+		require.NoError(t, err)
+		got, err = tpl.Execute(&NoProtoHello{Name: "test", Id: []int64{1, 2}}, true)
+		require.NoError(t, err)
+		require.Equal(t, "/v1/test?id=1&id=2", got)
+
+		got, err = tpl.Execute(nil, true)
+		require.NoError(t, err)
+		require.Equal(t, "/v1/{name}", got)
+	})
+}
+
+func Test_EncodeUrl_InvalidTemplate(t *testing.T) {
+	codec := New("json")
+	v := &NoProtoHello{Name: "test"}
+	require.Equal(t, "/v1/{name/test", codec.EncodeUrl("/v1/{name/{name}", v, false))
+	require.Equal(t, "/v1/{}/test}", codec.EncodeUrl("/v1/{}/{name}}", v, false))
+	// cached.
+	require.Equal(t, "/v1/{}/test}", codec.EncodeUrl("/v1/{}/{name}}", v, false))
+}
+
+func Benchmark_PathTemplate_Execute(b *testing.B) {
+	c := New("json")
+	tpl, err := c.CompileTemplate("http://helloworld.dev/{name}/sub/{sub.name}")
+	if err != nil {
+		b.Fatal(err)
+	}
+	v := &NoProtoHello{Name: "foo", Sub: &NoProtoSub{Name: "bar"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tpl.Execute(v, false)
+	}
+}
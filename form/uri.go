@@ -3,75 +3,40 @@ package form
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
-	"regexp"
-	"strings"
 
 	"github.com/spf13/cast"
-	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-var reg = regexp.MustCompile(`{[\\.\w]+}`)
-
 // EncodeUrl encode msg to url path.
 // pathTemplate is a template of url path like http://helloworld.dev/{name}/sub/{sub.name},
+// the parsed template is cached, the variables which can not be resolved are kept as is.
+// Use CompileTemplate to report the invalid template and the unresolved variables.
 func (c *Codec) EncodeUrl(pathTemplate string, v any, needQuery bool) string {
-	var repl func(in string) string
+	path, _ := lookupPathTemplate(pathTemplate).execute(c, v, needQuery)
+	return path
+}
 
-	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
-		return pathTemplate
+// DecodeUrl decodes the variables of the url path into v, it is the inverse of EncodeUrl.
+// pathTemplate is a template of url path like http://helloworld.dev/{name}/sub/{sub.name},
+// the variable {name=**} matches multiple path segments. The variables are percent-decoded,
+// and decoded by Decode, the empty variables are omitted.
+// It returns a *PathMismatchError if the path does not match the template.
+func (c *Codec) DecodeUrl(pathTemplate, path string, v any) error {
+	vs, err := MatchPath(pathTemplate, path)
+	if err != nil {
+		return err
 	}
+	return c.Decode(vs, v)
+}
 
-	pathParams := make(map[string]struct{})
-	if mg, ok := v.(proto.Message); ok {
-		repl = func(in string) string {
-			// in: {xxx}
-			if len(in) < 4 { //nolint:gomnd
-				return in
-			}
-			key := in[1 : len(in)-1]
-			vars := strings.Split(key, ".")
-			if value, err := getValueFromProtoWithField(mg.ProtoReflect(), vars); err == nil {
-				pathParams[key] = struct{}{}
-				return value
-			}
-			return in
-		}
-	} else {
-		repl = func(in string) string {
-			// in: {xxx}
-			if len(in) < 4 { //nolint:gomnd
-				return in
-			}
-			key := in[1 : len(in)-1]
-			vars := strings.Split(key, ".")
-			if value, err := getValueWithField(v, vars, c.TagName); err == nil {
-				pathParams[key] = struct{}{}
-				return value
-			}
-			return in
-		}
-	}
-	path := reg.ReplaceAllStringFunc(pathTemplate, repl)
-	if needQuery {
-		queryParams, err := c.Encode(v)
-		if err == nil && len(queryParams) > 0 {
-			for key := range pathParams {
-				delete(queryParams, key)
-			}
-			if query := queryParams.Encode(); query != "" {
-				path += "?" + query
-			}
-		}
-	} else {
-		if vv, ok := v.(proto.Message); ok {
-			if query := c.EncodeFieldMask(vv.ProtoReflect()); query != "" {
-				path += "?" + query
-			}
-		}
-	}
-	return path
+// MatchPath matches the url path against the path template, and returns the percent-decoded
+// variables keyed by the field path like sub.name, the empty variables are omitted.
+// It returns a *PathMismatchError if the path does not match the template.
+func MatchPath(pathTemplate, path string) (url.Values, error) {
+	return lookupPathTemplate(pathTemplate).match(path)
 }
 
 // EncodeFieldMask return field mask name=paths
@@ -83,12 +48,8 @@ func getValueFromProtoWithField(v protoreflect.Message, fieldPath []string) (str
 	var fd protoreflect.FieldDescriptor
 
 	for i, fieldName := range fieldPath {
-		fields := v.Descriptor().Fields()
-		if fd = fields.ByJSONName(fieldName); fd == nil {
-			fd = fields.ByName(protoreflect.Name(fieldName))
-			if fd == nil {
-				return "", fmt.Errorf("form: field path not found: %q", fieldName)
-			}
+		if fd = cachedProtoFields(v.Descriptor())[fieldName]; fd == nil {
+			return "", fmt.Errorf("form: field path not found: %q", fieldName)
 		}
 		if i == len(fieldPath)-1 {
 			break
@@ -110,15 +71,12 @@ func getValueWithField(s any, fieldPath []string, tagName string) (string, error
 	if v.Kind() != reflect.Struct {
 		return "", errors.New("form: not struct")
 	}
-	for i, fieldName := range fieldPath {
-		fields := findField(v, fieldName, tagName)
-		if !fields.IsValid() {
+	for _, fieldName := range fieldPath {
+		field := findField(v, fieldName, tagName)
+		if !field.IsValid() {
 			return "", fmt.Errorf("form: field path not found: %q", fieldName)
 		}
-		v = fields
-		if i == len(fieldPath)-1 {
-			break
-		}
+		v = field
 	}
 	for v.Kind() == reflect.Ptr {
 		v = v.Elem()
@@ -137,27 +95,9 @@ func findField(v reflect.Value, searchName, tagName string) reflect.Value {
 	if v.Kind() != reflect.Struct {
 		return reflect.Value{}
 	}
-
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Type().Field(i)
-		fv := v.Field(i)
-		// we can't access the value of unexported fields
-		if !fv.CanInterface() || field.PkgPath != "" {
-			continue
-		}
-		// don't check if it's omitted
-		tag := field.Tag.Get(tagName)
-		if tag == "-" {
-			continue
-		}
-		name := field.Name
-		tagNamed, _ := parseTag(tag)
-		if tagNamed != "" {
-			name = tagNamed
-		}
-		if name == searchName {
-			return v.FieldByName(field.Name)
-		}
+	i, ok := cachedStructFields(v.Type(), tagName)[searchName]
+	if !ok {
+		return reflect.Value{}
 	}
-	return reflect.Value{}
+	return v.Field(i)
 }
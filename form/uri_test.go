@@ -1,9 +1,12 @@
 package form
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/thinkgos/encoding/testdata/examplepb"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
@@ -111,6 +114,17 @@ func TestEncodeUrl(t *testing.T) {
 			},
 			want: "http://hello.dev/golang?updateMask=name1,name2",
 		},
+		{
+			"proto: multi-segment wildcard",
+			args{
+				"http://hello.dev/{name=**}/sub",
+				&examplepb.HelloRequest{
+					Name: "shelves/1",
+				},
+				false,
+			},
+			`http://hello.dev/shelves/1/sub`,
+		},
 		{
 			"no proto: no any param",
 			args{
@@ -190,3 +204,125 @@ func TestEncodeUrl(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeUrl(t *testing.T) {
+	type args struct {
+		pathTemplate string
+		path         string
+		msg          any
+	}
+	codec := New("json").DisableUseProtoNames()
+	tests := []struct {
+		name string
+		args args
+		want any
+	}{
+		{
+			"proto: no any param",
+			args{"http://hello.dev/sub", "http://hello.dev/sub", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{},
+		},
+		{
+			"proto: param",
+			args{"http://hello.dev/{name}/sub/{sub.name}", "http://hello.dev/test/sub/2233!!!", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "test", Sub: &examplepb.Sub{Name: "2233!!!"}},
+		},
+		{
+			"proto: param with proto [json_name=naming]",
+			args{"http://hello.dev/{name}/sub/{sub.naming}", "http://hello.dev/test/sub/5566!!!", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "test", Sub: &examplepb.Sub{Name: "5566!!!"}},
+		},
+		{
+			"proto: param with empty",
+			args{"http://hello.dev/{name}/sub/{sub.name}", "http://hello.dev/test/sub/", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "test"},
+		},
+		{
+			"proto: param with query",
+			args{"http://hello.dev/{name}/sub", "http://hello.dev/go/sub?sub.naming=golang", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "go"},
+		},
+		{
+			"proto: path without host",
+			args{"http://hello.dev/{name}/sub/{sub.name}", "/test/sub/go", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "test", Sub: &examplepb.Sub{Name: "go"}},
+		},
+		{
+			"proto: percent-decoded param",
+			args{"/v1/{name}/sub/{sub.name}", "/v1/a%2Fb/sub/hello%20world", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "a/b", Sub: &examplepb.Sub{Name: "hello world"}},
+		},
+		{
+			"proto: multi-segment wildcard",
+			args{"/v1/{name=**}/sub/{sub.name=*}:get", "/v1/shelves/1/books/2/sub/go:get", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "shelves/1/books/2", Sub: &examplepb.Sub{Name: "go"}},
+		},
+		{
+			"proto: param with verb",
+			args{"/v1/{name}:publish", "/v1/test:publish", &examplepb.HelloRequest{}},
+			&examplepb.HelloRequest{Name: "test"},
+		},
+		{
+			"no proto: no any param",
+			args{"http://hello.dev/sub", "http://hello.dev/sub", &NoProtoHello{}},
+			&NoProtoHello{},
+		},
+		{
+			"no proto: param",
+			args{"http://hello.dev/{name}/sub/{sub.name}", "http://hello.dev/test/sub/2233!!!", &NoProtoHello{}},
+			&NoProtoHello{Name: "test", Sub: &NoProtoSub{Name: "2233!!!"}},
+		},
+		{
+			"no proto: param with empty",
+			args{"http://hello.dev/{name}/sub/{sub.name}", "http://hello.dev/test/sub/", &NoProtoHello{}},
+			&NoProtoHello{Name: "test"},
+		},
+		{
+			"no proto: multi-segment wildcard",
+			args{"/v1/{name=**}", "/v1/a/b/c", &NoProtoHello{}},
+			&NoProtoHello{Name: "a/b/c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := codec.DecodeUrl(tt.args.pathTemplate, tt.args.path, tt.args.msg); err != nil {
+				t.Fatalf("DecodeUrl() error = %v", err)
+			}
+			if want, ok := tt.want.(proto.Message); ok {
+				if !proto.Equal(tt.args.msg.(proto.Message), want) {
+					t.Errorf("DecodeUrl() = %v, want %v", tt.args.msg, tt.want)
+				}
+			} else if !reflect.DeepEqual(tt.args.msg, tt.want) {
+				t.Errorf("DecodeUrl() = %v, want %v", tt.args.msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeUrl_Mismatch(t *testing.T) {
+	codec := New("json").DisableUseProtoNames()
+	for _, tt := range []struct {
+		pathTemplate string
+		path         string
+	}{
+		{"http://hello.dev/{name}/sub", "http://hello.dev/test/other"},
+		{"http://hello.dev/{name}/sub", "http://other.dev/test/sub"},
+		{"/v1/{name}", "/v1/a/b"},
+		{"/v1/{name}:publish", "/v1/test:cancel"},
+		{"/v1/{name=**}/sub", "/v1/a/b"},
+		{"/v1/sub", "/v1/sub/"},
+	} {
+		err := codec.DecodeUrl(tt.pathTemplate, tt.path, &NoProtoHello{})
+		var mismatch *PathMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("DecodeUrl(%q, %q) error = %v, want *PathMismatchError", tt.pathTemplate, tt.path, err)
+		}
+		if mismatch.Template != tt.pathTemplate {
+			t.Errorf("PathMismatchError.Template = %q, want %q", mismatch.Template, tt.pathTemplate)
+		}
+	}
+
+	if err := codec.DecodeUrl("/v1/{name}", "/v1/%zz", &NoProtoHello{}); err == nil {
+		t.Errorf("DecodeUrl() should return an error for invalid escape")
+	}
+}
@@ -0,0 +1,78 @@
+// Package gateway adapts the codecs of github.com/thinkgos/encoding to
+// github.com/grpc-ecosystem/grpc-gateway/v2/runtime.Marshaler and vice versa,
+// so the same codecs with identical options can be used by both.
+package gateway
+
+import (
+	"io"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+
+	"github.com/thinkgos/encoding"
+	"github.com/thinkgos/encoding/codec"
+)
+
+var defaultDelimiter = []byte("\n")
+
+// Marshaler adapts a codec.Marshaler to runtime.Marshaler.
+type Marshaler struct {
+	codec.Marshaler
+}
+
+// ToRuntime returns a runtime.Marshaler which delegates to m.
+func ToRuntime(m codec.Marshaler) *Marshaler {
+	return &Marshaler{Marshaler: m}
+}
+
+// NewDecoder returns a runtime.Decoder which reads byte sequence from "r".
+func (m *Marshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	return m.Marshaler.NewDecoder(r)
+}
+
+// NewEncoder returns a runtime.Encoder which writes bytes sequence into "w".
+func (m *Marshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return m.Marshaler.NewEncoder(w)
+}
+
+// Delimiter returns the record separator for the stream,
+// it delegates to the underlying marshaler if it implements runtime.Delimited,
+// otherwise returns "\n" as the grpc-gateway default.
+func (m *Marshaler) Delimiter() []byte {
+	if d, ok := m.Marshaler.(runtime.Delimited); ok {
+		return d.Delimiter()
+	}
+	return defaultDelimiter
+}
+
+// Codec adapts a runtime.Marshaler to codec.Marshaler.
+type Codec struct {
+	runtime.Marshaler
+}
+
+// FromRuntime returns a codec.Marshaler which delegates to m.
+func FromRuntime(m runtime.Marshaler) *Codec {
+	return &Codec{Marshaler: m}
+}
+
+// NewDecoder returns a codec.Decoder which reads byte sequence from "r".
+func (c *Codec) NewDecoder(r io.Reader) codec.Decoder {
+	return c.Marshaler.NewDecoder(r)
+}
+
+// NewEncoder returns a codec.Encoder which writes bytes sequence into "w".
+func (c *Codec) NewEncoder(w io.Writer) codec.Encoder {
+	return c.Marshaler.NewEncoder(w)
+}
+
+// WithEncoding returns the runtime.ServeMuxOption which registers the marshalers of
+// the Encoding for the wildcard and the given MIME types.
+// MIME types not registered on the Encoding fall back to its wildcard marshaler,
+// as Encoding.Get does.
+func WithEncoding(reg *encoding.Encoding, mimes ...string) []runtime.ServeMuxOption {
+	opts := make([]runtime.ServeMuxOption, 0, len(mimes)+1)
+	opts = append(opts, runtime.WithMarshalerOption(runtime.MIMEWildcard, ToRuntime(reg.Get(encoding.Mime_Wildcard))))
+	for _, mime := range mimes {
+		opts = append(opts, runtime.WithMarshalerOption(mime, ToRuntime(reg.Get(mime))))
+	}
+	return opts
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding"
+	"github.com/thinkgos/encoding/codec"
+	"github.com/thinkgos/encoding/json"
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+var (
+	_ runtime.Marshaler = (*Marshaler)(nil)
+	_ runtime.Delimited = (*Marshaler)(nil)
+	_ codec.Marshaler   = (*Codec)(nil)
+)
+
+type testMode struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func Test_Marshaler(t *testing.T) {
+	m := ToRuntime(&json.Codec{})
+	require.Equal(t, "application/json; charset=utf-8", m.ContentType(nil))
+	require.Equal(t, []byte("\n"), m.Delimiter())
+
+	want := &testMode{Id: "foo", Name: "bar"}
+	b, err := m.Marshal(want)
+	require.NoError(t, err)
+	got := &testMode{}
+	require.NoError(t, m.Unmarshal(b, got))
+	require.Equal(t, want, got)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, m.NewEncoder(buf).Encode(want))
+	got = &testMode{}
+	require.NoError(t, m.NewDecoder(buf).Decode(got))
+	require.Equal(t, want, got)
+
+	require.Equal(t, []byte("\n"), ToRuntime(&pro.Codec{}).Delimiter())
+}
+
+func Test_Codec(t *testing.T) {
+	c := FromRuntime(&runtime.JSONPb{})
+	require.Equal(t, "application/json", c.ContentType(nil))
+
+	want := &examplepb.SimpleMessage{Id: "foo"}
+	b, err := c.Marshal(want)
+	require.NoError(t, err)
+	got := &examplepb.SimpleMessage{}
+	require.NoError(t, c.Unmarshal(b, got))
+	require.True(t, proto.Equal(want, got))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, c.NewEncoder(buf).Encode(want))
+	got = &examplepb.SimpleMessage{}
+	require.NoError(t, c.NewDecoder(buf).Decode(got))
+	require.True(t, proto.Equal(want, got))
+
+	t.Run("register to encoding", func(t *testing.T) {
+		reg := encoding.New()
+		require.NoError(t, reg.Register("application/jsonpb", c))
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader([]byte(`{"id":"foo"}`)))
+		req.Header.Set("Content-Type", "application/jsonpb")
+		got := &examplepb.SimpleMessage{}
+		require.NoError(t, reg.Bind(req, got))
+		require.True(t, proto.Equal(want, got))
+	})
+}
+
+func Test_WithEncoding_ServeMux(t *testing.T) {
+	reg := encoding.New()
+	require.NoError(t, reg.Register(encoding.Mime_PROTOBUF, &pro.Codec{}))
+
+	mux := runtime.NewServeMux(WithEncoding(reg, encoding.Mime_JSON, encoding.Mime_PROTOBUF)...)
+	err := mux.HandlePath(http.MethodPost, "/v1/echo", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		inbound, outbound := runtime.MarshalerForRequest(mux, r)
+		msg := &examplepb.SimpleMessage{}
+		if err := inbound.NewDecoder(r.Body).Decode(msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, err := outbound.Marshal(msg)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", outbound.ContentType(msg))
+		_, _ = w.Write(b)
+	})
+	require.NoError(t, err)
+
+	want := &examplepb.SimpleMessage{Id: "foo"}
+	body, err := proto.Marshal(want)
+	require.NoError(t, err)
+
+	t.Run("proto in, json out", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/echo", bytes.NewReader(body))
+		req.Header.Set("Content-Type", encoding.Mime_PROTOBUF)
+		req.Header.Set("Accept", encoding.Mime_JSON)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, `{"id":"foo"}`, w.Body.String())
+	})
+	t.Run("proto in, proto out", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/echo", bytes.NewReader(body))
+		req.Header.Set("Content-Type", encoding.Mime_PROTOBUF)
+		req.Header.Set("Accept", encoding.Mime_PROTOBUF)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, encoding.Mime_PROTOBUF, w.Header().Get("Content-Type"))
+		got := &examplepb.SimpleMessage{}
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), got))
+		require.True(t, proto.Equal(want, got))
+	})
+	t.Run("unknown in, wildcard", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/echo", bytes.NewReader([]byte(`{"id":"foo"}`)))
+		req.Header.Set("Content-Type", "application/unknown")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, `{"id":"foo"}`, w.Body.String())
+	})
+}
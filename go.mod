@@ -5,11 +5,13 @@ go 1.23
 require (
 	github.com/go-playground/form/v4 v4.3.0
 	github.com/google/go-cmp v0.7.0
+	github.com/klauspost/compress v1.18.1
 	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/spf13/cast v1.10.0
 	github.com/stretchr/testify v1.11.1
 	github.com/ugorji/go/codec v1.3.1
 	golang.org/x/exp v0.0.0-20240525044651-4c93da0ed11d
+	golang.org/x/text v0.21.0
 	google.golang.org/genproto/googleapis/api v0.0.0-20250102185135-69823020774d
 	google.golang.org/protobuf v1.36.11
 	gopkg.in/yaml.v3 v3.0.1
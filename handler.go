@@ -0,0 +1,176 @@
+package encoding
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Handler returns an http.Handler which serves the static value v in the format negotiated by
+// the `Accept` header of each request, like Render, it is handy for the well-known documents.
+// The marshaled body is cached per MIME type after the first use, the cache is never invalidated,
+// so v must not be modified after it is passed.
+// It sets the ETag from the marshaled body and answers 304 Not Modified for the matched `If-None-Match`,
+// supports HEAD, and answers 405 Method Not Allowed for the methods other than GET and HEAD.
+// If Marshal fails, it uses the fallback of WithMarshalErrorFallback, or DefaultMarshalErrorFallback.
+func (r *Encoding) Handler(v any) http.Handler {
+	return &valueHandler{
+		encoding: r,
+		value:    func(*http.Request) any { return v },
+		cache:    &sync.Map{},
+	}
+}
+
+// HandlerFunc returns an http.Handler which serves the value returned by fn for each request,
+// like Handler, but the marshaled body is not cached. It answers 204 No Content if fn returns nil.
+func (r *Encoding) HandlerFunc(fn func(req *http.Request) any) http.Handler {
+	return &valueHandler{encoding: r, value: fn}
+}
+
+// valueHandler serves the value with the content negotiation.
+type valueHandler struct {
+	encoding *Encoding
+	value    func(*http.Request) any
+	cache    *sync.Map // map[string]*valueBody keyed by the MIME type, nil if not cached.
+}
+
+// valueBody is the marshaled body of the value.
+type valueBody struct {
+	contentType string
+	data        []byte
+	etag        string
+}
+
+func (h *valueHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	r := h.encoding
+	if r.strictAccept && !r.acceptable(req) {
+		w.Header().Add("Vary", "Accept")
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		if r.onRenderError != nil {
+			callErrorHook(r.onRenderError, req, ErrNotAcceptable)
+		}
+		return
+	}
+	mime, body, err := h.body(req)
+	mime = registeredMIME(r.load().outboundMarshalers(), mime)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.IncRender(mime, 0, true)
+		}
+		fallback := r.marshalErrorFallback
+		if fallback == nil {
+			fallback = DefaultMarshalErrorFallback
+		}
+		fallback(w, req, err)
+		if r.onRenderError != nil {
+			callErrorHook(r.onRenderError, req, err)
+		}
+		return
+	}
+	if body == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	contentType, data, etag := body.contentType, body.data, body.etag
+	header := w.Header()
+	header.Add("Vary", "Accept")
+	if r.acceptCharset {
+		header.Add("Vary", "Accept-Charset")
+		transformed, ct, err := r.transformCharset(req, contentType, data)
+		if err != nil {
+			if r.metrics != nil {
+				r.metrics.IncRender(mime, 0, true)
+			}
+			if errors.Is(err, ErrNotAcceptable) {
+				http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			if r.onRenderError != nil {
+				callErrorHook(r.onRenderError, req, err)
+			}
+			return
+		}
+		if ct != contentType || !bytes.Equal(transformed, data) {
+			contentType, data, etag = ct, transformed, computeETag(transformed)
+		}
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("ETag", etag)
+	if etagMatch(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	if req.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	n, err := w.Write(data)
+	if r.metrics != nil {
+		r.metrics.IncRender(mime, n, err != nil)
+	}
+	if err != nil && r.onRenderError != nil {
+		callErrorHook(r.onRenderError, req, err)
+	}
+}
+
+// body returns the negotiated MIME type and the marshaled body of the value,
+// the body is nil if the value is nil.
+func (h *valueHandler) body(req *http.Request) (string, *valueBody, error) {
+	mime, marshaller := h.encoding.outboundForRequest(req)
+	if h.cache != nil {
+		if b, ok := h.cache.Load(mime); ok {
+			return mime, b.(*valueBody), nil
+		}
+	}
+	v := h.value(req)
+	if v == nil {
+		return mime, nil, nil
+	}
+	data, err := marshaller.Marshal(v)
+	err = renderError(mime, v, err)
+	h.encoding.marshaled(registeredMIME(h.encoding.load().outboundMarshalers(), mime), v, len(data), err)
+	if err != nil {
+		return mime, nil, err
+	}
+	b := &valueBody{
+		contentType: h.encoding.load().outboundContentType(mime, marshaller, marshaller.ContentType(v)),
+		data:        data,
+		etag:        computeETag(data),
+	}
+	if h.cache != nil {
+		actual, _ := h.cache.LoadOrStore(mime, b)
+		b = actual.(*valueBody)
+	}
+	return mime, b, nil
+}
+
+// computeETag returns the strong ETag of the data.
+func computeETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch reports whether the `If-None-Match` header matches the etag,
+// it uses the weak comparison, and "*" matches any etag.
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, value := range strings.Split(ifNoneMatch, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || strings.TrimPrefix(value, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
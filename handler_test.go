@@ -0,0 +1,175 @@
+package encoding
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/codec"
+	exml "github.com/thinkgos/encoding/xml"
+	"github.com/thinkgos/encoding/yaml"
+)
+
+// countingMarshaler counts the calls of Marshal.
+type countingMarshaler struct {
+	codec.Marshaler
+	calls int
+}
+
+func (m *countingMarshaler) Marshal(v any) ([]byte, error) {
+	m.calls++
+	return m.Marshaler.Marshal(v)
+}
+
+func Test_Encoding_Handler(t *testing.T) {
+	registry := New()
+	jsonMarshaler := &countingMarshaler{Marshaler: registry.Get(Mime_JSON)}
+	require.NoError(t, registry.Register(Mime_JSON, jsonMarshaler))
+	require.NoError(t, registry.Register(Mime_XML, &exml.Codec{}))
+	require.NoError(t, registry.Register(Mime_YAML, &yaml.Codec{}))
+
+	handler := registry.Handler(&TestMode{Id: "foo", Name: "bar"})
+	serve := func(method, accept, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://example.com/.well-known/doc", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	etags := map[string]bool{}
+	for _, tt := range []struct {
+		accept      string
+		contentType string
+		check       func(t *testing.T, body []byte)
+	}{
+		{
+			accept:      Mime_JSON,
+			contentType: "application/json",
+			check: func(t *testing.T, body []byte) {
+				got := &TestMode{}
+				require.NoError(t, json.Unmarshal(body, got))
+				require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+			},
+		},
+		{
+			accept:      "text/html, " + Mime_XML + ";q=0.9",
+			contentType: "application/xml",
+			check: func(t *testing.T, body []byte) {
+				got := &TestMode{}
+				require.NoError(t, xml.Unmarshal(body, got))
+				require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+			},
+		},
+		{
+			accept:      Mime_YAML,
+			contentType: "application/x-yaml",
+			check: func(t *testing.T, body []byte) {
+				require.Contains(t, string(body), "id: foo")
+			},
+		},
+	} {
+		t.Run(tt.accept, func(t *testing.T) {
+			w := serve(http.MethodGet, tt.accept, "")
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Contains(t, w.Header().Get("Content-Type"), tt.contentType)
+			require.Equal(t, "Accept", w.Header().Get("Vary"))
+			tt.check(t, w.Body.Bytes())
+
+			etag := w.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+			require.False(t, etags[etag], "the etag should be distinct per format")
+			etags[etag] = true
+
+			// served from the cache.
+			w2 := serve(http.MethodGet, tt.accept, "")
+			require.Equal(t, w.Body.Bytes(), w2.Body.Bytes())
+			require.Equal(t, etag, w2.Header().Get("ETag"))
+
+			w = serve(http.MethodGet, tt.accept, `"other", W/`+etag)
+			require.Equal(t, http.StatusNotModified, w.Code)
+			require.Empty(t, w.Body.Bytes())
+
+			w = serve(http.MethodHead, tt.accept, "")
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, w2.Header().Get("Content-Length"), w.Header().Get("Content-Length"))
+			require.Empty(t, w.Body.Bytes())
+		})
+	}
+	require.Equal(t, 1, jsonMarshaler.calls)
+
+	w := serve(http.MethodPost, Mime_JSON, "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	require.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+}
+
+func Test_Encoding_HandlerFunc(t *testing.T) {
+	var gotErr error
+	registry := New(WithOnRenderError(func(_ *http.Request, err error) { gotErr = err }))
+	jsonMarshaler := &countingMarshaler{Marshaler: registry.Get(Mime_JSON)}
+	require.NoError(t, registry.Register(Mime_JSON, jsonMarshaler))
+
+	count := 0
+	handler := registry.HandlerFunc(func(req *http.Request) any {
+		count++
+		if req.URL.Query().Has("nil") {
+			return nil
+		}
+		return map[string]int{"count": count}
+	})
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_JSON)
+	for i := 1; i <= 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"count":`+string(rune('0'+i))+`}`, w.Body.String())
+	}
+	require.Equal(t, 2, jsonMarshaler.calls)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com?nil", nil))
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	// the marshal error falls back to DefaultMarshalErrorFallback.
+	w = httptest.NewRecorder()
+	registry.HandlerFunc(func(*http.Request) any { return make(chan int) }).ServeHTTP(w, req)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	require.Error(t, gotErr)
+}
+
+func Test_Encoding_Handler_AcceptCharset(t *testing.T) {
+	registry := New(WithAcceptCharset(true))
+	require.NoError(t, registry.Register(Mime_XML, &exml.Codec{}))
+	handler := registry.Handler(&TestMode{Id: "café"})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_XML)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	utf8ETag := w.Header().Get("ETag")
+
+	req.Header.Set("Accept-Charset", "iso-8859-1")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "charset=iso-8859-1")
+	require.Contains(t, w.Body.String(), "caf\xe9")
+	require.NotEqual(t, utf8ETag, w.Header().Get("ETag"))
+	require.Equal(t, []string{"Accept", "Accept-Charset"}, w.Header().Values("Vary"))
+
+	req.Header.Set("Accept-Charset", "x-unknown")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotAcceptable, w.Code)
+}
@@ -0,0 +1,107 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/thinkgos/encoding/form"
+)
+
+// headerTagName is the struct tag name of BindHeader.
+const headerTagName = "header"
+
+// headerCodec decodes the header values with the header tag.
+var headerCodec = form.New(headerTagName)
+
+// headerField is a struct field with the header tag.
+type headerField struct {
+	name      string // the name of the tag as it is spelled.
+	canonical string // the canonical header key of the name.
+}
+
+// headerFieldsCache caches the header fields, map[reflect.Type][]headerField.
+var headerFieldsCache sync.Map
+
+// BindHeader binds the passed struct pointer using the request headers with the `header` tag, like:
+//
+//	type Request struct {
+//		TraceId   string   `header:"X-Trace-Id"`
+//		TraceTags []string `header:"x-trace-tags,comma"`
+//	}
+//
+// The header is looked up by the canonical key regardless of how the tag is spelled,
+// and falls back to a case-insensitive match for the non-canonical keys set directly in the header map.
+// With the "comma" tag option, the header values are split by the commas which are not in the quoted string,
+// like `X-Trace-Tags: a,"b,c"` --> ["a", "\"b,c\""], the parameters like `en;q=0.8` are kept as is.
+func (r *Encoding) BindHeader(req *http.Request, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, bindHeader(req.Header, v), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func bindHeader(header http.Header, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("encoding: BindHeader requires a non-nil struct pointer")
+	}
+	fields := cachedHeaderFields(rv.Elem().Type())
+	vs := make(url.Values, len(fields))
+	for _, f := range fields {
+		values := lookupHeader(header, f.canonical)
+		if len(values) == 0 {
+			continue
+		}
+		vs[f.name] = values
+	}
+	// the comma fields are split by the codec, see form.Codec.Decode.
+	return headerCodec.Decode(vs, v)
+}
+
+// cachedHeaderFields returns the header fields of the struct type t, it is computed once per type.
+func cachedHeaderFields(t reflect.Type) []headerField {
+	if fields, ok := headerFieldsCache.Load(t); ok {
+		return fields.([]headerField)
+	}
+	var fields []headerField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag, ok := field.Tag.Lookup(headerTagName)
+		if !ok || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			continue
+		}
+		fields = append(fields, headerField{
+			name:      name,
+			canonical: textproto.CanonicalMIMEHeaderKey(name),
+		})
+	}
+	actual, _ := headerFieldsCache.LoadOrStore(t, fields)
+	return actual.([]headerField)
+}
+
+// lookupHeader returns the header values by the canonical key, or the first case-insensitive matched key.
+func lookupHeader(header http.Header, canonical string) []string {
+	if values, ok := header[canonical]; ok {
+		return values
+	}
+	for k, values := range header {
+		if strings.EqualFold(k, canonical) {
+			return values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type headerModel struct {
+	TraceId   string   `header:"x-trace-id"`
+	TraceTags []string `header:"X-TRACE-TAGS,comma"`
+	Languages []string `header:"Accept-Language,comma"`
+	Raw       []string `header:"X-Raw"`
+	Retry     *int     `header:"x-Retry-Count"`
+	Ignored   string   `header:"-"`
+	NoTag     string
+}
+
+func Test_Encoding_BindHeader(t *testing.T) {
+	registry := New()
+	retry := 3
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		want    *headerModel
+		wantErr bool
+	}{
+		{
+			name: "canonical header",
+			header: func() http.Header {
+				h := http.Header{}
+				h.Set("X-Trace-Id", "abc")
+				h.Add("X-Trace-Tags", `a, "b,c" ,d`)
+				h.Add("X-Trace-Tags", "e")
+				h.Set("Accept-Language", "en-US,en;q=0.9, zh;q=0.8")
+				h.Set("X-Raw", "a,b")
+				h.Set("X-Retry-Count", "3")
+				h.Set("Ignored", "ignored")
+				h.Set("NoTag", "ignored")
+				return h
+			}(),
+			want: &headerModel{
+				TraceId:   "abc",
+				TraceTags: []string{"a", `"b,c"`, "d", "e"},
+				Languages: []string{"en-US", "en;q=0.9", "zh;q=0.8"},
+				Raw:       []string{"a,b"},
+				Retry:     &retry,
+			},
+		},
+		{
+			name: "lowercase header behind HTTP/2 proxies",
+			header: http.Header{
+				"x-trace-id":   {"abc"},
+				"x-trace-tags": {"a,,b"},
+			},
+			want: &headerModel{
+				TraceId:   "abc",
+				TraceTags: []string{"a", "b"},
+			},
+		},
+		{
+			name:   "absent header",
+			header: http.Header{},
+			want:   &headerModel{},
+		},
+		{
+			name:    "invalid value",
+			header:  http.Header{"X-Retry-Count": {"x"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.Header = tt.header
+			got := &headerModel{}
+			err := registry.BindHeader(req, got)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.Error(t, registry.BindHeader(req, headerModel{}))
+	require.Error(t, registry.BindHeader(req, (*headerModel)(nil)))
+}
@@ -0,0 +1,34 @@
+package encoding
+
+import (
+	"sync"
+
+	"github.com/thinkgos/encoding/codec"
+	"github.com/thinkgos/encoding/internal/lru"
+)
+
+// maxHeaderCacheSize is the max number of the entries of a header cache, the least recently used one is evicted,
+// so the arbitrary header values of the clients can not grow it unboundedly.
+const maxHeaderCacheSize = 512
+
+// acceptResult is the negotiation result of the `Accept` header.
+type acceptResult struct {
+	mime      string
+	marshaler codec.Marshaler
+}
+
+// headerCaches is the caches of the negotiation results keyed by the raw `Content-Type` and `Accept` headers,
+// they belong to a registry snapshot, so they are invalidated when the Encoding is mutated.
+type headerCaches struct {
+	contentTypes *lru.Cache[string, Negotiation]
+	accepts      *lru.Cache[string, acceptResult]
+	// hooked is the marshalers which invoke the hooks, see WithOnMarshal.
+	hooked sync.Map // hookKey -> *hookedMarshaler
+}
+
+func newHeaderCaches() *headerCaches {
+	return &headerCaches{
+		contentTypes: lru.New[string, Negotiation](maxHeaderCacheSize),
+		accepts:      lru.New[string, acceptResult](maxHeaderCacheSize),
+	}
+}
@@ -0,0 +1,100 @@
+package encoding
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_headerCaches(t *testing.T) {
+	s := New().load()
+	hot := []string{"application/json;q=0.9, text/plain"}
+	for i := 0; i < 2*maxHeaderCacheSize; i++ {
+		s.marshalerFromHeaderAccept(hot)
+		s.marshalerFromHeaderAccept([]string{fmt.Sprintf("application/x-%d;q=0.5", i)})
+	}
+	// the arbitrary header values evict the least recently used ones, not the hot one.
+	require.Equal(t, maxHeaderCacheSize, s.caches.accepts.Len())
+	_, ok := s.caches.accepts.Get(hot[0])
+	require.True(t, ok)
+	_, ok = s.caches.accepts.Get("application/x-0;q=0.5")
+	require.False(t, ok)
+}
+
+func Test_Encoding_HeaderCache(t *testing.T) {
+	registry := New()
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Accept", "application/xml;q=0.9, application/json;q=0.1")
+
+	n := registry.NegotiateInbound(req)
+	require.Equal(t, Mime_Wildcard, n.MediaType)
+	require.Equal(t, map[string]string{"charset": "utf-8"}, n.Params)
+	mime, _ := registry.OutboundForRequestWithMime(req)
+	require.Equal(t, Mime_JSON, mime)
+	s := registry.load()
+	_, ok := s.caches.contentTypes.Get("application/xml; charset=utf-8")
+	require.True(t, ok)
+	_, ok = s.caches.accepts.Get("application/xml;q=0.9, application/json;q=0.1")
+	require.True(t, ok)
+
+	// the mutation of the Encoding invalidates the cache.
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.Equal(t, Mime_XML, registry.NegotiateInbound(req).MediaType)
+	mime, _ = registry.OutboundForRequestWithMime(req)
+	require.Equal(t, Mime_XML, mime)
+
+	// the multipart boundary is not cached.
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=abc")
+	require.Equal(t, Mime_MultipartPostForm, registry.NegotiateInbound(req).MediaType)
+	_, ok = registry.load().caches.contentTypes.Get("multipart/form-data; boundary=abc")
+	require.False(t, ok)
+}
+
+func Benchmark_Encoding_HeaderCache(b *testing.B) {
+	registry := New()
+	s := registry.load()
+	for _, header := range []struct {
+		name   string
+		values []string
+		parse  func(values []string)
+		cached func(values []string)
+	}{
+		{
+			name:   "Content-Type",
+			values: []string{"application/json; charset=utf-8"},
+			parse:  func(values []string) { _ = s.parseContentType(values) },
+			cached: func(values []string) { _ = s.negotiateContentType(values) },
+		},
+		{
+			name:   "Accept",
+			values: []string{"application/json, text/plain, */*"},
+			parse:  func(values []string) { _, _ = s.parseAccept(values) },
+			cached: func(values []string) { _, _ = s.marshalerFromHeaderAccept(values) },
+		},
+		{
+			name:   "Accept quoted",
+			values: []string{`application/json;v="1,2", text/plain, */*`},
+			parse:  func(values []string) { _, _ = s.parseAccept(values) },
+			cached: func(values []string) { _, _ = s.marshalerFromHeaderAccept(values) },
+		},
+	} {
+		b.Run(header.name+"/uncached", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				header.parse(header.values)
+			}
+		})
+		b.Run(header.name+"/cached", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				header.cached(header.values)
+			}
+		})
+	}
+}
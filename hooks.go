@@ -0,0 +1,144 @@
+package encoding
+
+import (
+	"io"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// WithOnMarshal sets the hook which is invoked after every marshal of Render, Handler, Encode
+// and the marshalers returned by Get, with the MIME type, the value, the size of the data and the error,
+// like recording the payload size or the tracing span. mime is Mime_Wildcard for the "*" Marshaler,
+// the registered MIME type for the structured syntax suffix, like "application/json" of "application/vnd.myapp+json".
+// It must be safe for concurrent use.
+func WithOnMarshal(fn func(mime string, v any, n int, err error)) Option {
+	return func(r *Encoding) {
+		r.onMarshal = fn
+	}
+}
+
+// WithOnUnmarshal sets the hook which is invoked after every unmarshal of Bind, BindQuery
+// and the marshalers returned by Get, with the MIME type, the value, the size of the data read and the error,
+// mime is Mime_Query for the query, Mime_Wildcard for the "*" Marshaler, the registered MIME type for the structured
+// syntax suffix, like WithOnMarshal.
+// The returned error replaces the error, so it can inject the validation of the value after unmarshal,
+// it should return err as is otherwise. It must be safe for concurrent use.
+func WithOnUnmarshal(fn func(mime string, v any, n int, err error) error) Option {
+	return func(r *Encoding) {
+		r.onUnmarshal = fn
+	}
+}
+
+// hookKey is the key of the hookedMarshaler cache.
+type hookKey struct {
+	mime      string
+	marshaler codec.Marshaler
+}
+
+// hooked returns the marshaler which invokes the hooks, the same marshaler is returned
+// for the same MIME type and marshaler of the snapshot, so the identity can be compared.
+// It returns the marshaler itself if there are no hooks.
+func (r *Encoding) hooked(s *registry, mime string, m codec.Marshaler) codec.Marshaler {
+	if r.onMarshal == nil && r.onUnmarshal == nil {
+		return m
+	}
+	key := hookKey{mime: mime, marshaler: m}
+	if h, ok := s.caches.hooked.Load(key); ok {
+		return h.(codec.Marshaler)
+	}
+	h, _ := s.caches.hooked.LoadOrStore(key, &hookedMarshaler{Marshaler: m, mime: mime, encoding: r})
+	return h.(codec.Marshaler)
+}
+
+// hookedMarshaler is the marshaler which invokes the hooks of the Encoding.
+// NOTE: it hides the optional interfaces of the marshaler, like codec.FormCodec.
+type hookedMarshaler struct {
+	codec.Marshaler
+	mime     string
+	encoding *Encoding
+}
+
+func (h *hookedMarshaler) Marshal(v any) ([]byte, error) {
+	data, err := h.Marshaler.Marshal(v)
+	h.encoding.marshaled(h.mime, v, len(data), err)
+	return data, err
+}
+
+func (h *hookedMarshaler) Unmarshal(data []byte, v any) error {
+	err := h.Marshaler.Unmarshal(data, v)
+	return h.encoding.unmarshaled(h.mime, v, len(data), err)
+}
+
+func (h *hookedMarshaler) NewEncoder(w io.Writer) codec.Encoder {
+	cw := &countingWriter{Writer: w}
+	return &hookedEncoder{Encoder: h.Marshaler.NewEncoder(cw), w: cw, h: h}
+}
+
+func (h *hookedMarshaler) NewDecoder(r io.Reader) codec.Decoder {
+	cr := &countingReader{Reader: r}
+	return &hookedDecoder{Decoder: h.Marshaler.NewDecoder(cr), r: cr, h: h}
+}
+
+type hookedEncoder struct {
+	codec.Encoder
+	w *countingWriter
+	h *hookedMarshaler
+}
+
+func (e *hookedEncoder) Encode(v any) error {
+	n := e.w.n
+	err := e.Encoder.Encode(v)
+	e.h.encoding.marshaled(e.h.mime, v, e.w.n-n, err)
+	return err
+}
+
+type hookedDecoder struct {
+	codec.Decoder
+	r *countingReader
+	h *hookedMarshaler
+}
+
+func (d *hookedDecoder) Decode(v any) error {
+	n := d.r.n
+	err := d.Decoder.Decode(v)
+	return d.h.encoding.unmarshaled(d.h.mime, v, d.r.n-n, err)
+}
+
+// marshaled invokes the marshal hook if it is set.
+func (r *Encoding) marshaled(mime string, v any, n int, err error) {
+	if r.onMarshal != nil {
+		r.onMarshal(mime, v, n, err)
+	}
+}
+
+// unmarshaled invokes the unmarshal hook if it is set, and returns the error of it.
+func (r *Encoding) unmarshaled(mime string, v any, n int, err error) error {
+	if r.onUnmarshal != nil {
+		return r.onUnmarshal(mime, v, n, err)
+	}
+	return err
+}
+
+// countingWriter counts the bytes written.
+type countingWriter struct {
+	io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	c.n += n
+	return n, err
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += n
+	return n, err
+}
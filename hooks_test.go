@@ -0,0 +1,123 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+type hookEvent struct {
+	mime string
+	v    any
+	n    int
+	err  error
+}
+
+func Test_WithOnMarshal_WithOnUnmarshal(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		marshals    []hookEvent
+		unmarshals  []hookEvent
+		errInvalid  = errors.New("invalid")
+		resetEvents = func() { marshals, unmarshals = nil, nil }
+	)
+	registry := New(
+		WithOnMarshal(func(mime string, v any, n int, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			marshals = append(marshals, hookEvent{mime, v, n, err})
+		}),
+		WithOnUnmarshal(func(mime string, v any, n int, err error) error {
+			mu.Lock()
+			defer mu.Unlock()
+			unmarshals = append(unmarshals, hookEvent{mime, v, n, err})
+			if m, ok := v.(*TestMode); ok && err == nil && m.Id == "" {
+				return errInvalid
+			}
+			return err
+		}),
+	)
+
+	// Bind and the validation.
+	body := `{"id":"foo"}`
+	req := httptest.NewRequest(http.MethodPost, "http://example.com?name=bar", strings.NewReader(body))
+	req.Header.Set("Content-Type", Mime_JSON)
+	got := &TestMode{}
+	require.NoError(t, registry.Bind(req, got))
+	require.Equal(t, []hookEvent{{Mime_JSON, got, len(body), nil}}, unmarshals)
+
+	resetEvents()
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/unknown")
+	require.Equal(t, errInvalid, registry.Bind(req, &TestMode{}))
+	require.Equal(t, Mime_Wildcard, unmarshals[0].mime)
+
+	resetEvents()
+	req = httptest.NewRequest(http.MethodGet, "http://example.com?id=foo", nil)
+	require.NoError(t, registry.BindQuery(req, &TestMode{}))
+	require.Equal(t, Mime_Query, unmarshals[0].mime)
+	require.Equal(t, len("id=foo"), unmarshals[0].n)
+
+	// Render, Handler and Encode.
+	resetEvents()
+	w := httptest.NewRecorder()
+	v := &TestMode{Id: "foo"}
+	require.NoError(t, registry.Render(w, req, v))
+	registry.Handler(v).ServeHTTP(httptest.NewRecorder(), req)
+	data, err := registry.Encode("Application/JSON", v)
+	require.NoError(t, err)
+	require.Equal(t, []hookEvent{
+		{Mime_Wildcard, v, w.Body.Len(), nil},
+		{Mime_Wildcard, v, w.Body.Len(), nil},
+		{Mime_JSON, v, len(data), nil},
+	}, marshals)
+
+	// Get.
+	resetEvents()
+	m := registry.Get("Application/JSON")
+	require.Same(t, m, registry.Get(Mime_JSON))
+	require.Same(t, registry.Get("application/unknown"), registry.Get(Mime_Wildcard))
+	require.NotSame(t, registry.Get(Mime_JSON), registry.Get(Mime_Wildcard))
+	_, ok := registry.Get(Mime_Query).(codec.FormMarshaler)
+	require.True(t, ok)
+
+	data, err = m.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, errInvalid, m.Unmarshal([]byte(`{}`), &TestMode{}))
+	var buf bytes.Buffer
+	require.NoError(t, m.NewEncoder(&buf).Encode(v))
+	n := buf.Len()
+	got = &TestMode{}
+	require.NoError(t, m.NewDecoder(&buf).Decode(got))
+	require.Equal(t, "foo", got.Id)
+	require.Equal(t, []hookEvent{{Mime_JSON, v, len(data), nil}, {Mime_JSON, v, n, nil}}, marshals)
+	require.Len(t, unmarshals, 2)
+	require.Equal(t, Mime_JSON, unmarshals[1].mime)
+	require.Equal(t, n, unmarshals[1].n)
+
+	// no hooks.
+	registry = New()
+	require.Same(t, registry.load().mimeMap[Mime_JSON], registry.Get(Mime_JSON))
+}
+
+func Test_Encoding_Lookup_Hooked(t *testing.T) {
+	registry := New(WithOnMarshal(func(string, any, int, error) {}))
+	m, ok := registry.Lookup(Mime_JSON)
+	require.True(t, ok)
+	require.IsType(t, &hookedMarshaler{}, m)
+	m, ok = registry.Lookup("application/unknown")
+	require.False(t, ok)
+	require.Same(t, registry.Get(Mime_Wildcard), m)
+	_, ok = registry.Get(Mime_PostForm).(codec.FormCodec)
+	require.True(t, ok)
+	_, ok = registry.Get(Mime_MultipartPostForm).(codec.FormCodec)
+	require.True(t, ok)
+}
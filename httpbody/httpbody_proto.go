@@ -32,3 +32,15 @@ func (h *HTTPBodyCodec) Marshal(v any) ([]byte, error) {
 	}
 	return h.Marshaler.Marshal(v)
 }
+
+// Size returns the size of the body bytes if v is a google.api.HttpBody message,
+// otherwise it falls back to the default Marshaler if it implements codec.Sizer.
+func (h *HTTPBodyCodec) Size(v any) (int, bool) {
+	if httpBody, ok := v.(*httpbody.HttpBody); ok {
+		return len(httpBody.Data), true
+	}
+	if s, ok := h.Marshaler.(codec.Sizer); ok {
+		return s.Size(v)
+	}
+	return 0, false
+}
@@ -52,3 +52,18 @@ func TestCodec_Marshal(t *testing.T) {
 		t.Errorf("Marshalled data not equal (%q, %q)", res, expected)
 	}
 }
+
+func TestCodec_Size(t *testing.T) {
+	m := HTTPBodyCodec{&jsonpb.Codec{}}
+	size, ok := m.Size(&httpbody.HttpBody{Data: []byte("Some test")})
+	if !ok || size != 9 {
+		t.Errorf("Size got = (%d, %v), want (9, true)", size, ok)
+	}
+	// jsonpb does not know the size.
+	if _, ok := m.Size(&httpbody.HttpBody{}); !ok {
+		t.Errorf("Size should return true for HttpBody")
+	}
+	if _, ok := m.Size(struct{}{}); ok {
+		t.Errorf("Size should return false for the default Marshaler without codec.Sizer")
+	}
+}
@@ -0,0 +1,69 @@
+// Package lru implements a fixed size, concurrency safe LRU cache.
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a fixed size LRU cache, it is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[K]*list.Element
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// New returns a new Cache which holds at most size entries,
+// size less than 1 is treated as 1.
+func New[K comparable, V any](size int) *Cache[K, V] {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache[K, V]{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[K]*list.Element, size),
+	}
+}
+
+// Get returns the value of the key, and marks it as the most recently used.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*entry[K, V]).value, true
+	}
+	return value, false
+}
+
+// Add adds or updates the value of the key, and marks it as the most recently used,
+// the least recently used entry is evicted if the cache is full.
+func (c *Cache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*entry[K, V]).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry[K, V]{key, value})
+	if c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*entry[K, V]).key)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,53 @@
+package lru
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Cache(t *testing.T) {
+	c := New[string, int](2)
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	// "b" is the least recently used.
+	c.Add("c", 3)
+	require.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	require.False(t, ok)
+
+	c.Add("a", 10)
+	v, ok = c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 10, v)
+	v, ok = c.Get("c")
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+
+	require.Equal(t, 1, New[string, int](0).size)
+}
+
+func Test_Cache_Concurrent(t *testing.T) {
+	c := New[string, int](8)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := strconv.Itoa(j % 16)
+				c.Add(key, j)
+				_, _ = c.Get(key)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 8, c.Len())
+}
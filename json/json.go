@@ -13,7 +13,7 @@ import (
 // it does not support advanced features of protobuf, e.g. map, oneof, ....
 //
 // The NewEncoder and NewDecoder types return *json.Encoder and
-// *json.Decoder respectively.
+// *json.Decoder respectively, NewDecoder returns a codec.DecoderFunc if Schema is set.
 type Codec struct {
 	// UseNumber causes the Decoder to unmarshal a number into an any as a
 	// Number instead of as a float64.
@@ -22,6 +22,11 @@ type Codec struct {
 	// is a struct and the input contains object keys which do not match any
 	// non-ignored, exported fields in the destination.
 	DisallowUnknownFields bool
+	// Schema returns the SchemaValidator of the target "v", nil return skips the validation.
+	// The raw document is validated before unmarshaling in both Unmarshal and the Decoder,
+	// which buffers every document of the stream, it returns a *SchemaError if the validation fails.
+	// See Schemas for selecting the schema per target type.
+	Schema func(v any) SchemaValidator
 }
 
 // ContentType always Returns "application/json; charset=utf-8".
@@ -31,10 +36,19 @@ func (*Codec) ContentType(_ any) string {
 func (*Codec) Marshal(v any) ([]byte, error) {
 	return json.Marshal(v)
 }
-func (*Codec) Unmarshal(data []byte, v any) error {
+func (c *Codec) Unmarshal(data []byte, v any) error {
+	if err := c.validate(data, v); err != nil {
+		return err
+	}
 	return json.Unmarshal(data, v)
 }
 func (c *Codec) NewDecoder(r io.Reader) codec.Decoder {
+	if c.Schema != nil {
+		return c.newValidatingDecoder(r)
+	}
+	return c.newDecoder(r)
+}
+func (c *Codec) newDecoder(r io.Reader) *json.Decoder {
 	decoder := json.NewDecoder(r)
 	if c.UseNumber {
 		decoder.UseNumber()
@@ -44,6 +58,20 @@ func (c *Codec) NewDecoder(r io.Reader) codec.Decoder {
 	}
 	return decoder
 }
+
+var _ codec.ConfigurableDecoder = (*Codec)(nil)
+
+// WithDecoderOptions returns a copy of the Codec with the DisallowUnknownFields and UseNumber overridden.
+func (c *Codec) WithDecoderOptions(opts codec.DecoderOptions) codec.Marshaler {
+	cc := *c
+	if opts.DisallowUnknownFields != nil {
+		cc.DisallowUnknownFields = *opts.DisallowUnknownFields
+	}
+	if opts.UseNumber != nil {
+		cc.UseNumber = *opts.UseNumber
+	}
+	return &cc
+}
 func (c *Codec) NewEncoder(w io.Writer) codec.Encoder {
 	return json.NewEncoder(w)
 }
@@ -14,6 +14,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	"github.com/thinkgos/encoding/codec"
 	"github.com/thinkgos/encoding/testdata/examplepb"
 )
 
@@ -283,3 +284,18 @@ var (
 		},
 	}
 )
+
+func TestCodec_WithDecoderOptions(t *testing.T) {
+	m := &Codec{UseNumber: true}
+	disallow, useNumber := true, false
+	got := m.WithDecoderOptions(codec.DecoderOptions{DisallowUnknownFields: &disallow, UseNumber: &useNumber})
+	if want := (&Codec{DisallowUnknownFields: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v; want = %v", got, want)
+	}
+	if !m.UseNumber || m.DisallowUnknownFields {
+		t.Errorf("the receiver is modified: %v", m)
+	}
+	if got := m.WithDecoderOptions(codec.DecoderOptions{}); !reflect.DeepEqual(got, m) {
+		t.Errorf("got = %v; want = %v", got, m)
+	}
+}
@@ -0,0 +1,102 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+var _ codec.ResettableDecoderFactory = (*Codec)(nil)
+
+// decoderPools are the pools of *pooledDecoder, indexed by the options of the Codec.
+var decoderPools [4]sync.Pool
+
+// pooledDecoder is a *json.Decoder which reads from a swappable reader.
+//
+// json.Decoder can not be reset, but it continues to read the next value from
+// the reader after a successful Decode, so it is reused only if the last Decode
+// succeeded and nothing but whitespace was left in its buffer, the state of the
+// last stream can not leak into the next one.
+type pooledDecoder struct {
+	*json.Decoder
+	r     swapReader
+	index int
+	dirty bool
+}
+
+func (d *pooledDecoder) Decode(v any) error {
+	if err := d.Decoder.Decode(v); err != nil {
+		d.dirty = true
+		return err
+	}
+	return nil
+}
+
+// reusable reports whether the decoder can be reused.
+func (d *pooledDecoder) reusable() bool {
+	if d.dirty {
+		return false
+	}
+	buffered, ok := d.Buffered().(*bytes.Reader)
+	if !ok {
+		return false
+	}
+	for buffered.Len() > 0 {
+		c, _ := buffered.ReadByte()
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// swapReader is an io.Reader whose underlying reader can be swapped.
+type swapReader struct {
+	io.Reader
+}
+
+func (c *Codec) poolIndex() int {
+	var index int
+	if c.UseNumber {
+		index |= 1
+	}
+	if c.DisallowUnknownFields {
+		index |= 2
+	}
+	return index
+}
+
+// AcquireDecoder returns a pooled Decoder which reads byte sequence from "r",
+// it behaves the same as the Decoder returned by NewDecoder.
+func (c *Codec) AcquireDecoder(r io.Reader) codec.Decoder {
+	index := c.poolIndex()
+	if d, ok := decoderPools[index].Get().(*pooledDecoder); ok {
+		d.r.Reader = r
+		return d
+	}
+	d := &pooledDecoder{index: index}
+	d.r.Reader = r
+	d.Decoder = json.NewDecoder(&d.r)
+	if c.UseNumber {
+		d.Decoder.UseNumber()
+	}
+	if c.DisallowUnknownFields {
+		d.Decoder.DisallowUnknownFields()
+	}
+	return d
+}
+
+// ReleaseDecoder returns the Decoder acquired by AcquireDecoder to the pool.
+func (*Codec) ReleaseDecoder(dec codec.Decoder) {
+	d, ok := dec.(*pooledDecoder)
+	if !ok {
+		return
+	}
+	d.r.Reader = nil
+	if d.reusable() {
+		decoderPools[d.index].Put(d)
+	}
+}
@@ -0,0 +1,112 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Codec_AcquireDecoder(t *testing.T) {
+	c := &Codec{UseNumber: true, DisallowUnknownFields: true}
+
+	t.Run("options", func(t *testing.T) {
+		d := c.AcquireDecoder(strings.NewReader(`{"id":1}`))
+		defer c.ReleaseDecoder(d)
+		var got map[string]any
+		require.NoError(t, d.Decode(&got))
+		require.Equal(t, map[string]any{"id": json.Number("1")}, got)
+
+		d2 := c.AcquireDecoder(strings.NewReader(`{"id":1,"unknown":2}`))
+		defer c.ReleaseDecoder(d2)
+		require.Error(t, d2.Decode(&struct {
+			Id int `json:"id"`
+		}{}))
+	})
+	t.Run("partially consumed body is not reused", func(t *testing.T) {
+		d := c.AcquireDecoder(strings.NewReader(`{"id":"a"} {"name":"b"}`))
+		var first map[string]any
+		require.NoError(t, d.Decode(&first))
+		require.False(t, d.(*pooledDecoder).reusable())
+		c.ReleaseDecoder(d)
+
+		d = c.AcquireDecoder(strings.NewReader(`{"id":"c"}` + "\n"))
+		var second map[string]any
+		require.NoError(t, d.Decode(&second))
+		require.Equal(t, map[string]any{"id": "c"}, second)
+		require.True(t, d.(*pooledDecoder).reusable())
+		c.ReleaseDecoder(d)
+	})
+	t.Run("failed decoder is not reused", func(t *testing.T) {
+		d := c.AcquireDecoder(strings.NewReader(`{"id":`))
+		require.Error(t, d.Decode(&map[string]any{}))
+		require.False(t, d.(*pooledDecoder).reusable())
+		c.ReleaseDecoder(d)
+	})
+}
+
+func decodeFresh(c *Codec, data []byte) (any, error) {
+	var v any
+	err := c.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+func decodePooled(c *Codec, data []byte) (any, error) {
+	var v any
+	d := c.AcquireDecoder(bytes.NewReader(data))
+	defer c.ReleaseDecoder(d)
+	err := d.Decode(&v)
+	return v, err
+}
+
+func Fuzz_Codec_AcquireDecoder(f *testing.F) {
+	f.Add([]byte(`{"id":"a"}`), []byte(`{"name":"b"}`))
+	f.Add([]byte(`{"id":"a"}{"id":"b"}`), []byte(`[1,2,3]`))
+	f.Add([]byte(`1 2 3`), []byte(`4`))
+	f.Add([]byte(`"abc`), []byte(`"def"`))
+	f.Add([]byte(`{"id":"a"}   `), []byte(``))
+	f.Add([]byte(`[1, {"a": [true, null]}]`+"\n"), []byte(`  {"b":1.5} x`))
+	f.Fuzz(func(t *testing.T, first, second []byte) {
+		c := &Codec{UseNumber: true}
+		// the first body may be partially consumed, or failed.
+		_, _ = decodePooled(c, first)
+
+		want, wantErr := decodeFresh(c, second)
+		got, err := decodePooled(c, second)
+		if (err != nil) != (wantErr != nil) {
+			t.Fatalf("decode %q after %q: error = %v, want %v", second, first, err, wantErr)
+		}
+		if err == nil && !reflect.DeepEqual(got, want) {
+			t.Fatalf("decode %q after %q: got = %#v, want %#v", second, first, got, want)
+		}
+	})
+}
+
+func Benchmark_Codec_Decoder(b *testing.B) {
+	c := &Codec{UseNumber: true}
+	data := []byte(`{"id":"foo","name":"bar"}`)
+	type model struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	b.Run("NewDecoder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = c.NewDecoder(bytes.NewReader(data)).Decode(&model{})
+		}
+	})
+	b.Run("AcquireDecoder", func(b *testing.B) {
+		b.ReportAllocs()
+		r := bytes.NewReader(data)
+		for i := 0; i < b.N; i++ {
+			r.Reset(data)
+			d := c.AcquireDecoder(io.Reader(r))
+			_ = d.Decode(&model{})
+			c.ReleaseDecoder(d)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// SchemaValidator validates the raw JSON document, like a compiled JSON Schema.
+// It should return a *SchemaError with the violations, other errors are wrapped into a *SchemaError.
+//
+// A santhosh-tekuri/jsonschema schema can be adapted like:
+//
+//	type schemaValidator struct{ *jsonschema.Schema }
+//
+//	func (s schemaValidator) Validate(data []byte) error {
+//		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+//		if err != nil {
+//			return err
+//		}
+//		var ve *jsonschema.ValidationError
+//		if err = s.Schema.Validate(doc); !errors.As(err, &ve) {
+//			return err
+//		}
+//		e := &json.SchemaError{Err: err}
+//		for _, unit := range ve.BasicOutput().Errors {
+//			if unit.Error != nil {
+//				e.Violations = append(e.Violations, json.SchemaViolation{Pointer: unit.InstanceLocation, Message: unit.Error.String()})
+//			}
+//		}
+//		return e
+//	}
+type SchemaValidator interface {
+	Validate(data []byte) error
+}
+
+// SchemaValidatorFunc is an adapter to allow the use of ordinary functions as SchemaValidator.
+type SchemaValidatorFunc func(data []byte) error
+
+// Validate calls f(data).
+func (f SchemaValidatorFunc) Validate(data []byte) error { return f(data) }
+
+// SchemaViolation is a violation of the JSON Schema.
+type SchemaViolation struct {
+	// Pointer is the JSON Pointer of the violating value in the document, like "/items/0/name".
+	Pointer string `json:"pointer"`
+	// Message is the description of the violation.
+	Message string `json:"message"`
+}
+
+// SchemaError is returned by Unmarshal and Decode when the document does not validate against the schema.
+type SchemaError struct {
+	// Violations is the violations of the schema, if the validator reports them.
+	Violations []SchemaViolation
+	// Err is the underlying error of the validator.
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	if len(e.Violations) == 0 {
+		if e.Err == nil {
+			return "json: schema validation failed"
+		}
+		return "json: schema validation failed: " + e.Err.Error()
+	}
+	var b strings.Builder
+	b.WriteString("json: schema validation failed: ")
+	for i, v := range e.Violations {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		if v.Pointer != "" {
+			b.WriteString(v.Pointer)
+			b.WriteString(": ")
+		}
+		b.WriteString(v.Message)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error of the validator.
+func (e *SchemaError) Unwrap() error { return e.Err }
+
+// StatusCode returns http.StatusBadRequest, the invalid document is the fault of the client.
+func (e *SchemaError) StatusCode() int { return http.StatusBadRequest }
+
+// Schemas is the SchemaValidator per target type, the Lookup method can be used as Codec.Schema:
+//
+//	schemas := json.Schemas{
+//		reflect.TypeOf(&OrderCreated{}): orderSchema,
+//		reflect.TypeOf(&UserDeleted{}):  userSchema,
+//	}
+//	c := &json.Codec{Schema: schemas.Lookup}
+type Schemas map[reflect.Type]SchemaValidator
+
+// Lookup returns the SchemaValidator of the type of "v", or of its element type if "v" is a pointer,
+// nil if there is none.
+func (s Schemas) Lookup(v any) SchemaValidator {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil
+	}
+	if validator, ok := s[t]; ok {
+		return validator
+	}
+	if t.Kind() == reflect.Pointer {
+		return s[t.Elem()]
+	}
+	return nil
+}
+
+// validate validates the raw document with the schema of "v".
+func (c *Codec) validate(data []byte, v any) error {
+	if c.Schema == nil {
+		return nil
+	}
+	validator := c.Schema(v)
+	if validator == nil {
+		return nil
+	}
+	err := validator.Validate(data)
+	if err == nil {
+		return nil
+	}
+	var schemaErr *SchemaError
+	if errors.As(err, &schemaErr) {
+		return schemaErr
+	}
+	return &SchemaError{Err: err}
+}
+
+// newValidatingDecoder returns a Decoder which buffers every document of the stream,
+// validates it, then decodes it into "v".
+func (c *Codec) newValidatingDecoder(r io.Reader) codec.Decoder {
+	decoder := json.NewDecoder(r)
+	return codec.DecoderFunc(func(v any) error {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return err
+		}
+		if err := c.validate(raw, v); err != nil {
+			return err
+		}
+		return c.newDecoder(bytes.NewReader(raw)).Decode(v)
+	})
+}
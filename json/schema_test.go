@@ -0,0 +1,98 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type orderCreated struct {
+	Id     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+type userDeleted struct {
+	User string `json:"user"`
+}
+
+// requiredSchema is a tiny schema which requires the string properties to be non-empty.
+type requiredSchema []string
+
+func (s requiredSchema) Validate(data []byte) error {
+	doc := map[string]any{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	e := &SchemaError{}
+	for _, name := range s {
+		if v, _ := doc[name].(string); v == "" {
+			e.Violations = append(e.Violations, SchemaViolation{Pointer: "/" + name, Message: fmt.Sprintf("%q is required", name)})
+		}
+	}
+	if len(e.Violations) > 0 {
+		return e
+	}
+	return nil
+}
+
+func TestCodec_Schema(t *testing.T) {
+	c := &Codec{
+		Schema: Schemas{
+			reflect.TypeOf(&orderCreated{}): requiredSchema{"id", "currency"},
+			reflect.TypeOf(userDeleted{}):   requiredSchema{"user"},
+		}.Lookup,
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		got := &orderCreated{}
+		require.NoError(t, c.Unmarshal([]byte(`{"id":"1","currency":"USD","amount":10}`), got))
+		require.Equal(t, &orderCreated{Id: "1", Amount: 10}, got)
+
+		// no schema for the type.
+		m := map[string]any{}
+		require.NoError(t, c.Unmarshal([]byte(`{}`), &m))
+	})
+	t.Run("multiple violations", func(t *testing.T) {
+		err := c.Unmarshal([]byte(`{"amount":10}`), &orderCreated{})
+		var schemaErr *SchemaError
+		require.ErrorAs(t, err, &schemaErr)
+		require.Equal(t, []SchemaViolation{
+			{Pointer: "/id", Message: `"id" is required`},
+			{Pointer: "/currency", Message: `"currency" is required`},
+		}, schemaErr.Violations)
+		require.Equal(t, http.StatusBadRequest, schemaErr.StatusCode())
+		require.Equal(t, `json: schema validation failed: /id: "id" is required; /currency: "currency" is required`, err.Error())
+	})
+	t.Run("schema per type", func(t *testing.T) {
+		require.NoError(t, c.Unmarshal([]byte(`{"user":"foo"}`), &userDeleted{}))
+		require.Error(t, c.Unmarshal([]byte(`{"id":"1","currency":"USD"}`), &userDeleted{}))
+	})
+	t.Run("decoder", func(t *testing.T) {
+		decoder := c.NewDecoder(strings.NewReader(`{"id":"1","currency":"USD"}
+{"id":"2"}`))
+		got := &orderCreated{}
+		require.NoError(t, decoder.Decode(got))
+		require.Equal(t, &orderCreated{Id: "1"}, got)
+
+		var schemaErr *SchemaError
+		require.ErrorAs(t, decoder.Decode(&orderCreated{}), &schemaErr)
+		require.Len(t, schemaErr.Violations, 1)
+	})
+	t.Run("validator error", func(t *testing.T) {
+		failed := errors.New("invalid document")
+		c := &Codec{Schema: func(any) SchemaValidator {
+			return SchemaValidatorFunc(func([]byte) error { return failed })
+		}}
+		err := c.Unmarshal([]byte(`{}`), &orderCreated{})
+		var schemaErr *SchemaError
+		require.ErrorAs(t, err, &schemaErr)
+		require.ErrorIs(t, err, failed)
+		require.Empty(t, schemaErr.Violations)
+	})
+}
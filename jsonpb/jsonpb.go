@@ -181,6 +181,18 @@ func (c *Codec) NewDecoder(r io.Reader) codec.Decoder {
 	}
 }
 
+var _ codec.ConfigurableDecoder = (*Codec)(nil)
+
+// WithDecoderOptions returns a copy of the Codec with the DiscardUnknown overridden by DisallowUnknownFields,
+// the UseNumber is ignored, protojson decodes the numbers by the field types.
+func (c *Codec) WithDecoderOptions(opts codec.DecoderOptions) codec.Marshaler {
+	cc := *c
+	if opts.DisallowUnknownFields != nil {
+		cc.DiscardUnknown = !*opts.DisallowUnknownFields
+	}
+	return &cc
+}
+
 // DecoderWrapper is a wrapper around a *json.Decoder that adds
 // support for protos to the Decode method.
 type DecoderWrapper struct {
@@ -17,6 +17,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	"github.com/thinkgos/encoding/codec"
 	"github.com/thinkgos/encoding/testdata/examplepb"
 )
 
@@ -551,6 +552,26 @@ func TestCodec_JSONPbDecoderUnknownField(t *testing.T) {
 	}
 }
 
+func TestCodec_WithDecoderOptions(t *testing.T) {
+	var (
+		m     = &Codec{}
+		allow = false
+		got   examplepb.ABitOfEverything
+	)
+	data := `{
+		"uuid": "6EC2446F-7E89-4127-B3E6-5C05E6BECBA7",
+		"unknownField": "111"
+	}`
+
+	dec := m.WithDecoderOptions(codec.DecoderOptions{DisallowUnknownFields: &allow}).NewDecoder(strings.NewReader(data))
+	if err := dec.Decode(&got); err != nil {
+		t.Errorf("dec.Decode(&got) failed with %v; want success; data=%q", err, data)
+	}
+	if m.DiscardUnknown {
+		t.Errorf("the receiver is modified: %v", m)
+	}
+}
+
 func TestCodec_UnmarshalNullField(t *testing.T) {
 	var out map[string]any
 
@@ -0,0 +1,139 @@
+// Package kit provides github.com/go-kit/kit/transport/http helpers
+// built on the github.com/thinkgos/encoding registry.
+//
+// EncodeResponseFunc and ErrorEncoder negotiate the response format with
+// the request's Accept header, go-kit does not pass the *http.Request to them,
+// so use PopulateRequest with httptransport.ServerBefore to make it available,
+// otherwise the Accept header populated by httptransport.PopulateRequestContext is used,
+// if both missing, the wildcard marshaler of the Encoding is used.
+//
+//	httptransport.NewServer(
+//		endpoint,
+//		kit.DecodeRequestFunc[pb.HelloRequest](reg),
+//		kit.EncodeResponseFunc(reg),
+//		httptransport.ServerBefore(kit.PopulateRequest),
+//		httptransport.ServerErrorEncoder(kit.ErrorEncoder(reg)),
+//	)
+package kit
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+
+	"github.com/thinkgos/encoding"
+)
+
+type requestCtxKey struct{}
+
+// PopulateRequest is a httptransport.RequestFunc which stores the *http.Request in the context.
+func PopulateRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestCtxKey{}, r)
+}
+
+// requestFromContext returns the request stored by PopulateRequest,
+// or a synthetic request carrying the Accept header stored by httptransport.PopulateRequestContext.
+func requestFromContext(ctx context.Context) *http.Request {
+	if r, ok := ctx.Value(requestCtxKey{}).(*http.Request); ok && r != nil {
+		return r
+	}
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if accept, ok := ctx.Value(httptransport.ContextKeyRequestAccept).(string); ok && accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	return r
+}
+
+// DecodeRequestFunc returns a httptransport.DecodeRequestFunc which binds the query
+// and the body (if any) of the request into a new *T with encoding.Encoding.BindAll,
+// so the value is validated once after all the sources are bound.
+func DecodeRequestFunc[T any](reg *encoding.Encoding) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (any, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		v := new(T)
+		if err := reg.BindAll(r, nil, v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// EncodeResponseFunc returns a httptransport.EncodeResponseFunc which renders the response
+// with the negotiated marshaler.
+//
+//   - If the response implements endpoint.Failer and failed, the error is returned,
+//     so the server's ErrorEncoder handles it.
+//   - If the response implements httptransport.Headerer, the provided headers will be applied.
+//   - If the response implements httptransport.StatusCoder, the provided status code
+//     will be used instead of 200.
+//   - A nil response, or status code 204, writes the status code without body.
+func EncodeResponseFunc(reg *encoding.Encoding) httptransport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response any) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if isNil(response) {
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		if f, ok := response.(endpoint.Failer); ok && f.Failed() != nil {
+			return f.Failed()
+		}
+		applyHeaders(w, response)
+		code := http.StatusOK
+		if sc, ok := response.(httptransport.StatusCoder); ok {
+			code = sc.StatusCode()
+		}
+		if code == http.StatusNoContent {
+			w.WriteHeader(code)
+			return nil
+		}
+		return reg.RenderStatus(w, requestFromContext(ctx), code, response)
+	}
+}
+
+// ErrorResponse is the body rendered by ErrorEncoder.
+type ErrorResponse = encoding.ErrorResponse
+
+// ErrorEncoder returns a httptransport.ErrorEncoder which renders the error as ErrorResponse
+// with the negotiated marshaler, see encoding.Encoding.RenderError.
+//
+//   - If the error implements httptransport.Headerer, the provided headers will be applied.
+//   - If the error implements httptransport.StatusCoder, the provided status code will be used,
+//     otherwise the status code is mapped from the error, see encoding.StatusCode.
+//   - If the negotiated marshaler can't marshal ErrorResponse, the error falls back
+//     to a plain text body.
+func ErrorEncoder(reg *encoding.Encoding) httptransport.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		applyHeaders(w, err)
+		_ = reg.RenderError(w, requestFromContext(ctx), err)
+	}
+}
+
+func applyHeaders(w http.ResponseWriter, v any) {
+	if headerer, ok := v.(httptransport.Headerer); ok {
+		for k, values := range headerer.Headers() {
+			for _, v := range values {
+				w.Header().Add(k, v)
+			}
+		}
+	}
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { // nolint: exhaustive
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
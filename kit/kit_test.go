@@ -0,0 +1,180 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding"
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+	"github.com/thinkgos/encoding/xml"
+)
+
+type helloRequest struct {
+	Id   string `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+type helloResponse struct {
+	Message string `json:"message" xml:"message"`
+	code    int
+	err     error
+}
+
+func (r *helloResponse) StatusCode() int      { return r.code }
+func (r *helloResponse) Headers() http.Header { return http.Header{"X-Hello": {"world"}} }
+func (r *helloResponse) Failed() error        { return r.err }
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string   { return "status error" }
+func (e *statusError) StatusCode() int { return e.code }
+
+func newServer(t *testing.T) *httptest.Server {
+	reg := encoding.New()
+	require.NoError(t, reg.Register(encoding.Mime_XML, &xml.Codec{}))
+	require.NoError(t, reg.Register(encoding.Mime_PROTOBUF, &pro.Codec{}))
+
+	hello := func(_ context.Context, request any) (any, error) {
+		req := request.(*helloRequest)
+		switch req.Name {
+		case "nil":
+			return nil, nil
+		case "error":
+			return nil, &statusError{code: http.StatusTeapot}
+		case "failed":
+			return &helloResponse{err: errors.New("failed")}, nil
+		default:
+			return &helloResponse{Message: req.Id + ":" + req.Name, code: http.StatusCreated}, nil
+		}
+	}
+	echo := func(_ context.Context, request any) (any, error) {
+		return request, nil
+	}
+	opts := []httptransport.ServerOption{
+		httptransport.ServerBefore(PopulateRequest),
+		httptransport.ServerErrorEncoder(ErrorEncoder(reg)),
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/hello", httptransport.NewServer(endpoint.Endpoint(hello), DecodeRequestFunc[helloRequest](reg), EncodeResponseFunc(reg), opts...))
+	mux.Handle("/echo", httptransport.NewServer(endpoint.Endpoint(echo), DecodeRequestFunc[examplepb.SimpleMessage](reg), EncodeResponseFunc(reg), opts...))
+	mux.Handle("/accept", httptransport.NewServer(
+		endpoint.Endpoint(hello),
+		DecodeRequestFunc[helloRequest](reg),
+		EncodeResponseFunc(reg),
+		httptransport.ServerBefore(httptransport.PopulateRequestContext),
+	))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, method, url, contentType, accept string, body []byte) (*http.Response, []byte) {
+	req, err := http.NewRequestWithContext(context.Background(), method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, b
+}
+
+func Test_Server(t *testing.T) {
+	srv := newServer(t)
+
+	t.Run("query and json body, json out", func(t *testing.T) {
+		resp, body := do(t, http.MethodPost, srv.URL+"/hello?id=1", encoding.Mime_JSON, encoding.Mime_JSON, []byte(`{"name":"foo"}`))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, "world", resp.Header.Get("X-Hello"))
+		require.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.Equal(t, `{"message":"1:foo"}`, string(body))
+	})
+	t.Run("get query, xml out", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, srv.URL+"/hello?id=1&name=bar", "", encoding.Mime_XML, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, "application/xml; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.Equal(t, `<helloResponse><message>1:bar</message></helloResponse>`, string(body))
+	})
+	t.Run("head keeps the status code", func(t *testing.T) {
+		resp, body := do(t, http.MethodHead, srv.URL+"/hello?id=1&name=bar", "", encoding.Mime_JSON, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, "world", resp.Header.Get("X-Hello"))
+		require.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.Empty(t, body)
+	})
+	t.Run("accept from PopulateRequestContext", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, srv.URL+"/accept?id=1&name=bar", "", encoding.Mime_XML, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, `<helloResponse><message>1:bar</message></helloResponse>`, string(body))
+	})
+	t.Run("nil response", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, srv.URL+"/hello?name=nil", "", "", nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Empty(t, body)
+	})
+	t.Run("endpoint error", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, srv.URL+"/hello?name=error", "", encoding.Mime_JSON, nil)
+		require.Equal(t, http.StatusTeapot, resp.StatusCode)
+		require.Equal(t, `{"code":418,"message":"status error"}`, string(body))
+	})
+	t.Run("failer response", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, srv.URL+"/hello?name=failed", "", encoding.Mime_XML, nil)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.Equal(t, `<ErrorResponse><code>500</code><message>failed</message></ErrorResponse>`, string(body))
+	})
+	t.Run("decode error, error can not marshal with proto", func(t *testing.T) {
+		resp, body := do(t, http.MethodPost, srv.URL+"/hello", encoding.Mime_JSON, encoding.Mime_PROTOBUF, []byte(`{"name":`))
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.NotEmpty(t, body)
+	})
+	t.Run("decode error is mapped to the status code", func(t *testing.T) {
+		resp, body := do(t, http.MethodPost, srv.URL+"/hello", "application/unknown", encoding.Mime_JSON, []byte(`{"name":`))
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, string(body), `"code":400`)
+	})
+	t.Run("proto in and out", func(t *testing.T) {
+		want := &examplepb.SimpleMessage{Id: "foo"}
+		b, err := proto.Marshal(want)
+		require.NoError(t, err)
+		resp, body := do(t, http.MethodPost, srv.URL+"/echo", encoding.Mime_PROTOBUF, encoding.Mime_PROTOBUF, b)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		got := &examplepb.SimpleMessage{}
+		require.NoError(t, proto.Unmarshal(body, got))
+		require.True(t, proto.Equal(want, got))
+	})
+}
+
+func Test_CanceledContext(t *testing.T) {
+	reg := encoding.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/?id=1", nil)
+	_, err := DecodeRequestFunc[helloRequest](reg)(ctx, req)
+	require.ErrorIs(t, err, context.Canceled)
+
+	w := httptest.NewRecorder()
+	err = EncodeResponseFunc(reg)(ctx, w, &helloResponse{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, w.Body.String())
+}
@@ -0,0 +1,20 @@
+module github.com/thinkgos/encoding/kratos
+
+go 1.23
+
+require (
+	github.com/go-kratos/kratos/v2 v2.9.1
+	github.com/stretchr/testify v1.11.1
+	github.com/thinkgos/encoding v0.0.0-00010101000000-000000000000
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/klauspost/compress v1.18.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/thinkgos/encoding => ../
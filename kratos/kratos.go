@@ -0,0 +1,117 @@
+// Package kratos adapts the codecs of github.com/thinkgos/encoding to
+// github.com/go-kratos/kratos/v2/encoding.Codec and vice versa,
+// so codecs configured once can be registered in both frameworks.
+//
+// Name mapping rules:
+//
+//   - ToKratos uses the given name, or derives it from the content type of the
+//     codec.Marshaler when the name is empty: the subtype of the media type, with
+//     the structured syntax suffix preferred and the "x-" prefix stripped,
+//     for example:
+//
+//     application/json; charset=utf-8 --> json
+//     application/x-protobuf          --> protobuf
+//     application/vnd.api+json        --> json
+//
+//   - FromKratos uses the given content type, or derives it as
+//     "application/" + Name() when the content type is empty,
+//     which is how kratos' http transport builds the Content-Type header.
+package kratos
+
+import (
+	"io"
+	"mime"
+	"strings"
+
+	kratosencoding "github.com/go-kratos/kratos/v2/encoding"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// Codec adapts a kratos encoding.Codec to codec.Marshaler.
+// The Decoder/Encoder are implemented by buffering the whole stream.
+type Codec struct {
+	kratosencoding.Codec
+	contentType string
+}
+
+// FromKratos returns a codec.Marshaler which delegates to c.
+// contentType is returned by ContentType, if empty it is "application/" + c.Name().
+func FromKratos(c kratosencoding.Codec, contentType string) codec.Marshaler {
+	if contentType == "" {
+		contentType = "application/" + c.Name()
+	}
+	return &Codec{
+		Codec:       c,
+		contentType: contentType,
+	}
+}
+
+// ContentType returns the content type which specified by FromKratos.
+func (c *Codec) ContentType(_ any) string {
+	return c.contentType
+}
+
+// NewDecoder returns a Decoder which reads the whole byte sequence from "r" and unmarshal it.
+func (c *Codec) NewDecoder(r io.Reader) codec.Decoder {
+	return codec.DecoderFunc(func(value any) error {
+		buffer, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return c.Unmarshal(buffer, value)
+	})
+}
+
+// NewEncoder returns an Encoder which marshals value and writes it into "w".
+func (c *Codec) NewEncoder(w io.Writer) codec.Encoder {
+	return codec.EncoderFunc(func(value any) error {
+		buffer, err := c.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buffer)
+		return err
+	})
+}
+
+// Marshaler adapts a codec.Marshaler to kratos encoding.Codec.
+type Marshaler struct {
+	codec.Marshaler
+	name string
+}
+
+// ToKratos returns a kratos encoding.Codec which delegates to m.
+// name is returned by Name, if empty it is derived from m's content type.
+func ToKratos(m codec.Marshaler, name string) kratosencoding.Codec {
+	if name == "" {
+		name = NameFromContentType(m.ContentType(nil))
+	}
+	return &Marshaler{
+		Marshaler: m,
+		name:      strings.ToLower(name),
+	}
+}
+
+// Name returns the name of the codec, which is used as the kratos content subtype.
+func (m *Marshaler) Name() string {
+	return m.name
+}
+
+// NameFromContentType derives the kratos codec name from a content type.
+// It returns the subtype of the media type, with the structured syntax
+// suffix preferred and the "x-" prefix stripped.
+func NameFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	_, subtype, found := strings.Cut(mediaType, "/")
+	if !found {
+		subtype = mediaType
+	}
+	if idx := strings.LastIndexByte(subtype, '+'); idx >= 0 {
+		subtype = subtype[idx+1:]
+	}
+	return strings.TrimPrefix(subtype, "x-")
+}
@@ -0,0 +1,83 @@
+package kratos
+
+import (
+	"bytes"
+	"testing"
+
+	kratosencoding "github.com/go-kratos/kratos/v2/encoding"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding/jsonpb"
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func Test_NameFromContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/json; charset=utf-8", "json"},
+		{"application/x-protobuf", "protobuf"},
+		{"application/x-msgpack; charset=utf-8", "msgpack"},
+		{"application/vnd.api+json", "json"},
+		{"Application/XML", "xml"},
+		{"yaml", "yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			require.Equal(t, tt.want, NameFromContentType(tt.contentType))
+		})
+	}
+}
+
+func Test_ToKratos(t *testing.T) {
+	strict := &jsonpb.Codec{
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: false},
+	}
+	c := ToKratos(strict, "")
+	require.Equal(t, "json", c.Name())
+	require.Equal(t, "strict-json", ToKratos(strict, "Strict-JSON").Name())
+
+	kratosencoding.RegisterCodec(ToKratos(&pro.Codec{}, "thinkgos-proto"))
+	got := kratosencoding.GetCodec("thinkgos-proto")
+	require.NotNil(t, got)
+
+	want := &examplepb.SimpleMessage{Id: "foo"}
+	b, err := got.Marshal(want)
+	require.NoError(t, err)
+	msg := &examplepb.SimpleMessage{}
+	require.NoError(t, got.Unmarshal(b, msg))
+	require.True(t, proto.Equal(want, msg))
+
+	t.Run("options are kept", func(t *testing.T) {
+		kratosencoding.RegisterCodec(ToKratos(strict, "thinkgos-json"))
+		c := kratosencoding.GetCodec("thinkgos-json")
+		err := c.Unmarshal([]byte(`{"id":"foo","unknown":1}`), &examplepb.SimpleMessage{})
+		require.Error(t, err)
+	})
+}
+
+func Test_FromKratos(t *testing.T) {
+	kratosencoding.RegisterCodec(ToKratos(&pro.Codec{}, "thinkgos-proto"))
+
+	m := FromKratos(kratosencoding.GetCodec("thinkgos-proto"), "")
+	require.Equal(t, "application/thinkgos-proto", m.ContentType(nil))
+	m = FromKratos(kratosencoding.GetCodec("thinkgos-proto"), "application/x-protobuf")
+	require.Equal(t, "application/x-protobuf", m.ContentType(nil))
+
+	want := &examplepb.SimpleMessage{Id: "foo"}
+	b, err := m.Marshal(want)
+	require.NoError(t, err)
+	got := &examplepb.SimpleMessage{}
+	require.NoError(t, m.Unmarshal(b, got))
+	require.True(t, proto.Equal(want, got))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, m.NewEncoder(buf).Encode(want))
+	got = &examplepb.SimpleMessage{}
+	require.NoError(t, m.NewDecoder(buf).Decode(got))
+	require.True(t, proto.Equal(want, got))
+}
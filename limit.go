@@ -0,0 +1,84 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge is returned by Bind when the request body exceeds the limit of WithMaxBodyBytes
+// or BindWithLimit, the caller should respond with 413 Content Too Large.
+var ErrBodyTooLarge = errors.New("encoding: request body too large")
+
+// WithMaxBodyBytes limits the size of the request body of Bind, BindAll and BindAny to n bytes,
+// for all the codecs including the multipart form, it is unlimited if n <= 0, which is the default.
+// Bind returns ErrBodyTooLarge if the body exceeds the limit, or its Content-Length does.
+// see BindWithLimit to override the limit per call, like the upload endpoints.
+func WithMaxBodyBytes(n int64) Option {
+	return func(r *Encoding) {
+		r.maxBodyBytes = n
+	}
+}
+
+// BindWithLimit is like Bind, but limits the size of the request body to n bytes instead of
+// the limit of WithMaxBodyBytes, it is unlimited if n <= 0.
+func (r *Encoding) BindWithLimit(req *http.Request, v any, n int64, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindWithLimit(req, v, n, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+// limitBody limits the request body to limit bytes if limit > 0, the returned done restores
+// the body and replaces the error of the bind with ErrBodyTooLarge if the body exceeds the limit,
+// since the decoders may hide the error of the reader, like "unexpected EOF".
+func limitBody(req *http.Request, limit int64) (done func(error) error) {
+	if limit <= 0 || req.Body == nil || req.Body == http.NoBody {
+		return noLimit
+	}
+	body := &limitedReadCloser{
+		ReadCloser: req.Body,
+		n:          limit,
+		exceeded:   req.ContentLength > limit,
+	}
+	req.Body = body
+	return func(err error) error {
+		// the body may be replaced by the preserved one, see WithPreserveBody.
+		if req.Body == body {
+			req.Body = body.ReadCloser
+		}
+		if body.exceeded {
+			return fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, limit)
+		}
+		return err
+	}
+}
+
+func noLimit(err error) error { return err }
+
+// limitedReadCloser is the body which fails once it reads more than n bytes.
+type limitedReadCloser struct {
+	io.ReadCloser
+	n        int64 // the remaining bytes.
+	exceeded bool
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	if int64(n) > l.n {
+		l.exceeded = true
+		n, l.n = int(l.n), 0
+		return n, ErrBodyTooLarge
+	}
+	l.n -= int64(n)
+	return n, err
+}
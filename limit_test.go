@@ -0,0 +1,66 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithMaxBodyBytes(t *testing.T) {
+	body := `{"id":"foo","name":"` + strings.Repeat("x", 64) + `"}`
+	newRequest := func(contentLength int64) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", Mime_JSON)
+		req.ContentLength = contentLength
+		return req
+	}
+	var bindErrors int
+	registry := New(WithMaxBodyBytes(32), WithOnBindError(func(*http.Request, error) { bindErrors++ }))
+
+	// the known and the unknown length.
+	require.ErrorIs(t, registry.Bind(newRequest(int64(len(body))), &TestMode{}), ErrBodyTooLarge)
+	require.ErrorIs(t, registry.Bind(newRequest(-1), &TestMode{}), ErrBodyTooLarge)
+	_, err := registry.BindAny(newRequest(-1))
+	require.ErrorIs(t, err, ErrBodyTooLarge)
+	require.Equal(t, 3, bindErrors)
+
+	// the per-call override.
+	got := &TestMode{}
+	require.NoError(t, registry.BindWithLimit(newRequest(-1), got, int64(len(body))))
+	require.Equal(t, "foo", got.Id)
+	require.NoError(t, registry.BindWithLimit(newRequest(-1), &TestMode{}, 0))
+	require.ErrorIs(t, New().BindWithLimit(newRequest(-1), &TestMode{}, 8), ErrBodyTooLarge)
+
+	// unlimited by default, and the body is restored.
+	require.NoError(t, New().Bind(newRequest(-1), &TestMode{}))
+	req := newRequest(-1)
+	rawBody := req.Body
+	require.NoError(t, New(WithMaxBodyBytes(1024)).Bind(req, &TestMode{}))
+	require.Equal(t, rawBody, req.Body)
+
+	// the query is not limited.
+	req = httptest.NewRequest(http.MethodGet, "http://example.com?id="+strings.Repeat("x", 64), nil)
+	require.NoError(t, registry.Bind(req, &TestMode{}))
+
+	// the multipart form.
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	require.NoError(t, mw.WriteField("id", strings.Repeat("x", 64)))
+	require.NoError(t, mw.Close())
+	newMultipart := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", io.NopCloser(bytes.NewReader(buf.Bytes())))
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.ContentLength = -1
+		return req
+	}
+	require.ErrorIs(t, registry.Bind(newMultipart(), &TestMode{}), ErrBodyTooLarge)
+	got = &TestMode{}
+	require.NoError(t, registry.BindWithLimit(newMultipart(), got, int64(buf.Len())))
+	require.Equal(t, strings.Repeat("x", 64), got.Id)
+}
@@ -0,0 +1,67 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrUnsupportedMediaType is the error which UnsupportedMediaTypeError matches with errors.Is,
+// the caller should respond with 415 Unsupported Media Type.
+var ErrUnsupportedMediaType = errors.New("encoding: unsupported media type")
+
+// UnsupportedMediaTypeError is returned by Bind in strict mode when the request `Content-Type`
+// is not registered, or it is missing, see WithStrictContentType and WithRequireContentType.
+type UnsupportedMediaTypeError struct {
+	// ContentType is the offending `Content-Type` header, it is empty if the header is missing.
+	ContentType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	if e.ContentType == "" {
+		return ErrUnsupportedMediaType.Error() + ": missing Content-Type"
+	}
+	return ErrUnsupportedMediaType.Error() + ": " + e.ContentType
+}
+
+// Is reports whether the target is ErrUnsupportedMediaType.
+func (e *UnsupportedMediaTypeError) Is(target error) bool {
+	return target == ErrUnsupportedMediaType
+}
+
+// WithStrictContentType makes Bind return *UnsupportedMediaTypeError if the request `Content-Type`
+// is present but not registered, instead of falling back to the "*" Marshaler,
+// the missing `Content-Type` still falls back unless WithRequireContentType is set.
+// The MIME type override of WithInboundMIME and BindWith are not affected.
+func WithStrictContentType() Option {
+	return func(r *Encoding) {
+		r.strictContentType = true
+	}
+}
+
+// WithRequireContentType makes Bind return *UnsupportedMediaTypeError if the request has a body
+// but no `Content-Type`, instead of falling back to the "*" Marshaler,
+// the request without a body, which some clients send without `Content-Type`, is not affected.
+func WithRequireContentType() Option {
+	return func(r *Encoding) {
+		r.requireContentType = true
+	}
+}
+
+// checkContentType returns *UnsupportedMediaTypeError if the inbound negotiation falls back
+// to the "*" Marshaler in strict mode.
+func (r *Encoding) checkContentType(req *http.Request, n Negotiation) error {
+	if !n.Fallback() || (!r.strictContentType && !r.requireContentType) {
+		return nil
+	}
+	contentType := req.Header.Get(contentTypeHeader)
+	if contentType == "" {
+		if r.requireContentType && hasBody(req) {
+			return &UnsupportedMediaTypeError{}
+		}
+		return nil
+	}
+	if r.strictContentType {
+		return &UnsupportedMediaTypeError{ContentType: contentType}
+	}
+	return nil
+}
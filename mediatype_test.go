@@ -0,0 +1,70 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithStrictContentType(t *testing.T) {
+	newRequest := func(body, contentType string) *http.Request {
+		var req *http.Request
+		if body == "" {
+			req = httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+		} else {
+			req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return req
+	}
+	strict := New(WithStrictContentType())
+	required := New(WithRequireContentType())
+	both := New(WithStrictContentType(), WithRequireContentType())
+
+	for _, tt := range []struct {
+		name     string
+		encoding *Encoding
+		req      *http.Request
+		wantErr  bool
+		want     string
+	}{
+		{"strict, unregistered", strict, newRequest(`{"id":"foo"}`, "application/graphql"), true, "application/graphql"},
+		{"strict, registered", strict, newRequest(`{"id":"foo"}`, Mime_JSON), false, ""},
+		{"strict, missing", strict, newRequest(`{"id":"foo"}`, ""), false, ""},
+		{"required, missing", required, newRequest(`{"id":"foo"}`, ""), true, ""},
+		{"required, unregistered", required, newRequest(`{"id":"foo"}`, "application/graphql"), false, ""},
+		{"both, unregistered", both, newRequest(`{"id":"foo"}`, "application/graphql"), true, "application/graphql"},
+		{"both, missing", both, newRequest(`{"id":"foo"}`, ""), true, ""},
+		{"lenient", New(), newRequest(`{"id":"foo"}`, "application/graphql"), false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.encoding.Bind(tt.req, &TestMode{})
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrUnsupportedMediaType)
+			var e *UnsupportedMediaTypeError
+			require.True(t, errors.As(err, &e))
+			require.Equal(t, tt.want, e.ContentType)
+		})
+	}
+
+	// the request without a body is not affected.
+	require.NoError(t, New(WithRequireContentType(), WithAllowEmptyBody()).Bind(newRequest("", ""), &TestMode{}))
+
+	// the MIME type override is not affected.
+	req := newRequest(`{"id":"foo"}`, "application/graphql")
+	req = req.WithContext(WithInboundMIME(req.Context(), Mime_JSON))
+	require.NoError(t, both.Bind(req, &TestMode{}))
+	require.NoError(t, both.BindWith(newRequest(`{"id":"foo"}`, "application/graphql"), &TestMode{}, Mime_JSON))
+
+	require.EqualError(t, &UnsupportedMediaTypeError{ContentType: "text/csv"}, "encoding: unsupported media type: text/csv")
+	require.EqualError(t, &UnsupportedMediaTypeError{}, "encoding: unsupported media type: missing Content-Type")
+}
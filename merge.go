@@ -0,0 +1,40 @@
+package encoding
+
+import (
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Merge merges the body into the proto.Message target for this call instead of resetting it,
+// like the message pre-populated with the route defaults. The body is decoded into a new message
+// of the same type, then merged by proto.Merge, so the fields which the body does not mention are kept:
+//
+//	scalar field    --> overwritten if it is populated in the body
+//	repeated field  --> appended
+//	map field       --> merged by key
+//	message field   --> merged recursively
+//
+// The target is not modified if the body fails to decode. The non proto.Message target is decoded
+// as is, which follows the natural merge behavior of the decoder, like encoding/json keeps the absent
+// struct fields, replaces the slices and merges the maps. The query is always merged by the form codecs.
+func Merge() BindOption {
+	return func(o *bindOptions) {
+		o.merge = true
+	}
+}
+
+// decodeMerged decodes the body of the request into v, or into a new message which is merged into v
+// if Merge is set and v is a proto.Message.
+func (r *Encoding) decodeMerged(req *http.Request, n Negotiation, v any, o *bindOptions) error {
+	m, ok := v.(proto.Message)
+	if !ok || o == nil || !o.merge {
+		return r.decodeNegotiated(req, n, v, o)
+	}
+	body := m.ProtoReflect().New().Interface()
+	if err := r.decodeNegotiated(req, n, body, o); err != nil {
+		return err
+	}
+	proto.Merge(m, body)
+	return nil
+}
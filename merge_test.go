@@ -0,0 +1,79 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding/jsonpb"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func Test_Encoding_Bind_Merge(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", Mime_JSON)
+		return req
+	}
+	defaults := func() *examplepb.ABitOfEverything {
+		return &examplepb.ABitOfEverything{
+			Uuid:                "keep",
+			StringValue:         "default",
+			RepeatedStringValue: []string{"a"},
+			SingleNested:        &examplepb.ABitOfEverything_Nested{Name: "nested", Amount: 1},
+			MappedStringValue:   map[string]string{"env": "prod"},
+		}
+	}
+	const body = `{"stringValue":"new","repeatedStringValue":["b"],"singleNested":{"amount":2},"mappedStringValue":{"tier":"web"}}`
+	registry := New()
+	require.NoError(t, registry.Register(Mime_JSON, &jsonpb.Codec{}))
+
+	t.Run("merge", func(t *testing.T) {
+		got := defaults()
+		require.NoError(t, registry.Bind(newRequest(body), got, Merge()))
+		want := &examplepb.ABitOfEverything{
+			Uuid:                "keep",                                                        // kept
+			StringValue:         "new",                                                         // overwritten
+			RepeatedStringValue: []string{"a", "b"},                                            // appended
+			SingleNested:        &examplepb.ABitOfEverything_Nested{Name: "nested", Amount: 2}, // merged
+			MappedStringValue:   map[string]string{"env": "prod", "tier": "web"},
+		}
+		require.True(t, proto.Equal(want, got), got.String())
+	})
+	t.Run("reset", func(t *testing.T) {
+		got := defaults()
+		require.NoError(t, registry.Bind(newRequest(body), got))
+		want := &examplepb.ABitOfEverything{
+			StringValue:         "new",
+			RepeatedStringValue: []string{"b"}, // replaced
+			SingleNested:        &examplepb.ABitOfEverything_Nested{Amount: 2},
+			MappedStringValue:   map[string]string{"tier": "web"},
+		}
+		require.True(t, proto.Equal(want, got), got.String())
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		got := defaults()
+		require.Error(t, registry.Bind(newRequest(`{"stringValue":1}`), got, Merge()))
+		require.True(t, proto.Equal(defaults(), got))
+	})
+	t.Run("bind all", func(t *testing.T) {
+		req := newRequest(`{"repeatedStringValue":["b"]}`)
+		req.URL.RawQuery = url.Values{"string_value": {"query"}}.Encode()
+		got := defaults()
+		require.NoError(t, registry.BindAll(req, nil, got, Merge()))
+		require.Equal(t, "query", got.StringValue)
+		require.Equal(t, []string{"a", "b"}, got.RepeatedStringValue)
+		require.Equal(t, "nested", got.SingleNested.Name)
+	})
+	t.Run("struct", func(t *testing.T) {
+		// the decoder of the struct keeps the absent fields anyway.
+		got := &TestMode{Id: "keep"}
+		require.NoError(t, New().Bind(newRequest(`{"name":"new"}`), got, Merge()))
+		require.Equal(t, &TestMode{Id: "keep", Name: "new"}, got)
+	})
+}
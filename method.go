@@ -0,0 +1,59 @@
+package encoding
+
+import (
+	"net/http"
+	"strings"
+)
+
+var methodOverrideHeader = http.CanonicalHeaderKey("X-HTTP-Method-Override")
+
+// methodOverrideField is the form field of the method override, like `_method=DELETE`.
+const methodOverrideField = "_method"
+
+// overridableMethods is the whitelist of the method overrides, the others are ignored.
+var overridableMethods = map[string]string{
+	"GET":     http.MethodGet,
+	"HEAD":    http.MethodHead,
+	"PUT":     http.MethodPut,
+	"PATCH":   http.MethodPatch,
+	"DELETE":  http.MethodDelete,
+	"OPTIONS": http.MethodOptions,
+}
+
+// WithMethodOverride makes Bind honor the method override of the POST request, like the clients behind
+// the restrictive proxies which send `POST` with `X-HTTP-Method-Override: DELETE` and the parameters in the query,
+// so the overridden method decides between the query and the body as if it were the method of the request.
+// The `X-HTTP-Method-Override` header comes first, then the `_method` field of the query,
+// or of the form which is parsed already, like `_method=DELETE`.
+// The override must be one of GET, HEAD, PUT, PATCH, DELETE and OPTIONS, case-insensitive, otherwise it is ignored.
+// req.Method is not modified.
+func WithMethodOverride() Option {
+	return func(r *Encoding) {
+		r.methodOverride = true
+	}
+}
+
+// bindMethod returns the method of the request which decides between the query and the body,
+// it is the method override if WithMethodOverride is set.
+func (r *Encoding) bindMethod(req *http.Request) string {
+	if !r.methodOverride || req.Method != http.MethodPost {
+		return req.Method
+	}
+	if method, ok := methodOverride(req); ok {
+		return method
+	}
+	return req.Method
+}
+
+// methodOverride returns the whitelisted method override of the request.
+func methodOverride(req *http.Request) (string, bool) {
+	value := req.Header.Get(methodOverrideHeader)
+	if value == "" && req.URL != nil {
+		value = req.URL.Query().Get(methodOverrideField)
+	}
+	if value == "" && req.PostForm != nil {
+		value = req.PostForm.Get(methodOverrideField)
+	}
+	method, ok := overridableMethods[strings.ToUpper(strings.TrimSpace(value))]
+	return method, ok
+}
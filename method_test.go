@@ -0,0 +1,79 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithMethodOverride(t *testing.T) {
+	newRequest := func(method, target, body string) *http.Request {
+		var req *http.Request
+		if body == "" {
+			req = httptest.NewRequest(method, target, nil)
+		} else {
+			req = httptest.NewRequest(method, target, strings.NewReader(body))
+			req.Header.Set("Content-Type", Mime_JSON)
+		}
+		return req
+	}
+	registry := New(WithMethodOverride())
+
+	t.Run("override to GET", func(t *testing.T) {
+		for _, override := range []string{"GET", "get", " Get "} {
+			req := newRequest(http.MethodPost, "http://example.com?id=foo&name=bar", "")
+			req.Header.Set("X-HTTP-Method-Override", override)
+			got := &TestMode{}
+			require.NoError(t, registry.Bind(req, got))
+			require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+			require.Equal(t, http.MethodPost, req.Method)
+		}
+	})
+	t.Run("override to DELETE with the query field", func(t *testing.T) {
+		req := newRequest(http.MethodPost, "http://example.com?_method=DELETE&id=foo", "")
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(req, got))
+		require.Equal(t, &TestMode{Id: "foo"}, got)
+	})
+	t.Run("override with the parsed form field", func(t *testing.T) {
+		req := newRequest(http.MethodPost, "http://example.com?id=foo", "")
+		req.PostForm = url.Values{"_method": {"GET"}}
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(req, got))
+		require.Equal(t, &TestMode{Id: "foo"}, got)
+	})
+	t.Run("override to PATCH", func(t *testing.T) {
+		req := newRequest(http.MethodPost, "http://example.com?id=query", `{"id":"foo","name":"bar"}`)
+		req.Header.Set("X-HTTP-Method-Override", http.MethodPatch)
+		got := &TestMode{}
+		require.NoError(t, registry.Bind(req, got))
+		require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+		require.Equal(t, http.MethodPost, req.Method)
+	})
+	t.Run("ignored", func(t *testing.T) {
+		for _, override := range []string{"TRACE", "CONNECT", "FOO", ""} {
+			req := newRequest(http.MethodPost, "http://example.com?id=foo", "")
+			req.Header.Set("X-HTTP-Method-Override", override)
+			require.ErrorIs(t, registry.Bind(req, &TestMode{}), ErrEmptyBody, override)
+		}
+		// only the POST request is overridden.
+		req := newRequest(http.MethodPut, "http://example.com?id=foo", "")
+		req.Header.Set("X-HTTP-Method-Override", http.MethodGet)
+		require.ErrorIs(t, registry.Bind(req, &TestMode{}), ErrEmptyBody)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		req := newRequest(http.MethodPost, "http://example.com?id=foo", "")
+		req.Header.Set("X-HTTP-Method-Override", http.MethodGet)
+		require.ErrorIs(t, New().Bind(req, &TestMode{}), ErrEmptyBody)
+
+		req = newRequest(http.MethodPost, "http://example.com?id=foo", "")
+		req.Header.Set("X-HTTP-Method-Override", http.MethodGet)
+		got := &TestMode{}
+		require.NoError(t, registry.Clone().Bind(req, got))
+		require.Equal(t, &TestMode{Id: "foo"}, got)
+	})
+}
@@ -0,0 +1,40 @@
+package encoding
+
+import (
+	"io"
+)
+
+// Sink receives the metrics of the Encoding, it must be safe for concurrent use.
+// see github.com/thinkgos/encoding/expvarsink for an expvar backed implementation.
+type Sink interface {
+	// IncBind is called when Bind or BindQuery finished, mime is the negotiated MIME type,
+	// the structured syntax suffix like "application/vnd.myapp+json" is keyed by the registered
+	// "application/json", Mime_Query for the query, bytes is the size of the body or the raw query read.
+	IncBind(mime string, bytes int, err bool)
+	// IncRender is called when Render finished, mime is the negotiated MIME type keyed like IncBind,
+	// bytes is the size of the body written.
+	IncRender(mime string, bytes int, err bool)
+	// IncFallback is called when the negotiation falls back to the "*" Marshaler,
+	// inbound is true for the `Content-Type` negotiation, false for the `Accept` negotiation.
+	IncFallback(inbound bool)
+}
+
+// WithMetrics sets the metrics sink, Bind, BindQuery, Render and the negotiation
+// report to it.
+func WithMetrics(sink Sink) Option {
+	return func(r *Encoding) {
+		r.metrics = sink
+	}
+}
+
+// countingReadCloser counts the bytes read.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += n
+	return n, err
+}
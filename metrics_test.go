@@ -0,0 +1,142 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	requests int
+	bytes    int
+	errors   int
+}
+
+// recordingSink records the metrics in memory.
+type recordingSink struct {
+	mu        sync.Mutex
+	bind      map[string]counter
+	render    map[string]counter
+	fallbacks [2]int // [outbound, inbound]
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{
+		bind:   make(map[string]counter),
+		render: make(map[string]counter),
+	}
+}
+
+func (s *recordingSink) IncBind(mime string, bytes int, err bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bind[mime] = s.bind[mime].add(bytes, err)
+}
+
+func (s *recordingSink) IncRender(mime string, bytes int, err bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.render[mime] = s.render[mime].add(bytes, err)
+}
+
+func (s *recordingSink) IncFallback(inbound bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inbound {
+		s.fallbacks[1]++
+	} else {
+		s.fallbacks[0]++
+	}
+}
+
+func (c counter) add(bytes int, err bool) counter {
+	c.requests++
+	c.bytes += bytes
+	if err {
+		c.errors++
+	}
+	return c
+}
+
+func total(m map[string]counter) counter {
+	var t counter
+	for _, c := range m {
+		t.requests += c.requests
+		t.bytes += c.bytes
+		t.errors += c.errors
+	}
+	return t
+}
+
+func Test_WithMetrics(t *testing.T) {
+	sink := newRecordingSink()
+	registry := New(WithMetrics(sink))
+
+	body := `{"id":"foo","name":"bar"}`
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+	req.Header.Set("Content-Type", Mime_JSON)
+	require.NoError(t, registry.Bind(req, &TestMode{}))
+	require.Equal(t, counter{requests: 1, bytes: len(body)}, sink.bind[Mime_JSON])
+
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{`))
+	req.Header.Set("Content-Type", "application/unknown")
+	require.Error(t, registry.Bind(req, &TestMode{}))
+	require.Equal(t, counter{requests: 1, bytes: 1, errors: 1}, sink.bind[Mime_Wildcard])
+	require.Equal(t, [2]int{0, 1}, sink.fallbacks)
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com?id=foo", nil)
+	require.NoError(t, registry.Bind(req, &TestMode{}))
+	require.Equal(t, counter{requests: 1, bytes: len("id=foo")}, sink.bind[Mime_Query])
+
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, req, &TestMode{Id: "foo", Name: "bar"}))
+	require.Equal(t, counter{requests: 1, bytes: w.Body.Len()}, sink.render[Mime_Wildcard])
+	require.Equal(t, [2]int{1, 1}, sink.fallbacks)
+
+	req.Header.Set("Accept", Mime_JSON)
+	require.EqualError(t, registry.Render(errWriter{httptest.NewRecorder()}, req, &TestMode{}), "write failed")
+	require.Equal(t, counter{requests: 1, errors: 1}, sink.render[Mime_JSON])
+	require.Equal(t, [2]int{1, 1}, sink.fallbacks)
+
+	// the request body is restored after Bind.
+	rd := strings.NewReader(body)
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", rd)
+	origBody := req.Body
+	req.Header.Set("Content-Type", Mime_JSON)
+	require.NoError(t, registry.Bind(req, &TestMode{}))
+	require.Equal(t, origBody, req.Body)
+}
+
+func Test_WithMetrics_StructuredSuffix(t *testing.T) {
+	sink := newRecordingSink()
+	var marshaled, unmarshaled []string
+	registry := New(
+		WithMetrics(sink),
+		WithOnMarshal(func(mime string, _ any, _ int, _ error) { marshaled = append(marshaled, mime) }),
+		WithOnUnmarshal(func(mime string, _ any, _ int, err error) error {
+			unmarshaled = append(unmarshaled, mime)
+			return err
+		}),
+	)
+
+	// the vendor types are keyed by the registered MIME type which they resolve to.
+	for _, vendor := range []string{"application/vnd.a.v1+json", "application/vnd.b.v2+json"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"id":"foo"}`))
+		req.Header.Set("Content-Type", vendor)
+		req.Header.Set("Accept", vendor)
+		require.NoError(t, registry.Bind(req, &TestMode{}))
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.Render(w, req, &TestMode{Id: "foo"}))
+		require.Equal(t, vendor+"; charset=utf-8", w.Header().Get("Content-Type"))
+	}
+	require.Len(t, sink.bind, 1)
+	require.Equal(t, 2, sink.bind[Mime_JSON].requests)
+	require.Len(t, sink.render, 1)
+	require.Equal(t, 2, sink.render[Mime_JSON].requests)
+	require.Equal(t, []string{Mime_JSON, Mime_JSON}, marshaled)
+	require.Equal(t, []string{Mime_JSON, Mime_JSON}, unmarshaled)
+}
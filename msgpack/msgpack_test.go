@@ -2,6 +2,7 @@ package msgpack
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -50,3 +51,91 @@ func TestCodec_Encoder_Decoder(t *testing.T) {
 
 	require.Equal(t, want, got)
 }
+
+func TestCodec_AcquireDecoder(t *testing.T) {
+	codec := Codec{}
+
+	first, err := codec.Marshal(&testMode{Foo: "FOO"})
+	require.NoError(t, err)
+	second, err := codec.Marshal(&testMode{Foo: "BAR"})
+	require.NoError(t, err)
+
+	// partially consumed body.
+	d := codec.AcquireDecoder(bytes.NewReader(append(first, second...)))
+	got := &testMode{}
+	require.NoError(t, d.Decode(got))
+	require.Equal(t, &testMode{Foo: "FOO"}, got)
+	codec.ReleaseDecoder(d)
+
+	// truncated body.
+	d = codec.AcquireDecoder(bytes.NewReader(first[:len(first)-1]))
+	require.Error(t, d.Decode(&testMode{}))
+	codec.ReleaseDecoder(d)
+
+	for i := 0; i < 3; i++ {
+		d = codec.AcquireDecoder(bytes.NewReader(second))
+		got = &testMode{}
+		require.NoError(t, d.Decode(got))
+		require.Equal(t, &testMode{Foo: "BAR"}, got)
+		codec.ReleaseDecoder(d)
+	}
+}
+
+type canonicalMode struct {
+	Name   string            `msgpack:"name"`
+	Labels map[string]string `msgpack:"labels"`
+	Nested map[string]any    `msgpack:"nested"`
+}
+
+func newCanonicalValue() map[string]any {
+	labels := make(map[string]string)
+	nested := make(map[string]any)
+	for i := 0; i < 32; i++ {
+		labels[fmt.Sprintf("label-%d", i)] = fmt.Sprintf("value-%d", i)
+		nested[fmt.Sprintf("key-%d", i)] = map[string]int{"a": i, "b": i + 1, "c": i + 2}
+	}
+	return map[string]any{
+		"struct": &canonicalMode{Name: "foo", Labels: labels, Nested: nested},
+		"labels": labels,
+		"nested": nested,
+	}
+}
+
+func TestCodec_Canonical(t *testing.T) {
+	codec := &Codec{Canonical: true}
+	v := newCanonicalValue()
+
+	want, err := codec.Marshal(v)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		got, err := codec.Marshal(v)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+	// the canonical output is decoded as usual.
+	got := &canonicalMode{}
+	b, err := codec.Marshal(v["struct"])
+	require.NoError(t, err)
+	require.NoError(t, codec.Unmarshal(b, got))
+	require.Equal(t, v["struct"].(*canonicalMode).Labels, got.Labels)
+
+	// without Canonical, the output is only equal in length as the map iteration order is random.
+	nonCanonical, err := (&Codec{}).Marshal(v)
+	require.NoError(t, err)
+	require.Len(t, nonCanonical, len(want))
+}
+
+func Benchmark_Codec_Marshal_Canonical(b *testing.B) {
+	v := newCanonicalValue()
+	for _, canonical := range []bool{false, true} {
+		codec := &Codec{Canonical: canonical}
+		b.Run(fmt.Sprintf("canonical=%t", canonical), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Marshal(v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package encoding
+
+// WithMultipartMaxMemory sets the maximum memory in bytes of the multipart form parsed by Bind and BindAny,
+// the file parts exceeding it are stored in the temporary files, it is 32 MB if n <= 0, which is the default.
+// see MultipartMaxMemory to override it per call, and WithMaxBodyBytes to limit the whole body.
+func WithMultipartMaxMemory(n int64) Option {
+	return func(r *Encoding) {
+		r.multipartMaxMemory = n
+	}
+}
+
+// MultipartMaxMemory overrides the maximum memory in bytes of the multipart form of WithMultipartMaxMemory
+// for this call, like the media upload endpoints, it is ignored if n <= 0.
+func MultipartMaxMemory(n int64) BindOption {
+	return func(o *bindOptions) {
+		o.multipartMaxMemory = n
+	}
+}
+
+// multipartMemory returns the maximum memory of the multipart form of the call.
+func (r *Encoding) multipartMemory(o *bindOptions) int64 {
+	if o != nil && o.multipartMaxMemory > 0 {
+		return o.multipartMaxMemory
+	}
+	if r.multipartMaxMemory > 0 {
+		return r.multipartMaxMemory
+	}
+	return defaultMemory
+}
@@ -0,0 +1,58 @@
+package encoding
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithMultipartMaxMemory(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	require.NoError(t, mw.WriteField("id", "foo"))
+	fw, err := mw.CreateFormFile("file", "file.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(strings.Repeat("x", 1024)))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(buf.Bytes()))
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req
+	}
+	// onDisk reports whether the file part is stored in the temporary file.
+	onDisk := func(req *http.Request) bool {
+		f, err := req.MultipartForm.File["file"][0].Open()
+		require.NoError(t, err)
+		defer f.Close()
+		_, ok := f.(*os.File)
+		return ok
+	}
+
+	for _, tt := range []struct {
+		name     string
+		encoding *Encoding
+		opts     []BindOption
+		onDisk   bool
+	}{
+		{"default", New(), nil, false},
+		{"option", New(WithMultipartMaxMemory(512)), nil, true},
+		{"per call", New(), []BindOption{MultipartMaxMemory(512)}, true},
+		{"per call overrides option", New(WithMultipartMaxMemory(512)), []BindOption{MultipartMaxMemory(4096)}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest()
+			got := &TestMode{}
+			require.NoError(t, tt.encoding.Bind(req, got, tt.opts...))
+			require.Equal(t, "foo", got.Id)
+			require.Equal(t, tt.onDisk, onDisk(req))
+			require.NoError(t, req.MultipartForm.RemoveAll())
+		})
+	}
+}
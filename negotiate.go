@@ -0,0 +1,172 @@
+package encoding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// Negotiation is the result of the `Content-Type` negotiation.
+type Negotiation struct {
+	// MediaType is the registered MIME type, or Mime_Wildcard if it falls back to the "*" Marshaler.
+	MediaType string
+	// Params is the parameters of the `Content-Type`, like charset and boundary, or the parameters
+	// of the matched `Accept` media range of the outbound negotiation, like version,
+	// it is nil if there are no parameters. It may be shared, so it must not be modified.
+	Params map[string]string
+	// Marshaler is the negotiated marshaler.
+	Marshaler codec.Marshaler
+}
+
+// Fallback reports whether the negotiation fell back to the "*" Marshaler.
+func (n Negotiation) Fallback() bool { return n.MediaType == Mime_Wildcard }
+
+// Version returns the version parameter of the negotiation, or the version embedded in
+// the vendor media type, like "v2" of "application/vnd.myapp.v2+json", see VendorVersion.
+func (n Negotiation) Version() string {
+	if v, ok := n.Params["version"]; ok {
+		return v
+	}
+	return VendorVersion(n.MediaType)
+}
+
+// Negotiate returns the inbound and outbound negotiation for this request in one call,
+// like NegotiateInbound and OutboundForRequestWithMime, honoring all the fallbacks and overrides,
+// the Params of the outbound negotiation is the parameters of the `Accept` media range which matches
+// the negotiated MIME type exactly, like "application/vnd.myapp+json; version=2", or nil.
+// see WithNegotiation to memoize it per request.
+// The middleware can answer 415 Unsupported Media Type if in.Fallback() and the request has a body,
+// see OutboundForRequestStrict for 406 Not Acceptable.
+func (r *Encoding) Negotiate(req *http.Request) (in, out Negotiation) {
+	in = r.NegotiateInbound(req)
+	out.MediaType, out.Marshaler = r.outboundForRequest(req)
+	if out.MediaType != Mime_Wildcard {
+		out.Params = acceptParams(req.Header[acceptHeader], out.MediaType)
+	}
+	return in, out
+}
+
+// acceptParams returns the parameters of the acceptable `Accept` media range of the MIME type.
+func acceptParams(accept []string, mime string) map[string]string {
+	if len(accept) == 0 {
+		return nil
+	}
+	for _, r := range ParseAccept(accept...) {
+		if r.Q > 0 && r.Type == mime {
+			return r.Params
+		}
+	}
+	return nil
+}
+
+// negotiationKey is the context key of the negotiationCache.
+type negotiationKey struct{}
+
+// negotiationCache memoizes the negotiation of a request, each result is valid only for
+// the registry snapshot it is negotiated with.
+type negotiationCache struct {
+	inboundSnapshot *registry
+	inbound         Negotiation
+
+	outboundSnapshot *registry
+	outboundMime     string
+	outbound         codec.Marshaler
+}
+
+// WithNegotiation returns a shallow copy of req whose context memoizes the inbound and outbound negotiation,
+// so the later Bind and Render with the returned request do not parse the headers and walk the registry again,
+// it is typically used in the middleware. The memoized result is skipped if the Encoding is mutated in between.
+// It returns req itself if the negotiation is memoized already.
+// NOTE: the `Content-Type` and `Accept` headers should not be modified after the first negotiation.
+func (r *Encoding) WithNegotiation(req *http.Request) *http.Request {
+	if _, ok := req.Context().Value(negotiationKey{}).(*negotiationCache); ok {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), negotiationKey{}, &negotiationCache{}))
+}
+
+// negotiationFromRequest returns the negotiationCache of the request, or nil if it is not memoized.
+func negotiationFromRequest(req *http.Request) *negotiationCache {
+	c, _ := req.Context().Value(negotiationKey{}).(*negotiationCache)
+	return c
+}
+
+// NegotiateInbound returns the inbound negotiation for this request, like InboundForRequest,
+// and carries the parameters of the `Content-Type` header, so they need not be parsed again.
+// see WithNegotiation to memoize it per request.
+// The registered MIME type override of WithInboundMIME takes precedence over the `Content-Type` header.
+func (r *Encoding) NegotiateInbound(req *http.Request) Negotiation {
+	s := r.load()
+	if mime, m, _ := s.overrideMarshaler(req, inboundMIMEKey{}); m != nil {
+		return Negotiation{MediaType: mime, Params: parseContentTypeParams(req), Marshaler: m}
+	}
+	c := negotiationFromRequest(req)
+	if c != nil && c.inboundSnapshot == s {
+		return c.inbound
+	}
+	n := s.negotiateContentType(req.Header[contentTypeHeader])
+	if r.metrics != nil && n.MediaType == Mime_Wildcard {
+		r.metrics.IncFallback(true)
+	}
+	if c != nil {
+		c.inboundSnapshot, c.inbound = s, n
+	}
+	return n
+}
+
+// readMultipartForm reads the multipart form of the request body with the boundary,
+// and sets it to the MultipartForm of the request, the file parts exceeding maxMemory are stored on disk.
+func readMultipartForm(req *http.Request, boundary string, maxMemory int64) error {
+	if boundary == "" {
+		return errors.New("encoding: parse multipart form: no multipart boundary param in Content-Type")
+	}
+	if req.Body == nil {
+		return errors.New("encoding: parse multipart form: missing body")
+	}
+	form, err := multipart.NewReader(req.Body, boundary).ReadForm(maxMemory)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("encoding: parse multipart form: body is empty or already consumed: %w", err)
+		}
+		return fmt.Errorf("encoding: parse multipart form: %w", err)
+	}
+	req.MultipartForm = form
+	return nil
+}
+
+// parseContentTypeParams returns the parameters of the `Content-Type` header of the request.
+func parseContentTypeParams(req *http.Request) map[string]string {
+	_, params, err := mime.ParseMediaType(req.Header.Get(contentTypeHeader))
+	if err != nil || len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// outboundContentType returns the content type of the response with the negotiated MIME type,
+// the media type of the marshaler's content type is replaced by the negotiated one with the parameters kept,
+// if the marshaler is registered under both, like "text/xml" and "application/xml",
+// or the MIME type is matched by its structured syntax suffix, see structuredContentType.
+// The content type which depends on the value, like the HttpBody, is kept as is.
+func (s *registry) outboundContentType(mime string, m codec.Marshaler, contentType string) string {
+	if mime == Mime_Wildcard {
+		return contentType
+	}
+	mediaType, params, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if strings.EqualFold(mediaType, mime) {
+		return contentType
+	}
+	if registered, ok := s.outboundMarshalers()[normalizeMIME(mediaType)]; ok && reflect.TypeOf(registered) == reflect.TypeOf(m) {
+		return replaceMediaType(mime, params)
+	}
+	return structuredContentType(mime, contentType)
+}
@@ -0,0 +1,221 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	hb "google.golang.org/genproto/googleapis/api/httpbody"
+
+	"github.com/thinkgos/encoding/httpbody"
+	"github.com/thinkgos/encoding/json"
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_Encoding_NegotiateInbound(t *testing.T) {
+	registry := New()
+
+	tests := []struct {
+		contentType   []string
+		wantMediaType string
+		wantParams    map[string]string
+	}{
+		{[]string{"application/json"}, Mime_JSON, nil},
+		{[]string{"application/json; charset=UTF-8; version=2"}, Mime_JSON, map[string]string{"charset": "UTF-8", "version": "2"}},
+		{[]string{"Application/JSON; Charset=utf-8"}, Mime_JSON, map[string]string{"charset": "utf-8"}},
+		{[]string{"application/unknown; v=1", "application/json; v=2"}, Mime_JSON, map[string]string{"v": "2"}},
+		{[]string{"multipart/form-data; boundary=xyz"}, Mime_MultipartPostForm, map[string]string{"boundary": "xyz"}},
+		{[]string{"application/unknown; charset=latin1"}, Mime_Wildcard, map[string]string{"charset": "latin1"}},
+		{[]string{"application/json; charset"}, Mime_Wildcard, nil},
+		{nil, Mime_Wildcard, nil},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.contentType), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+			req.Header[contentTypeHeader] = tt.contentType
+			got := registry.NegotiateInbound(req)
+			require.Equal(t, tt.wantMediaType, got.MediaType)
+			require.Equal(t, tt.wantParams, got.Params)
+			require.Equal(t, registry.Get(tt.wantMediaType), got.Marshaler)
+		})
+	}
+}
+
+func Test_Encoding_Bind_MultipartBoundary(t *testing.T) {
+	registry := New()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	require.NoError(t, mw.WriteField("id", "foo"))
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(body.Bytes()))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	got := &TestMode{}
+	require.NoError(t, registry.Bind(req, got))
+	require.Equal(t, &TestMode{Id: "foo"}, got)
+	require.NotNil(t, req.MultipartForm)
+
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(body.Bytes()))
+	req.Header.Set("Content-Type", Mime_MultipartPostForm)
+	require.ErrorContains(t, registry.Bind(req, &TestMode{}), "no multipart boundary")
+}
+
+func Test_Encoding_WithNegotiation(t *testing.T) {
+	sink := newRecordingSink()
+	registry := New(WithMetrics(sink))
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"id":"foo","name":"bar"}`))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Accept", "application/unknown")
+		return req
+	}
+
+	req := registry.WithNegotiation(newRequest())
+	require.Same(t, req, registry.WithNegotiation(req))
+
+	// a request reused across Bind and Render.
+	got := &TestMode{}
+	require.NoError(t, registry.Bind(req, got))
+	require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+	n := registry.NegotiateInbound(req)
+	require.Equal(t, Mime_JSON, n.MediaType)
+	require.Equal(t, map[string]string{"charset": "utf-8"}, n.Params)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, req, got))
+	require.JSONEq(t, `{"id":"foo","name":"bar"}`, w.Body.String())
+	w = httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, req, got))
+	// the outbound negotiation is not repeated.
+	require.Equal(t, 1, sink.fallbacks[0])
+
+	// the memoized result is skipped if the registry is mutated.
+	require.NoError(t, registry.Register("application/unknown", &marshalers[1]))
+	require.Equal(t, registry.Get("application/unknown"), registry.OutboundForRequest(req))
+	require.Equal(t, 1, sink.fallbacks[0])
+	require.NoError(t, registry.Delete(Mime_JSON))
+	require.Equal(t, Mime_Wildcard, registry.NegotiateInbound(req).MediaType)
+
+	// the memoized result belongs to the registry snapshot.
+	other := New()
+	require.Equal(t, Mime_JSON, other.NegotiateInbound(req).MediaType)
+	require.Equal(t, other.Get(Mime_Wildcard), other.OutboundForRequest(req))
+}
+
+func Benchmark_Encoding_BindRender(b *testing.B) {
+	registry := New()
+	body := []byte(`{"id":"foo","name":"bar"}`)
+	for _, memoized := range []bool{false, true} {
+		b.Run(fmt.Sprintf("memoized=%v", memoized), func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+			req.Header.Set("Content-Type", "application/unknown; v=1, application/json; charset=utf-8")
+			req.Header.Set("Accept", "text/html, application/xhtml+xml;q=0.9, application/json;q=0.8")
+			if memoized {
+				req = registry.WithNegotiation(req)
+			}
+			rd := bytes.NewReader(body)
+			w := &discardResponseWriter{header: make(http.Header)}
+			v := &TestMode{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rd.Reset(body)
+				req.Body = io.NopCloser(rd)
+				if err := registry.Bind(req, v); err != nil {
+					b.Fatal(err)
+				}
+				if err := registry.Render(w, req, v); err != nil {
+					b.Fatal(err)
+				}
+				// the second negotiation, like the other middleware or handler.
+				registry.NegotiateInbound(req)
+				registry.OutboundForRequest(req)
+			}
+		})
+	}
+}
+
+func Test_Encoding_OutboundForRequestWithMime(t *testing.T) {
+	registry := New()
+	xmlCodec := &xml.Codec{}
+	require.NoError(t, registry.Register(Mime_XML, xmlCodec))
+	require.NoError(t, registry.Register(Mime_XML2, xmlCodec))
+	require.NoError(t, registry.Register("application/x-json", &httpbody.HTTPBodyCodec{Marshaler: &json.Codec{}}))
+
+	render := func(accept string, v any) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.Render(w, req, v))
+		return w
+	}
+
+	for _, tt := range []struct {
+		accept      string
+		mime        string
+		contentType string
+	}{
+		{Mime_XML2, Mime_XML2, "text/xml; charset=utf-8"},
+		{Mime_XML, Mime_XML, "application/xml; charset=utf-8"},
+		{"text/html, Text/XML;q=0.9", Mime_XML2, "text/xml; charset=utf-8"},
+		{"application/x-json", "application/x-json", "application/json; charset=utf-8"},
+		{"image/png", Mime_Wildcard, "application/json; charset=utf-8"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", tt.accept)
+		mime, m := registry.OutboundForRequestWithMime(req)
+		require.Equal(t, tt.mime, mime, tt.accept)
+		require.Same(t, registry.OutboundForRequest(req), m)
+
+		w := render(tt.accept, &TestMode{Id: "foo"})
+		require.Equal(t, tt.contentType, w.Header().Get("Content-Type"), tt.accept)
+	}
+
+	// the content type which depends on the value is kept.
+	w := render("application/x-json", &hb.HttpBody{ContentType: "image/png", Data: []byte{0x89}})
+	require.Equal(t, "image/png", w.Header().Get("Content-Type"))
+
+	// so is the Handler.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_XML2)
+	w = httptest.NewRecorder()
+	registry.Handler(&TestMode{Id: "foo"}).ServeHTTP(w, req)
+	require.Equal(t, "text/xml; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func Test_Encoding_Negotiate(t *testing.T) {
+	sink := newRecordingSink()
+	registry := New(WithMetrics(sink))
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Accept", "text/html, application/json;q=0.9")
+	in, out := registry.Negotiate(req)
+	require.Equal(t, Negotiation{MediaType: Mime_XML, Params: map[string]string{"charset": "utf-8"}, Marshaler: registry.Get(Mime_XML)}, in)
+	require.Equal(t, Negotiation{MediaType: Mime_JSON, Marshaler: registry.Get(Mime_JSON)}, out)
+	require.False(t, in.Fallback())
+	require.False(t, out.Fallback())
+
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Accept", "text/html")
+	in, out = registry.Negotiate(req)
+	require.True(t, in.Fallback())
+	require.True(t, out.Fallback())
+	require.Equal(t, registry.Get(Mime_Wildcard), in.Marshaler)
+	require.Equal(t, registry.Get(Mime_Wildcard), out.Marshaler)
+	require.Equal(t, [2]int{1, 1}, sink.fallbacks)
+
+	// the overrides are honored.
+	req = req.WithContext(WithOutboundMIME(WithInboundMIME(req.Context(), Mime_JSON), Mime_XML))
+	in, out = registry.Negotiate(req)
+	require.Equal(t, Mime_JSON, in.MediaType)
+	require.Equal(t, Mime_XML, out.MediaType)
+}
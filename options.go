@@ -0,0 +1,204 @@
+package encoding
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+	"github.com/thinkgos/encoding/form"
+	"github.com/thinkgos/encoding/msgpack"
+	"github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/raw"
+	"github.com/thinkgos/encoding/text"
+	"github.com/thinkgos/encoding/toml"
+	"github.com/thinkgos/encoding/xml"
+	"github.com/thinkgos/encoding/yaml"
+)
+
+// Option is the option of the Encoding.
+type Option func(*Encoding)
+
+// WithOnBindError sets the callback which is invoked whenever Bind, BindQuery or BindUri
+// returns a non-nil error, it is used to observe the failures, it can not alter the returned error,
+// and the panic in the callback is recovered.
+func WithOnBindError(fn func(*http.Request, error)) Option {
+	return func(r *Encoding) {
+		r.onBindError = fn
+	}
+}
+
+// WithOnRenderError sets the callback which is invoked whenever Render returns a non-nil error,
+// it is used to observe the failures, it can not alter the returned error,
+// and the panic in the callback is recovered.
+func WithOnRenderError(fn func(*http.Request, error)) Option {
+	return func(r *Encoding) {
+		r.onRenderError = fn
+	}
+}
+
+// WithGetBodyBinding enables Bind to decode the body of the GET request, like the search query
+// of Elasticsearch, when it has a non-empty body and a registered Content-Type,
+// otherwise the GET request binds the query as before.
+func WithGetBodyBinding() Option {
+	return func(r *Encoding) {
+		r.getBodyBinding = true
+	}
+}
+
+// WithPreserveBody enables Bind to restore the request body after decoding, so the downstream
+// middleware, like the logging and the auditing, can read the original payload again.
+// The body is read into a buffer, bounded by WithMaxBodyBytes, and decoded from it.
+// If the form has been parsed before Bind, like by ParseMultipartForm, the body has been consumed
+// and only the form values in req.PostForm and req.MultipartForm are preserved.
+func WithPreserveBody() Option {
+	return func(r *Encoding) {
+		r.preserveBody = true
+	}
+}
+
+// WithBodyDefaults enables Bind to set the default values of the `default:"..."` tag to the fields
+// of the body decoded by the non-form codecs, like JSON, with the query codec which supports it, like form.Codec.
+// Unlike the query and the form, which keep the explicit zero value, the body can not distinguish it
+// from the absent one, so the default value is set to the zero value field.
+func WithBodyDefaults() Option {
+	return func(r *Encoding) {
+		r.bodyDefaults = true
+	}
+}
+
+// mirrorMode is when the outbound negotiation follows the request `Content-Type`,
+// the later mode extends the earlier one.
+type mirrorMode int
+
+const (
+	mirrorNone            mirrorMode = iota
+	mirrorWildcardAccept             // the `Accept` is absent or only "*/*".
+	mirrorUnmatchedAccept            // none of the `Accept` is registered.
+)
+
+// WithMirrorContentType enables Render to answer with the format of the request body,
+// when the `Accept` header is absent or only "*/*" and the request `Content-Type` is registered,
+// the explicit `Accept` always wins, see OutboundForRequest.
+// It is a no-op with WithOutboundFollowsInbound, which extends it, regardless of the order of the options.
+func WithMirrorContentType() Option {
+	return func(r *Encoding) {
+		r.mirror = max(r.mirror, mirrorWildcardAccept)
+	}
+}
+
+// WithOutboundFollowsInbound extends WithMirrorContentType to the unmatched `Accept` like "text/html",
+// Render answers with the format of the request body, when the `Accept` header is absent or none of it is registered,
+// and the request `Content-Type` is registered, unless the `Accept` excludes it explicitly by q=0,
+// the matched `Accept` always wins, see OutboundForRequest. It takes precedence over WithMirrorContentType.
+func WithOutboundFollowsInbound() Option {
+	return func(r *Encoding) {
+		r.mirror = mirrorUnmatchedAccept
+	}
+}
+
+// WithMarshalErrorFallback sets the fallback which writes the error response when Marshal fails in Render,
+// so the handler can simply ignore the error of Render, if fn is nil, DefaultMarshalErrorFallback is used.
+// It is opt-in, without it Render never touches the ResponseWriter when Marshal fails,
+// so the caller can write its own error response.
+// The fallback must not use the codecs of the Encoding, as the failing codec may be re-entered.
+func WithMarshalErrorFallback(fn func(http.ResponseWriter, *http.Request, error)) Option {
+	return func(r *Encoding) {
+		if fn == nil {
+			fn = DefaultMarshalErrorFallback
+		}
+		r.marshalErrorFallback = fn
+	}
+}
+
+// DefaultMarshalErrorFallback writes a 500 Internal Server Error response,
+// the body is an "application/problem+json" if the `Accept` header contains json,
+// otherwise a "text/plain". The error is not exposed to the client.
+func DefaultMarshalErrorFallback(w http.ResponseWriter, req *http.Request, _ error) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("X-Content-Type-Options", "nosniff")
+	if req != nil && strings.Contains(strings.Join(req.Header[acceptHeader], ","), "json") {
+		h.Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500}`))
+		return
+	}
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+}
+
+// callErrorHook calls the error callback, and recovers the panic in it.
+func callErrorHook(fn func(*http.Request, error), req *http.Request, err error) {
+	defer func() {
+		_ = recover()
+	}()
+	fn(req, err)
+}
+
+// WithMarshaler registers the marshaler with the case-insensitive MIME type when the Encoding is created,
+// like Register, the later one wins for the same MIME type, New panics if it is invalid.
+func WithMarshaler(mime string, m codec.Marshaler) Option {
+	return func(r *Encoding) {
+		r.registrations = append(r.registrations, registration{mime: mime, marshaler: m})
+	}
+}
+
+// WithWildcard registers the "*" Marshaler, both the inbound and outbound, see Mime_Wildcard.
+func WithWildcard(m codec.Marshaler) Option {
+	return WithMarshaler(Mime_Wildcard, m)
+}
+
+// WithQueryCodec registers the marshaler of the query, see Mime_Query.
+func WithQueryCodec(m codec.FormMarshaler) Option {
+	return WithMarshaler(Mime_Query, m)
+}
+
+// WithUriCodec registers the marshaler of the uri, see Mime_Uri.
+func WithUriCodec(m codec.UriMarshaler) Option {
+	return WithMarshaler(Mime_Uri, m)
+}
+
+// WithFormTag registers the form-based marshalers with the struct tag instead of "json",
+// like "form", they are Mime_PostForm, Mime_MultipartPostForm, Mime_Query and Mime_Uri.
+func WithFormTag(tag string) Option {
+	return func(r *Encoding) {
+		r.registrations = append(r.registrations,
+			registration{mime: Mime_PostForm, marshaler: form.New(tag)},
+			registration{mime: Mime_MultipartPostForm, marshaler: &form.MultipartCodec{Codec: form.New(tag)}},
+			registration{mime: Mime_Query, marshaler: &form.QueryCodec{Codec: form.New(tag)}},
+			registration{mime: Mime_Uri, marshaler: &form.UriCodec{Codec: form.New(tag)}},
+		)
+	}
+}
+
+// WithAllBuiltin registers all the builtin marshalers besides the default ones, which are:
+//
+//	Mime_PROTOBUF: proto.Codec
+//	Mime_XML:      xml.Codec
+//	Mime_XML2:     xml.Codec
+//	Mime_MSGPACK:  msgpack.Codec
+//	Mime_MSGPACK2: msgpack.Codec
+//	Mime_YAML:     yaml.Codec
+//	Mime_TOML:     toml.Codec
+//	Mime_Plain:    text.Codec
+//	Mime_OctetStream: raw.Codec
+//
+// The text.Codec and raw.Codec copy the body verbatim into *string, *[]byte or io.Writer,
+// and render string or []byte untouched, the other types fail.
+func WithAllBuiltin() Option {
+	return func(r *Encoding) {
+		xmlCodec, msgpackCodec := &xml.Codec{}, &msgpack.Codec{}
+		r.registrations = append(r.registrations,
+			registration{mime: Mime_PROTOBUF, marshaler: &proto.Codec{}},
+			registration{mime: Mime_XML, marshaler: xmlCodec},
+			registration{mime: Mime_XML2, marshaler: xmlCodec},
+			registration{mime: Mime_MSGPACK, marshaler: msgpackCodec},
+			registration{mime: Mime_MSGPACK2, marshaler: msgpackCodec},
+			registration{mime: Mime_YAML, marshaler: &yaml.Codec{}},
+			registration{mime: Mime_TOML, marshaler: &toml.Codec{}},
+			registration{mime: Mime_Plain, marshaler: &text.Codec{}},
+			registration{mime: Mime_OctetStream, marshaler: &raw.Codec{}},
+		)
+	}
+}
@@ -0,0 +1,479 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/msgpack"
+	pro "github.com/thinkgos/encoding/proto"
+)
+
+func Test_WithOnBindError(t *testing.T) {
+	var gotReqs []*http.Request
+	var gotErrs []error
+	registry := New(WithOnBindError(func(req *http.Request, err error) {
+		gotReqs = append(gotReqs, req)
+		gotErrs = append(gotErrs, err)
+		panic("should be recovered")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com?id=xxx", nil)
+	// BindQuery via Bind is reported only once.
+	err := registry.Bind(req, &struct {
+		Id int `json:"id"`
+	}{})
+	require.Error(t, err)
+	require.Len(t, gotErrs, 1)
+	require.Equal(t, req, gotReqs[0])
+	require.Equal(t, err, gotErrs[0])
+
+	err = registry.BindUri(url.Values{"id": {"xxx"}}, &struct {
+		Id int `json:"id"`
+	}{})
+	require.Error(t, err)
+	require.Len(t, gotErrs, 2)
+	require.Nil(t, gotReqs[1])
+
+	require.NoError(t, registry.BindQuery(httptest.NewRequest(http.MethodGet, "http://example.com?id=1", nil), &struct {
+		Id int `json:"id"`
+	}{}))
+	require.Len(t, gotErrs, 2)
+}
+
+type errWriter struct {
+	http.ResponseWriter
+}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func Test_WithOnRenderError(t *testing.T) {
+	var gotErr error
+	registry := New(WithOnRenderError(func(_ *http.Request, err error) {
+		gotErr = err
+		panic("should be recovered")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	err := registry.Render(errWriter{httptest.NewRecorder()}, req, map[string]string{"id": "foo"})
+	require.EqualError(t, err, "write failed")
+	require.Equal(t, err, gotErr)
+}
+
+type failingMarshaler struct {
+	dummyMarshaler
+	calls int
+}
+
+func (m *failingMarshaler) Marshal(any) ([]byte, error) {
+	m.calls++
+	return nil, errors.New("marshal failed")
+}
+
+func Test_WithMarshalErrorFallback(t *testing.T) {
+	t.Run("default fallback", func(t *testing.T) {
+		m := &failingMarshaler{}
+		registry := New(WithMarshalErrorFallback(nil))
+		require.NoError(t, registry.Register(Mime_JSON, m))
+		require.NoError(t, registry.Register(Mime_Plain, m))
+
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", Mime_JSON)
+		w := httptest.NewRecorder()
+		require.EqualError(t, registry.Render(w, req, map[string]string{"id": "foo"}), "encoding: render map[string]string as MIME(application/json): marshal failed")
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500}`, w.Body.String())
+		require.Equal(t, 1, m.calls)
+
+		req.Header.Set("Accept", Mime_Plain)
+		w = httptest.NewRecorder()
+		require.Error(t, registry.Render(w, req, map[string]string{"id": "foo"}))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "Internal Server Error", w.Body.String())
+		require.Equal(t, 2, m.calls)
+	})
+	t.Run("custom fallback", func(t *testing.T) {
+		var gotErr error
+		registry := New(WithMarshalErrorFallback(func(w http.ResponseWriter, _ *http.Request, err error) {
+			gotErr = err
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		require.NoError(t, registry.Register(Mime_Wildcard, &failingMarshaler{}))
+
+		w := httptest.NewRecorder()
+		err := registry.Render(w, httptest.NewRequest(http.MethodGet, "http://example.com", nil), "foo")
+		require.Equal(t, err, gotErr)
+		require.Equal(t, http.StatusTeapot, w.Code)
+		require.Empty(t, w.Body.String())
+	})
+	t.Run("without fallback", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Register(Mime_Wildcard, &failingMarshaler{}))
+
+		w := httptest.NewRecorder()
+		require.Error(t, registry.Render(w, httptest.NewRequest(http.MethodGet, "http://example.com", nil), "foo"))
+		// the ResponseWriter is not touched.
+		require.False(t, w.Flushed)
+		require.Empty(t, w.Header())
+		require.Empty(t, w.Body.String())
+	})
+}
+
+func Test_WithGetBodyBinding(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		var rd io.Reader
+		if body != "" {
+			rd = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(http.MethodGet, "http://example.com?id=query", rd)
+		req.Header.Set("Content-Type", Mime_JSON)
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		encoding *Encoding
+		req      *http.Request
+		want     *TestMode
+	}{
+		{
+			name:     "option on, json body",
+			encoding: New(WithGetBodyBinding()),
+			req:      newRequest(`{"id":"body","name":"bar"}`),
+			want:     &TestMode{Id: "body", Name: "bar"},
+		},
+		{
+			name:     "option off, json body",
+			encoding: New(),
+			req:      newRequest(`{"id":"body","name":"bar"}`),
+			want:     &TestMode{Id: "query"},
+		},
+		{
+			name:     "option on, no body",
+			encoding: New(WithGetBodyBinding()),
+			req:      newRequest(""),
+			want:     &TestMode{Id: "query"},
+		},
+		{
+			name:     "option on, unknown Content-Type",
+			encoding: New(WithGetBodyBinding()),
+			req: func() *http.Request {
+				req := newRequest(`{"id":"body"}`)
+				req.Header.Set("Content-Type", "application/unknown")
+				return req
+			}(),
+			want: &TestMode{Id: "query"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &TestMode{}
+			require.NoError(t, tt.encoding.Bind(tt.req, got))
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_WithPreserveBody(t *testing.T) {
+	registry := New(WithPreserveBody(), WithMaxBodyBytes(64))
+	newRequest := func(body, contentType string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	readBody := func(req *http.Request) string {
+		data, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	body := `{"id":"foo"}`
+	req := newRequest(body, Mime_JSON)
+	got := &TestMode{}
+	require.NoError(t, registry.Bind(req, got))
+	require.Equal(t, "foo", got.Id)
+	require.Equal(t, body, readBody(req))
+
+	// the malformed body is preserved too.
+	req = newRequest(`{"id":`, Mime_JSON)
+	require.Error(t, registry.Bind(req, &TestMode{}))
+	require.Equal(t, `{"id":`, readBody(req))
+
+	// the multipart form.
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	require.NoError(t, mw.WriteField("id", "bar"))
+	require.NoError(t, mw.Close())
+	req = newRequest(buf.String(), mw.FormDataContentType())
+	got = &TestMode{}
+	require.NoError(t, New(WithPreserveBody()).Bind(req, got))
+	require.Equal(t, "bar", got.Id)
+	require.Equal(t, buf.String(), readBody(req))
+
+	// the body is bounded.
+	req = newRequest(`{"id":"`+strings.Repeat("x", 64)+`"}`, Mime_JSON)
+	req.ContentLength = -1
+	require.ErrorIs(t, registry.Bind(req, &TestMode{}), ErrBodyTooLarge)
+
+	// opt-in.
+	req = newRequest(body, Mime_JSON)
+	require.NoError(t, New().Bind(req, &TestMode{}))
+	require.Empty(t, readBody(req))
+}
+
+func Test_WithBodyDefaults(t *testing.T) {
+	type query struct {
+		Page     int    `json:"page" default:"1"`
+		PageSize int    `json:"page_size" default:"20"`
+		Sort     string `json:"sort" default:"created_at"`
+	}
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com?page=0", strings.NewReader(body))
+		req.Header.Set("Content-Type", Mime_JSON)
+		return req
+	}
+
+	got := &query{}
+	require.NoError(t, New(WithBodyDefaults()).Bind(newRequest(`{"page":0,"sort":"name"}`), got))
+	require.Equal(t, &query{Page: 1, PageSize: 20, Sort: "name"}, got)
+
+	// the query keeps the explicit zero value.
+	got = &query{}
+	require.NoError(t, New().BindQuery(newRequest(""), got))
+	require.Equal(t, &query{Page: 0, PageSize: 20, Sort: "created_at"}, got)
+
+	// opt-in.
+	got = &query{}
+	require.NoError(t, New().Bind(newRequest(`{"sort":"name"}`), got))
+	require.Equal(t, &query{Sort: "name"}, got)
+}
+
+func Test_WithMirrorContentType(t *testing.T) {
+	registry := New(WithMirrorContentType())
+	require.NoError(t, registry.Register(Mime_PROTOBUF, &pro.Codec{}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		accept      []string
+		want        string
+	}{
+		{
+			name:        "proto in, no accept",
+			contentType: Mime_PROTOBUF,
+			want:        Mime_PROTOBUF,
+		},
+		{
+			name:        "proto in, wildcard accept",
+			contentType: Mime_PROTOBUF + "; charset=utf-8",
+			accept:      []string{"*/*;q=0.8"},
+			want:        Mime_PROTOBUF,
+		},
+		{
+			name:        "proto in, json accept",
+			contentType: Mime_PROTOBUF,
+			accept:      []string{Mime_JSON},
+			want:        Mime_JSON,
+		},
+		{
+			name:        "proto in, unregistered accept",
+			contentType: Mime_PROTOBUF,
+			accept:      []string{"text/html, */*"},
+			want:        Mime_Wildcard,
+		},
+		{
+			name: "no content type, no accept",
+			want: Mime_Wildcard,
+		},
+		{
+			name:        "unregistered content type, no accept",
+			contentType: "application/unknown",
+			want:        Mime_Wildcard,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req.Header["Accept"] = tt.accept
+			mime, got := registry.outboundForRequest(req)
+			require.Equal(t, tt.want, mime)
+			require.Equal(t, registry.Get(tt.want), got)
+			require.Equal(t, got, registry.OutboundForRequest(req))
+		})
+	}
+
+	// it is opt-in.
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", Mime_PROTOBUF)
+	require.Equal(t, registry.Get(Mime_Wildcard), New().OutboundForRequest(req))
+}
+
+func Test_WithOutboundFollowsInbound(t *testing.T) {
+	registry := New(WithOutboundFollowsInbound())
+	require.NoError(t, registry.Register(Mime_PROTOBUF, &pro.Codec{}))
+	require.NoError(t, registry.Register(Mime_Wildcard, &msgpack.Codec{}))
+
+	for _, tt := range []struct {
+		name        string
+		contentType string
+		accept      []string
+		want        string
+	}{
+		{"json in, no accept", Mime_JSON, nil, Mime_JSON},
+		{"json in, wildcard accept", Mime_JSON, []string{"*/*"}, Mime_JSON},
+		{"json in, unregistered accept", Mime_JSON, []string{"text/html, image/*"}, Mime_JSON},
+		{"json in, proto accept", Mime_JSON, []string{Mime_PROTOBUF}, Mime_PROTOBUF},
+		{"json in, json excluded", Mime_JSON, []string{"text/html, application/json;q=0"}, Mime_Wildcard},
+		{"no content type, no accept", "", nil, Mime_Wildcard},
+		{"unregistered content type", "application/unknown", []string{"text/html"}, Mime_Wildcard},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req.Header["Accept"] = tt.accept
+			mime, got := registry.OutboundForRequestWithMime(req)
+			require.Equal(t, tt.want, mime)
+			require.Equal(t, registry.Get(tt.want), got)
+		})
+	}
+
+	// the vendor type follows the request body as well.
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", "application/vnd.a+json")
+	req.Header.Set("Accept", "text/html")
+	mime, got := registry.OutboundForRequestWithMime(req)
+	require.Equal(t, "application/vnd.a+json", mime)
+	require.Equal(t, registry.Get(Mime_JSON), got)
+
+	// it extends WithMirrorContentType regardless of the order of the options.
+	req.Header.Set("Content-Type", Mime_JSON)
+	for _, r := range []*Encoding{
+		New(WithMirrorContentType(), WithOutboundFollowsInbound()),
+		New(WithOutboundFollowsInbound(), WithMirrorContentType()),
+		New(WithOutboundFollowsInbound(), WithMirrorContentType()).Clone(),
+	} {
+		mime, _ = r.OutboundForRequestWithMime(req)
+		require.Equal(t, Mime_JSON, mime)
+	}
+	mime, _ = New(WithMirrorContentType()).OutboundForRequestWithMime(req)
+	require.Equal(t, Mime_Wildcard, mime)
+}
+
+func Test_WithAllBuiltin_Raw(t *testing.T) {
+	newRequest := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	registry := New(WithAllBuiltin())
+
+	var s string
+	require.NoError(t, registry.Bind(newRequest(Mime_Plain, "hello, world"), &s))
+	require.Equal(t, "hello, world", s)
+	require.NoError(t, registry.Bind(newRequest("text/plain; charset=iso-8859-1", "caf\xe9"), &s))
+	require.Equal(t, "café", s)
+	var b []byte
+	require.NoError(t, registry.Bind(newRequest(Mime_OctetStream, "\x00\x01"), &b))
+	require.Equal(t, []byte{0, 1}, b)
+	w := &bytes.Buffer{}
+	require.NoError(t, registry.Bind(newRequest(Mime_OctetStream, "foo"), w))
+	require.Equal(t, "foo", w.String())
+	v, err := registry.BindAny(newRequest(Mime_OctetStream, "foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), v)
+
+	// the limit and the unsupported target.
+	err = New(WithAllBuiltin(), WithMaxBodyBytes(2)).Bind(newRequest(Mime_Plain, "foo"), &s)
+	require.ErrorIs(t, err, ErrBodyTooLarge)
+	var bindErr *BindError
+	require.ErrorAs(t, registry.Bind(newRequest(Mime_Plain, "foo"), &TestMode{}), &bindErr)
+	require.Equal(t, Mime_Plain, bindErr.MIME)
+
+	// render.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_Plain)
+	rw := httptest.NewRecorder()
+	require.NoError(t, registry.Render(rw, req, "hello"))
+	require.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+	require.Equal(t, "hello", rw.Body.String())
+	req.Header.Set("Accept", Mime_OctetStream)
+	rw = httptest.NewRecorder()
+	require.NoError(t, registry.Render(rw, req, []byte{0, 1}))
+	require.Equal(t, Mime_OctetStream, rw.Header().Get("Content-Type"))
+	require.Equal(t, []byte{0, 1}, rw.Body.Bytes())
+	var renderErr *RenderError
+	require.ErrorAs(t, registry.Render(httptest.NewRecorder(), req, &TestMode{}), &renderErr)
+}
+
+func Test_WithMarshaler(t *testing.T) {
+	// backward compatible.
+	require.Equal(t, []string{Mime_JSON, Mime_PostForm, Mime_MultipartPostForm}, New().MIMETypes())
+
+	registry := New(
+		WithAllBuiltin(),
+		WithMarshaler("Application/X-Protobuf", &marshalers[0]),
+		WithWildcard(&marshalers[1]),
+	)
+	require.Equal(t, []string{
+		Mime_JSON, Mime_MSGPACK2, Mime_OctetStream, Mime_TOML, Mime_MSGPACK, Mime_PROTOBUF,
+		Mime_PostForm, Mime_YAML, Mime_XML, Mime_MultipartPostForm, Mime_Plain, Mime_XML2,
+	}, registry.MIMETypes())
+	require.Same(t, &marshalers[0], registry.Get(Mime_PROTOBUF))
+	require.Same(t, &marshalers[1], registry.Get(Mime_Wildcard))
+	require.Same(t, registry.Get(Mime_XML), registry.Get(Mime_XML2))
+	require.Same(t, registry.Get(Mime_MSGPACK), registry.Get(Mime_MSGPACK2))
+
+	for _, opt := range []Option{
+		WithMarshaler("", &marshalers[0]),
+		WithMarshaler(Mime_JSON, nil),
+		WithWildcard(nil),
+		WithQueryCodec(nil),
+		WithMarshaler(Mime_Uri, &marshalers[0]),
+	} {
+		_, err := NewWithError(opt)
+		require.Error(t, err)
+		require.Panics(t, func() { New(opt) })
+	}
+}
+
+func Test_WithFormTag(t *testing.T) {
+	type formModel struct {
+		Id   string `form:"id" json:"-"`
+		Name string `form:"name" json:"-"`
+	}
+	registry := New(WithFormTag("form"))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com?id=foo", strings.NewReader(url.Values{"name": {"bar"}}.Encode()))
+	req.Header.Set("Content-Type", Mime_PostForm)
+	var got formModel
+	require.NoError(t, registry.Bind(req, &got))
+	require.Equal(t, "bar", got.Name)
+
+	got = formModel{}
+	req = httptest.NewRequest(http.MethodGet, "http://example.com?id=foo&name=bar", nil)
+	require.NoError(t, registry.Bind(req, &got))
+	require.Equal(t, formModel{Id: "foo", Name: "bar"}, got)
+
+	got = formModel{}
+	require.NoError(t, registry.BindUri(url.Values{"id": {"foo"}}, &got))
+	require.Equal(t, "foo", got.Id)
+
+	values, err := registry.EncodeQuery(&formModel{Id: "foo"})
+	require.NoError(t, err)
+	require.Equal(t, "foo", values.Get("id"))
+}
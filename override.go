@@ -0,0 +1,85 @@
+package encoding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// inboundMIMEKey and outboundMIMEKey are the context keys of the MIME type overrides.
+type (
+	inboundMIMEKey  struct{}
+	outboundMIMEKey struct{}
+)
+
+// WithInboundMIME returns a copy of ctx with the inbound MIME type override, which Bind and NegotiateInbound
+// use to decode the request body regardless of the `Content-Type` header, like:
+//
+//	func webhook(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+//			ctx := encoding.WithInboundMIME(req.Context(), encoding.Mime_JSON)
+//			ctx = encoding.WithOutboundMIME(ctx, encoding.Mime_JSON)
+//			next.ServeHTTP(w, req.WithContext(ctx))
+//		})
+//	}
+//
+// The parameters of the `Content-Type` header, like the multipart boundary, are still used.
+// If the MIME type is not registered, it falls back to the header negotiation,
+// or Bind returns an error if WithStrictMIMEOverride is set.
+func WithInboundMIME(ctx context.Context, mime string) context.Context {
+	return context.WithValue(ctx, inboundMIMEKey{}, mime)
+}
+
+// WithOutboundMIME returns a copy of ctx with the outbound MIME type override, which Render and OutboundForRequest
+// use to encode the response regardless of the `Accept` header, see WithInboundMIME.
+// If the MIME type is not registered, it falls back to the header negotiation,
+// or Render returns an error if WithStrictMIMEOverride is set.
+func WithOutboundMIME(ctx context.Context, mime string) context.Context {
+	return context.WithValue(ctx, outboundMIMEKey{}, mime)
+}
+
+// WithStrictMIMEOverride makes Bind and Render return an error if the MIME type override
+// of WithInboundMIME or WithOutboundMIME is not registered, instead of falling back to the header negotiation.
+func WithStrictMIMEOverride() Option {
+	return func(r *Encoding) {
+		r.strictMIMEOverride = true
+	}
+}
+
+// overrideMarshaler returns the MIME type override of the request context for the key and its marshaler,
+// the marshaler is nil if the MIME type is not registered, found is false if there is no override.
+func (s *registry) overrideMarshaler(req *http.Request, key any) (string, codec.Marshaler, bool) {
+	mime, ok := req.Context().Value(key).(string)
+	if !ok {
+		return "", nil, false
+	}
+	marshalers := s.mimeMap
+	if key == (outboundMIMEKey{}) {
+		marshalers = s.outboundMarshalers()
+	}
+	mediaType, _, _ := strings.Cut(mime, ";")
+	mediaType, m, ok := lookupStructured(marshalers, strings.TrimSpace(mediaType))
+	if !ok {
+		return mime, nil, true
+	}
+	return mediaType, m, true
+}
+
+// checkOverride returns an error if the MIME type override of the request context for the key
+// is not registered in strict mode.
+func (r *Encoding) checkOverride(req *http.Request, key any) error {
+	if !r.strictMIMEOverride {
+		return nil
+	}
+	if mime, m, found := r.load().overrideMarshaler(req, key); found && m == nil {
+		direction := "inbound"
+		if key == (outboundMIMEKey{}) {
+			direction = "outbound"
+		}
+		return fmt.Errorf("encoding: %s MIME(%s) override marshaller not registered", direction, mime)
+	}
+	return nil
+}
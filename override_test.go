@@ -0,0 +1,92 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/xml"
+)
+
+// overrideMiddleware sets the MIME type overrides like the router middleware.
+func overrideMiddleware(inbound, outbound string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if inbound != "" {
+			ctx = WithInboundMIME(ctx, inbound)
+		}
+		if outbound != "" {
+			ctx = WithOutboundMIME(ctx, outbound)
+		}
+		next(w, req.WithContext(ctx))
+	})
+}
+
+func Test_Encoding_MIMEOverride(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/webhook", strings.NewReader(`{"id":"foo","name":"bar"}`))
+		// the conflicting headers.
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		req.Header.Set("Accept", Mime_XML)
+		return req
+	}
+	newHandler := func(registry *Encoding, gotErr *error) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			v := &TestMode{}
+			if *gotErr = registry.Bind(req, v); *gotErr != nil {
+				return
+			}
+			*gotErr = registry.Render(w, req, v)
+		}
+	}
+
+	t.Run("override beats headers", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+		var gotErr error
+		w := httptest.NewRecorder()
+		req := registry.WithNegotiation(newRequest())
+		overrideMiddleware("Application/JSON", Mime_JSON, newHandler(registry, &gotErr)).ServeHTTP(w, req)
+		require.NoError(t, gotErr)
+		require.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		require.JSONEq(t, `{"id":"foo","name":"bar"}`, w.Body.String())
+
+		req = req.WithContext(WithInboundMIME(req.Context(), Mime_JSON))
+		n := registry.NegotiateInbound(req)
+		require.Equal(t, Mime_JSON, n.MediaType)
+		require.Equal(t, map[string]string{"charset": "utf-8"}, n.Params)
+		mime, _ := registry.InboundForRequest(req)
+		require.Equal(t, Mime_JSON, mime)
+		// the memoized negotiation does not beat the override.
+		require.Equal(t, registry.Get(Mime_XML), registry.OutboundForRequest(req))
+		require.Equal(t, registry.Get(Mime_JSON), registry.OutboundForRequest(req.WithContext(WithOutboundMIME(req.Context(), Mime_JSON))))
+	})
+	t.Run("unregistered override falls back", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+		var gotErr error
+		w := httptest.NewRecorder()
+		req := newRequest()
+		req.Header.Set("Content-Type", Mime_JSON)
+		overrideMiddleware("application/unknown", "application/unknown", newHandler(registry, &gotErr)).ServeHTTP(w, req)
+		require.NoError(t, gotErr)
+		require.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+	})
+	t.Run("unregistered override in strict mode", func(t *testing.T) {
+		registry := New(WithStrictMIMEOverride())
+		var gotErr error
+		overrideMiddleware("application/unknown", "", newHandler(registry, &gotErr)).ServeHTTP(httptest.NewRecorder(), newRequest())
+		require.EqualError(t, gotErr, "encoding: inbound MIME(application/unknown) override marshaller not registered")
+
+		w := httptest.NewRecorder()
+		req := newRequest()
+		req.Header.Set("Content-Type", Mime_JSON)
+		overrideMiddleware(Mime_JSON, "application/unknown", newHandler(registry, &gotErr)).ServeHTTP(w, req)
+		require.EqualError(t, gotErr, "encoding: outbound MIME(application/unknown) override marshaller not registered")
+		require.Empty(t, w.Header())
+		require.Empty(t, w.Body.String())
+	})
+}
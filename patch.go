@@ -0,0 +1,94 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// BindPatch binds the body of the PATCH request into the proto message like Bind, and sets the paths of mask
+// to the fields which are present in the body, so the update can apply only them, for example:
+//
+//	// PATCH /users/42 {"displayName":"foo","address":{"city":"bar"},"tags":["a"]}
+//	// --> [address.city display_name tags]
+//
+// The keys are resolved by the JSON name and the proto name of the fields, the paths are the sorted proto names.
+// The nested message is followed, but the list, the map, the well-known types and the null value
+// are the leaves, so they appear as their parent path only.
+// The unknown keys are skipped, the codec rejects them if it disallows the unknown fields.
+// The body must be decodable into a generic document, see BindAny.
+func (r *Encoding) BindPatch(req *http.Request, msg proto.Message, mask *fieldmaskpb.FieldMask, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(msg, r.bindPatch(req, msg, mask, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindPatch(req *http.Request, msg proto.Message, mask *fieldmaskpb.FieldMask, o *bindOptions) error {
+	var data []byte
+	if hasBody(req) {
+		done, err := r.prepareBody(req, r.maxBodyBytes)
+		if err != nil {
+			return err
+		}
+		data, err = io.ReadAll(req.Body)
+		if err = done(err); err != nil {
+			return err
+		}
+	}
+	body := req.Body
+	defer func() { req.Body = body }()
+
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	if err := r.bindRequestBody(req, msg, o); err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	doc, err := r.bindAnyBody(req)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	switch doc := doc.(type) {
+	case nil:
+	case map[string]any:
+		paths = appendFieldMaskPaths(paths, msg.ProtoReflect().Descriptor(), "", doc)
+		sort.Strings(paths)
+	default:
+		return errors.New("encoding: the body of the patch is not an object")
+	}
+	mask.Paths = paths
+	return nil
+}
+
+// appendFieldMaskPaths appends the field mask paths of the keys of the document to paths.
+func appendFieldMaskPaths(paths []string, md protoreflect.MessageDescriptor, prefix string, doc map[string]any) []string {
+	fields := md.Fields()
+	for key, value := range doc {
+		fd := fields.ByJSONName(key)
+		if fd == nil {
+			fd = fields.ByTextName(key)
+		}
+		if fd == nil {
+			continue
+		}
+		path := prefix + string(fd.Name())
+		nested, ok := value.(map[string]any)
+		if ok && len(nested) > 0 && fd.Message() != nil && !fd.IsList() && !fd.IsMap() &&
+			!strings.HasPrefix(string(fd.Message().FullName()), "google.protobuf.") {
+			paths = appendFieldMaskPaths(paths, fd.Message(), path+".", nested)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,56 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/thinkgos/encoding/jsonpb"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func Test_Encoding_BindPatch(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPatch, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", Mime_JSON)
+		return req
+	}
+	registry := New()
+	require.NoError(t, registry.Register(Mime_JSON, &jsonpb.Codec{}))
+
+	got, mask := &examplepb.Complex{}, &fieldmaskpb.FieldMask{}
+	req := newRequest(`{
+		"id": "1",
+		"numberOne": "one",
+		"very_simple": {"component": "c"},
+		"simples": ["a"],
+		"map": {"k": "v"},
+		"timestamp": "2024-01-01T00:00:00Z",
+		"uint32": 5,
+		"age": null
+	}`)
+	require.NoError(t, registry.BindPatch(req, got, mask))
+	require.Equal(t, int64(1), got.Id)
+	require.Equal(t, "c", got.GetSimple().GetComponent())
+	require.Equal(t, []string{"age", "id", "map", "no_one", "simple.component", "simples", "timestamp", "uint32"}, mask.Paths)
+
+	// the empty nested message is the leaf.
+	require.NoError(t, registry.BindPatch(newRequest(`{"simple":{}}`), &examplepb.Complex{}, mask))
+	require.Equal(t, []string{"simple"}, mask.Paths)
+	require.NoError(t, registry.BindPatch(newRequest(`{}`), &examplepb.Complex{}, mask))
+	require.Empty(t, mask.Paths)
+
+	// the unknown keys.
+	require.Error(t, registry.BindPatch(newRequest(`{"id":"1","unknown":1}`), &examplepb.Complex{}, mask))
+	require.NoError(t, registry.Register(Mime_JSON, &jsonpb.Codec{UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true}}))
+	require.NoError(t, registry.BindPatch(newRequest(`{"id":"1","unknown":1}`), &examplepb.Complex{}, mask))
+	require.Equal(t, []string{"id"}, mask.Paths)
+
+	// the malformed body.
+	require.Error(t, registry.BindPatch(newRequest(`{"id":`), &examplepb.Complex{}, mask))
+}
@@ -0,0 +1,67 @@
+package encoding
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BindPathValues binds the passed struct pointer with the path values of the request,
+// which are matched by the pattern of the http.ServeMux since Go 1.22, like `GET /users/{id}`,
+// using the uri codec.Marshaler. names are the wildcard names of the pattern,
+// if names is empty, it derives them from the request Pattern, like [id] of `GET /users/{id}`.
+// The missing or empty path value is treated as absent, so it does not overwrite the field.
+//
+// It composes with the other binds, the later one overwrites the fields which are present, for example:
+//
+//	mux.HandleFunc("PUT /users/{id}", func(w http.ResponseWriter, req *http.Request) {
+//		var v User
+//		if err := enc.Bind(req, &v); err != nil { // the body, or the query of GET request.
+//			// ...
+//		}
+//		if err := enc.BindPathValues(req, nil, &v); err != nil { // the path values win.
+//			// ...
+//		}
+//	})
+func (r *Encoding) BindPathValues(req *http.Request, names []string, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindPathValues(req, names, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindPathValues(req *http.Request, names []string, v any, o *bindOptions) error {
+	if len(names) == 0 {
+		names = patternWildcards(req.Pattern)
+	}
+	raws := make(url.Values, len(names))
+	for _, name := range names {
+		if value := req.PathValue(name); value != "" {
+			raws[name] = []string{value}
+		}
+	}
+	return bindError(Mime_Uri, v, o.configureForm(r.load().mimeUri).Decode(raws, v))
+}
+
+// patternWildcards returns the wildcard names of the http.ServeMux pattern,
+// like [id path] of `GET /users/{id}/files/{path...}`, the `{$}` is skipped.
+func patternWildcards(pattern string) []string {
+	var names []string
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return names
+		}
+		name := strings.TrimSuffix(pattern[start+1:start+end], "...")
+		if name != "" && name != "$" {
+			names = append(names, name)
+		}
+		pattern = pattern[start+end+1:]
+	}
+}
@@ -0,0 +1,76 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func Test_Encoding_BindPathValues(t *testing.T) {
+	type post struct {
+		Id     int64  `json:"id"`
+		PostId string `json:"post_id"`
+		Title  string `json:"title"`
+	}
+
+	registry := New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}/posts/{post_id}", func(w http.ResponseWriter, req *http.Request) {
+		var got post
+		// the query first, then the path values win.
+		require.NoError(t, registry.BindQuery(req, &got))
+		require.NoError(t, registry.BindPathValues(req, nil, &got))
+		require.Equal(t, post{Id: 1, PostId: "p 2", Title: "hello"}, got)
+
+		gotProto := &examplepb.Complex{}
+		require.NoError(t, registry.BindPathValues(req, []string{"id"}, gotProto))
+		require.True(t, proto.Equal(&examplepb.Complex{Id: 1}, gotProto))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /complex/{id}/ages/{age}/{rest...}", func(w http.ResponseWriter, req *http.Request) {
+		got := &examplepb.Complex{Age: 10, NoOne: "keep"}
+		require.NoError(t, registry.BindPathValues(req, nil, got))
+		require.True(t, proto.Equal(&examplepb.Complex{Id: 11, Age: 20, NoOne: "keep"}, got))
+
+		// the missing path value is absent.
+		var gotPost post
+		require.NoError(t, registry.BindPathValues(req, []string{"id", "post_id"}, &gotPost))
+		require.Equal(t, post{Id: 11}, gotPost)
+
+		require.Error(t, registry.BindPathValues(req, []string{"rest"}, &struct {
+			Rest int `json:"rest"`
+		}{}))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, path := range []string{
+		"/users/1/posts/p%202?id=100&title=hello",
+		"/complex/11/ages/20/not/a/number",
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusNoContent, w.Code, path)
+	}
+}
+
+func Test_patternWildcards(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", nil},
+		{"/users", nil},
+		{"GET /users/{id}/posts/{post_id}", []string{"id", "post_id"}},
+		{"example.com/files/{path...}", []string{"path"}},
+		{"GET /users/{$}", nil},
+		{"GET /users/{id", nil},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, patternWildcards(tt.pattern), tt.pattern)
+	}
+}
@@ -23,6 +23,24 @@ func (*Codec) Marshal(value any) ([]byte, error) {
 	}
 	return proto.Marshal(message)
 }
+
+// MarshalAppend appends the marshaled "value" to b.
+func (*Codec) MarshalAppend(b []byte, value any) ([]byte, error) {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return nil, errors.New("unable to marshal non proto field")
+	}
+	return proto.MarshalOptions{}.MarshalAppend(b, message)
+}
+
+// Size returns the size of the marshaled "value" if it is a proto message.
+func (*Codec) Size(value any) (int, bool) {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return 0, false
+	}
+	return proto.Size(message), true
+}
 func (*Codec) Unmarshal(data []byte, value any) error {
 	message, ok := value.(proto.Message)
 	if !ok {
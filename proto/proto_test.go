@@ -124,3 +124,37 @@ func TestCodec_EncoderDecoder(t *testing.T) {
 		t.Fatalf("Decode should returned an error")
 	}
 }
+
+func TestCodec_Size_MarshalAppend(t *testing.T) {
+	codec := Codec{}
+
+	want, err := codec.Marshal(message)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %s", err.Error())
+	}
+	size, ok := codec.Size(message)
+	if !ok || size != len(want) {
+		t.Errorf("Size got = (%d, %v), want (%d, true)", size, ok, len(want))
+	}
+	got, err := codec.MarshalAppend([]byte("prefix"), message)
+	if err != nil {
+		t.Fatalf("MarshalAppend returned error: %s", err.Error())
+	}
+	if !bytes.HasPrefix(got, []byte("prefix")) || len(got) != len("prefix")+len(want) {
+		t.Errorf("MarshalAppend got = %v, want prefix with %d bytes", got, len(want))
+	}
+	unmarshaled := &examplepb.ABitOfEverything{}
+	if err = codec.Unmarshal(got[len("prefix"):], unmarshaled); err != nil {
+		t.Fatalf("Unmarshal returned error: %s", err.Error())
+	}
+	if !proto.Equal(unmarshaled, message) {
+		t.Errorf("MarshalAppend got = %v, want %v", unmarshaled, message)
+	}
+
+	if _, ok := codec.Size(&testInvalidProtoMessage{Id: 11}); ok {
+		t.Errorf("Size should return false for non proto field")
+	}
+	if _, err := codec.MarshalAppend(nil, &testInvalidProtoMessage{Id: 11}); err == nil {
+		t.Errorf("MarshalAppend should return an error for non proto field")
+	}
+}
@@ -0,0 +1,74 @@
+package raw
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// Codec is a Codec implementation which copies the raw bytes verbatim, like "application/octet-stream".
+// It marshals string, []byte and the non-nil pointers of them, and unmarshals into *[]byte, *string,
+// *any which is set to []byte, or io.Writer which the bytes are copied to, the other types fail.
+type Codec struct{}
+
+// ContentType always Returns "application/octet-stream".
+func (*Codec) ContentType(_ any) string {
+	return "application/octet-stream"
+}
+func (*Codec) Marshal(v any) ([]byte, error) {
+	switch vv := v.(type) {
+	case []byte:
+		return vv, nil
+	case string:
+		return []byte(vv), nil
+	case *[]byte:
+		if vv != nil {
+			return *vv, nil
+		}
+	case *string:
+		if vv != nil {
+			return []byte(*vv), nil
+		}
+	}
+	return nil, fmt.Errorf("raw: unsupported type %T, want string or []byte", v)
+}
+func (*Codec) Unmarshal(data []byte, v any) error {
+	switch vv := v.(type) {
+	case *[]byte:
+		*vv = append([]byte(nil), data...)
+	case *string:
+		*vv = string(data)
+	case *any:
+		*vv = append([]byte(nil), data...)
+	case io.Writer:
+		_, err := vv.Write(data)
+		return err
+	default:
+		return fmt.Errorf("raw: unsupported type %T, want *[]byte, *string or io.Writer", v)
+	}
+	return nil
+}
+func (c *Codec) NewDecoder(r io.Reader) codec.Decoder {
+	return codec.DecoderFunc(func(v any) error {
+		if w, ok := v.(io.Writer); ok {
+			_, err := io.Copy(w, r)
+			return err
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return c.Unmarshal(data, v)
+	})
+}
+func (c *Codec) NewEncoder(w io.Writer) codec.Encoder {
+	return codec.EncoderFunc(func(v any) error {
+		data, err := c.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,61 @@
+package encoding
+
+import (
+	"sync"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// maxPooledBufferSize is the max capacity of the buffer which is put back to the pool.
+const maxPooledBufferSize = 4 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any { return new([]byte) },
+}
+
+// appendMarshaler is implemented by the marshaler which can append the marshaled value to a buffer,
+// like proto.Codec.
+type appendMarshaler interface {
+	MarshalAppend(b []byte, v any) ([]byte, error)
+}
+
+// marshal marshals v with the marshaler, if the marshaler implements codec.Sizer and knows the size,
+// and implements appendMarshaler too, it marshals into a pooled buffer which is pre-sized,
+// release must be called after the data is used.
+func marshal(m codec.Marshaler, v any) (data []byte, release func(), err error) {
+	release = func() {}
+	if m, err = resolveMarshaler(m); err != nil {
+		return nil, release, err
+	}
+	s, ok := m.(codec.Sizer)
+	if !ok {
+		data, err = m.Marshal(v)
+		return data, release, err
+	}
+	size, ok := s.Size(v)
+	if !ok {
+		data, err = m.Marshal(v)
+		return data, release, err
+	}
+	am, ok := m.(appendMarshaler)
+	if !ok {
+		data, err = m.Marshal(v)
+		return data, release, err
+	}
+	buf := bufferPool.Get().(*[]byte)
+	if cap(*buf) < size {
+		*buf = make([]byte, 0, size)
+	}
+	data, err = am.MarshalAppend((*buf)[:0], v)
+	if err != nil {
+		bufferPool.Put(buf)
+		return nil, release, err
+	}
+	release = func() {
+		if cap(data) <= maxPooledBufferSize {
+			*buf = data[:0]
+			bufferPool.Put(buf)
+		}
+	}
+	return data, release, nil
+}
@@ -0,0 +1,90 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding/codec"
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+// unsizedMarshaler hides the codec.Sizer of the marshaler.
+type unsizedMarshaler struct {
+	codec.Marshaler
+}
+
+func largeProtoMessage() *examplepb.Complex {
+	return &examplepb.Complex{
+		Id:      11,
+		NoOne:   strings.Repeat("foo", 64<<10),
+		Simples: strings.Split(strings.Repeat("bar,", 1024), ","),
+	}
+}
+
+func Test_Encoding_Render_Sizer(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_PROTOBUF, &pro.Codec{}))
+	msg := largeProtoMessage()
+	want, err := proto.Marshal(msg)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_PROTOBUF)
+	for i := 0; i < 2; i++ { // the second one reuses the pooled buffer.
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.Render(w, req, msg))
+		require.Equal(t, strconv.Itoa(len(want)), w.Header().Get("Content-Length"))
+		require.Equal(t, want, w.Body.Bytes())
+	}
+
+	// the marshaler without codec.Sizer falls back, the Content-Length is set anyway.
+	require.NoError(t, registry.Register(Mime_PROTOBUF, unsizedMarshaler{&pro.Codec{}}))
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.Render(w, req, msg))
+	require.Equal(t, strconv.Itoa(len(want)), w.Header().Get("Content-Length"))
+	require.Equal(t, want, w.Body.Bytes())
+
+	// the marshal error is returned as is.
+	require.NoError(t, registry.Register(Mime_PROTOBUF, &pro.Codec{}))
+	require.Error(t, registry.Render(httptest.NewRecorder(), req, &TestMode{}))
+}
+
+func Benchmark_Encoding_Render_LargeProto(b *testing.B) {
+	msg := largeProtoMessage()
+	for _, tt := range []struct {
+		name      string
+		marshaler codec.Marshaler
+	}{
+		{"sized", &pro.Codec{}},
+		{"unsized", unsizedMarshaler{&pro.Codec{}}},
+	} {
+		registry := New()
+		require.NoError(b, registry.Register(Mime_PROTOBUF, tt.marshaler))
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", Mime_PROTOBUF)
+		b.Run(tt.name, func(b *testing.B) {
+			w := &discardResponseWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := registry.Render(w, req, msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
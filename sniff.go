@@ -0,0 +1,94 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// WithContentSniffing makes Bind sniff the body of the request without the `Content-Type` header,
+// instead of falling back to the "*" Marshaler, like the webhook senders which omit it.
+// The leading bytes of the body are peeked, nothing is lost, and it chooses:
+//
+//	`{` or `[`  --> "application/json"
+//	`<`         --> "application/xml"
+//	`key=`      --> "application/x-www-form-urlencoded"
+//	otherwise   --> "*"
+//
+// the sniffed MIME type falls back to "*" if it is not registered, and the *BindError of the
+// decoder reports it with Sniffed. The unregistered `Content-Type` is not sniffed.
+func WithContentSniffing() Option {
+	return func(r *Encoding) {
+		r.sniffContentType = true
+	}
+}
+
+// sniffNegotiation sniffs the body of the request without the `Content-Type` header,
+// it reports whether the body is sniffed, the returned restore restores the body.
+func (r *Encoding) sniffNegotiation(req *http.Request, n Negotiation) (Negotiation, func(), bool) {
+	if !r.sniffContentType || !n.Fallback() || len(req.Header[contentTypeHeader]) > 0 || !hasBody(req) {
+		return n, func() {}, false
+	}
+	body := req.Body
+	br := bufio.NewReaderSize(body, sniffLen)
+	sniffed := &sniffedBody{Reader: br, Closer: body}
+	req.Body = sniffed
+	restore := func() {
+		// the body may be replaced by the preserved one, see WithPreserveBody.
+		if req.Body == sniffed {
+			req.Body = body
+		}
+	}
+	// the error is reported by the decoder.
+	data, _ := br.Peek(sniffLen)
+	mime := sniffMIME(data)
+	if mime == Mime_Wildcard {
+		return n, restore, false
+	}
+	mime, m, ok := lookupStructured(r.load().mimeMap, mime)
+	if !ok {
+		return n, restore, false
+	}
+	return Negotiation{MediaType: mime, Marshaler: m}, restore, true
+}
+
+// sniffMIME returns the MIME type of the leading bytes of the body, or Mime_Wildcard.
+func sniffMIME(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 {
+		return Mime_Wildcard
+	}
+	switch data[0] {
+	case '{', '[':
+		return Mime_JSON
+	case '<':
+		return Mime_XML
+	}
+	if isFormKey(data) {
+		return Mime_PostForm
+	}
+	return Mime_Wildcard
+}
+
+// isFormKey reports whether the data starts with the urlencoded key followed by "=".
+func isFormKey(data []byte) bool {
+	for i, c := range data {
+		switch {
+		case c == '=':
+			return i > 0
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '_', c == '-', c == '.', c == '~', c == '%', c == '+', c == '[', c == ']':
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// sniffedBody is the body whose leading bytes are buffered, Close closes the original body.
+type sniffedBody struct {
+	*bufio.Reader
+	io.Closer
+}
@@ -0,0 +1,92 @@
+package encoding
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_sniffMIME(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"id":"foo"}`, Mime_JSON},
+		{" \r\n\t[1,2]", Mime_JSON},
+		{"\xef\xbb\xbf{}", Mime_JSON},
+		{`<?xml version="1.0"?><TestMode/>`, Mime_XML},
+		{"id=foo&name=bar", Mime_PostForm},
+		{"filter%5Bname%5D=foo", Mime_PostForm},
+		{"=foo", Mime_Wildcard},
+		{"id foo", Mime_Wildcard},
+		{"foo", Mime_Wildcard},
+		{"", Mime_Wildcard},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, sniffMIME([]byte(tt.data)), tt.data)
+	}
+}
+
+func Test_WithContentSniffing(t *testing.T) {
+	newRequest := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return req
+	}
+	registry := New(WithContentSniffing())
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"json", `{"id":"foo","name":"bar"}`},
+		{"xml", `<TestMode><id>foo</id><name>bar</name></TestMode>`},
+		{"form", "id=foo&name=bar"},
+		{"long json", `{"id":"foo","name":"bar"` + strings.Repeat(" ", 2*sniffLen) + `}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest("", tt.body)
+			rawBody := req.Body
+			got := &TestMode{}
+			require.NoError(t, registry.Bind(req, got))
+			require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+			require.Equal(t, rawBody, req.Body)
+		})
+	}
+
+	// off by default.
+	require.Error(t, New().Bind(newRequest("", "id=foo&name=bar"), &TestMode{}))
+	// the unregistered sniffed MIME type falls back to "*".
+	require.Error(t, New(WithContentSniffing()).Bind(newRequest("", "<TestMode><id>foo</id></TestMode>"), &TestMode{}))
+	// the unregistered `Content-Type` is not sniffed.
+	require.Error(t, registry.Bind(newRequest("text/x-unknown", "id=foo&name=bar"), &TestMode{}))
+
+	// the sniffed MIME type is reported by the *BindError.
+	err := registry.Bind(newRequest("", `{"id":1}`), &TestMode{})
+	var e *BindError
+	require.True(t, errors.As(err, &e))
+	require.True(t, e.Sniffed)
+	require.Equal(t, Mime_JSON, e.MIME)
+	require.Contains(t, err.Error(), "encoding: bind sniffed MIME(application/json) into *encoding.TestMode")
+	err = New().Bind(newRequest("", `{"id":1}`), &TestMode{})
+	require.True(t, errors.As(err, &e))
+	require.False(t, e.Sniffed)
+
+	// the preserved body is kept.
+	req := newRequest("", "id=foo")
+	require.NoError(t, New(WithContentSniffing(), WithPreserveBody()).Bind(req, &TestMode{}))
+	data, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, "id=foo", string(data))
+}
@@ -0,0 +1,82 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// defaultSubprotocols returns the default mapping from WebSocket subprotocols to MIME types.
+//
+//	"json":     Mime_JSON
+//	"msgpack":  Mime_MSGPACK
+//	"protobuf": Mime_PROTOBUF
+//	"xml":      Mime_XML
+//	"yaml":     Mime_YAML
+//	"toml":     Mime_TOML
+func defaultSubprotocols() map[string]string {
+	return map[string]string{
+		"json":     Mime_JSON,
+		"msgpack":  Mime_MSGPACK,
+		"protobuf": Mime_PROTOBUF,
+		"xml":      Mime_XML,
+		"yaml":     Mime_YAML,
+		"toml":     Mime_TOML,
+	}
+}
+
+// RegisterSubprotocol maps a case-sensitive WebSocket subprotocol, which is negotiated
+// by the `Sec-WebSocket-Protocol` header, to a case-insensitive MIME type.
+// you can override default mapping with same subprotocol.
+func (r *Encoding) RegisterSubprotocol(proto, mime string) error {
+	if len(proto) == 0 {
+		return errors.New("encoding: empty subprotocol")
+	}
+	if len(mime) == 0 {
+		return errors.New("encoding: empty MIME type")
+	}
+	return r.update(func(s *registry) error {
+		s.subprotocols[proto] = normalizeMIME(mime)
+		return nil
+	})
+}
+
+// DeleteSubprotocol remove the WebSocket subprotocol mapping.
+func (r *Encoding) DeleteSubprotocol(proto string) {
+	_ = r.update(func(s *registry) error {
+		delete(s.subprotocols, proto)
+		return nil
+	})
+}
+
+// Subprotocols returns the sorted WebSocket subprotocols which have a registered marshaler,
+// it can be used as the supported subprotocols of the WebSocket upgrader.
+func (r *Encoding) Subprotocols() []string {
+	s := r.load()
+	protos := make([]string, 0, len(s.subprotocols))
+	for proto, mime := range s.subprotocols {
+		if _, ok := s.mimeMap[mime]; ok {
+			protos = append(protos, proto)
+		}
+	}
+	sort.Strings(protos)
+	return protos
+}
+
+// ForSubprotocol returns the marshaler for the negotiated WebSocket subprotocol.
+// Unlike Get, it does not fall back to the "*" Marshaler, the subprotocol must be mapped
+// to a MIME type which has a registered marshaler.
+func (r *Encoding) ForSubprotocol(proto string) (codec.Marshaler, error) {
+	s := r.load()
+	mime, ok := s.subprotocols[proto]
+	if !ok {
+		return nil, fmt.Errorf("encoding: subprotocol(%s) not supported", proto)
+	}
+	m, ok := s.mimeMap[mime]
+	if !ok {
+		return nil, fmt.Errorf("encoding: subprotocol(%s) MIME(%s) marshaller not registered", proto, mime)
+	}
+	return m, nil
+}
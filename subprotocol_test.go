@@ -0,0 +1,43 @@
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/msgpack"
+)
+
+func Test_Encoding_Subprotocol(t *testing.T) {
+	registry := New()
+
+	m, err := registry.ForSubprotocol("json")
+	require.NoError(t, err)
+	require.Equal(t, registry.Get(Mime_JSON), m)
+
+	// mapped but no marshaler registered.
+	_, err = registry.ForSubprotocol("msgpack")
+	require.Error(t, err)
+	require.Equal(t, []string{"json"}, registry.Subprotocols())
+
+	require.NoError(t, registry.Register(Mime_MSGPACK, &msgpack.Codec{}))
+	m, err = registry.ForSubprotocol("msgpack")
+	require.NoError(t, err)
+	require.Equal(t, registry.Get(Mime_MSGPACK), m)
+	require.Equal(t, []string{"json", "msgpack"}, registry.Subprotocols())
+
+	// not mapped.
+	_, err = registry.ForSubprotocol("v1.json")
+	require.Error(t, err)
+	require.NoError(t, registry.RegisterSubprotocol("v1.json", Mime_JSON))
+	m, err = registry.ForSubprotocol("v1.json")
+	require.NoError(t, err)
+	require.Equal(t, registry.Get(Mime_JSON), m)
+
+	registry.DeleteSubprotocol("v1.json")
+	_, err = registry.ForSubprotocol("v1.json")
+	require.Error(t, err)
+
+	require.Error(t, registry.RegisterSubprotocol("", Mime_JSON))
+	require.Error(t, registry.RegisterSubprotocol("json", ""))
+}
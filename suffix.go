@@ -0,0 +1,133 @@
+package encoding
+
+import (
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// structuredSuffixes is the MIME types of the structured syntax suffixes of RFC 6839 and RFC 8428,
+// in the lookup order, the other suffix like "+foo" looks up "application/foo".
+var structuredSuffixes = map[string][]string{
+	"json":    {Mime_JSON},
+	"xml":     {Mime_XML, Mime_XML2},
+	"yaml":    {"application/yaml", Mime_YAML},
+	"toml":    {Mime_TOML},
+	"msgpack": {Mime_MSGPACK2, Mime_MSGPACK},
+	"cbor":    {"application/cbor"},
+}
+
+// lookupStructured returns the registered MIME type and marshaler of the marshalers which matches the media type,
+// if the media type is not registered, it falls back to the marshaler of the structured syntax suffix,
+// like "application/vnd.myapp.v2+json" --> the marshaler of "application/json",
+// the returned MIME type is the lowercase media type itself then.
+func lookupStructured(marshalers map[string]codec.Marshaler, mediaType string) (string, codec.Marshaler, bool) {
+	if mime, m, ok := lookup(marshalers, mediaType); ok {
+		return mime, m, true
+	}
+	if mime, ok := suffixMIME(marshalers, mediaType); ok {
+		return normalizeMIME(mediaType), marshalers[mime], true
+	}
+	return "", nil, false
+}
+
+// suffixMIME returns the registered MIME type of the marshalers which the structured syntax suffix
+// of the media type resolves to, like "application/vnd.myapp.v2+json" --> "application/json".
+func suffixMIME(marshalers map[string]codec.Marshaler, mediaType string) (string, bool) {
+	i := strings.LastIndexByte(mediaType, '+')
+	if i < 0 || i == len(mediaType)-1 || strings.IndexByte(mediaType[:i], '/') < 0 {
+		return "", false
+	}
+	suffix := normalizeMIME(mediaType[i+1:])
+	mimes, ok := structuredSuffixes[suffix]
+	if !ok {
+		mimes = []string{"application/" + suffix}
+	}
+	for _, mime := range mimes {
+		if _, ok := marshalers[mime]; ok {
+			return mime, true
+		}
+	}
+	return "", false
+}
+
+// registeredMIME returns the registered MIME type of the marshalers which the negotiated MIME type
+// resolves to, like "application/json" of "application/vnd.myapp.v2+json", the metrics and the hooks
+// are keyed by it, so the keys are bounded by the registry instead of the media types of the clients.
+func registeredMIME(marshalers map[string]codec.Marshaler, mime string) string {
+	if _, ok := marshalers[mime]; ok || isSpecialMIME(mime) {
+		return mime
+	}
+	if registered, ok := suffixMIME(marshalers, mime); ok {
+		return registered
+	}
+	return mime
+}
+
+// structuredContentType returns the content type of the marshaler with the media type replaced by
+// the negotiated MIME type, if the MIME type is matched by its structured syntax suffix, like:
+//
+//	application/vnd.myapp.v2+json, application/json; charset=utf-8 --> application/vnd.myapp.v2+json; charset=utf-8
+func structuredContentType(mime, contentType string) string {
+	i := strings.LastIndexByte(mime, '+')
+	if i < 0 {
+		return contentType
+	}
+	mediaType, params, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if strings.EqualFold(mediaType, mime) {
+		return contentType
+	}
+	suffix := mime[i+1:]
+	if mimes, ok := structuredSuffixes[suffix]; ok {
+		for _, v := range mimes {
+			if strings.EqualFold(mediaType, v) {
+				return replaceMediaType(mime, params)
+			}
+		}
+	}
+	if _, subtype, _ := strings.Cut(mediaType, "/"); strings.EqualFold(subtype, suffix) {
+		return replaceMediaType(mime, params)
+	}
+	return contentType
+}
+
+func replaceMediaType(mediaType, params string) string {
+	if params == "" {
+		return mediaType
+	}
+	return mediaType + ";" + params
+}
+
+// VendorVersion returns the version embedded in the vendor media type, like "v2" of
+// "application/vnd.myapp.v2+json", it is the last dot-separated segment of the vendor subtype
+// which is "v" followed by the digits, or empty if there is none.
+func VendorVersion(mediaType string) string {
+	_, subtype, _ := strings.Cut(mediaType, "/")
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+		subtype = subtype[:i]
+	}
+	if len(subtype) < 4 || !strings.EqualFold(subtype[:4], "vnd.") {
+		return ""
+	}
+	segments := strings.Split(subtype[4:], ".")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if isVersion(segments[i]) {
+			return strings.ToLower(segments[i])
+		}
+	}
+	return ""
+}
+
+// isVersion reports whether the segment is like "v2" or "V10".
+func isVersion(segment string) bool {
+	if len(segment) < 2 || (segment[0] != 'v' && segment[0] != 'V') {
+		return false
+	}
+	for i := 1; i < len(segment); i++ {
+		if segment[i] < '0' || segment[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
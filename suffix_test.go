@@ -0,0 +1,149 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/msgpack"
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_Encoding_StructuredSuffix(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	require.NoError(t, registry.Register(Mime_MSGPACK, &msgpack.Codec{}))
+	jsonMarshaler := registry.Get(Mime_JSON)
+
+	t.Run("inbound", func(t *testing.T) {
+		for _, tt := range []struct {
+			contentType string
+			wantMime    string
+			want        any
+		}{
+			{"application/vnd.myapp.v2+json; charset=utf-8", "application/vnd.myapp.v2+json", jsonMarshaler},
+			{"Application/Problem+JSON", "application/problem+json", jsonMarshaler},
+			{"application/atom+xml", "application/atom+xml", registry.Get(Mime_XML)},
+			{"application/vnd.myapp+msgpack", "application/vnd.myapp+msgpack", registry.Get(Mime_MSGPACK)},
+			{"application/vnd.myapp+cbor", Mime_Wildcard, registry.Get(Mime_Wildcard)},
+			{"application/json+", Mime_Wildcard, registry.Get(Mime_Wildcard)},
+		} {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+			req.Header.Set("Content-Type", tt.contentType)
+			mime, m := registry.InboundForRequest(req)
+			require.Equal(t, tt.wantMime, mime, tt.contentType)
+			require.Same(t, tt.want, m, tt.contentType)
+		}
+	})
+	t.Run("render echoes the vendor type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", "application/vnd.myapp.v2+json")
+		w := httptest.NewRecorder()
+		require.NoError(t, registry.Render(w, req, &TestMode{Id: "foo"}))
+		require.Equal(t, "application/vnd.myapp.v2+json; charset=utf-8", w.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"id":"foo","name":""}`, w.Body.String())
+
+		req.Header.Set("Accept", "application/rss+xml")
+		w = httptest.NewRecorder()
+		require.NoError(t, registry.Render(w, req, &TestMode{Id: "foo"}))
+		require.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "application/rss+xml"))
+	})
+	t.Run("exact match wins for the same quality", func(t *testing.T) {
+		for _, tt := range []struct {
+			accept string
+			want   string
+		}{
+			{"text/html, application/xhtml+xml, application/xml, */*", Mime_XML},
+			{"application/xhtml+xml, application/*, application/json;q=0.9", "application/xhtml+xml"},
+			{"application/xhtml+xml, application/json;q=0.9", "application/xhtml+xml"},
+			{"application/vnd.a+json;q=0.9, application/xml;q=0.5", "application/vnd.a+json"},
+			{`application/atom+xml;v="a,b", application/json`, Mime_JSON},
+		} {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.Header.Set("Accept", tt.accept)
+			mime, _ := registry.outboundForRequest(req)
+			require.Equal(t, tt.want, mime, tt.accept)
+		}
+	})
+	t.Run("exact match wins", func(t *testing.T) {
+		registry := New()
+		require.NoError(t, registry.Register("application/vnd.myapp.v2+json", &marshalers[1]))
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+		req.Header.Set("Content-Type", "application/vnd.myapp.v2+json")
+		req.Header.Set("Accept", "application/vnd.myapp.v2+json")
+		_, m := registry.InboundForRequest(req)
+		require.Same(t, &marshalers[1], m)
+		require.Same(t, &marshalers[1], registry.OutboundForRequest(req))
+	})
+}
+
+func Test_structuredContentType(t *testing.T) {
+	for _, tt := range []struct {
+		mime, contentType, want string
+	}{
+		{"application/vnd.a+json", "application/json; charset=utf-8", "application/vnd.a+json; charset=utf-8"},
+		{"application/vnd.a+xml", "text/xml", "application/vnd.a+xml"},
+		{"application/vnd.a+msgpack", "application/x-msgpack", "application/vnd.a+msgpack"},
+		{"application/vnd.a+json", "application/vnd.a+json; v=1", "application/vnd.a+json; v=1"},
+		{"application/vnd.a+json", "text/plain", "text/plain"},
+		{Mime_JSON, "application/json; charset=utf-8", "application/json; charset=utf-8"},
+	} {
+		require.Equal(t, tt.want, structuredContentType(tt.mime, tt.contentType))
+	}
+}
+
+func Test_VendorVersion(t *testing.T) {
+	for _, tt := range []struct {
+		mediaType, want string
+	}{
+		{"application/vnd.acme.v2+json", "v2"},
+		{"application/VND.acme.V10+json", "v10"},
+		{"application/vnd.acme.v1.v2", "v2"},
+		{"application/vnd.acme.v2.beta+json", "v2"},
+		{"application/vnd.acme+json", ""},
+		{"application/vnd.acme.version+json", ""},
+		{"application/x.acme.v2+json", ""},
+		{Mime_JSON, ""},
+		{"", ""},
+	} {
+		require.Equal(t, tt.want, VendorVersion(tt.mediaType), tt.mediaType)
+	}
+}
+
+func Test_Encoding_VendorTypes(t *testing.T) {
+	const v1, v2 = "application/vnd.acme.v1+json", "application/vnd.acme.v2+json"
+	registry := New()
+	require.NoError(t, registry.Register(v1, &msgpack.Codec{}))
+	require.NoError(t, registry.Register(v2, &xml.Codec{}))
+
+	for _, tt := range []struct {
+		accept    string
+		want      string
+		marshaler string
+		version   string
+	}{
+		{"application/vnd.acme.v3+json, application/vnd.acme.v1+json", v1, v1, "v1"},
+		{"application/vnd.acme.v1+json;q=0.8, application/vnd.acme.v2+json;q=0.9, application/json;q=0.5", v2, v2, "v2"},
+		{"application/vnd.acme.v3+json, application/json;q=0.9, application/vnd.acme.v2+json", v2, v2, "v2"},
+		{"application/vnd.acme.v3+json, application/vnd.acme.v2+json;q=0.9", "application/vnd.acme.v3+json", Mime_JSON, "v3"},
+		{"application/vnd.acme.v2+json; version=2.1, */*;q=0.1", v2, v2, "2.1"},
+		{"application/json, application/vnd.acme.v1+json;q=0.1", Mime_JSON, Mime_JSON, ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept", tt.accept)
+		_, out := registry.Negotiate(req)
+		require.Equal(t, tt.want, out.MediaType, tt.accept)
+		require.Same(t, registry.Get(tt.marshaler), out.Marshaler, tt.accept)
+		require.Equal(t, tt.version, out.Version(), tt.accept)
+	}
+
+	// the inbound.
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("Content-Type", v1+"; charset=utf-8")
+	in, _ := registry.Negotiate(req)
+	require.Same(t, registry.Get(v1), in.Marshaler)
+	require.Equal(t, "v1", in.Version())
+}
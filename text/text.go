@@ -0,0 +1,75 @@
+package text
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// Codec is a Codec implementation which copies the UTF-8 text verbatim, like "text/plain".
+// It marshals string, []byte and the non-nil pointers of them, and unmarshals into *string,
+// *[]byte, *any which is set to string, or io.Writer which the text is copied to, the other types fail.
+// The body with the non UTF-8 charset parameter is transcoded by Bind before the decoder.
+type Codec struct{}
+
+// ContentType always Returns "text/plain; charset=utf-8".
+func (*Codec) ContentType(_ any) string {
+	return "text/plain; charset=utf-8"
+}
+func (*Codec) Marshal(v any) ([]byte, error) {
+	switch vv := v.(type) {
+	case string:
+		return []byte(vv), nil
+	case []byte:
+		return vv, nil
+	case *string:
+		if vv != nil {
+			return []byte(*vv), nil
+		}
+	case *[]byte:
+		if vv != nil {
+			return *vv, nil
+		}
+	}
+	return nil, fmt.Errorf("text: unsupported type %T, want string or []byte", v)
+}
+func (*Codec) Unmarshal(data []byte, v any) error {
+	switch vv := v.(type) {
+	case *string:
+		*vv = string(data)
+	case *[]byte:
+		*vv = append([]byte(nil), data...)
+	case *any:
+		*vv = string(data)
+	case io.Writer:
+		_, err := vv.Write(data)
+		return err
+	default:
+		return fmt.Errorf("text: unsupported type %T, want *string, *[]byte or io.Writer", v)
+	}
+	return nil
+}
+func (c *Codec) NewDecoder(r io.Reader) codec.Decoder {
+	return codec.DecoderFunc(func(v any) error {
+		if w, ok := v.(io.Writer); ok {
+			_, err := io.Copy(w, r)
+			return err
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return c.Unmarshal(data, v)
+	})
+}
+func (c *Codec) NewEncoder(w io.Writer) codec.Encoder {
+	return codec.EncoderFunc(func(v any) error {
+		data, err := c.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
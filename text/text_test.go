@@ -0,0 +1,83 @@
+package text
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodec_ContentType(t *testing.T) {
+	codec := Codec{}
+
+	want := "text/plain; charset=utf-8"
+	if got := codec.ContentType(nil); got != want {
+		t.Errorf("m.ContentType(_) failed, got = %q; want %q; ", got, want)
+	}
+}
+
+func TestCodec_Marshal(t *testing.T) {
+	codec := Codec{}
+	s, b := "foo", []byte("bar")
+
+	for _, v := range []any{"foo", []byte("foo"), &s, &b} {
+		got, err := codec.Marshal(v)
+		if err != nil {
+			t.Errorf("codec.Marshal(%#v) failed with %v; want success", v, err)
+		}
+		if len(got) != 3 {
+			t.Errorf("codec.Marshal(%#v) = %q", v, got)
+		}
+	}
+	for _, v := range []any{nil, 1, struct{}{}, (*string)(nil), map[string]any{}} {
+		if _, err := codec.Marshal(v); err == nil {
+			t.Errorf("codec.Marshal(%#v) not failed", v)
+		}
+	}
+}
+
+func TestCodec_Unmarshal(t *testing.T) {
+	codec := Codec{}
+	data := []byte("héllo")
+
+	var b []byte
+	if err := codec.Unmarshal(data, &b); err != nil || !bytes.Equal(b, data) {
+		t.Errorf("codec.Unmarshal(data, &b) = %q, %v", b, err)
+	}
+	var s string
+	if err := codec.Unmarshal(data, &s); err != nil || s != string(data) {
+		t.Errorf("codec.Unmarshal(data, &s) = %q, %v", s, err)
+	}
+	var a any
+	if err := codec.Unmarshal(data, &a); err != nil || !reflect.DeepEqual(a, string(data)) {
+		t.Errorf("codec.Unmarshal(data, &a) = %#v, %v", a, err)
+	}
+	if err := codec.Unmarshal(data, &struct{}{}); err == nil {
+		t.Errorf("codec.Unmarshal(data, &struct{}{}) not failed")
+	}
+}
+
+func TestCodec_Decoder(t *testing.T) {
+	codec := Codec{}
+
+	w := &bytes.Buffer{}
+	if err := codec.NewDecoder(strings.NewReader("foo")).Decode(w); err != nil || w.String() != "foo" {
+		t.Errorf("dec.Decode(w) = %q, %v", w.String(), err)
+	}
+	var b []byte
+	if err := codec.NewDecoder(strings.NewReader("foo")).Decode(&b); err != nil || string(b) != "foo" {
+		t.Errorf("dec.Decode(&b) = %q, %v", b, err)
+	}
+}
+
+func TestCodec_Encoder(t *testing.T) {
+	codec := Codec{}
+
+	w := &bytes.Buffer{}
+	if err := codec.NewEncoder(w).Encode([]byte("foo")); err != nil || w.String() != "foo" {
+		t.Errorf("enc.Encode(_) = %q, %v", w.String(), err)
+	}
+	if err := codec.NewEncoder(w).Encode(1); err == nil {
+		t.Errorf("enc.Encode(1) not failed")
+	}
+}
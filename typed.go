@@ -0,0 +1,109 @@
+package encoding
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// Codec is a strongly-typed codec for the type T with a resolved marshaler, see TypedCodec.
+// It is safe for concurrent use.
+type Codec[T any] struct {
+	encoding  *Encoding
+	mime      string
+	marshaler codec.Marshaler
+}
+
+// TypedCodec returns a Codec for the type T with the marshaler of the MIME type, like:
+//
+//	c, err := encoding.TypedCodec[examplepb.SimpleMessage](reg, encoding.Mime_PROTOBUF)
+//
+// The MIME type is case-insensitive, the marshaler is resolved once, later changes of the Encoding do not affect it.
+// It returns an error if the MIME type is not registered, or the marshaler can not handle *T,
+// like the proto marshaler with a non proto.Message type.
+func TypedCodec[T any](reg *Encoding, mime string) (*Codec[T], error) {
+	s := reg.load()
+	var m codec.Marshaler
+	switch mime {
+	case Mime_Query, Mime_Uri, Mime_Wildcard, Mime_DefaultInbound, Mime_DefaultOutbound:
+		m = s.get(mime)
+	default:
+		mime = normalizeMIME(mime)
+		m = s.mimeMap[mime]
+	}
+	if m == nil {
+		return nil, fmt.Errorf("encoding: MIME(%s) marshaller not registered", mime)
+	}
+	// check the marshaler can handle *T, and pre-compute the reflection metadata.
+	v := new(T)
+	if _, err := m.Marshal(v); err != nil {
+		return nil, fmt.Errorf("encoding: MIME(%s) marshaller can not handle %T: %w", mime, v, err)
+	}
+	if f, ok := m.(codec.FormCodec); ok {
+		if err := f.Decode(url.Values{}, v); err != nil {
+			return nil, fmt.Errorf("encoding: MIME(%s) marshaller can not handle %T: %w", mime, v, err)
+		}
+	}
+	return &Codec[T]{
+		encoding:  reg,
+		mime:      mime,
+		marshaler: m,
+	}, nil
+}
+
+// MIME returns the MIME type of the Codec.
+func (c *Codec[T]) MIME() string { return c.mime }
+
+// Marshal marshals v into byte sequence.
+func (c *Codec[T]) Marshal(v *T) ([]byte, error) {
+	return c.marshaler.Marshal(v)
+}
+
+// Unmarshal unmarshals the byte sequence into a new *T.
+func (c *Codec[T]) Unmarshal(data []byte) (*T, error) {
+	v := new(T)
+	if err := c.marshaler.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// DecodeRequest decodes the request body into a new *T, regardless of the `Content-Type` header.
+// Like BindWith, the body is limited by WithMaxBodyBytes, its content coding is decoded,
+// and the context of the request is checked between the reads of the body, see Bind.
+func (c *Codec[T]) DecodeRequest(req *http.Request) (*T, error) {
+	v := new(T)
+	err := c.decodeRequest(req, v)
+	if err != nil {
+		if c.encoding.onBindError != nil {
+			callErrorHook(c.encoding.onBindError, req, err)
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func (c *Codec[T]) decodeRequest(req *http.Request, v *T) error {
+	n := Negotiation{MediaType: c.mime, Marshaler: c.marshaler}
+	if c.mime == Mime_MultipartPostForm {
+		n.Params = parseContentTypeParams(req)
+	}
+	done, err := c.encoding.prepareBody(req, c.encoding.maxBodyBytes)
+	if err != nil {
+		return err
+	}
+	return done(c.encoding.bindNegotiated(req, n, v, nil))
+}
+
+// EncodeResponse writes v as the response body, regardless of the `Accept` header.
+// Like RenderWith, it never touches the ResponseWriter before Marshal succeeds, sets the `Content-Length`,
+// and does not write the body of the HEAD request.
+func (c *Codec[T]) EncodeResponse(w http.ResponseWriter, req *http.Request, v *T) error {
+	err := c.encoding.renderNegotiated(w, req, 0, v, c.mime, c.marshaler)
+	if err != nil && c.encoding.onRenderError != nil {
+		callErrorHook(c.encoding.onRenderError, req, err)
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package encoding
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+)
+
+func Test_TypedCodec_Proto(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_PROTOBUF, &pro.Codec{}))
+
+	c, err := TypedCodec[examplepb.Complex](registry, "Application/X-Protobuf")
+	require.NoError(t, err)
+	require.Equal(t, Mime_PROTOBUF, c.MIME())
+
+	want := &examplepb.Complex{Id: 11, NoOne: "foo"}
+	b, err := c.Marshal(want)
+	require.NoError(t, err)
+	got, err := c.Unmarshal(b)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(want, got))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(b))
+	got, err = c.DecodeRequest(req)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(want, got))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, c.EncodeResponse(w, req, want))
+	require.Equal(t, b, w.Body.Bytes())
+	require.Equal(t, (&pro.Codec{}).ContentType(want), w.Header().Get("Content-Type"))
+}
+
+func Test_TypedCodec_Struct(t *testing.T) {
+	registry := New()
+	for _, mime := range []string{Mime_JSON, Mime_PostForm} {
+		c, err := TypedCodec[TestMode](registry, mime)
+		require.NoError(t, err)
+
+		want := &TestMode{Id: "foo", Name: "bar"}
+		b, err := c.Marshal(want)
+		require.NoError(t, err)
+		got, err := c.Unmarshal(b)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(b))
+		got, err = c.DecodeRequest(req)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		w := httptest.NewRecorder()
+		require.NoError(t, c.EncodeResponse(w, req, want))
+		require.Equal(t, b, w.Body.Bytes())
+	}
+}
+
+func Test_TypedCodec_Mismatch(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.Register(Mime_PROTOBUF, &pro.Codec{}))
+
+	_, err := TypedCodec[TestMode](registry, Mime_PROTOBUF)
+	require.ErrorContains(t, err, "can not handle *encoding.TestMode")
+
+	_, err = TypedCodec[chan int](registry, Mime_JSON)
+	require.Error(t, err)
+
+	_, err = TypedCodec[TestMode](registry, Mime_MSGPACK)
+	require.ErrorContains(t, err, "not registered")
+}
+
+func Test_TypedCodec_DecodeRequest_MaxBodyBytes(t *testing.T) {
+	c, err := TypedCodec[TestMode](New(WithMaxBodyBytes(32)), Mime_JSON)
+	require.NoError(t, err)
+
+	body := `{"id":"foo","name":"` + strings.Repeat("x", 64) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+	req.ContentLength = -1
+	_, err = c.DecodeRequest(req)
+	require.ErrorIs(t, err, ErrBodyTooLarge)
+
+	req = httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"id":"foo"}`))
+	got, err := c.DecodeRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, &TestMode{Id: "foo"}, got)
+}
+
+func Test_TypedCodec_EncodeResponse(t *testing.T) {
+	c, err := TypedCodec[TestMode](New(), Mime_JSON)
+	require.NoError(t, err)
+	want := &TestMode{Id: "foo"}
+	b, err := c.Marshal(want)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, c.EncodeResponse(w, httptest.NewRequest(http.MethodGet, "http://example.com", nil), want))
+	require.Equal(t, b, w.Body.Bytes())
+	require.Equal(t, strconv.Itoa(len(b)), w.Header().Get("Content-Length"))
+
+	// the body of the HEAD request is not written.
+	w = httptest.NewRecorder()
+	require.NoError(t, c.EncodeResponse(w, httptest.NewRequest(http.MethodHead, "http://example.com", nil), want))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Body.Bytes())
+	require.Equal(t, strconv.Itoa(len(b)), w.Header().Get("Content-Length"))
+
+	// the marshal error is wrapped in RenderError.
+	fc, err := TypedCodec[TestMode](New(), Mime_JSON)
+	require.NoError(t, err)
+	fc.marshaler = &failingMarshaler{}
+	var e *RenderError
+	require.ErrorAs(t, fc.EncodeResponse(httptest.NewRecorder(), nil, want), &e)
+}
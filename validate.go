@@ -0,0 +1,85 @@
+package encoding
+
+import (
+	"errors"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// BindOption is the per-call option of Bind and the other binds.
+type BindOption func(*bindOptions)
+
+type bindOptions struct {
+	skipValidation     bool
+	multipartMaxMemory int64
+	decoder            codec.DecoderOptions
+	merge              bool
+}
+
+// newBindOptions returns the options of the call, it is nil if there are no options.
+func newBindOptions(opts []BindOption) *bindOptions {
+	if len(opts) == 0 {
+		return nil
+	}
+	o := &bindOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// SkipValidation skips the validator of WithValidator for this call,
+// like the draft endpoint which stores the incomplete value.
+func SkipValidation() BindOption {
+	return func(o *bindOptions) {
+		o.skipValidation = true
+	}
+}
+
+// ValidationError is returned by Bind and the other binds when the validator of WithValidator
+// rejects the bound value, the caller should respond with 400 Bad Request.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string { return "encoding: validation: " + e.Err.Error() }
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// WithValidator sets the validator which is called after the value is bound successfully by Bind,
+// BindQuery, BindUri, BindAll and the other binds, its error is wrapped by *ValidationError,
+// so go-playground/validator or protovalidate can be plugged in, like:
+//
+//	validate := validator.New()
+//	enc := encoding.New(encoding.WithValidator(validate.Struct))
+//
+// see ValidateMethod to call the Validate method of the value, and SkipValidation to skip it per call.
+func WithValidator(validator func(v any) error) Option {
+	return func(r *Encoding) {
+		r.validator = validator
+	}
+}
+
+// ValidateMethod calls the Validate method of v if it implements interface{ Validate() error },
+// like the messages generated by protoc-gen-validate, it is the validator of WithValidator.
+func ValidateMethod(v any) error {
+	if vv, ok := v.(interface{ Validate() error }); ok {
+		return vv.Validate()
+	}
+	return nil
+}
+
+// validate validates v with the validator if the bind succeeds.
+func (r *Encoding) validate(v any, err error, o *bindOptions) error {
+	if err != nil || r.validator == nil || (o != nil && o.skipValidation) {
+		return err
+	}
+	if err = r.validator(v); err != nil {
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			return err
+		}
+		return &ValidationError{Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type validatedMode struct {
+	Id string `json:"id"`
+}
+
+func (v *validatedMode) Validate() error {
+	if v.Id == "" {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+func Test_WithValidator(t *testing.T) {
+	var bindErrors []error
+	registry := New(
+		WithValidator(ValidateMethod),
+		WithOnBindError(func(_ *http.Request, err error) { bindErrors = append(bindErrors, err) }),
+	)
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+		req.Header.Set("Content-Type", Mime_JSON)
+		return req
+	}
+
+	require.NoError(t, registry.Bind(newRequest(`{"id":"foo"}`), &validatedMode{}))
+	err := registry.Bind(newRequest(`{}`), &validatedMode{})
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	require.EqualError(t, err, "encoding: validation: id is required")
+	require.Equal(t, []error{err}, bindErrors)
+
+	// the decode error is not a validation error.
+	err = registry.Bind(newRequest(`{`), &validatedMode{})
+	require.Error(t, err)
+	require.False(t, errors.As(err, &ve))
+
+	// the other binds.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.ErrorAs(t, registry.BindQuery(req, &validatedMode{}), &ve)
+	require.ErrorAs(t, registry.BindUri(url.Values{}, &validatedMode{}), &ve)
+	require.NoError(t, registry.BindUri(url.Values{"id": {"foo"}}, &validatedMode{}))
+	require.ErrorAs(t, registry.BindAll(newRequest(`{}`), nil, &validatedMode{}), &ve)
+	require.ErrorAs(t, registry.BindWith(newRequest(`{}`), &validatedMode{}, Mime_JSON), &ve)
+	require.NoError(t, registry.BindAll(newRequest(`{}`), url.Values{"id": {"foo"}}, &validatedMode{}))
+
+	// skip per call.
+	require.NoError(t, registry.Bind(newRequest(`{}`), &validatedMode{}, SkipValidation()))
+	require.NoError(t, registry.BindQuery(req, &validatedMode{}, SkipValidation()))
+	require.NoError(t, registry.BindUri(url.Values{}, &validatedMode{}, SkipValidation()))
+
+	// the custom validator, and the values without Validate.
+	registry = New(WithValidator(func(v any) error {
+		if m, ok := v.(*TestMode); ok && m.Name == "" {
+			return &ValidationError{Err: errors.New("name is required")}
+		}
+		return nil
+	}))
+	err = registry.Bind(newRequest(`{"id":"foo"}`), &TestMode{})
+	require.ErrorAs(t, err, &ve)
+	require.EqualError(t, err, "encoding: validation: name is required")
+	require.NoError(t, New(WithValidator(ValidateMethod)).Bind(newRequest(`{}`), &TestMode{}))
+	require.NoError(t, New().Bind(newRequest(`{}`), &validatedMode{}))
+}
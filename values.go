@@ -0,0 +1,82 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// BindValues binds the passed struct pointer with the url.Values using the Mime_PostForm codec.Marshaler,
+// like the form parsed by the framework before, such as ParseForm of gin and echo.
+// It returns ErrNotRegistered if Mime_PostForm is not registered.
+// NOTE: the *http.Request passed to the bind error callback is nil.
+func (r *Encoding) BindValues(vals url.Values, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindValues(vals, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, nil, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindValues(vals url.Values, v any, o *bindOptions) error {
+	m, err := r.formCodec(Mime_PostForm, o)
+	if err != nil {
+		return err
+	}
+	return bindError(Mime_PostForm, v, m.Decode(vals, v))
+}
+
+// BindMultipart binds the passed struct pointer with the multipart form using the Mime_MultipartPostForm
+// codec.Marshaler, like the form parsed by the framework before, such as ParseMultipartForm of gin and echo,
+// the files are decoded if the marshaler implements codec.MultipartDecoder.
+// It returns ErrNotRegistered if Mime_MultipartPostForm is not registered.
+// NOTE: the *http.Request passed to the bind error callback is nil.
+func (r *Encoding) BindMultipart(form *multipart.Form, v any, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindMultipart(form, v, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, nil, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindMultipart(form *multipart.Form, v any, o *bindOptions) error {
+	if form == nil {
+		return errors.New("encoding: bind multipart: nil multipart form")
+	}
+	m, err := r.formCodec(Mime_MultipartPostForm, o)
+	if err != nil {
+		return err
+	}
+	return bindError(Mime_MultipartPostForm, v, decodeMultipart(m, form, v))
+}
+
+// formCodec returns the codec.FormCodec registered for the MIME type.
+func (r *Encoding) formCodec(mime string, o *bindOptions) (codec.FormCodec, error) {
+	marshaler, ok := r.load().mimeMap[mime]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, mime)
+	}
+	marshaler, err := resolveMarshaler(marshaler)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := o.configureDecoder(marshaler).(codec.FormCodec)
+	if !ok {
+		return nil, fmt.Errorf("encoding: not supported marshaller(%v)", mime)
+	}
+	return m, nil
+}
+
+// decodeMultipart decodes the multipart form with codec.MultipartDecoder if m implements it,
+// otherwise only the values are decoded.
+func decodeMultipart(m codec.FormCodec, form *multipart.Form, v any) error {
+	if md, ok := m.(codec.MultipartDecoder); ok {
+		return md.DecodeMultipart(form, v)
+	}
+	return m.Decode(form.Value, v)
+}
@@ -0,0 +1,74 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Encoding_BindValues(t *testing.T) {
+	registry := New()
+
+	got := &TestMode{}
+	require.NoError(t, registry.BindValues(url.Values{"id": {"foo"}, "name": {"bar"}}, got))
+	require.Equal(t, &TestMode{Id: "foo", Name: "bar"}, got)
+
+	type Target struct {
+		Age int `json:"age"`
+	}
+	var e *BindError
+	require.True(t, errors.As(registry.BindValues(url.Values{"age": {"ten"}}, &Target{}), &e))
+	require.Equal(t, Mime_PostForm, e.MIME)
+	require.Equal(t, "age", e.Field)
+
+	require.NoError(t, registry.Delete(Mime_PostForm))
+	require.ErrorIs(t, registry.BindValues(url.Values{"id": {"foo"}}, &TestMode{}), ErrNotRegistered)
+}
+
+func Test_Encoding_BindMultipart(t *testing.T) {
+	type Upload struct {
+		Id   string                `json:"id"`
+		File *multipart.FileHeader `json:"file"`
+	}
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	require.NoError(t, mw.WriteField("id", "foo"))
+	fw, err := mw.CreateFormFile("file", "file.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	form, err := multipart.NewReader(buf, mw.Boundary()).ReadForm(defaultMemory)
+	require.NoError(t, err)
+	defer form.RemoveAll() //nolint: errcheck
+
+	registry := New()
+	got := &Upload{}
+	require.NoError(t, registry.BindMultipart(form, got))
+	require.Equal(t, "foo", got.Id)
+	require.NotNil(t, got.File)
+	require.Equal(t, "file.txt", got.File.Filename)
+
+	require.Error(t, registry.BindMultipart(nil, &Upload{}))
+	require.NoError(t, registry.Delete(Mime_MultipartPostForm))
+	require.ErrorIs(t, registry.BindMultipart(form, &Upload{}), ErrNotRegistered)
+}
+
+func Test_Encoding_Bind_ParsedForm_ContentEncoding(t *testing.T) {
+	// the content coding of the consumed body is not decoded.
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(""))
+	req.ContentLength = 10
+	req.Header.Set("Content-Type", Mime_PostForm)
+	req.Header.Set("Content-Encoding", "gzip")
+	req.PostForm = url.Values{"id": {"foo"}}
+	got := &TestMode{}
+	require.NoError(t, New().Bind(req, got))
+	require.Equal(t, &TestMode{Id: "foo"}, got)
+}
@@ -0,0 +1,80 @@
+package encoding
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// BindWith binds the passed struct pointer with the marshaler registered for the case-insensitive MIME type,
+// regardless of the `Content-Type` header, like the legacy client which posts JSON with "text/plain".
+// Mime_Query binds the query, Mime_Uri binds the path values of the request like BindPathValues,
+// Mime_Wildcard uses the "*" Marshaler, and "multipart/form-data" still parses the multipart form
+// with the boundary of the `Content-Type` header.
+// It returns ErrNotRegistered if the MIME type is not registered, it never falls back to the "*" Marshaler.
+func (r *Encoding) BindWith(req *http.Request, v any, mime string, opts ...BindOption) error {
+	o := newBindOptions(opts)
+	err := r.validate(v, r.bindWith(req, v, mime, o), o)
+	if err != nil && r.onBindError != nil {
+		callErrorHook(r.onBindError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) bindWith(req *http.Request, v any, mime string, o *bindOptions) error {
+	s := r.load()
+	n := Negotiation{Params: parseContentTypeParams(req)}
+	switch mime {
+	case Mime_Query:
+		return r.bindQuery(req, v, o)
+	case Mime_Uri:
+		return r.bindPathValues(req, nil, v, o)
+	case Mime_Wildcard, Mime_DefaultInbound:
+		n.MediaType, n.Marshaler = Mime_Wildcard, s.mimeInboundDefault
+	default:
+		var ok bool
+		n.MediaType, n.Marshaler, ok = lookupStructured(s.mimeMap, mime)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrNotRegistered, mime)
+		}
+	}
+	done, err := r.prepareBody(req, r.maxBodyBytes)
+	if err != nil {
+		return err
+	}
+	return done(r.bindNegotiated(req, n, v, o))
+}
+
+// RenderWith writes the response with the marshaler registered for the case-insensitive MIME type,
+// regardless of the `Accept` header, Mime_Wildcard uses the "*" Marshaler, see Render.
+// It returns ErrNotRegistered if the MIME type is not registered, it never falls back to the "*" Marshaler.
+func (r *Encoding) RenderWith(w http.ResponseWriter, req *http.Request, v any, mime string) error {
+	err := r.renderWith(w, req, v, mime)
+	if err != nil && r.onRenderError != nil {
+		callErrorHook(r.onRenderError, req, err)
+	}
+	return err
+}
+
+func (r *Encoding) renderWith(w http.ResponseWriter, req *http.Request, v any, mime string) error {
+	if v == nil {
+		return nil
+	}
+	s := r.load()
+	var (
+		mediaType string
+		m         codec.Marshaler
+	)
+	switch mime {
+	case Mime_Wildcard, Mime_DefaultOutbound:
+		mediaType, m = Mime_Wildcard, s.mimeOutboundDefault
+	default:
+		var ok bool
+		mediaType, m, ok = lookupStructured(s.outboundMarshalers(), mime)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrNotRegistered, mime)
+		}
+	}
+	return r.renderNegotiated(w, req, 0, v, mediaType, m)
+}
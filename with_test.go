@@ -0,0 +1,88 @@
+package encoding
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thinkgos/encoding/xml"
+)
+
+func Test_Encoding_BindWith(t *testing.T) {
+	var bindErrors int
+	registry := New(WithOnBindError(func(*http.Request, error) { bindErrors++ }))
+	newRequest := func(body, contentType string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com?id=query", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	got := &TestMode{}
+	require.NoError(t, registry.BindWith(newRequest(`{"id":"foo"}`, Mime_Plain), got, "Application/JSON"))
+	require.Equal(t, &TestMode{Id: "foo"}, got)
+
+	got = &TestMode{}
+	require.NoError(t, registry.BindWith(newRequest(`{"id":"foo"}`, Mime_JSON), got, Mime_Query))
+	require.Equal(t, &TestMode{Id: "query"}, got)
+
+	got = &TestMode{}
+	require.NoError(t, registry.BindWith(newRequest(`{"id":"foo"}`, Mime_Plain), got, Mime_Wildcard))
+	require.Equal(t, &TestMode{Id: "foo"}, got)
+
+	// the multipart form with the boundary of the header.
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	require.NoError(t, mw.WriteField("id", "multipart"))
+	require.NoError(t, mw.Close())
+	got = &TestMode{}
+	require.NoError(t, registry.BindWith(newRequest(body.String(), mw.FormDataContentType()), got, Mime_MultipartPostForm))
+	require.Equal(t, &TestMode{Id: "multipart"}, got)
+
+	// the path values.
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		got := &TestMode{}
+		require.NoError(t, registry.BindWith(req, got, Mime_Uri))
+		require.Equal(t, &TestMode{Id: "42"}, got)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "http://example.com/users/42", nil))
+
+	// not registered.
+	err := registry.BindWith(newRequest(`<TestMode/>`, Mime_XML), &TestMode{}, Mime_XML)
+	require.ErrorIs(t, err, ErrNotRegistered)
+	require.ErrorContains(t, err, Mime_XML)
+	require.Equal(t, 1, bindErrors)
+}
+
+func Test_Encoding_RenderWith(t *testing.T) {
+	var renderErrors int
+	registry := New(WithOnRenderError(func(*http.Request, error) { renderErrors++ }))
+	require.NoError(t, registry.Register(Mime_XML, &xml.Codec{}))
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept", Mime_JSON)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, registry.RenderWith(w, req, &TestMode{Id: "foo"}, "Application/XML"))
+	require.Contains(t, w.Header().Get("Content-Type"), Mime_XML)
+	require.Contains(t, w.Body.String(), "<id>foo</id>")
+
+	w = httptest.NewRecorder()
+	require.NoError(t, registry.RenderWith(w, req, &TestMode{Id: "foo"}, Mime_Wildcard))
+	require.Contains(t, w.Header().Get("Content-Type"), Mime_JSON)
+
+	w = httptest.NewRecorder()
+	require.NoError(t, registry.RenderWith(w, req, nil, Mime_XML))
+	require.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	err := registry.RenderWith(w, req, &TestMode{Id: "foo"}, Mime_YAML)
+	require.ErrorIs(t, err, ErrNotRegistered)
+	require.ErrorContains(t, err, Mime_YAML)
+	require.Empty(t, w.Body.String())
+	require.Equal(t, 1, renderErrors)
+}
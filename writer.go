@@ -0,0 +1,121 @@
+package encoding
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrWriterFlushed is returned by the NegotiatedWriter when it is written or flushed after flushed.
+var ErrWriterFlushed = errors.New("encoding: negotiated writer already flushed")
+
+// NegotiatedWriter is a http.ResponseWriter which defers the marshaling, the handler assigns
+// the payload and the status, and an outer layer, like the middleware, decides when to Flush,
+// after setting status, after recovery, after metrics, etc.
+// The direct Write calls bypass the negotiation and mark the writer as raw, the payload is ignored then.
+// It is not safe for concurrent use.
+type NegotiatedWriter struct {
+	w        http.ResponseWriter
+	req      *http.Request
+	encoding *Encoding
+
+	payload     any
+	status      int
+	wroteHeader bool
+	raw         bool
+	flushed     bool
+}
+
+// NewNegotiatedWriter returns a NegotiatedWriter which renders the payload with the Encoding for the request.
+func NewNegotiatedWriter(w http.ResponseWriter, req *http.Request, reg *Encoding) *NegotiatedWriter {
+	return &NegotiatedWriter{
+		w:        w,
+		req:      req,
+		encoding: reg,
+	}
+}
+
+// SetPayload sets the payload which is rendered when Flush, the last one wins.
+func (nw *NegotiatedWriter) SetPayload(v any) { nw.payload = v }
+
+// Payload returns the payload.
+func (nw *NegotiatedWriter) Payload() any { return nw.payload }
+
+// SetStatus sets the status code which is written when Flush or the first Write, the last one wins.
+func (nw *NegotiatedWriter) SetStatus(code int) { nw.status = code }
+
+// Status returns the status code, it is http.StatusOK if not set.
+func (nw *NegotiatedWriter) Status() int {
+	if nw.status == 0 {
+		return http.StatusOK
+	}
+	return nw.status
+}
+
+// Raw reports whether the writer is written directly by Write.
+func (nw *NegotiatedWriter) Raw() bool { return nw.raw }
+
+// Flushed reports whether the writer is flushed.
+func (nw *NegotiatedWriter) Flushed() bool { return nw.flushed }
+
+// Header returns the header map of the underlying http.ResponseWriter.
+func (nw *NegotiatedWriter) Header() http.Header { return nw.w.Header() }
+
+// WriteHeader is the same as SetStatus, the status code is deferred.
+func (nw *NegotiatedWriter) WriteHeader(code int) { nw.SetStatus(code) }
+
+// Write writes the data directly, it bypasses the negotiation and marks the writer as raw.
+// It returns ErrWriterFlushed if the writer is flushed without raw writes.
+func (nw *NegotiatedWriter) Write(b []byte) (int, error) {
+	if nw.flushed && !nw.raw {
+		return 0, ErrWriterFlushed
+	}
+	nw.raw = true
+	nw.writeHeader()
+	return nw.w.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, it is used by http.ResponseController.
+func (nw *NegotiatedWriter) Unwrap() http.ResponseWriter { return nw.w }
+
+// Flush performs the negotiation, marshaling and writing of the payload with the status code.
+// It only marks the writer flushed if it is raw, and returns ErrWriterFlushed if it is flushed already.
+// If Render fails, the header is not written unless the marshal error fallback is set, see WithMarshalErrorFallback.
+func (nw *NegotiatedWriter) Flush() error {
+	if nw.flushed {
+		return ErrWriterFlushed
+	}
+	nw.flushed = true
+	if nw.raw {
+		return nil
+	}
+	if err := nw.encoding.Render(deferredHeaderWriter{nw}, nw.req, nw.payload); err != nil {
+		return err
+	}
+	// the nil payload is not rendered, write the status code only.
+	nw.writeHeader()
+	return nil
+}
+
+func (nw *NegotiatedWriter) writeHeader() {
+	if !nw.wroteHeader {
+		nw.wroteHeader = true
+		nw.w.WriteHeader(nw.Status())
+	}
+}
+
+// deferredHeaderWriter writes the status code of the NegotiatedWriter before the first Write.
+type deferredHeaderWriter struct {
+	nw *NegotiatedWriter
+}
+
+func (d deferredHeaderWriter) Header() http.Header { return d.nw.w.Header() }
+
+func (d deferredHeaderWriter) WriteHeader(code int) {
+	d.nw.wroteHeader = true
+	d.nw.w.WriteHeader(code)
+}
+
+func (d deferredHeaderWriter) Write(b []byte) (int, error) {
+	d.nw.writeHeader()
+	return d.nw.w.Write(b)
+}
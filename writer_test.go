@@ -0,0 +1,162 @@
+package encoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// negotiatedMiddleware wraps the handler with a NegotiatedWriter which is flushed at last,
+// the panic is recovered to a 500 payload.
+func negotiatedMiddleware(reg *Encoding, flushErr *error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			nw := NewNegotiatedWriter(w, req, reg)
+			defer func() {
+				if v := recover(); v != nil {
+					nw.SetStatus(http.StatusInternalServerError)
+					nw.SetPayload(map[string]any{"error": v})
+				}
+				*flushErr = nw.Flush()
+			}()
+			next.ServeHTTP(nw, req)
+		})
+	}
+}
+
+// headerMiddleware sets the header after the handler, before the flush.
+func headerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req)
+		w.Header().Set("X-Handled", "true")
+	})
+}
+
+func Test_NegotiatedWriter(t *testing.T) {
+	registry := New()
+
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantStatus  int
+		wantBody    string
+		wantHandled bool
+	}{
+		{
+			name: "payload",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				nw := w.(*NegotiatedWriter)
+				nw.SetStatus(http.StatusCreated)
+				nw.SetPayload(&TestMode{Id: "foo", Name: "bar"})
+			},
+			wantStatus:  http.StatusCreated,
+			wantBody:    `{"id":"foo","name":"bar"}`,
+			wantHandled: true,
+		},
+		{
+			name: "WriteHeader is deferred",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				w.(*NegotiatedWriter).SetPayload(&TestMode{Id: "foo"})
+			},
+			wantStatus:  http.StatusAccepted,
+			wantBody:    `{"id":"foo","name":""}`,
+			wantHandled: true,
+		},
+		{
+			name: "nil payload",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			wantStatus:  http.StatusNoContent,
+			wantHandled: true,
+		},
+		{
+			name: "panic recovery",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.(*NegotiatedWriter).SetPayload(&TestMode{Id: "foo"})
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"boom"}`,
+		},
+		{
+			name: "raw write bypasses the negotiation",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.(*NegotiatedWriter).SetPayload(&TestMode{Id: "foo"})
+				w.WriteHeader(http.StatusTeapot)
+				_, _ = w.Write([]byte("raw"))
+			},
+			wantStatus: http.StatusTeapot,
+			wantBody:   "raw",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flushErr error
+			handler := negotiatedMiddleware(registry, &flushErr)(headerMiddleware(tt.handler))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+			require.NoError(t, flushErr)
+			require.Equal(t, tt.wantStatus, w.Code)
+			require.Equal(t, tt.wantBody, w.Body.String())
+			if tt.wantHandled {
+				require.Equal(t, "true", w.Header().Get("X-Handled"))
+			}
+		})
+	}
+}
+
+func Test_NegotiatedWriter_Flushed(t *testing.T) {
+	registry := New()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+
+	w := httptest.NewRecorder()
+	nw := NewNegotiatedWriter(w, req, registry)
+	nw.SetPayload(&TestMode{Id: "foo"})
+	require.NoError(t, nw.Flush())
+	require.True(t, nw.Flushed())
+	require.ErrorIs(t, nw.Flush(), ErrWriterFlushed)
+	_, err := nw.Write([]byte("after"))
+	require.ErrorIs(t, err, ErrWriterFlushed)
+	require.Equal(t, `{"id":"foo","name":""}`, w.Body.String())
+
+	// raw writes are allowed after flush.
+	w = httptest.NewRecorder()
+	nw = NewNegotiatedWriter(w, req, registry)
+	_, err = nw.Write([]byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, nw.Flush())
+	_, err = nw.Write([]byte("b"))
+	require.NoError(t, err)
+	require.ErrorIs(t, nw.Flush(), ErrWriterFlushed)
+	require.True(t, nw.Raw())
+	require.Equal(t, "ab", w.Body.String())
+
+	// the marshal error fallback writes the header.
+	failing := New(WithMarshalErrorFallback(nil))
+	require.NoError(t, failing.Register(Mime_Wildcard, &failingMarshaler{}))
+	w = httptest.NewRecorder()
+	nw = NewNegotiatedWriter(w, req, failing)
+	nw.SetStatus(http.StatusCreated)
+	nw.SetPayload("foo")
+	require.EqualError(t, nw.Flush(), "encoding: render string as MIME(*): marshal failed")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, "Internal Server Error", w.Body.String())
+}
+
+func Test_NegotiatedWriter_Head(t *testing.T) {
+	registry := New()
+	req := httptest.NewRequest(http.MethodHead, "http://example.com", nil)
+
+	w := httptest.NewRecorder()
+	nw := NewNegotiatedWriter(w, req, registry)
+	nw.SetStatus(http.StatusCreated)
+	nw.SetPayload(&TestMode{Id: "foo"})
+	require.NoError(t, nw.Flush())
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Empty(t, w.Body.String())
+}
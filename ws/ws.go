@@ -0,0 +1,122 @@
+// Package ws provides helpers to read and write WebSocket messages with codec.Marshaler.
+//
+// The negotiated subprotocol selects the marshaler, see encoding.Encoding.ForSubprotocol.
+//
+//	m, err := reg.ForSubprotocol(conn.Subprotocol())
+//	if err != nil {
+//		return err
+//	}
+//	err = ws.ReadMessage(conn, m, &req)
+//	...
+//	err = ws.WriteMessage(conn, m, resp)
+//
+// github.com/gorilla/websocket *Conn satisfies MessageReader and MessageWriter directly,
+// github.com/coder/websocket (nhooyr.io/websocket) *Conn satisfies them with WithContext.
+package ws
+
+import (
+	"context"
+	"mime"
+	"strings"
+
+	"github.com/thinkgos/encoding/codec"
+)
+
+// The message types are defined in RFC 6455, section 11.8.
+const (
+	// TextMessage denotes a text data message.
+	TextMessage = 1
+	// BinaryMessage denotes a binary data message.
+	BinaryMessage = 2
+)
+
+// MessageReader reads a complete message from the connection.
+type MessageReader interface {
+	ReadMessage() (messageType int, data []byte, err error)
+}
+
+// MessageWriter writes a complete message to the connection.
+type MessageWriter interface {
+	WriteMessage(messageType int, data []byte) error
+}
+
+// ReadMessage reads the next message from conn and unmarshals it into v with m.
+func ReadMessage(conn MessageReader, m codec.Marshaler, v any) error {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return m.Unmarshal(data, v)
+}
+
+// WriteMessage marshals v with m and writes it to conn as a single message,
+// the message type is chosen by MessageType.
+func WriteMessage(conn MessageWriter, m codec.Marshaler, v any) error {
+	data, err := m.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(MessageType(m, v), data)
+}
+
+// MessageType returns TextMessage if the content type of the marshaler is textual,
+// like text/*, json, xml, yaml, toml and form, otherwise BinaryMessage.
+func MessageType(m codec.Marshaler, v any) int {
+	if IsText(m.ContentType(v)) {
+		return TextMessage
+	}
+	return BinaryMessage
+}
+
+// IsText reports whether the content type is textual.
+func IsText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	if typ == "text" {
+		return true
+	}
+	if typ != "application" {
+		return false
+	}
+	if idx := strings.LastIndexByte(subtype, '+'); idx >= 0 {
+		subtype = subtype[idx+1:]
+	}
+	switch strings.TrimPrefix(subtype, "x-") {
+	case "json", "xml", "yaml", "toml", "www-form-urlencoded", "javascript":
+		return true
+	default:
+		return false
+	}
+}
+
+// ContextConn is a WebSocket connection which reads and writes messages with a context,
+// like github.com/coder/websocket (nhooyr.io/websocket) *Conn.
+type ContextConn[T ~int] interface {
+	Read(ctx context.Context) (T, []byte, error)
+	Write(ctx context.Context, typ T, data []byte) error
+}
+
+// Conn adapts a ContextConn to MessageReader and MessageWriter.
+type Conn[T ~int] struct {
+	ctx  context.Context
+	conn ContextConn[T]
+}
+
+// WithContext returns a Conn which reads and writes messages on conn with ctx.
+func WithContext[T ~int](ctx context.Context, conn ContextConn[T]) *Conn[T] {
+	return &Conn[T]{ctx: ctx, conn: conn}
+}
+
+// ReadMessage implements MessageReader.
+func (c *Conn[T]) ReadMessage() (int, []byte, error) {
+	typ, data, err := c.conn.Read(c.ctx)
+	return int(typ), data, err
+}
+
+// WriteMessage implements MessageWriter.
+func (c *Conn[T]) WriteMessage(messageType int, data []byte) error {
+	return c.conn.Write(c.ctx, T(messageType), data)
+}
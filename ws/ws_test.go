@@ -0,0 +1,171 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/encoding"
+	"github.com/thinkgos/encoding/msgpack"
+	pro "github.com/thinkgos/encoding/proto"
+	"github.com/thinkgos/encoding/testdata/examplepb"
+	"github.com/thinkgos/encoding/xml"
+)
+
+type message struct {
+	typ  int
+	data []byte
+}
+
+// pipe is an in-memory gorilla style connection.
+type pipe struct {
+	ch chan message
+}
+
+func newPipe() *pipe { return &pipe{ch: make(chan message, 1)} }
+
+func (p *pipe) ReadMessage() (int, []byte, error) {
+	msg, ok := <-p.ch
+	if !ok {
+		return 0, nil, errors.New("closed")
+	}
+	return msg.typ, msg.data, nil
+}
+
+func (p *pipe) WriteMessage(messageType int, data []byte) error {
+	p.ch <- message{messageType, data}
+	return nil
+}
+
+type messageType int
+
+// contextPipe is an in-memory nhooyr style connection.
+type contextPipe struct {
+	ch chan message
+}
+
+func (p *contextPipe) Read(ctx context.Context) (messageType, []byte, error) {
+	select {
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case msg := <-p.ch:
+		return messageType(msg.typ), msg.data, nil
+	}
+}
+
+func (p *contextPipe) Write(ctx context.Context, typ messageType, data []byte) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case p.ch <- message{int(typ), data}:
+		return nil
+	}
+}
+
+type TestMode struct {
+	Id   string `json:"id" xml:"id" msgpack:"id"`
+	Name string `json:"name" xml:"name" msgpack:"name"`
+}
+
+func newEncoding(t *testing.T) *encoding.Encoding {
+	reg := encoding.New()
+	require.NoError(t, reg.Register(encoding.Mime_MSGPACK, &msgpack.Codec{}))
+	require.NoError(t, reg.Register(encoding.Mime_PROTOBUF, &pro.Codec{}))
+	require.NoError(t, reg.Register(encoding.Mime_XML, &xml.Codec{}))
+	return reg
+}
+
+func Test_ReadWriteMessage(t *testing.T) {
+	reg := newEncoding(t)
+
+	tests := []struct {
+		proto   string
+		want    any
+		wantTyp int
+	}{
+		{"json", &TestMode{Id: "foo", Name: "bar"}, TextMessage},
+		{"xml", &TestMode{Id: "foo", Name: "bar"}, TextMessage},
+		{"msgpack", &TestMode{Id: "foo", Name: "bar"}, BinaryMessage},
+		{"protobuf", &examplepb.SimpleMessage{Id: "foo"}, BinaryMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proto, func(t *testing.T) {
+			m, err := reg.ForSubprotocol(tt.proto)
+			require.NoError(t, err)
+
+			conn := newPipe()
+			require.NoError(t, WriteMessage(conn, m, tt.want))
+			msg := <-conn.ch
+			require.Equal(t, tt.wantTyp, msg.typ)
+			conn.ch <- msg
+
+			switch want := tt.want.(type) {
+			case proto.Message:
+				got := &examplepb.SimpleMessage{}
+				require.NoError(t, ReadMessage(conn, m, got))
+				require.True(t, proto.Equal(want, got))
+			default:
+				got := &TestMode{}
+				require.NoError(t, ReadMessage(conn, m, got))
+				require.Equal(t, want, got)
+			}
+		})
+	}
+}
+
+func Test_WithContext(t *testing.T) {
+	reg := newEncoding(t)
+	m, err := reg.ForSubprotocol("msgpack")
+	require.NoError(t, err)
+
+	p := &contextPipe{ch: make(chan message, 1)}
+	conn := WithContext(context.Background(), p)
+	want := &TestMode{Id: "foo", Name: "bar"}
+	require.NoError(t, WriteMessage(conn, m, want))
+	got := &TestMode{}
+	require.NoError(t, ReadMessage(conn, m, got))
+	require.Equal(t, want, got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ReadMessage(WithContext(ctx, p), m, got)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_ReadMessage_Error(t *testing.T) {
+	reg := newEncoding(t)
+	m, err := reg.ForSubprotocol("json")
+	require.NoError(t, err)
+
+	conn := newPipe()
+	conn.ch <- message{TextMessage, []byte(`{"id":`)}
+	require.Error(t, ReadMessage(conn, m, &TestMode{}))
+
+	close(conn.ch)
+	require.Error(t, ReadMessage(conn, m, &TestMode{}))
+}
+
+func Test_IsText(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json; charset=utf-8", true},
+		{"application/problem+json", true},
+		{"application/x-yaml", true},
+		{"text/plain", true},
+		{"application/x-www-form-urlencoded", true},
+		{"application/x-protobuf", false},
+		{"application/x-msgpack", false},
+		{"image/png", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			require.Equal(t, tt.want, IsText(tt.contentType))
+		})
+	}
+}
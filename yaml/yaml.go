@@ -1,7 +1,10 @@
 package yaml
 
 import (
+	"bytes"
 	"io"
+	"math"
+	"strconv"
 
 	"gopkg.in/yaml.v3"
 
@@ -9,21 +12,113 @@ import (
 )
 
 // Codec is a Codec implementation with yaml.
-type Codec struct{}
+type Codec struct {
+	// FlowStyle emits the documents in flow style, all strings and mapping keys are double quoted,
+	// so the output is both valid YAML and valid JSON, like {"name": "foo", "tags": ["on", "yes"]}.
+	// The non-string mapping keys and the non-finite floats are emitted as strings too,
+	// the strings which contain control characters may be escaped in YAML only form.
+	// The decode direction is unchanged.
+	FlowStyle bool
+}
 
 // ContentType always Returns "application/x-yaml; charset=utf-8".
 func (*Codec) ContentType(_ any) string {
 	return "application/x-yaml; charset=utf-8"
 }
-func (*Codec) Marshal(v any) ([]byte, error) {
-	return yaml.Marshal(v)
+func (c *Codec) Marshal(v any) ([]byte, error) {
+	if !c.FlowStyle {
+		return yaml.Marshal(v)
+	}
+	b := &bytes.Buffer{}
+	if err := c.NewEncoder(b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
 }
 func (*Codec) Unmarshal(data []byte, v any) error {
 	return yaml.Unmarshal(data, v)
 }
-func (*Codec) NewEncoder(w io.Writer) codec.Encoder {
-	return yaml.NewEncoder(w)
+func (c *Codec) NewEncoder(w io.Writer) codec.Encoder {
+	if !c.FlowStyle {
+		return yaml.NewEncoder(w)
+	}
+	return codec.EncoderFunc(func(v any) error {
+		node := &yaml.Node{}
+		if err := node.Encode(v); err != nil {
+			return err
+		}
+		toFlowStyle(node)
+		enc := yaml.NewEncoder(w)
+		if err := enc.Encode(node); err != nil {
+			return err
+		}
+		return enc.Close()
+	})
 }
 func (*Codec) NewDecoder(r io.Reader) codec.Decoder {
 	return yaml.NewDecoder(r)
 }
+
+// toFlowStyle sets the node tree to JSON compatible flow style,
+// the mappings and sequences use flow style, the mapping keys and
+// the scalars which are not JSON literal are double quoted strings.
+func toFlowStyle(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			toFlowStyle(n)
+		}
+	case yaml.MappingNode:
+		node.Style = yaml.FlowStyle
+		for i, n := range node.Content {
+			if i%2 == 0 {
+				quoteScalar(n)
+			} else {
+				toFlowStyle(n)
+			}
+		}
+	case yaml.SequenceNode:
+		node.Style = yaml.FlowStyle
+		for _, n := range node.Content {
+			toFlowStyle(n)
+		}
+	case yaml.ScalarNode:
+		if !isJSONLiteral(node) {
+			quoteScalar(node)
+		}
+	}
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+}
+
+// quoteScalar sets the node to a double quoted string.
+func quoteScalar(node *yaml.Node) {
+	node.Tag = "!!str"
+	node.Style = yaml.DoubleQuotedStyle
+}
+
+// isJSONLiteral reports whether the scalar is a JSON null, bool or number.
+func isJSONLiteral(node *yaml.Node) bool {
+	switch node.ShortTag() {
+	case "!!null":
+		node.Value = "null"
+		return true
+	case "!!bool":
+		b, err := strconv.ParseBool(node.Value)
+		if err != nil {
+			return false
+		}
+		node.Value = strconv.FormatBool(b)
+		return true
+	case "!!int":
+		_, err := strconv.ParseInt(node.Value, 10, 64)
+		if err != nil {
+			_, err = strconv.ParseUint(node.Value, 10, 64)
+		}
+		return err == nil
+	case "!!float":
+		f, err := strconv.ParseFloat(node.Value, 64)
+		return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+	default:
+		return false
+	}
+}
@@ -2,9 +2,13 @@ package yaml
 
 import (
 	"bytes"
+	"encoding/json"
 	"math"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestCodec_ContentType(t *testing.T) {
@@ -156,3 +160,54 @@ var unmarshalerTests = []struct {
 		map[string]any{"v": -0.1},
 	},
 }
+
+func TestCodec_FlowStyle(t *testing.T) {
+	type Nested struct {
+		Switch string  `yaml:"switch" json:"switch"`
+		Count  int     `yaml:"count" json:"count"`
+		Ratio  float64 `yaml:"ratio" json:"ratio"`
+	}
+	type Model struct {
+		Name    string            `yaml:"name" json:"name"`
+		Flags   []string          `yaml:"flags" json:"flags"`
+		Numbers []string          `yaml:"numbers" json:"numbers"`
+		Enabled bool              `yaml:"enabled" json:"enabled"`
+		Empty   *Nested           `yaml:"empty" json:"empty"`
+		Nested  Nested            `yaml:"nested" json:"nested"`
+		Labels  map[string]string `yaml:"labels" json:"labels"`
+	}
+	codec := &Codec{FlowStyle: true}
+	value := &Model{
+		Name:    "foo: bar, \"baz\" 中文 " + strings.Repeat("long ", 30),
+		Flags:   []string{"on", "yes", "no", "off", "true", "null", "~", ""},
+		Numbers: []string{"1", "0x10", "1e3", ".inf", "010"},
+		Enabled: true,
+		Nested:  Nested{Switch: "y", Count: 12, Ratio: 1.5},
+		Labels:  map[string]string{"on": "yes", "1": "2"},
+	}
+
+	got, err := codec.Marshal(value)
+	require.NoError(t, err)
+
+	// valid YAML
+	fromYaml := &Model{}
+	require.NoError(t, codec.Unmarshal(got, fromYaml))
+	require.Equal(t, value, fromYaml)
+
+	// valid JSON
+	fromJSON := &Model{}
+	require.NoError(t, json.Unmarshal(got, fromJSON))
+	require.Equal(t, value, fromJSON)
+
+	// non-string mapping keys and non-finite floats are quoted.
+	got, err = codec.Marshal(map[int]float64{1: math.Inf(1)})
+	require.NoError(t, err)
+	require.Equal(t, "{\"1\": \".inf\"}\n", string(got))
+
+	// the decode direction is unchanged.
+	block, err := (&Codec{}).Marshal(value)
+	require.NoError(t, err)
+	fromBlock := &Model{}
+	require.NoError(t, codec.Unmarshal(block, fromBlock))
+	require.Equal(t, value, fromBlock)
+}